@@ -8,8 +8,10 @@ import (
 	"time"
 
 	"github.com/mfmahendr/url-shortener-backend/config"
+	"github.com/mfmahendr/url-shortener-backend/internal/controllers"
 	"github.com/mfmahendr/url-shortener-backend/internal/di"
 	"github.com/mfmahendr/url-shortener-backend/internal/middleware"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils"
 	"github.com/mfmahendr/url-shortener-backend/internal/utils/validators"
 )
 
@@ -21,13 +23,18 @@ func main() {
 	ctx := context.Background()
 
 	validators.Init()
+	utils.LoadTrustedProxyHops()
 	firebaseApp := config.InitFirebase(ctx)
 	
-	// initialize the controller and services using dependency injection
-	controller, err := di.InitializeController(ctx, firebaseApp, os.Getenv("SAFE_BROWSING_API_KEY"))
+	// initialize the controller, services and background jobs using dependency injection
+	app, err := di.InitializeApp(ctx, firebaseApp, os.Getenv("SAFE_BROWSING_API_KEY"))
 	if err != nil {
 		log.Fatalf("failed to initialize app: %v", err)
 	}
+	controller := app.Controller
+
+	// keeps the local threat lists fresh in SAFE_BROWSING_MODE=update
+	go app.SafeBrowsing.Run(ctx)
 
 	// middleware and routes setup
 	controller.RateLimiter.SetLimit(5, 30 * time.Second)		// 5 request per 30 seconds
@@ -37,7 +44,23 @@ func main() {
 	}
 
 	
-	controller.RegisterRoutes(*authMiddleware)
+	controller.RegisterRoutes(*authMiddleware, controllers.LoadRouteConfig())
+
+	// request/response validation against the API spec (on by default in development)
+	specValidator, err := middleware.LoadOpenAPIValidator("./docs/apispec.yml")
+	if err != nil {
+		log.Fatalf("failed to initialize OpenAPI validation: %v", err)
+	}
+
+	// background sync of external blacklist feeds (no-op when none are configured);
+	// every instance runs it, but each sync is done by the one holding its Redis lease
+	go app.FeedSyncer.Run(ctx)
+
+	// periodic Safe Browsing rescan of existing links (off without an API key), leased the same way
+	go app.Rescanner.Run(ctx)
+
+	// writes the clicks counted in Redis to the links, leased the same way
+	go app.ClickFlusher.Run(ctx)
 
 
 	// start the HTTP server
@@ -47,5 +70,5 @@ func main() {
 	}
 
 	log.Printf("Server listening on port %s", port)
-	log.Fatal(http.ListenAndServe(":"+port, middleware.CORS(controller.Router)))
+	log.Fatal(http.ListenAndServe(":"+port, middleware.CORS(middleware.RequestMetadata(specValidator.Handler(controller.Router)))))
 }
\ No newline at end of file
@@ -0,0 +1,38 @@
+// Command backfill writes the listing fields into links created before they
+// existed (see BackfillShortlinkDefaults) and moves blacklist URL rules to
+// IDs that include their type (see MoveLegacyURLRules). It can be run more
+// than once.
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/mfmahendr/url-shortener-backend/config"
+	firestore_service "github.com/mfmahendr/url-shortener-backend/internal/services/firestore"
+)
+
+func main() {
+	if err := config.LoadEnv(); err != nil {
+		log.Fatalf("Error loading .env file: %v", err)
+	}
+
+	ctx := context.Background()
+	firebaseApp := config.InitFirebase(ctx)
+	store, err := firestore_service.New(ctx, firebaseApp)
+	if err != nil {
+		log.Fatalf("failed to initialize firestore: %v", err)
+	}
+
+	updated, err := store.BackfillShortlinkDefaults(ctx)
+	if err != nil {
+		log.Fatalf("backfill stopped after %d links: %v", updated, err)
+	}
+	log.Printf("Backfilled %d links", updated)
+
+	moved, err := store.MoveLegacyURLRules(ctx)
+	if err != nil {
+		log.Fatalf("moving url rules stopped after %d rules: %v", moved, err)
+	}
+	log.Printf("Moved %d blacklist url rules", moved)
+}
@@ -6,13 +6,14 @@ import (
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
 	"github.com/mfmahendr/url-shortener-backend/internal/utils"
 )
 
 func (c *URLController) Analytics(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	// authenticate user access and ownership
 	ctx := r.Context()
-	shortID := ps.ByName("short_id")
+	shortID := linkKey(r, ps)
 
 	user, ok := ctx.Value(utils.UserKey).(string)
 	if !ok {
@@ -20,7 +21,7 @@ func (c *URLController) Analytics(w http.ResponseWriter, r *http.Request, ps htt
 		return
 	}
 
-	isOwner, err := c.shortenService.IsOwner(ctx, shortID, user)
+	isOwner, err := c.shortenService.Authorize(ctx, shortID, user, models.PermissionView)
 	if verifyOwnerAccess(w, err, isOwner) {
 		return
 	}
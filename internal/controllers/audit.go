@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+)
+
+func (c *URLController) GetAuditLogs(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	q := r.URL.Query()
+	query := dto.AuditLogsQuery{
+		ActorUID:   q.Get("actor_uid"),
+		Action:     q.Get("action"),
+		TargetType: q.Get("target_type"),
+		TargetID:   q.Get("target_id"),
+	}
+	var timeRange dto.ClickLogsQuery
+	parseClickLogsQuery(r, &timeRange)
+	query.After, query.Before = timeRange.After, timeRange.Before
+	query.PaginationQuery = timeRange.PaginationQuery
+	// newest entries first unless asked otherwise
+	if q.Get("order") == "" {
+		query.OrderDesc = true
+	}
+
+	resp, err := c.auditService.ListAuditLogs(r.Context(), query)
+	if err != nil {
+		statusCode := mapErrorToStatusCode(err)
+		http.Error(w, "Failed to retrieve audit logs: "+err.Error(), statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
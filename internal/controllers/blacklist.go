@@ -2,12 +2,18 @@ package controllers
 
 import (
 	"encoding/json"
+	"log"
 	"net/http"
+	"strings"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/blacklist_service"
 )
 
+const maxBlacklistImportSize = 10 << 20
+
 func (c *URLController) FetchBlacklistItems(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	items, err := c.blacklistManager.ListBlacklisted(r.Context())
 	if err != nil {
@@ -30,59 +36,122 @@ func (c *URLController) AddToBlacklist(w http.ResponseWriter, r *http.Request, _
 		return
 	}
 
-	var err error
-	switch req.Type {
-	case "domain":
-		err = c.blacklistManager.BlacklistDomain(r.Context(), req.Value)
-	case "url":
-		err = c.blacklistManager.BlacklistURL(r.Context(), req.Value)
-	default:
-		http.Error(w, "Invalid type: must be 'domain' or 'url'", http.StatusBadRequest)
-		return
-	}
-
+	item, err := c.blacklistService.Add(r.Context(), req)
 	if err != nil {
 		statusCode := mapErrorToStatusCode(err)
 		http.Error(w, "Failed to add to blacklist: "+err.Error(), statusCode)
 		return
 	}
+	c.recordBlacklistChange(r, models.AuditBlacklistAdd, item.Type, req.Value)
 
 	w.Header().Set("Content-Type", "application/json")
-	resp := map[string]string{"status": "added", "type": req.Type, "value": req.Value}
+	resp := map[string]string{"status": "added", "id": item.ID, "type": item.Type, "value": req.Value, "links": "disabling"}
+	if item.Action == models.BlacklistActionPreview {
+		resp["action"] = item.Action
+		resp["links"] = "previewing"
+	}
 	_ = json.NewEncoder(w).Encode(resp)
 }
 
 
-func (c *URLController) RemoveFromBlacklist(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+// RemoveFromBlacklist removes the rule given by the :id parameter or, on the
+// legacy route, by the type and value query parameters.
+func (c *URLController) RemoveFromBlacklist(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	blacklistValue := r.URL.Query().Get("value")
 	blacklistType := r.URL.Query().Get("type")
+	reenable := r.URL.Query().Get("reenable") == "true"
 
-	if blacklistValue == "" || blacklistType == "" {
-		http.Error(w, "Missing value or type", http.StatusBadRequest)
-		return
+	if id := ps.ByName("id"); id != "" {
+		item, err := c.blacklistManager.GetBlacklistItem(r.Context(), id)
+		if err != nil {
+			statusCode := mapErrorToStatusCode(err)
+			http.Error(w, "Failed to remove from blacklist: "+err.Error(), statusCode)
+			return
+		}
+		blacklistType, blacklistValue = item.Type, item.Value
 	}
 
-	var err error
-	switch blacklistType {
-	case "domain":
-		err = c.blacklistManager.UnblacklistDomain(r.Context(), blacklistValue)
-	case "url":
-		err = c.blacklistManager.UnblacklistURL(r.Context(), blacklistValue)
-	default:
-		http.Error(w, "Invalid type: must be 'domain' or 'url'", http.StatusBadRequest)
+	if blacklistValue == "" || blacklistType == "" {
+		http.Error(w, "Missing value or type", http.StatusBadRequest)
 		return
 	}
 
+	err := c.blacklistService.Remove(r.Context(), dto.BlacklistItemRequest{Type: blacklistType, Value: blacklistValue}, reenable)
 	if err != nil {
 		statusCode := mapErrorToStatusCode(err)
 		http.Error(w, "Failed to remove from blacklist: "+err.Error(), statusCode)
 		return
 	}
+	c.recordBlacklistChange(r, models.AuditBlacklistRemove, blacklistType, blacklistValue)
 
 	w.Header().Set("Content-Type", "application/json")
 	response := map[string]string{"status": "removed", "type": blacklistType, "value": blacklistValue}
+	if reenable {
+		response["links"] = "reenabling"
+	}
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		http.Error(w, "Failed to encode response", 500)
 		return
 	}
-}
\ No newline at end of file
+}
+
+func (c *URLController) recordBlacklistChange(r *http.Request, action, itemType, value string) {
+	item := map[string]interface{}{"type": itemType, "value": value}
+	before, after := item, map[string]interface{}(nil)
+	if action == models.AuditBlacklistAdd {
+		before, after = nil, item
+	}
+
+	if err := c.auditService.Record(r.Context(), action, models.AuditTargetBlacklist, itemType+":"+value, before, after); err != nil {
+		log.Printf("Failed to record audit log for %s %s: %v", action, value, err)
+	}
+}
+
+func (c *URLController) ImportBlacklist(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = blacklist_service.FormatList
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "text/csv") {
+			format = blacklist_service.FormatCSV
+		}
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxBlacklistImportSize)
+	resp, err := c.blacklistService.Import(r.Context(), body, format)
+	if err != nil {
+		statusCode := mapErrorToStatusCode(err)
+		http.Error(w, "Failed to import blacklist: "+err.Error(), statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (c *URLController) ExportBlacklist(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = blacklist_service.FormatCSV
+	}
+
+	switch format {
+	case blacklist_service.FormatCSV:
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", "attachment; filename=\"blacklist.csv\"")
+	case blacklist_service.FormatJSON:
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", "attachment; filename=\"blacklist.json\"")
+	case blacklist_service.FormatList:
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Content-Disposition", "attachment; filename=\"blacklist.txt\"")
+	default:
+		http.Error(w, "Unsupported format; use ?format=csv, ?format=json or ?format=list", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	if err := c.blacklistService.Export(r.Context(), w, format); err != nil {
+		statusCode := mapErrorToStatusCode(err)
+		http.Error(w, "Failed to export blacklist: "+err.Error(), statusCode)
+		return
+	}
+}
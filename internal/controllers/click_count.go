@@ -8,12 +8,13 @@ import (
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
 	"github.com/mfmahendr/url-shortener-backend/internal/utils"
 )
 
 func (c *URLController) GetClickCount(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	ctx := r.Context()
-	shortID := ps.ByName("short_id")
+	shortID := linkKey(r, ps)
 
 	user, ok := ctx.Value(utils.UserKey).(string)
 	if !ok {
@@ -21,7 +22,7 @@ func (c *URLController) GetClickCount(w http.ResponseWriter, r *http.Request, ps
 		return
 	}
 
-	isOwner, err := c.shortenService.IsOwner(ctx, shortID, user)
+	isOwner, err := c.shortenService.Authorize(ctx, shortID, user, models.PermissionView)
 	if verifyOwnerAccess(w, err, isOwner) {
 		return
 	}
@@ -43,7 +44,7 @@ func (c *URLController) GetClickCount(w http.ResponseWriter, r *http.Request, ps
 }
 
 func (c *URLController) ExportAllClickCount(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-	shortID := ps.ByName("short_id")
+	shortID := linkKey(r, ps)
 
 	// check ownership
 	user, ok := r.Context().Value(utils.UserKey).(string)
@@ -52,7 +53,7 @@ func (c *URLController) ExportAllClickCount(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	isOwner, err := c.shortenService.IsOwner(r.Context(), shortID, user)
+	isOwner, err := c.shortenService.Authorize(r.Context(), shortID, user, models.PermissionView)
 	if verifyOwnerAccess(w, err, isOwner) {
 		return
 	}
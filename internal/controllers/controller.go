@@ -3,27 +3,49 @@ package controllers
 import (
 	"github.com/julienschmidt/httprouter"
 	mw "github.com/mfmahendr/url-shortener-backend/internal/middleware"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/audit_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/blacklist_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/domain_service"
 	firestore "github.com/mfmahendr/url-shortener-backend/internal/services/firestore"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/notification_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/reserved_service"
 	tracking "github.com/mfmahendr/url-shortener-backend/internal/services/tracking_service"
 	"github.com/mfmahendr/url-shortener-backend/internal/services/url_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/workspace_service"
 )
 
 type URLController struct {
 	shortenService   url_service.URLService
 	trackingService  tracking.TrackingService
 	blacklistManager firestore.BlacklistManager
+	blacklistService blacklist_service.BlacklistService
+	reservedService  reserved_service.ReservedService
+	domainService    domain_service.DomainService
+	workspaceService workspace_service.WorkspaceService
+	auditService     audit_service.AuditService
+	notifications    notification_service.NotificationService
 
 	Router *httprouter.Router
+	routes []RegisteredRoute
+	config RouteConfig
 
 	RateLimiter *mw.SlidingWindowLimiter
+	Idempotency *mw.Idempotency
 }
 
-func New(s url_service.URLService, t tracking.TrackingService, b firestore.BlacklistManager, l *mw.SlidingWindowLimiter) *URLController {
+func New(s url_service.URLService, t tracking.TrackingService, b firestore.BlacklistManager, bs blacklist_service.BlacklistService, rs reserved_service.ReservedService, ds domain_service.DomainService, ws workspace_service.WorkspaceService, a audit_service.AuditService, n notification_service.NotificationService, l *mw.SlidingWindowLimiter, i *mw.Idempotency) *URLController {
 	return &URLController{
-		shortenService:  s,
-		trackingService: t,
+		shortenService:   s,
+		trackingService:  t,
 		blacklistManager: b,
-		Router:          httprouter.New(),
-		RateLimiter:     l,
+		blacklistService: bs,
+		reservedService:  rs,
+		domainService:    ds,
+		workspaceService: ws,
+		auditService:     a,
+		notifications:    n,
+		Router:           httprouter.New(),
+		RateLimiter:      l,
+		Idempotency:      i,
 	}
 }
@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+)
+
+func (c *URLController) RegisterDomain(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var req dto.DomainRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Host == "" {
+		http.Error(w, "Failed to register domain: "+shortlink_errors.ErrValidateRequest.Error(), http.StatusBadRequest)
+		return
+	}
+
+	domain, err := c.domainService.Register(r.Context(), req)
+	if err != nil {
+		statusCode := mapErrorToStatusCode(err)
+		http.Error(w, "Failed to register domain: "+err.Error(), statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(domain)
+}
+
+func (c *URLController) ListDomains(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	domains, err := c.domainService.List(r.Context(), r.URL.Query().Get("workspace_id"))
+	if err != nil {
+		statusCode := mapErrorToStatusCode(err)
+		http.Error(w, "Failed to list domains: "+err.Error(), statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(domains)
+}
+
+func (c *URLController) VerifyDomain(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	domain, err := c.domainService.Verify(r.Context(), ps.ByName("host"))
+	if err != nil {
+		statusCode := mapErrorToStatusCode(err)
+		http.Error(w, "Failed to verify domain: "+err.Error(), statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(domain)
+}
+
+func (c *URLController) DeleteDomain(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	host := ps.ByName("host")
+	if err := c.domainService.Delete(r.Context(), host); err != nil {
+		statusCode := mapErrorToStatusCode(err)
+		http.Error(w, "Failed to delete domain: "+err.Error(), statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "deleted", "host": host})
+}
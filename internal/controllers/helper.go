@@ -7,20 +7,33 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/julienschmidt/httprouter"
 	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
 	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/urlcanon"
 )
 
 func mapErrorToStatusCode(err error) (statusCode int) {
 	switch {
-	case errors.Is(err, shortlink_errors.ErrBlacklistedID), errors.Is(err, shortlink_errors.ErrForbidden), errors.Is(err, shortlink_errors.ErrForbiddenInput):
+	case errors.Is(err, shortlink_errors.ErrBlacklistedID), errors.Is(err, shortlink_errors.ErrForbidden), errors.Is(err, shortlink_errors.ErrForbiddenInput), errors.Is(err, shortlink_errors.ErrDomainNotVerified),
+		errors.Is(err, shortlink_errors.ErrPrivateAddress), errors.Is(err, shortlink_errors.ErrSelfReference), errors.Is(err, shortlink_errors.ErrRedirectLoop), errors.Is(err, shortlink_errors.ErrTooManyRedirects):
 		statusCode = http.StatusForbidden
-	case errors.Is(err, shortlink_errors.ErrResourceExists), errors.Is(err, shortlink_errors.ErrIDExists):
+	case errors.Is(err, shortlink_errors.ErrResourceExists), errors.Is(err, shortlink_errors.ErrIDExists), errors.Is(err, shortlink_errors.ErrLastOwner),
+		errors.Is(err, shortlink_errors.ErrIdempotencyKeyInProgress):
 		statusCode = http.StatusConflict
+	case errors.Is(err, shortlink_errors.ErrInvitationExpired), errors.Is(err, shortlink_errors.ErrLinkDisabled), errors.Is(err, shortlink_errors.ErrLinkExpired):
+		statusCode = http.StatusGone
 	case errors.Is(err, shortlink_errors.ErrGenerateID), errors.Is(err, shortlink_errors.ErrSaveShortlink), errors.Is(err, shortlink_errors.ErrFailedRetrieveData):
 		statusCode = http.StatusInternalServerError
-	case errors.Is(err, shortlink_errors.ErrValidateRequest):
+	case errors.Is(err, shortlink_errors.ErrQuotaExceeded), errors.Is(err, shortlink_errors.ErrNoPublicBaseURL):
+		statusCode = http.StatusServiceUnavailable
+	case errors.Is(err, shortlink_errors.ErrValidateRequest), errors.Is(err, shortlink_errors.ErrUnsupportedScheme), errors.Is(err, shortlink_errors.ErrInvalidCursor):
 		statusCode = http.StatusBadRequest
+	case errors.Is(err, shortlink_errors.ErrDomainVerification), errors.Is(err, shortlink_errors.ErrIdempotencyKeyReused):
+		statusCode = http.StatusUnprocessableEntity
+	case errors.Is(err, shortlink_errors.ErrBatchTooLarge):
+		statusCode = http.StatusRequestEntityTooLarge
 	case errors.Is(err, shortlink_errors.ErrNotFound):
 		statusCode = http.StatusNotFound
 	default:
@@ -30,6 +43,16 @@ func mapErrorToStatusCode(err error) (statusCode int) {
 	return statusCode
 }
 
+// linkKey identifies the link of a /u/... route: the short_id parameter and,
+// for links on a custom domain, the domain query parameter.
+func linkKey(r *http.Request, ps httprouter.Params) string {
+	domain := r.URL.Query().Get("domain")
+	if host, err := urlcanon.Host(domain); err == nil {
+		domain = host
+	}
+	return models.LinkKey(domain, ps.ByName("short_id"))
+}
+
 func verifyOwnerAccess(w http.ResponseWriter, err error, isOwner bool) bool {
 	if err != nil {
 		statusCode := mapErrorToStatusCode(err)
@@ -54,6 +77,7 @@ func parseClickLogsQuery(r *http.Request, query *dto.ClickLogsQuery) {
 		t, _ := time.Parse(time.RFC3339, before)
 		query.Before = t
 	}
+	query.Source = r.URL.Query().Get("source")
 }
 
 func parsePaginationQuery(r *http.Request, query *dto.PaginationQuery) {
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+)
+
+func (c *URLController) UpdateShortlink(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var req dto.UpdateShortlinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Failed to update shortlink: "+shortlink_errors.ErrValidateRequest.Error(), http.StatusBadRequest)
+		return
+	}
+
+	link, err := c.shortenService.UpdateShortlink(r.Context(), linkKey(r, ps), req)
+	if err != nil {
+		statusCode := mapErrorToStatusCode(err)
+		http.Error(w, "Failed to update shortlink: "+err.Error(), statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(link)
+}
+
+func (c *URLController) DeleteShortlink(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	shortID := linkKey(r, ps)
+	if err := c.shortenService.DeleteShortlink(r.Context(), shortID); err != nil {
+		statusCode := mapErrorToStatusCode(err)
+		http.Error(w, "Failed to delete shortlink: "+err.Error(), statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "deleted", "short_id": shortID})
+}
+
+func (c *URLController) TransferShortlink(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var req dto.TransferShortlinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Failed to transfer shortlink: "+shortlink_errors.ErrValidateRequest.Error(), http.StatusBadRequest)
+		return
+	}
+
+	link, err := c.shortenService.TransferShortlink(r.Context(), linkKey(r, ps), req)
+	if err != nil {
+		statusCode := mapErrorToStatusCode(err)
+		http.Error(w, "Failed to transfer shortlink: "+err.Error(), statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(link)
+}
@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils"
+)
+
+func (c *URLController) GetNotifications(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	user, ok := r.Context().Value(utils.UserKey).(string)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var query dto.PaginationQuery
+	parsePaginationQuery(r, &query)
+	// newest first unless asked otherwise
+	if r.URL.Query().Get("order") == "" {
+		query.OrderDesc = true
+	}
+
+	resp, err := c.notifications.ListNotifications(r.Context(), user, query)
+	if err != nil {
+		statusCode := mapErrorToStatusCode(err)
+		http.Error(w, "Failed to retrieve notifications: "+err.Error(), statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
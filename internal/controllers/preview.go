@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"embed"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+)
+
+//go:embed templates/preview.html
+var templateFS embed.FS
+
+var previewTemplate = template.Must(template.ParseFS(templateFS, "templates/preview.html"))
+
+const forcedPreviewWarning = "An administrator marked this destination as suspicious."
+
+type previewPage struct {
+	*dto.LinkPreview
+	// ContinueURL is the path the preview was requested on, /r/{short_id}
+	// or /{short_id}, with ?continue=1 and the src parameter, if any.
+	ContinueURL string
+}
+
+// renderPreview shows the interstitial page for shortID, leading to target,
+// instead of redirecting.
+func (c *URLController) renderPreview(w http.ResponseWriter, r *http.Request, shortID, target string, forced bool) {
+	preview, err := c.shortenService.Preview(r.Context(), shortID, target)
+	if err != nil {
+		http.Error(w, "Failed to preview link: "+err.Error(), mapErrorToStatusCode(err))
+		return
+	}
+	if forced {
+		preview.Warnings = append([]string{forcedPreviewWarning}, preview.Warnings...)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	continueQuery := url.Values{"continue": {"1"}}
+	if src := r.URL.Query().Get("src"); src != "" {
+		// the click is still attributed to where the visitor came from
+		continueQuery.Set("src", src)
+	}
+	page := previewPage{LinkPreview: preview, ContinueURL: strings.TrimSuffix(r.URL.Path, previewSuffix) + "?" + continueQuery.Encode()}
+	if err := previewTemplate.Execute(w, page); err != nil {
+		log.Printf("Failed to render preview for %s: %v", shortID, err)
+	}
+}
@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/qrcode"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+	val "github.com/mfmahendr/url-shortener-backend/internal/utils/validators"
+)
+
+// QRCode draws a QR code for the short URL of a link. The URL carries
+// ?src=qr, so scans are told apart from other clicks in the analytics.
+func (c *URLController) QRCode(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	shortID := linkKey(r, ps)
+
+	user, ok := ctx.Value(utils.UserKey).(string)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	isOwner, err := c.shortenService.Authorize(ctx, shortID, user, models.PermissionView)
+	if verifyOwnerAccess(w, err, isOwner) {
+		return
+	}
+
+	query := parseQRCodeQuery(r)
+	opts, err := c.qrCodeOptions(query)
+	if err != nil {
+		http.Error(w, "Failed to create QR code: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	visitURL, err := c.shortURL(r, ps.ByName("short_id"))
+	if err != nil {
+		http.Error(w, "Failed to create QR code: "+err.Error(), mapErrorToStatusCode(err))
+		return
+	}
+
+	render, contentType := qrcode.PNG, "image/png"
+	if query.Format == "svg" {
+		render, contentType = qrcode.SVG, "image/svg+xml"
+	}
+
+	var buf bytes.Buffer
+	content := visitURL + "?src=" + models.ClickSourceQR
+	if err := render(&buf, content, opts); err != nil {
+		log.Printf("Failed to render QR code for %s: %v", shortID, err)
+		http.Error(w, "Failed to create QR code", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	io.Copy(w, &buf)
+}
+
+// parseQRCodeQuery reads the QR code options, a 256 pixel black on white
+// PNG with error correction M by default.
+func parseQRCodeQuery(r *http.Request) dto.QRCodeQuery {
+	q := r.URL.Query()
+	query := dto.QRCodeQuery{
+		Format:     strings.ToLower(q.Get("format")),
+		Size:       256,
+		ECC:        strings.ToUpper(q.Get("ecc")),
+		Foreground: q.Get("fg"),
+		Background: q.Get("bg"),
+	}
+	if query.Format == "" {
+		query.Format = "png"
+	}
+	if size := q.Get("size"); size != "" {
+		// anything that is not a number fails validation
+		query.Size, _ = strconv.Atoi(size)
+	}
+	if query.ECC == "" {
+		query.ECC = "M"
+	}
+	if query.Foreground == "" {
+		query.Foreground = "#000000"
+	}
+	if query.Background == "" {
+		query.Background = "#ffffff"
+	}
+	query.Logo, _ = strconv.ParseBool(q.Get("logo"))
+	return query
+}
+
+func (c *URLController) qrCodeOptions(query dto.QRCodeQuery) (qrcode.Options, error) {
+	if err := val.Validate.Struct(query); err != nil {
+		return qrcode.Options{}, shortlink_errors.ErrValidateRequest
+	}
+
+	opts := qrcode.Options{Size: query.Size, Level: query.ECC}
+	var err error
+	if opts.Foreground, err = qrcode.ParseColor(query.Foreground); err != nil {
+		return opts, err
+	}
+	if opts.Background, err = qrcode.ParseColor(query.Background); err != nil {
+		return opts, err
+	}
+	if query.Logo {
+		if c.config.QRLogo == nil {
+			return opts, shortlink_errors.ErrNoQRLogo
+		}
+		opts.Logo = c.config.QRLogo
+	}
+	return opts, nil
+}
+
+// shortURL is the URL a link is visited on: its custom domain, given by the
+// domain query parameter, if it is verified, or else PUBLIC_BASE_URL. The
+// host the request was sent to is never used, as clients can choose it.
+func (c *URLController) shortURL(r *http.Request, shortID string) (string, error) {
+	path := "/r/" + shortID
+	if c.config.RootShortURLs {
+		path = "/" + shortID
+	}
+
+	if domain := r.URL.Query().Get("domain"); domain != "" {
+		if c.domainService == nil {
+			return "", shortlink_errors.ErrDomainNotVerified
+		}
+		host, err := c.domainService.CustomDomain(r.Context(), domain)
+		if err != nil {
+			return "", err
+		}
+		if host == "" {
+			return "", shortlink_errors.ErrDomainNotVerified
+		}
+		return "https://" + host + path, nil
+	}
+	if c.config.PublicBaseURL == "" {
+		return "", shortlink_errors.ErrNoPublicBaseURL
+	}
+	return c.config.PublicBaseURL + path, nil
+}
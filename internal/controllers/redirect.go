@@ -2,49 +2,83 @@ package controllers
 
 import (
 	"context"
-	"net"
 	"net/http"
 	"strings"
 	"time"
 	"log"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/targeting"
 )
 
+// previewSuffix appended to a short ID ("/r/abc+") shows the preview page
+// for any link.
+const previewSuffix = "+"
+
 func (c *URLController) Redirect(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
     ctx := r.Context()
     shortID := p.ByName("short_id")
+    requested := strings.HasSuffix(shortID, previewSuffix)
+    shortID = strings.TrimSuffix(shortID, previewSuffix)
+
+    // short IDs are only unique per domain
+    if c.domainService != nil {
+        domain, err := c.domainService.CustomDomain(ctx, r.Host)
+        if err != nil {
+            log.Printf("Error looking up custom domain %s: %v", r.Host, err)
+            http.Error(w, err.Error(), mapErrorToStatusCode(err))
+            return
+        }
+        shortID = models.LinkKey(domain, shortID)
+    }
 
-    url, err := c.shortenService.Resolve(ctx, shortID)
+    link, err := c.shortenService.ResolveLink(ctx, shortID)
     if err != nil {
         log.Printf("Error resolving short ID %s: %v", shortID, err)
         http.Error(w, err.Error(), mapErrorToStatusCode(err))
         return
     }
 
-    ip := "unknown"
-    if remote := r.RemoteAddr; remote != "" {
-        if host, _, err := net.SplitHostPort(remote); err == nil {
-            ip = host
-        } else {
-            ip = remote
+    ip := utils.ClientIP(r)
+    ua := r.UserAgent()
+
+    // the first redirect rule the visitor matches picks the destination,
+    // the link's URL is the fallback
+    target, ruleID := link.URL, ""
+    if rule, ok := targeting.Match(link.Rules, targeting.NewVisitor(r, ip, c.config.GeoIP)); ok {
+        target, ruleID = rule.URL, rule.ID
+    }
+
+    forced := false
+    if c.blacklistService != nil {
+        if forced, err = c.blacklistService.RequiresPreview(ctx, target); err != nil {
+            // fail towards showing the preview rather than redirecting blindly
+            log.Printf("Preview rule check failed for %s: %v", shortID, err)
+            forced = true
         }
     }
-    if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
-        ip = strings.Split(forwarded, ",")[0]
+
+    // the preview page links back here with ?continue=1
+    if requested || ((link.Preview || forced) && r.URL.Query().Get("continue") != "1") {
+        c.renderPreview(w, r, shortID, target, forced)
+        return
     }
-    ua := r.UserAgent()
 
-    go func(shortID, ip, ua string) {
+    // QR codes link to the short URL with ?src=qr
+    src := r.URL.Query().Get("src")
+
+    go func(shortID, ip, ua, src, ruleID string) {
         trackCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
         defer cancel()
 
-        if err := c.trackingService.TrackClick(trackCtx, shortID, ip, ua); err != nil {
+        if err := c.trackingService.TrackClick(trackCtx, shortID, ip, ua, src, ruleID); err != nil {
             log.Printf("TrackClick failed for %s: %v", shortID, err)
         } else {
             log.Printf("TrackClick success for %s", shortID)
         }
-    }(shortID, ip, ua)
+    }(shortID, ip, ua, src, ruleID)
 
-    http.Redirect(w, r, url, http.StatusFound)
+    http.Redirect(w, r, target, http.StatusFound)
 }
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+)
+
+func (c *URLController) FetchReservedIDs(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	items, err := c.reservedService.List(r.Context())
+	if err != nil {
+		statusCode := mapErrorToStatusCode(err)
+		http.Error(w, "Failed to retrieve reserved IDs: "+err.Error(), statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(items); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (c *URLController) AddReservedID(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var req dto.ReservedIDRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Word == "" {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	item, err := c.reservedService.Add(r.Context(), req)
+	if err != nil {
+		statusCode := mapErrorToStatusCode(err)
+		http.Error(w, "Failed to reserve ID: "+err.Error(), statusCode)
+		return
+	}
+	c.recordReservedIDChange(r, models.AuditReservedIDAdd, item.Word, item.WorkspaceID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(item)
+}
+
+func (c *URLController) RemoveReservedID(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	word := ps.ByName("word")
+	if word == "" {
+		word = r.URL.Query().Get("word") // legacy route
+	}
+	workspaceID := r.URL.Query().Get("workspace_id")
+	if word == "" {
+		http.Error(w, "Missing word", http.StatusBadRequest)
+		return
+	}
+
+	if err := c.reservedService.Remove(r.Context(), word, workspaceID); err != nil {
+		statusCode := mapErrorToStatusCode(err)
+		http.Error(w, "Failed to remove reserved ID: "+err.Error(), statusCode)
+		return
+	}
+	c.recordReservedIDChange(r, models.AuditReservedIDRemove, word, workspaceID)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"status": "removed", "word": word})
+}
+
+func (c *URLController) recordReservedIDChange(r *http.Request, action, word, workspaceID string) {
+	item, targetID := map[string]interface{}{"word": word}, word
+	if workspaceID != "" {
+		item["workspace_id"] = workspaceID
+		targetID = workspaceID + "/" + word
+	}
+	before, after := item, map[string]interface{}(nil)
+	if action == models.AuditReservedIDAdd {
+		before, after = nil, item
+	}
+
+	if err := c.auditService.Record(r.Context(), action, models.AuditTargetReservedID, targetID, before, after); err != nil {
+		log.Printf("Failed to record audit log for %s %s: %v", action, word, err)
+	}
+}
@@ -1,26 +1,241 @@
 package controllers
 
 import (
+	"context"
+	"fmt"
+	"image"
+	"log"
 	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/julienschmidt/httprouter"
 	mw "github.com/mfmahendr/url-shortener-backend/internal/middleware"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/reserved_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/qrcode"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/targeting"
+	val "github.com/mfmahendr/url-shortener-backend/internal/utils/validators"
 )
 
-func (c *URLController) RegisterRoutes(auth mw.AuthMiddleware) {
-	c.Router.GET("/health", c.RateLimiter.Apply(c.HealthCheck))
-	c.Router.GET("/", c.RateLimiter.Apply(c.Home))
+// APIPrefix is where the versioned API is mounted.
+const APIPrefix = "/api/v1"
+
+// legacyDeprecatedSince is when the unversioned /u and /admin routes were
+// replaced by the versioned API.
+var legacyDeprecatedSince = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+// defaultLegacySunset is when the unversioned routes are removed unless
+// LEGACY_API_SUNSET says otherwise.
+var defaultLegacySunset = time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)
+
+// reservedRootPaths are never served as root-level short IDs, whether or not
+// a route exists for them. They include the first segment of every route.
+var reservedRootPaths = []string{
+	"docs", "health", "metrics", "api", "admin", "u", "r", "favicon.ico", "robots.txt",
+}
+
+type (
+	RouteConfig struct {
+		// RootShortURLs serves links at /{short_id} as well as /r/{short_id}.
+		RootShortURLs bool
+		// LegacySunset is announced in the Sunset header of the unversioned
+		// routes.
+		LegacySunset time.Time
+		// PublicBaseURL is where links without a custom domain are served,
+		// such as https://sho.rt. Without it there are only QR codes for
+		// links on custom domains.
+		PublicBaseURL string
+		// QRLogo is drawn in the middle of QR codes that ask for it.
+		QRLogo image.Image
+		// GeoIP finds the country of visitors for redirect rules; without
+		// it rules with countries never match.
+		GeoIP targeting.CountryLookup
+	}
+
+	// RegisteredRoute is a method and path served by the router.
+	RegisteredRoute struct {
+		Method string
+		Path   string
+	}
+
+	// route is an API route under APIPrefix. legacy is the unversioned path
+	// it replaces, if any; it stays available as a deprecated alias.
+	route struct {
+		method string
+		path   string
+		legacy string
+		handle httprouter.Handle
+	}
+)
+
+// LoadRouteConfig reads ROOT_SHORT_URLS (default false), LEGACY_API_SUNSET
+// (a date such as 2027-04-30, default 2027-04-30), PUBLIC_BASE_URL and
+// QR_LOGO_FILE (a PNG or JPEG) and GEOIP_DATABASE (a MaxMind .mmdb file).
+func LoadRouteConfig() RouteConfig {
+	cfg := RouteConfig{LegacySunset: defaultLegacySunset}
+	if v := strings.TrimSpace(os.Getenv("ROOT_SHORT_URLS")); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.RootShortURLs = b
+		} else {
+			log.Printf("Invalid ROOT_SHORT_URLS %q, links are only served under /r/", v)
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("LEGACY_API_SUNSET")); v != "" {
+		if t, err := time.Parse(time.DateOnly, v); err == nil {
+			cfg.LegacySunset = t
+		} else {
+			log.Printf("Invalid LEGACY_API_SUNSET %q, using %s", v, defaultLegacySunset.Format(time.DateOnly))
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("PUBLIC_BASE_URL")); v != "" {
+		if u, err := url.Parse(v); err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" {
+			cfg.PublicBaseURL = strings.TrimSuffix(v, "/")
+		} else {
+			log.Printf("Invalid PUBLIC_BASE_URL %q, QR codes are only drawn for custom domains", v)
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("QR_LOGO_FILE")); v != "" {
+		if logo, err := qrcode.LoadLogo(v); err == nil {
+			cfg.QRLogo = logo
+		} else {
+			log.Printf("Failed to load QR_LOGO_FILE, QR codes have no logo: %v", err)
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("GEOIP_DATABASE")); v != "" {
+		if geo, err := targeting.OpenGeoIP(v); err == nil {
+			cfg.GeoIP = geo
+		} else {
+			log.Printf("Failed to open GEOIP_DATABASE, country rules never match: %v", err)
+		}
+	}
+	return cfg
+}
+
+func (c *URLController) RegisterRoutes(auth mw.AuthMiddleware, config RouteConfig) {
+	c.config = config
+
+	c.handle(http.MethodGet, "/health", c.RateLimiter.Apply(c.HealthCheck))
+	c.handle(http.MethodGet, "/", c.RateLimiter.Apply(c.Home))
 	c.Router.ServeFiles("/docs/*filepath", http.Dir("./docs"))
+	c.routes = append(c.routes, RegisteredRoute{http.MethodGet, "/docs/*filepath"})
+
+	c.handle(http.MethodGet, "/r/:short_id", c.RateLimiter.Apply(auth.OptionalAuth(c.Redirect)))
+
+	user := func(h httprouter.Handle) httprouter.Handle {
+		return c.RateLimiter.Apply(auth.RequireAuth(c.Idempotency.Apply(h)))
+	}
+	admin := func(h httprouter.Handle) httprouter.Handle {
+		return c.RateLimiter.Apply(auth.RequireAdminAuth(c.Idempotency.Apply(h)))
+	}
+	// for handlers that honour Idempotency-Key themselves
+	ownKeys := func(h httprouter.Handle) httprouter.Handle {
+		return c.RateLimiter.Apply(auth.RequireAuth(h))
+	}
+	deprecation := mw.Deprecation{Since: legacyDeprecatedSince, Sunset: config.LegacySunset}
+
+	for _, rt := range c.apiRoutes(user, ownKeys, admin) {
+		c.handle(rt.method, APIPrefix+rt.path, rt.handle)
+		if rt.legacy != "" {
+			c.handle(rt.method, rt.legacy, deprecation.Apply(APIPrefix+rt.path, rt.handle))
+		}
+	}
+
+	if config.RootShortURLs {
+		c.ServeRootShortURLs(c.RateLimiter.Apply(auth.OptionalAuth(c.Redirect)))
+	}
+}
+
+// Routes lists the routes registered by RegisterRoutes, in httprouter syntax.
+func (c *URLController) Routes() []RegisteredRoute {
+	return c.routes
+}
+
+func (c *URLController) handle(method, path string, handle httprouter.Handle) {
+	c.Router.Handle(method, path, handle)
+	c.routes = append(c.routes, RegisteredRoute{method, path})
+}
+
+func (c *URLController) apiRoutes(user, ownKeys, admin func(httprouter.Handle) httprouter.Handle) []route {
+	return []route{
+		// links
+		{http.MethodGet, "/links", "/u/shortlinks", user(c.GetShortlinks)},
+		{http.MethodPost, "/links", "/u/shorten", user(c.Shorten)},
+		{http.MethodPost, "/link-batches", "/u/shorten/batch", ownKeys(c.ShortenBatch)},
+		{http.MethodPatch, "/links/:short_id", "/u/shortlinks/:short_id", user(c.UpdateShortlink)},
+		{http.MethodDelete, "/links/:short_id", "/u/shortlinks/:short_id", user(c.DeleteShortlink)},
+		{http.MethodPost, "/links/:short_id/transfer", "/u/shortlinks/:short_id/transfer", user(c.TransferShortlink)},
+		{http.MethodGet, "/links/:short_id/clicks", "/u/analytics/:short_id", user(c.Analytics)},
+		{http.MethodGet, "/links/:short_id/clicks/count", "/u/click-count/:short_id", user(c.GetClickCount)},
+		{http.MethodGet, "/links/:short_id/clicks/export", "/u/click-count/:short_id/export", user(c.ExportAllClickCount)},
+		{http.MethodGet, "/links/:short_id/qr", "/u/shortlinks/:short_id/qr", user(c.QRCode)},
+
+		// tags
+		{http.MethodGet, "/tags", "", user(c.ListTags)},
+		{http.MethodGet, "/tags/:tag/analytics", "", user(c.GetTagAnalytics)},
+		{http.MethodPatch, "/tags/:tag", "", user(c.RenameTag)},
+		{http.MethodDelete, "/tags/:tag", "", user(c.DeleteTag)},
+
+		// custom domains
+		{http.MethodGet, "/domains", "/u/domains", user(c.ListDomains)},
+		{http.MethodPost, "/domains", "/u/domains", user(c.RegisterDomain)},
+		{http.MethodPost, "/domains/:host/verify", "/u/domains/:host/verify", user(c.VerifyDomain)},
+		{http.MethodDelete, "/domains/:host", "/u/domains/:host", user(c.DeleteDomain)},
+
+		{http.MethodGet, "/notifications", "/u/notifications", user(c.GetNotifications)},
+
+		// workspaces
+		{http.MethodGet, "/workspaces", "/u/workspaces", user(c.ListWorkspaces)},
+		{http.MethodPost, "/workspaces", "/u/workspaces", user(c.CreateWorkspace)},
+		{http.MethodGet, "/workspaces/:workspace_id/members", "/u/workspaces/:workspace_id/members", user(c.ListWorkspaceMembers)},
+		{http.MethodPatch, "/workspaces/:workspace_id/members/:uid", "/u/workspaces/:workspace_id/members/:uid", user(c.UpdateWorkspaceMember)},
+		{http.MethodDelete, "/workspaces/:workspace_id/members/:uid", "/u/workspaces/:workspace_id/members/:uid", user(c.RemoveWorkspaceMember)},
+		{http.MethodPost, "/workspaces/:workspace_id/invitations", "/u/workspaces/:workspace_id/invitations", user(c.InviteWorkspaceMember)},
+		{http.MethodPost, "/invitations/:invitation_id/accept", "/u/invitations/:invitation_id/accept", user(c.AcceptWorkspaceInvitation)},
+
+		// admin
+		{http.MethodGet, "/admin/blacklist", "/admin/blacklist", admin(c.FetchBlacklistItems)},
+		{http.MethodPost, "/admin/blacklist", "/admin/blacklist", admin(c.AddToBlacklist)},
+		{http.MethodDelete, "/admin/blacklist/:id", "/admin/blacklist", admin(c.RemoveFromBlacklist)},
+		{http.MethodPost, "/admin/blacklist/import", "/admin/blacklist/import", admin(c.ImportBlacklist)},
+		{http.MethodGet, "/admin/blacklist/export", "/admin/blacklist/export", admin(c.ExportBlacklist)},
+		{http.MethodGet, "/admin/reserved-ids", "/admin/reserved-ids", admin(c.FetchReservedIDs)},
+		{http.MethodPost, "/admin/reserved-ids", "/admin/reserved-ids", admin(c.AddReservedID)},
+		{http.MethodDelete, "/admin/reserved-ids/:word", "/admin/reserved-ids", admin(c.RemoveReservedID)},
+		{http.MethodGet, "/admin/audit", "/admin/audit", admin(c.GetAuditLogs)},
+	}
+}
+
+// ServeRootShortURLs makes GET /{short_id} call redirect. httprouter cannot
+// register a root wildcard next to the other routes, so the links are served
+// by its NotFound handler: only paths no route matches get there. Call it
+// after registering all other routes. Like httprouter on conflicting routes,
+// it panics if a reserved root path is not also refused as a custom ID.
+func (c *URLController) ServeRootShortURLs(redirect httprouter.Handle) {
+	reserved := make(map[string]bool)
+	for _, p := range reservedRootPaths {
+		reserved[p] = true
+	}
+
+	// words the service uses must also be refused as custom IDs, otherwise a
+	// link could be created that is never reachable at the root
+	builtin := reserved_service.NewBuiltin()
+	for p := range reserved {
+		if val.Validate.Var(p, "short_id") == nil && builtin.Check(context.Background(), p, "") == nil {
+			panic(fmt.Sprintf("root path /%s is not a reserved short ID word", p))
+		}
+	}
 
-	c.Router.GET("/r/:short_id", c.RateLimiter.Apply(auth.OptionalAuth(c.Redirect)))
-	
-	c.Router.GET("/u/shortlinks", c.RateLimiter.Apply(auth.RequireAuth(c.GetShortlinks)))
-	c.Router.POST("/u/shorten", c.RateLimiter.Apply(auth.RequireAuth(c.Shorten)))
-	c.Router.GET("/u/click-count/:short_id", c.RateLimiter.Apply(auth.RequireAuth(c.GetClickCount)))
-	c.Router.GET("/u/click-count/:short_id/export", c.RateLimiter.Apply(auth.RequireAuth(c.ExportAllClickCount)))
-	c.Router.GET("/u/analytics/:short_id", c.RateLimiter.Apply(auth.RequireAuth(c.Analytics)))
-
-	// admin
-	c.Router.GET("/admin/blacklist", c.RateLimiter.Apply(auth.RequireAdminAuth(c.FetchBlacklistItems)))
-	c.Router.POST("/admin/blacklist", c.RateLimiter.Apply(auth.RequireAdminAuth(c.AddToBlacklist)))
-	c.Router.DELETE("/admin/blacklist", c.RateLimiter.Apply(auth.RequireAdminAuth(c.RemoveFromBlacklist)))
+	c.Router.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/")
+		if (r.Method != http.MethodGet && r.Method != http.MethodHead) || reserved[strings.ToLower(id)] ||
+			val.Validate.Var(strings.TrimSuffix(id, previewSuffix), "short_id") != nil {
+			http.NotFound(w, r)
+			return
+		}
+		redirect(w, r, httprouter.Params{{Key: "short_id", Value: id}})
+	})
+	c.routes = append(c.routes, RegisteredRoute{http.MethodGet, "/:short_id"})
 }
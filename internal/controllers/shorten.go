@@ -7,6 +7,7 @@ import (
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
 	"github.com/mfmahendr/url-shortener-backend/internal/utils"
 	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
 )
@@ -26,14 +27,15 @@ func (c *URLController) Shorten(w http.ResponseWriter, r *http.Request, _ httpro
 		return
 	}
 
-	shortID, err := c.shortenService.Shorten(ctx, req)
+	key, err := c.shortenService.Shorten(ctx, req)
 	if err != nil {
 		statusCode := mapErrorToStatusCode(err)
 		http.Error(w, "Failed to shorten URL: "+err.Error(), statusCode)
 		return
 	}
 
-	response := dto.ShortenResponse{ShortID: shortID}
+	shortID, domain := models.SplitLinkKey(key)
+	response := dto.ShortenResponse{ShortID: shortID, Domain: domain}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	mw "github.com/mfmahendr/url-shortener-backend/internal/middleware"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+)
+
+const maxBatchShortenSize = 10 << 20
+
+// ShortenBatch creates the links of a JSON array of shorten requests, or of
+// newline-delimited JSON objects with Content-Type application/x-ndjson.
+// Items fail independently; the response reports the result of each.
+func (c *URLController) ShortenBatch(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := r.Context()
+	if _, ok := ctx.Value(utils.UserKey).(string); !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	idempotencyKey := r.Header.Get("Idempotency-Key")
+	if len(idempotencyKey) > mw.MaxIdempotencyKeyLength {
+		http.Error(w, "Failed to shorten URLs: Idempotency-Key is too long", http.StatusBadRequest)
+		return
+	}
+
+	reqs, err := decodeBatch(http.MaxBytesReader(w, r.Body, maxBatchShortenSize), r.Header.Get("Content-Type"))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "Failed to shorten URLs: request body is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Failed to shorten URLs: "+shortlink_errors.ErrValidateRequest.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.shortenService.ShortenBatch(ctx, idempotencyKey, reqs)
+	if err != nil {
+		statusCode := mapErrorToStatusCode(err)
+		http.Error(w, "Failed to shorten URLs: "+err.Error(), statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Idempotency-Key", resp.IdempotencyKey)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func decodeBatch(body io.Reader, contentType string) ([]dto.ShortenRequest, error) {
+	var reqs []dto.ShortenRequest
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType != "application/x-ndjson" {
+		err := json.NewDecoder(body).Decode(&reqs)
+		return reqs, err
+	}
+
+	dec := json.NewDecoder(body)
+	for {
+		var req dto.ShortenRequest
+		err := dec.Decode(&req)
+		if err == io.EOF {
+			return reqs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		reqs = append(reqs, req)
+	}
+}
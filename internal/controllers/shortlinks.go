@@ -3,6 +3,7 @@ package controllers
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/mfmahendr/url-shortener-backend/internal/dto"
@@ -24,10 +25,27 @@ func (c *URLController) GetShortlinks(w http.ResponseWriter, r *http.Request, _
 		isPrivateQ = "all"
 	}
 	parsePaginationQuery(r, paginationQ)
+	q := r.URL.Query()
 	shortlinksQ := &dto.UserLinksQuery{
 		IsPrivate:       isPrivateQ,
+		WorkspaceID:     q.Get("workspace_id"),
+		DestinationHost: q.Get("destination_host"),
+		Tag:             q.Get("tag"),
+		State:           q.Get("state"),
+		Search:          q.Get("q"),
+		Sort:            q.Get("sort"),
 		PaginationQuery: *paginationQ,
 	}
+	for name, t := range map[string]*time.Time{"created_after": &shortlinksQ.CreatedAfter, "created_before": &shortlinksQ.CreatedBefore} {
+		if v := q.Get(name); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				http.Error(w, "Failed to get users' shortlinks: invalid "+name, http.StatusBadRequest)
+				return
+			}
+			*t = parsed
+		}
+	}
 
 	linksReq := &dto.UserLinksRequest{
 		CreatedBy:      createdBy,
@@ -39,6 +57,7 @@ func (c *URLController) GetShortlinks(w http.ResponseWriter, r *http.Request, _
 		statusCode := mapErrorToStatusCode(err)
 		if statusCode != http.StatusNotFound {
 			http.Error(w, "Failed to get users' shortlinks: "+err.Error(), statusCode)
+			return
 		}
 		resp = &dto.UserLinksResponse{}
 	}
@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+)
+
+func (c *URLController) ListTags(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	tags, err := c.shortenService.ListTags(r.Context(), r.URL.Query().Get("workspace_id"))
+	if err != nil {
+		statusCode := mapErrorToStatusCode(err)
+		http.Error(w, "Failed to list tags: "+err.Error(), statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.TagsResponse{Tags: tags})
+}
+
+func (c *URLController) GetTagAnalytics(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	analytics, err := c.shortenService.TagAnalytics(r.Context(), r.URL.Query().Get("workspace_id"), ps.ByName("tag"))
+	if err != nil {
+		statusCode := mapErrorToStatusCode(err)
+		http.Error(w, "Failed to fetch tag analytics: "+err.Error(), statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(analytics)
+}
+
+func (c *URLController) RenameTag(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var req dto.RenameTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Failed to rename tag: "+shortlink_errors.ErrValidateRequest.Error(), http.StatusBadRequest)
+		return
+	}
+
+	summary, err := c.shortenService.RenameTag(r.Context(), r.URL.Query().Get("workspace_id"), ps.ByName("tag"), req)
+	if err != nil {
+		statusCode := mapErrorToStatusCode(err)
+		http.Error(w, "Failed to rename tag: "+err.Error(), statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
+
+func (c *URLController) DeleteTag(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	tag := ps.ByName("tag")
+	if err := c.shortenService.DeleteTag(r.Context(), r.URL.Query().Get("workspace_id"), tag); err != nil {
+		statusCode := mapErrorToStatusCode(err)
+		http.Error(w, "Failed to delete tag: "+err.Error(), statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "deleted", "tag": tag})
+}
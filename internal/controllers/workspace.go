@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+)
+
+func (c *URLController) CreateWorkspace(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var req dto.CreateWorkspaceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Failed to create workspace: "+shortlink_errors.ErrValidateRequest.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ws, err := c.workspaceService.CreateWorkspace(r.Context(), req)
+	if err != nil {
+		statusCode := mapErrorToStatusCode(err)
+		http.Error(w, "Failed to create workspace: "+err.Error(), statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ws)
+}
+
+func (c *URLController) ListWorkspaces(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	workspaces, err := c.workspaceService.ListWorkspaces(r.Context())
+	if err != nil {
+		statusCode := mapErrorToStatusCode(err)
+		http.Error(w, "Failed to list workspaces: "+err.Error(), statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(workspaces)
+}
+
+func (c *URLController) ListWorkspaceMembers(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	members, err := c.workspaceService.ListMembers(r.Context(), ps.ByName("workspace_id"))
+	if err != nil {
+		statusCode := mapErrorToStatusCode(err)
+		http.Error(w, "Failed to list members: "+err.Error(), statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(members)
+}
+
+func (c *URLController) InviteWorkspaceMember(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var req dto.InviteMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Failed to invite member: "+shortlink_errors.ErrValidateRequest.Error(), http.StatusBadRequest)
+		return
+	}
+
+	inv, err := c.workspaceService.InviteMember(r.Context(), ps.ByName("workspace_id"), req)
+	if err != nil {
+		statusCode := mapErrorToStatusCode(err)
+		http.Error(w, "Failed to invite member: "+err.Error(), statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(inv)
+}
+
+func (c *URLController) AcceptWorkspaceInvitation(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ws, err := c.workspaceService.AcceptInvitation(r.Context(), ps.ByName("invitation_id"))
+	if err != nil {
+		statusCode := mapErrorToStatusCode(err)
+		http.Error(w, "Failed to accept invitation: "+err.Error(), statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ws)
+}
+
+func (c *URLController) UpdateWorkspaceMember(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var req dto.UpdateMemberRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Failed to update member: "+shortlink_errors.ErrValidateRequest.Error(), http.StatusBadRequest)
+		return
+	}
+
+	workspaceID, uid := ps.ByName("workspace_id"), ps.ByName("uid")
+	if err := c.workspaceService.UpdateMemberRole(r.Context(), workspaceID, uid, req); err != nil {
+		statusCode := mapErrorToStatusCode(err)
+		http.Error(w, "Failed to update member: "+err.Error(), statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "updated", "uid": uid, "role": req.Role})
+}
+
+func (c *URLController) RemoveWorkspaceMember(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	workspaceID, uid := ps.ByName("workspace_id"), ps.ByName("uid")
+	if err := c.workspaceService.RemoveMember(r.Context(), workspaceID, uid); err != nil {
+		statusCode := mapErrorToStatusCode(err)
+		http.Error(w, "Failed to remove member: "+err.Error(), statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "removed", "uid": uid})
+}
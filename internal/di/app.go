@@ -0,0 +1,19 @@
+package di
+
+import (
+	"github.com/mfmahendr/url-shortener-backend/internal/controllers"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/blacklist_service"
+	safebrowsing_service "github.com/mfmahendr/url-shortener-backend/internal/services/safebrowsing"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/tracking_service"
+)
+
+// App holds the controller and the background jobs of one instance. They are
+// built together so they share a single Firestore client, Redis client and
+// blacklist index.
+type App struct {
+	Controller   *controllers.URLController
+	SafeBrowsing *safebrowsing_service.SafeBrowsingServiceImpl
+	FeedSyncer   *blacklist_service.FeedSyncer
+	Rescanner    *safebrowsing_service.Rescanner
+	ClickFlusher *tracking_service.ClickCountFlusher
+}
@@ -10,13 +10,23 @@ import (
 	"github.com/mfmahendr/url-shortener-backend/config"
 	"github.com/mfmahendr/url-shortener-backend/internal/controllers"
 	"github.com/mfmahendr/url-shortener-backend/internal/middleware"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/audit_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/blacklist_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/domain_service"
 	firestore_service "github.com/mfmahendr/url-shortener-backend/internal/services/firestore"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/idgen_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/notification_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/preflight_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/reputation_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/reserved_service"
 	// "google.golang.org/api/safebrowsing/v4"
 
 	safebrowsing_service "github.com/mfmahendr/url-shortener-backend/internal/services/safebrowsing"
 
 	"github.com/mfmahendr/url-shortener-backend/internal/services/tracking_service"
 	"github.com/mfmahendr/url-shortener-backend/internal/services/url_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/workspace_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/lease"
 
 	"github.com/google/wire"
 )
@@ -30,20 +40,84 @@ var firestoreServiceSet = wire.NewSet(
 	wire.Bind(new(firestore_service.FirestoreService), new(*firestore_service.FirestoreServiceImpl)),
 	wire.Bind(new(firestore_service.Shortlink), new(*firestore_service.FirestoreServiceImpl)),
 	wire.Bind(new(firestore_service.ClickLog), new(*firestore_service.FirestoreServiceImpl)),
+	wire.Bind(new(firestore_service.ClickCounter), new(*firestore_service.FirestoreServiceImpl)),
 	wire.Bind(new(firestore_service.BlacklistManager), new(*firestore_service.FirestoreServiceImpl)),
-	wire.Bind(new(firestore_service.BlacklistChecker), new(*firestore_service.FirestoreServiceImpl)),
+	wire.Bind(new(firestore_service.WorkspaceMembership), new(*firestore_service.FirestoreServiceImpl)),
+	wire.Bind(new(firestore_service.WorkspaceManager), new(*firestore_service.FirestoreServiceImpl)),
+	wire.Bind(new(firestore_service.AuditLog), new(*firestore_service.FirestoreServiceImpl)),
+	wire.Bind(new(firestore_service.BlacklistStore), new(*firestore_service.FirestoreServiceImpl)),
+	wire.Bind(new(firestore_service.LinkStatus), new(*firestore_service.FirestoreServiceImpl)),
+	wire.Bind(new(firestore_service.Notifications), new(*firestore_service.FirestoreServiceImpl)),
+	wire.Bind(new(firestore_service.ReservedIDStore), new(*firestore_service.FirestoreServiceImpl)),
+	wire.Bind(new(firestore_service.DomainStore), new(*firestore_service.FirestoreServiceImpl)),
 )
 
-func InitializeController(ctx context.Context, app *firebase.App, safeBrowsingKey string) (*controllers.URLController, error) {
+var auditServiceSet = wire.NewSet(
+	audit_service.New,
+	wire.Bind(new(audit_service.Recorder), new(audit_service.AuditService)),
+)
+
+var notificationServiceSet = wire.NewSet(
+	notification_service.New,
+	wire.Bind(new(notification_service.Notifier), new(notification_service.NotificationService)),
+)
+
+// blacklistEnforcementSet disables existing links when blacklist rules change.
+var blacklistEnforcementSet = wire.NewSet(
+	notificationServiceSet,
+	blacklist_service.NewIndex,
+	blacklist_service.NewEnforcer,
+	wire.Bind(new(blacklist_service.LinkEnforcer), new(*blacklist_service.Enforcer)),
+)
+
+// InitializeApp builds the controller and the background jobs on one set of
+// clients. The Safe Browsing client is shared by the controller and the
+// rescanner, so update mode keeps a single local database.
+func InitializeApp(ctx context.Context, app *firebase.App, safeBrowsingKey string) (*App, error) {
 	wire.Build(
 		firestoreServiceSet,
+		auditServiceSet,
+		blacklistEnforcementSet,
 		config.NewRedisClient,
 		tracking_service.New,
-		safebrowsing_service.New,
+		wire.Bind(new(safebrowsing_service.ThreatFinder), new(*safebrowsing_service.SafeBrowsingServiceImpl)),
+		reputation_service.LoadConfig,
+		reputation_service.New,
+		wire.Bind(new(reputation_service.URLChecker), new(*reputation_service.Composite)),
         // safebrowsing.NewService,
+		preflight_service.LoadConfig,
+		preflight_service.New,
+		wire.Bind(new(preflight_service.Preflight), new(*preflight_service.PreflightImpl)),
+		idgen_service.LoadConfig,
+		idgen_service.New,
+		reserved_service.LoadConfig,
+		reserved_service.New,
+		wire.Bind(new(reserved_service.Checker), new(*reserved_service.ReservedServiceImpl)),
+		wire.Bind(new(reserved_service.ReservedService), new(*reserved_service.ReservedServiceImpl)),
+		domain_service.LoadConfig,
+		domain_service.NewResolver,
+		domain_service.New,
+		wire.Bind(new(domain_service.DomainChecker), new(*domain_service.DomainServiceImpl)),
+		wire.Bind(new(domain_service.DomainService), new(*domain_service.DomainServiceImpl)),
+		url_service.LoadConfig,
 		url_service.New,
+		workspace_service.New,
+		blacklist_service.New,
+		wire.Bind(new(firestore_service.BlacklistChecker), new(*blacklist_service.Index)),
 		middleware.NewRateLimiter,
+		middleware.LoadIdempotencyConfig,
+		middleware.NewIdempotency,
 		controllers.New,
+		safebrowsing_service.LoadConfig,
+		safebrowsing_service.New,
+		blacklist_service.LoadFeedConfig,
+		blacklist_service.NewFeedSyncer,
+		safebrowsing_service.LoadRescanConfig,
+		safebrowsing_service.NewRescanner,
+		tracking_service.LoadFlushConfig,
+		tracking_service.NewClickCountFlusher,
+		lease.New,
+		wire.Struct(new(App), "*"),
 	)
 	return nil, nil
 }
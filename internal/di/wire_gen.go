@@ -13,26 +13,86 @@ import (
 	"github.com/mfmahendr/url-shortener-backend/config"
 	"github.com/mfmahendr/url-shortener-backend/internal/controllers"
 	"github.com/mfmahendr/url-shortener-backend/internal/middleware"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/audit_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/blacklist_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/domain_service"
 	"github.com/mfmahendr/url-shortener-backend/internal/services/firestore"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/idgen_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/notification_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/preflight_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/reputation_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/reserved_service"
 	"github.com/mfmahendr/url-shortener-backend/internal/services/safebrowsing"
 	"github.com/mfmahendr/url-shortener-backend/internal/services/tracking_service"
 	"github.com/mfmahendr/url-shortener-backend/internal/services/url_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/workspace_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/lease"
 )
 
 // Injectors from wire.go:
 
-func InitializeController(ctx context.Context, app *firebase.App, safeBrowsingKey string) (*controllers.URLController, error) {
+// InitializeApp builds the controller and the background jobs on one set of
+// clients. The Safe Browsing client is shared by the controller and the
+// rescanner, so update mode keeps a single local database.
+func InitializeApp(ctx context.Context, app *firebase.App, safeBrowsingKey string) (*App, error) {
 	firestoreServiceImpl, err := firestore_service.New(ctx, app)
 	if err != nil {
 		return nil, err
 	}
+	index := blacklist_service.NewIndex(firestoreServiceImpl)
+	reputation_serviceConfig := reputation_service.LoadConfig()
+	safebrowsing_serviceConfig := safebrowsing_service.LoadConfig()
 	client := config.NewRedisClient()
-	urlSafetyChecker := safebrowsing_service.New(ctx, safeBrowsingKey, client)
-	urlService := url_service.New(firestoreServiceImpl, firestoreServiceImpl, urlSafetyChecker)
+	safeBrowsingServiceImpl, err := safebrowsing_service.New(ctx, safeBrowsingKey, safebrowsing_serviceConfig, client)
+	if err != nil {
+		return nil, err
+	}
+	composite, err := reputation_service.New(reputation_serviceConfig, safeBrowsingServiceImpl)
+	if err != nil {
+		return nil, err
+	}
+	auditService := audit_service.New(firestoreServiceImpl)
+	preflight_serviceConfig := preflight_service.LoadConfig()
+	preflightImpl := preflight_service.New(preflight_serviceConfig)
+	idgen_serviceConfig := idgen_service.LoadConfig()
+	idGenerator, err := idgen_service.New(idgen_serviceConfig, client)
+	if err != nil {
+		return nil, err
+	}
+	reserved_serviceConfig := reserved_service.LoadConfig()
+	reservedServiceImpl, err := reserved_service.New(firestoreServiceImpl, reserved_serviceConfig)
+	if err != nil {
+		return nil, err
+	}
+	txtResolver := domain_service.NewResolver()
+	domain_serviceConfig := domain_service.LoadConfig()
+	domainServiceImpl := domain_service.New(firestoreServiceImpl, firestoreServiceImpl, txtResolver, domain_serviceConfig)
+	url_serviceConfig := url_service.LoadConfig()
+	urlService := url_service.New(firestoreServiceImpl, index, composite, firestoreServiceImpl, auditService, preflightImpl, idGenerator, reservedServiceImpl, domainServiceImpl, url_serviceConfig)
 	trackingService := tracking_service.New(firestoreServiceImpl, client)
+	notificationService := notification_service.New(firestoreServiceImpl)
+	enforcer := blacklist_service.NewEnforcer(firestoreServiceImpl, notificationService, index)
+	blacklistService := blacklist_service.New(firestoreServiceImpl, auditService, index, enforcer)
+	workspaceService := workspace_service.New(firestoreServiceImpl, auditService)
 	slidingWindowLimiter := middleware.NewRateLimiter(client)
-	urlController := controllers.New(urlService, trackingService, firestoreServiceImpl, slidingWindowLimiter)
-	return urlController, nil
+	idempotencyConfig := middleware.LoadIdempotencyConfig()
+	idempotency := middleware.NewIdempotency(client, idempotencyConfig)
+	urlController := controllers.New(urlService, trackingService, firestoreServiceImpl, blacklistService, reservedServiceImpl, domainServiceImpl, workspaceService, auditService, notificationService, slidingWindowLimiter, idempotency)
+	feedConfig := blacklist_service.LoadFeedConfig()
+	leaseLease := lease.New(client)
+	feedSyncer := blacklist_service.NewFeedSyncer(firestoreServiceImpl, enforcer, feedConfig, leaseLease)
+	rescanConfig := safebrowsing_service.LoadRescanConfig()
+	rescanner := safebrowsing_service.NewRescanner(firestoreServiceImpl, safeBrowsingServiceImpl, notificationService, rescanConfig, leaseLease)
+	flushConfig := tracking_service.LoadFlushConfig()
+	clickCountFlusher := tracking_service.NewClickCountFlusher(firestoreServiceImpl, client, flushConfig, leaseLease)
+	diApp := &App{
+		Controller:   urlController,
+		SafeBrowsing: safeBrowsingServiceImpl,
+		FeedSyncer:   feedSyncer,
+		Rescanner:    rescanner,
+		ClickFlusher: clickCountFlusher,
+	}
+	return diApp, nil
 }
 
 func InitializeAuthMiddleware(app *firebase.App) (*middleware.AuthMiddleware, error) {
@@ -44,4 +104,13 @@ func InitializeAuthMiddleware(app *firebase.App) (*middleware.AuthMiddleware, er
 
 var firebaseAppSet = wire.NewSet(config.InitFirebase)
 
-var firestoreServiceSet = wire.NewSet(firestore_service.New, wire.Bind(new(firestore_service.FirestoreService), new(*firestore_service.FirestoreServiceImpl)), wire.Bind(new(firestore_service.Shortlink), new(*firestore_service.FirestoreServiceImpl)), wire.Bind(new(firestore_service.ClickLog), new(*firestore_service.FirestoreServiceImpl)), wire.Bind(new(firestore_service.BlacklistManager), new(*firestore_service.FirestoreServiceImpl)), wire.Bind(new(firestore_service.BlacklistChecker), new(*firestore_service.FirestoreServiceImpl)))
+var firestoreServiceSet = wire.NewSet(firestore_service.New, wire.Bind(new(firestore_service.FirestoreService), new(*firestore_service.FirestoreServiceImpl)), wire.Bind(new(firestore_service.Shortlink), new(*firestore_service.FirestoreServiceImpl)), wire.Bind(new(firestore_service.ClickLog), new(*firestore_service.FirestoreServiceImpl)), wire.Bind(new(firestore_service.ClickCounter), new(*firestore_service.FirestoreServiceImpl)), wire.Bind(new(firestore_service.BlacklistManager), new(*firestore_service.FirestoreServiceImpl)), wire.Bind(new(firestore_service.WorkspaceMembership), new(*firestore_service.FirestoreServiceImpl)), wire.Bind(new(firestore_service.WorkspaceManager), new(*firestore_service.FirestoreServiceImpl)), wire.Bind(new(firestore_service.AuditLog), new(*firestore_service.FirestoreServiceImpl)), wire.Bind(new(firestore_service.BlacklistStore), new(*firestore_service.FirestoreServiceImpl)), wire.Bind(new(firestore_service.LinkStatus), new(*firestore_service.FirestoreServiceImpl)), wire.Bind(new(firestore_service.Notifications), new(*firestore_service.FirestoreServiceImpl)), wire.Bind(new(firestore_service.ReservedIDStore), new(*firestore_service.FirestoreServiceImpl)), wire.Bind(new(firestore_service.DomainStore), new(*firestore_service.FirestoreServiceImpl)))
+
+var auditServiceSet = wire.NewSet(audit_service.New, wire.Bind(new(audit_service.Recorder), new(audit_service.AuditService)))
+
+var notificationServiceSet = wire.NewSet(notification_service.New, wire.Bind(new(notification_service.Notifier), new(notification_service.NotificationService)))
+
+// blacklistEnforcementSet disables existing links when blacklist rules change.
+var blacklistEnforcementSet = wire.NewSet(
+	notificationServiceSet, blacklist_service.NewIndex, blacklist_service.NewEnforcer, wire.Bind(new(blacklist_service.LinkEnforcer), new(*blacklist_service.Enforcer)),
+)
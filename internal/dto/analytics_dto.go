@@ -15,9 +15,12 @@ type ClickLogDTO struct {
 	Timestamp time.Time `json:"timestamp"`
 	IP        string    `json:"ip"`
 	UserAgent string    `json:"user_agent"`
+	Source    string    `json:"source,omitempty"`
+	// RuleID is the redirect rule the visitor was sent on by, if any.
+	RuleID string `json:"rule_id,omitempty"`
 }
 
 type ClickLogsRequest struct {
-	ShortID   string    `json:"short_id" validate:"required,short_id"`
+	ShortID   string    `json:"short_id" validate:"required,link_id"`
 	ClickLogsQuery
 }
\ No newline at end of file
@@ -0,0 +1,22 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+)
+
+type AuditLogsQuery struct {
+	ActorUID   string    `json:"actor_uid,omitempty"`
+	Action     string    `json:"action,omitempty"`
+	TargetType string    `json:"target_type,omitempty"`
+	TargetID   string    `json:"target_id,omitempty"`
+	After      time.Time `json:"after"`
+	Before     time.Time `json:"before"`
+	PaginationQuery
+}
+
+type AuditLogsResponse struct {
+	Logs       []models.AuditLog `json:"logs"`
+	NextCursor string            `json:"next_cursor"`
+}
@@ -1,6 +1,23 @@
 package dto
 
 type BlacklistItemRequest struct {
-	Type  string `json:"type"`  // "domain" or "url"
+	Type  string `json:"type"` // "domain", "domain_suffix", "url", "url_prefix" or "regex"
 	Value string `json:"value"`
-}
\ No newline at end of file
+	// Action is "block" (default) or "preview".
+	Action string `json:"action,omitempty"`
+}
+
+type BlacklistImportResult struct {
+	Line   int    `json:"line"`
+	Type   string `json:"type,omitempty"`
+	Value  string `json:"value"`
+	Status string `json:"status"` // "added", "exists", "duplicate", "invalid" or "failed"
+	Error  string `json:"error,omitempty"`
+}
+
+type BlacklistImportResponse struct {
+	Added   int                     `json:"added"`
+	Skipped int                     `json:"skipped"`
+	Invalid int                     `json:"invalid"`
+	Results []BlacklistImportResult `json:"results"`
+}
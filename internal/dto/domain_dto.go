@@ -0,0 +1,25 @@
+package dto
+
+import "time"
+
+type DomainRequest struct {
+	Host string `json:"host" validate:"required,fqdn"`
+	// WorkspaceID registers the domain for a workspace instead of the caller.
+	WorkspaceID string `json:"workspace_id,omitempty"`
+}
+
+type DomainDTO struct {
+	Host        string     `json:"host"`
+	WorkspaceID string     `json:"workspace_id,omitempty"`
+	Verified    bool       `json:"verified"`
+	VerifiedAt  *time.Time `json:"verified_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	// Verification is the TXT record proving control of the domain.
+	Verification DomainVerification `json:"verification"`
+}
+
+type DomainVerification struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
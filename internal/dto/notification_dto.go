@@ -0,0 +1,8 @@
+package dto
+
+import "github.com/mfmahendr/url-shortener-backend/internal/models"
+
+type NotificationsResponse struct {
+	Notifications []models.Notification `json:"notifications"`
+	NextCursor    string                `json:"next_cursor"`
+}
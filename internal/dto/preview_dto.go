@@ -0,0 +1,11 @@
+package dto
+
+// LinkPreview is what the interstitial page shows before a visitor is sent
+// to the destination of a link.
+type LinkPreview struct {
+	ShortID  string   `json:"short_id"`
+	URL      string   `json:"url"`
+	Domain   string   `json:"domain"`
+	Title    string   `json:"title,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
@@ -10,8 +10,33 @@ type PaginationQuery struct {
 	OrderDesc bool   `json:"order_desc" validate:"-"`
 }
 
+// Sort orders of the link listing.
+const (
+	LinkSortCreated     = "created_at"
+	LinkSortClicks      = "clicks"
+	LinkSortLastClicked = "last_clicked"
+)
+
+// States the link listing can be filtered on. Active links are neither
+// disabled nor expired.
+const (
+	LinkStateActive   = "active"
+	LinkStateDisabled = "disabled"
+	LinkStateExpired  = "expired"
+)
+
 type UserLinksQuery struct {
-	IsPrivate string `json:"is_private" validate:"omitempty,oneof=true yes no false all"`
+	IsPrivate   string `json:"is_private" validate:"omitempty,oneof=true yes no false all"`
+	WorkspaceID string `json:"workspace_id,omitempty" validate:"omitempty"`
+	// DestinationHost lists the links whose destination is on this host.
+	DestinationHost string    `json:"destination_host,omitempty" validate:"omitempty,max=253"`
+	Tag             string    `json:"tag,omitempty" validate:"omitempty,max=50"`
+	CreatedAfter    time.Time `json:"created_after"`
+	CreatedBefore   time.Time `json:"created_before"`
+	State           string    `json:"state,omitempty" validate:"omitempty,oneof=active disabled expired"`
+	// Search is a case-insensitive substring of the short ID, URL or title.
+	Search string `json:"q,omitempty" validate:"omitempty,max=200"`
+	Sort   string `json:"sort,omitempty" validate:"omitempty,oneof=created_at clicks last_clicked"`
 	PaginationQuery
 }
 
@@ -19,5 +44,20 @@ type ClickLogsQuery struct {
 	UserAgent string    `json:"user_agent,omitempty" validate:"omitempty"`
 	After     time.Time `json:"after" validate:"omitempty,datetime"`
 	Before    time.Time `json:"before" validate:"omitempty,datetime"`
+	// Source lists only the clicks from that source, such as qr.
+	Source string `json:"source,omitempty" validate:"omitempty,oneof=qr"`
 	PaginationQuery
 }
+
+// QRCodeQuery is how the QR code of a link is drawn.
+type QRCodeQuery struct {
+	Format string `json:"format" validate:"oneof=png svg"`
+	// Size is the width of the image in pixels.
+	Size int    `json:"size" validate:"min=64,max=2048"`
+	ECC  string `json:"ecc" validate:"oneof=L M Q H"`
+	// Foreground and Background are colors such as #000 or #1a2b3c.
+	Foreground string `json:"fg" validate:"required"`
+	Background string `json:"bg" validate:"required"`
+	// Logo draws the configured logo in the middle of the code.
+	Logo bool `json:"logo"`
+}
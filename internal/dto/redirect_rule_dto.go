@@ -0,0 +1,30 @@
+package dto
+
+import "time"
+
+// RedirectRule sends the visitors who meet all of its conditions to URL
+// instead of the link's URL. Rules are tried in order, the first match wins,
+// and a condition left empty matches everyone.
+type RedirectRule struct {
+	// ID is recorded on the clicks the rule redirects; rules without one
+	// are numbered rule-1, rule-2 and so on.
+	ID        string   `json:"id" validate:"omitempty,rule_id"`
+	URL       string   `json:"url" validate:"required,url"`
+	Devices   []string `json:"devices,omitempty" validate:"omitempty,max=3,dive,oneof=ios android desktop"`
+	Countries []string `json:"countries,omitempty" validate:"omitempty,max=250,dive,iso3166_1_alpha2"`
+	// Languages are tags such as "en" or "pt-BR"; "en" also matches "en-GB".
+	Languages []string      `json:"languages,omitempty" validate:"omitempty,max=50,dive,bcp47_language_tag"`
+	Schedule  *RuleSchedule `json:"schedule,omitempty"`
+}
+
+// RuleSchedule limits a rule to a period and to some days and hours. From
+// and To are set together; a To before From spans midnight.
+type RuleSchedule struct {
+	StartsAt *time.Time `json:"starts_at,omitempty"`
+	EndsAt   *time.Time `json:"ends_at,omitempty"`
+	Days     []string   `json:"days,omitempty" validate:"omitempty,max=7,dive,oneof=mon tue wed thu fri sat sun"`
+	From     string     `json:"from,omitempty" validate:"omitempty,datetime=15:04"`
+	To       string     `json:"to,omitempty" validate:"omitempty,datetime=15:04"`
+	// Timezone is an IANA name such as Europe/Paris; UTC by default.
+	Timezone string `json:"timezone,omitempty" validate:"omitempty,timezone"`
+}
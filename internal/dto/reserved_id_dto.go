@@ -0,0 +1,9 @@
+package dto
+
+type ReservedIDRequest struct {
+	Word string `json:"word" validate:"required,alphanum,min=2,max=30"`
+	// Match is "exact" (default) or "contains".
+	Match string `json:"match,omitempty" validate:"omitempty,oneof=exact contains"`
+	// WorkspaceID reserves the word for this workspace only.
+	WorkspaceID string `json:"workspace_id,omitempty"`
+}
@@ -1,11 +1,54 @@
 package dto
 
+import "time"
+
 type ShortenRequest struct {
-	URL       string `json:"url" validate:"required,url"`
-	CustomID  string `json:"custom_id" validate:"omitempty,short_id"`
-	IsPrivate bool   `json:"is_private"`
+	URL         string `json:"url" validate:"required,url"`
+	CustomID    string `json:"custom_id" validate:"omitempty,short_id"`
+	IsPrivate   bool   `json:"is_private"`
+	WorkspaceID string `json:"workspace_id,omitempty"`
+	Title       string `json:"title,omitempty" validate:"omitempty,max=200"`
+	// FetchTitle sets Title, when it is empty, to the title of the
+	// destination page.
+	FetchTitle bool     `json:"fetch_title,omitempty"`
+	Notes      string   `json:"notes,omitempty" validate:"omitempty,max=2000"`
+	Tags       []string `json:"tags,omitempty" validate:"omitempty,max=20,dive,tag"`
+	Preview    bool     `json:"preview,omitempty"`
+	// ExpiresAt is when the link stops redirecting; it must be in the future.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// Domain is a verified custom domain to create the link on.
+	Domain string `json:"domain,omitempty"`
+	// ReuseExisting returns the caller's existing link to the same canonical
+	// URL, if there is one, instead of creating a new link.
+	ReuseExisting bool `json:"reuse_existing,omitempty"`
+	// Rules send some visitors somewhere else than URL.
+	Rules []RedirectRule `json:"rules,omitempty" validate:"omitempty,max=20,dive"`
 }
 
 type ShortenResponse struct {
 	ShortID string `json:"short_id"`
+	Domain  string `json:"domain,omitempty"`
+}
+
+// BatchShortenResult is the outcome of one item of a batch, identified by
+// its position in the request. Error is a code such as "forbidden_input";
+// Message describes it.
+type BatchShortenResult struct {
+	Index   int    `json:"index"`
+	ShortID string `json:"short_id,omitempty"`
+	Domain  string `json:"domain,omitempty"`
+	Error   string `json:"error,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type BatchShortenResponse struct {
+	// IdempotencyKey lets the batch be retried without creating its links
+	// twice; it is generated unless the request had one.
+	IdempotencyKey string `json:"idempotency_key"`
+	// Replayed is set when the response was stored by an earlier request
+	// with the same key.
+	Replayed bool                 `json:"replayed,omitempty"`
+	Created  int                  `json:"created"`
+	Failed   int                  `json:"failed"`
+	Results  []BatchShortenResult `json:"results"`
 }
@@ -14,8 +14,46 @@ type UserLinksResponse struct {
 }
 
 type ShortlinkDTO struct {
-	ShortID   string    `json:"short_id"`
-	URL       string    `json:"url"`
-	CreatedAt time.Time `json:"created_at"`
-	IsPrivate bool      `json:"is_private"`
+	ShortID     string     `json:"short_id"`
+	Domain      string     `json:"domain,omitempty"`
+	URL         string     `json:"url"`
+	CreatedAt   time.Time  `json:"created_at"`
+	IsPrivate   bool       `json:"is_private"`
+	WorkspaceID string     `json:"workspace_id,omitempty"`
+	Title       string     `json:"title,omitempty"`
+	Notes       string     `json:"notes,omitempty"`
+	Preview     bool       `json:"preview,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	// Rules send some visitors somewhere else than URL.
+	Rules []RedirectRule `json:"rules,omitempty"`
+
+	ClickCount    int64      `json:"click_count"`
+	LastClickedAt *time.Time `json:"last_clicked_at,omitempty"`
+
+	Disabled       bool   `json:"disabled,omitempty"`
+	DisabledReason string `json:"disabled_reason,omitempty"`
+	ThreatType     string `json:"threat_type,omitempty"`
+}
+
+// UpdateShortlinkRequest is a partial update; nil fields are left untouched.
+type UpdateShortlinkRequest struct {
+	URL       *string `json:"url,omitempty" validate:"omitempty,url"`
+	IsPrivate *bool   `json:"is_private,omitempty"`
+	Title     *string `json:"title,omitempty" validate:"omitempty,max=200"`
+	Notes     *string `json:"notes,omitempty" validate:"omitempty,max=2000"`
+	// Tags replaces all tags of the link; an empty list removes them.
+	Tags    *[]string `json:"tags,omitempty" validate:"omitempty,max=20,dive,tag"`
+	Preview *bool     `json:"preview,omitempty"`
+	// ExpiresAt sets when the link stops redirecting; the zero time
+	// (0001-01-01T00:00:00Z) removes the expiry.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// Rules replaces all redirect rules of the link; an empty list removes
+	// them.
+	Rules *[]RedirectRule `json:"rules,omitempty" validate:"omitempty,max=20,dive"`
+}
+
+type TransferShortlinkRequest struct {
+	WorkspaceID string `json:"workspace_id,omitempty" validate:"required_without=NewOwner"`
+	NewOwner    string `json:"new_owner,omitempty" validate:"required_without=WorkspaceID"`
 }
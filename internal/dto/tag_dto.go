@@ -0,0 +1,35 @@
+package dto
+
+import "time"
+
+// TagSummary adds up the links with a tag.
+type TagSummary struct {
+	Tag           string     `json:"tag"`
+	Links         int        `json:"links"`
+	TotalClicks   int64      `json:"total_clicks"`
+	LastClickedAt *time.Time `json:"last_clicked_at,omitempty"`
+}
+
+type TagsResponse struct {
+	Tags []TagSummary `json:"tags"`
+}
+
+// TagLinkStats are the click counts of one link with a tag.
+type TagLinkStats struct {
+	ShortID       string     `json:"short_id"`
+	Domain        string     `json:"domain,omitempty"`
+	Title         string     `json:"title,omitempty"`
+	ClickCount    int64      `json:"click_count"`
+	LastClickedAt *time.Time `json:"last_clicked_at,omitempty"`
+}
+
+// TagAnalyticsResponse is the summary of a tag and its links, most clicked
+// first.
+type TagAnalyticsResponse struct {
+	TagSummary
+	LinkStats []TagLinkStats `json:"link_stats"`
+}
+
+type RenameTagRequest struct {
+	Name string `json:"name" validate:"required,tag"`
+}
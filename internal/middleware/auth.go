@@ -40,7 +40,7 @@ func (m *AuthMiddleware) RequireAuth(next httprouter.Handle) httprouter.Handle {
 			return
 		}
 
-		ctx := context.WithValue(r.Context(), utils.UserKey, token.UID)
+		ctx := withUserClaims(r.Context(), token)
 		r = r.WithContext(ctx)
 		next(w, r, p)
 	}
@@ -59,7 +59,7 @@ func (m *AuthMiddleware) OptionalAuth(next httprouter.Handle) httprouter.Handle
 				return
 			}
 
-			ctx := context.WithValue(r.Context(), utils.UserKey, token.UID)
+			ctx := withUserClaims(r.Context(), token)
 			r = r.WithContext(ctx)
 		}
 		next(w, r, p)
@@ -88,7 +88,16 @@ func (m *AuthMiddleware) RequireAdminAuth(next httprouter.Handle) httprouter.Han
 			return
 		}
 
-		ctx = context.WithValue(ctx, utils.UserKey, token.UID)
+		ctx = withUserClaims(ctx, token)
 		next(w, r.WithContext(ctx), ps)
 	}
 }
+
+func withUserClaims(ctx context.Context, token *auth.Token) context.Context {
+	ctx = context.WithValue(ctx, utils.UserKey, token.UID)
+	// invitations are matched on the email, so only a verified one is passed on
+	if email, ok := token.Claims["email"].(string); ok && token.Claims["email_verified"] == true {
+		ctx = context.WithValue(ctx, utils.UserEmailKey, email)
+	}
+	return ctx
+}
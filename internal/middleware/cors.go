@@ -4,11 +4,9 @@ import (
 	"net/http"
 	"os"
 	"strings"
-
-	"github.com/julienschmidt/httprouter"
 )
 
-func CORS(router *httprouter.Router) http.Handler {
+func CORS(router http.Handler) http.Handler {
 	allowed := strings.Split(os.Getenv("ALLOWED_ORIGINS"), ",")
 	allowedMap := make(map[string]bool)
 	for _, origin := range allowed {
@@ -19,8 +17,10 @@ func CORS(router *httprouter.Router) http.Handler {
 		origin := r.Header.Get("Origin")
 		if allowedMap[origin] {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PATCH, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID, Idempotency-Key")
+			// response headers browsers hide from scripts unless listed
+			w.Header().Set("Access-Control-Expose-Headers", "Idempotent-Replayed, Deprecation, Sunset, Link")
 			w.Header().Set("Access-Control-Allow-Credentials", "true")
 		}
 
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// Deprecation marks routes that have been replaced by another route.
+type Deprecation struct {
+	// Since is when the routes were deprecated.
+	Since time.Time
+	// Sunset is when they will be removed; zero if not decided yet.
+	Sunset time.Time
+}
+
+// Apply adds the Deprecation (RFC 9745), Sunset (RFC 8594) and a
+// successor-version Link header to the responses of next. The ":name"
+// parameters of the successor path are filled in from the route parameters
+// or the query string; the path is cut before the first one missing.
+func (d Deprecation) Apply(successor string, next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		h := w.Header()
+		h.Set("Deprecation", "@"+strconv.FormatInt(d.Since.Unix(), 10))
+		if !d.Sunset.IsZero() {
+			h.Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+		}
+		h.Add("Link", "<"+successorPath(successor, r, ps)+`>; rel="successor-version"`)
+		next(w, r, ps)
+	}
+}
+
+func successorPath(successor string, r *http.Request, ps httprouter.Params) string {
+	segments := strings.Split(successor, "/")
+	for i, seg := range segments {
+		name, ok := strings.CutPrefix(seg, ":")
+		if !ok {
+			continue
+		}
+		value := ps.ByName(name)
+		if value == "" {
+			value = r.URL.Query().Get(name)
+		}
+		if value == "" {
+			return strings.Join(segments[:i], "/")
+		}
+		segments[i] = url.PathEscape(value)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,330 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultIdempotencyWindow = 24 * time.Hour
+
+	// idempotencyLockTTL is how long a key stays claimed by a request that
+	// has not completed. The claim is renewed while the request runs, so this
+	// only bounds how long the key stays unusable when its instance dies.
+	idempotencyLockTTL = 2 * time.Minute
+
+	// MaxIdempotencyKeyLength bounds the Idempotency-Key header.
+	MaxIdempotencyKeyLength = 255
+
+	// maxIdempotentBody is the largest request body that is fingerprinted;
+	// requests with larger bodies are passed through without a key.
+	maxIdempotentBody = 10 << 20
+)
+
+var (
+	// claimed values are only changed by the request that holds them
+	renewClaimScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+	storeClaimScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+end
+return false`)
+	releaseClaimScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+type (
+	IdempotencyConfig struct {
+		// Window is how long a key and its response are kept; 0 turns keys off.
+		Window time.Duration
+	}
+
+	// Idempotency makes POST, PATCH and DELETE requests with an
+	// Idempotency-Key header safe to retry. The first request with a key runs
+	// and its response is kept in Redis for the window; later requests with the
+	// same key and the same method, path and body get that response again,
+	// with an Idempotent-Replayed header. Reusing a key for a different request
+	// is refused with 422, and a retry that arrives while the first request is
+	// still running with 409. Keys are scoped to the authenticated user.
+	Idempotency struct {
+		client *redis.Client
+		window time.Duration
+	}
+
+	// idempotentResponse is what is stored under a key. Until the request
+	// completes only the fingerprint and the claim are set.
+	idempotentResponse struct {
+		Fingerprint string `json:"fingerprint"`
+		// Claim tells the request holding the key apart from a later one
+		// with the same fingerprint.
+		Claim  string      `json:"claim,omitempty"`
+		Done   bool        `json:"done"`
+		Status int         `json:"status,omitempty"`
+		Header http.Header `json:"header,omitempty"`
+		Body   []byte      `json:"body,omitempty"`
+	}
+)
+
+// LoadIdempotencyConfig reads IDEMPOTENCY_KEY_TTL (default 24h, 0 disables).
+func LoadIdempotencyConfig() IdempotencyConfig {
+	config := IdempotencyConfig{Window: defaultIdempotencyWindow}
+	if v := strings.TrimSpace(os.Getenv("IDEMPOTENCY_KEY_TTL")); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			config.Window = d
+		} else {
+			log.Printf("Invalid IDEMPOTENCY_KEY_TTL %q, using %s", v, defaultIdempotencyWindow)
+		}
+	}
+	return config
+}
+
+func NewIdempotency(client *redis.Client, config IdempotencyConfig) *Idempotency {
+	return &Idempotency{client: client, window: config.Window}
+}
+
+// Apply honours the Idempotency-Key header on the mutating requests of next.
+// It must run after authentication. A nil Idempotency, or one without a
+// window, returns next unchanged.
+func (i *Idempotency) Apply(next httprouter.Handle) httprouter.Handle {
+	if i == nil || i.window <= 0 || i.client == nil {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		key := r.Header.Get("Idempotency-Key")
+		uid, _ := r.Context().Value(utils.UserKey).(string)
+		if key == "" || uid == "" || !isMutating(r.Method) {
+			next(w, r, ps)
+			return
+		}
+		if len(key) > MaxIdempotencyKeyLength {
+			http.Error(w, "Idempotency-Key is too long", http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBody+1))
+		if err != nil {
+			http.Error(w, "Failed to read request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+		if len(body) > maxIdempotentBody {
+			next(w, r, ps)
+			return
+		}
+
+		ctx := r.Context()
+		redisKey := "idempotency:" + uid + ":" + key
+		fingerprint := requestFingerprint(r, body)
+
+		pending, claimed, err := i.claim(ctx, redisKey, fingerprint)
+		if err != nil {
+			log.Printf("Error idempotency key: %v", err)
+			http.Error(w, "Idempotency store error", http.StatusInternalServerError)
+			return
+		}
+		if !claimed {
+			i.replay(ctx, w, redisKey, fingerprint)
+			return
+		}
+
+		// a panicking handler must not leave the key claimed; the panic goes
+		// on up to the server once the key is released
+		completed := false
+		done := make(chan struct{})
+		go i.renew(ctx, redisKey, pending, done)
+		defer func() {
+			close(done)
+			if !completed {
+				i.release(ctx, redisKey, pending)
+			}
+		}()
+
+		rec := &responseRecorder{header: make(http.Header), status: http.StatusOK}
+		next(rec, r, ps)
+		completed = true
+		i.store(ctx, redisKey, pending, fingerprint, rec)
+
+		for k, vals := range rec.header {
+			w.Header()[k] = vals
+		}
+		w.WriteHeader(rec.status)
+		w.Write(rec.body.Bytes())
+	}
+}
+
+// claim reserves key for the request with the given fingerprint and returns
+// the value it holds the key with. It is false when the key is already in
+// use. The claim expires after claimTTL unless it is renewed; store keeps the
+// response for the whole window.
+func (i *Idempotency) claim(ctx context.Context, key, fingerprint string) (string, bool, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", false, err
+	}
+	pending, err := json.Marshal(idempotentResponse{Fingerprint: fingerprint, Claim: hex.EncodeToString(b)})
+	if err != nil {
+		return "", false, err
+	}
+	claimed, err := i.client.SetNX(ctx, key, pending, i.claimTTL()).Result()
+	return string(pending), claimed, err
+}
+
+func (i *Idempotency) claimTTL() time.Duration {
+	return min(idempotencyLockTTL, i.window)
+}
+
+// renew keeps key claimed by pending until done is closed, so a retry cannot
+// run the request again however long the first one takes.
+func (i *Idempotency) renew(ctx context.Context, key, pending string, done <-chan struct{}) {
+	// the handler may still be running after the client went away
+	ctx = context.WithoutCancel(ctx)
+	ttl := i.claimTTL()
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		n, err := renewClaimScript.Run(ctx, i.client, []string{key}, pending, ttl.Milliseconds()).Int()
+		switch {
+		case err != nil:
+			log.Printf("Failed to renew idempotency key: %v", err)
+		case n == 0:
+			return
+		}
+	}
+}
+
+// release frees a key claimed by pending so the request can be retried with
+// it.
+func (i *Idempotency) release(ctx context.Context, key, pending string) {
+	if err := releaseClaimScript.Run(context.WithoutCancel(ctx), i.client, []string{key}, pending).Err(); err != nil {
+		log.Printf("Failed to release idempotency key: %v", err)
+	}
+}
+
+// store keeps the response under a key that is still claimed by pending.
+// Server errors are not kept, so the request can be retried with the same
+// key.
+func (i *Idempotency) store(ctx context.Context, key, pending, fingerprint string, rec *responseRecorder) {
+	// the response is stored even if the client went away meanwhile
+	ctx = context.WithoutCancel(ctx)
+
+	if rec.status >= http.StatusInternalServerError {
+		i.release(ctx, key, pending)
+		return
+	}
+
+	stored, err := json.Marshal(idempotentResponse{
+		Fingerprint: fingerprint,
+		Done:        true,
+		Status:      rec.status,
+		Header:      rec.header,
+		Body:        rec.body.Bytes(),
+	})
+	if err == nil {
+		err = storeClaimScript.Run(ctx, i.client, []string{key}, pending, stored, i.window.Milliseconds()).Err()
+	}
+	switch {
+	case errors.Is(err, redis.Nil):
+		log.Printf("Idempotency key %s was no longer claimed, its response is not stored", key)
+	case err != nil:
+		log.Printf("Failed to store idempotent response: %v", err)
+	}
+}
+
+func (i *Idempotency) replay(ctx context.Context, w http.ResponseWriter, key, fingerprint string) {
+	raw, err := i.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// the first request failed and released the key in the meantime
+		http.Error(w, "A request with this Idempotency-Key was in progress, try again", http.StatusConflict)
+		return
+	}
+	var stored idempotentResponse
+	if err == nil {
+		err = json.Unmarshal(raw, &stored)
+	}
+	if err != nil {
+		log.Printf("Error idempotency key: %v", err)
+		http.Error(w, "Idempotency store error", http.StatusInternalServerError)
+		return
+	}
+
+	switch {
+	case stored.Fingerprint != fingerprint:
+		http.Error(w, shortlink_errors.ErrIdempotencyKeyReused.Error(), http.StatusUnprocessableEntity)
+	case !stored.Done:
+		http.Error(w, "A request with this Idempotency-Key is still in progress", http.StatusConflict)
+	default:
+		for k, vals := range stored.Header {
+			w.Header()[k] = vals
+		}
+		w.Header().Set("Idempotent-Replayed", "true")
+		w.WriteHeader(stored.Status)
+		w.Write(stored.Body)
+	}
+}
+
+// requestFingerprint identifies a request by its method, path, query and body.
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func isMutating(method string) bool {
+	return method == http.MethodPost || method == http.MethodPatch || method == http.MethodDelete
+}
+
+// responseRecorder holds a response back until it has been stored.
+type responseRecorder struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) Header() http.Header { return r.header }
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.body.Write(b)
+}
@@ -0,0 +1,275 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+)
+
+func init() {
+	openapi3filter.RegisterBodyDecoder("application/x-ndjson", ndjsonBodyDecoder)
+	// QR codes
+	openapi3filter.RegisterBodyDecoder("image/png", openapi3filter.FileBodyDecoder)
+	openapi3filter.RegisterBodyDecoder("image/svg+xml", openapi3filter.PlainBodyDecoder)
+}
+
+// OpenAPIValidator checks requests and responses against the OpenAPI spec.
+// Invalid requests are refused with 400 before they reach the handlers.
+// Responses that do not match the spec are logged, or replaced with a 500 in
+// strict mode so tests notice them. Only JSON responses are held back to
+// check their body; others, like the click exports, are streamed and only
+// their status and headers are checked. Paths missing from the spec, such as the
+// static docs, are passed through unchecked.
+type OpenAPIValidator struct {
+	router routers.Router
+	strict bool
+}
+
+// NewOpenAPIValidator loads the spec at specPath. Its servers are ignored:
+// requests are matched on the path alone, whatever host they were sent to.
+func NewOpenAPIValidator(specPath string, strict bool) (*OpenAPIValidator, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromFile(specPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load OpenAPI spec: %w", err)
+	}
+	if err := doc.Validate(loader.Context); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI spec: %w", err)
+	}
+	doc.Servers = nil
+
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to route OpenAPI spec: %w", err)
+	}
+	return &OpenAPIValidator{router: router, strict: strict}, nil
+}
+
+// LoadOpenAPIValidator reads OPENAPI_VALIDATION: "off", "warn" (invalid
+// responses are logged) or "strict" (invalid responses become a 500). It
+// defaults to "warn" when APP_ENV is development and "off" otherwise, in
+// which case the validator is nil and Handler returns the handler unchanged.
+func LoadOpenAPIValidator(specPath string) (*OpenAPIValidator, error) {
+	mode := strings.ToLower(strings.TrimSpace(os.Getenv("OPENAPI_VALIDATION")))
+	if mode == "" {
+		mode = "off"
+		if os.Getenv("APP_ENV") == "development" {
+			mode = "warn"
+		}
+	}
+
+	switch mode {
+	case "off":
+		return nil, nil
+	case "warn", "strict":
+		return NewOpenAPIValidator(specPath, mode == "strict")
+	default:
+		log.Printf("Invalid OPENAPI_VALIDATION %q, validation is off", mode)
+		return nil, nil
+	}
+}
+
+func (v *OpenAPIValidator) Handler(next http.Handler) http.Handler {
+	if v == nil {
+		return next
+	}
+
+	options := &openapi3filter.Options{
+		// authentication is checked by AuthMiddleware, with the real tokens
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, params, err := v.router.FindRoute(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		request := &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: params,
+			Route:      route,
+			Options:    options,
+		}
+		if err := openapi3filter.ValidateRequest(r.Context(), request); err != nil {
+			http.Error(w, "Invalid request: "+validationMessage(err), http.StatusBadRequest)
+			return
+		}
+
+		vw := &validatingWriter{w: w, header: make(http.Header), status: http.StatusOK}
+		// check reports whether the response may be sent; a body of nil is
+		// not checked
+		vw.check = func(body []byte) bool {
+			opts := *options
+			opts.ExcludeResponseBody = body == nil
+			response := &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: request,
+				Status:                 vw.status,
+				Header:                 vw.header,
+				Body:                   io.NopCloser(bytes.NewReader(body)),
+				Options:                &opts,
+			}
+			if err := openapi3filter.ValidateResponse(r.Context(), response); err != nil {
+				msg := fmt.Sprintf("%s %s: %d response does not match the OpenAPI spec: %s", r.Method, route.Path, vw.status, validationMessage(err))
+				log.Print(msg)
+				if v.strict {
+					http.Error(w, msg, http.StatusInternalServerError)
+					return false
+				}
+			}
+			return true
+		}
+
+		next.ServeHTTP(vw, r)
+		vw.finish()
+	})
+}
+
+// validatingWriter holds a JSON response back until its body has been
+// validated. Any other response, a download (with Content-Disposition) or
+// one the handler flushes is checked without its body as soon as its header
+// is written, and then streamed.
+type validatingWriter struct {
+	w           http.ResponseWriter
+	check       func(body []byte) bool
+	header      http.Header
+	status      int
+	wroteHeader bool
+	streaming   bool
+	// discard drops the rest of a streamed response that failed the check
+	discard bool
+	body    bytes.Buffer
+}
+
+func (v *validatingWriter) Header() http.Header { return v.header }
+
+func (v *validatingWriter) WriteHeader(status int) {
+	if v.wroteHeader {
+		return
+	}
+	v.status, v.wroteHeader = status, true
+	if !isJSON(v.header.Get("Content-Type")) || v.header.Get("Content-Disposition") != "" {
+		v.stream()
+	}
+}
+
+func (v *validatingWriter) Write(b []byte) (int, error) {
+	if !v.wroteHeader {
+		if v.header.Get("Content-Type") == "" {
+			v.header.Set("Content-Type", http.DetectContentType(b))
+		}
+		v.WriteHeader(http.StatusOK)
+	}
+	switch {
+	case v.discard:
+		return len(b), nil
+	case v.streaming:
+		return v.w.Write(b)
+	default:
+		return v.body.Write(b)
+	}
+}
+
+func (v *validatingWriter) Flush() {
+	if !v.wroteHeader {
+		v.WriteHeader(http.StatusOK)
+	}
+	v.stream()
+	if f, ok := v.w.(http.Flusher); ok && !v.discard {
+		f.Flush()
+	}
+}
+
+// stream checks the status and headers and sends them with what has been
+// written so far; later writes go straight to the client.
+func (v *validatingWriter) stream() {
+	if v.streaming {
+		return
+	}
+	v.streaming = true
+	if !v.check(nil) {
+		v.discard = true
+		return
+	}
+	v.send()
+}
+
+// finish sends a response that was held back, once its body is checked.
+func (v *validatingWriter) finish() {
+	if v.streaming {
+		return
+	}
+	if v.check(v.body.Bytes()) {
+		v.send()
+	}
+}
+
+func (v *validatingWriter) send() {
+	for k, vals := range v.header {
+		v.w.Header()[k] = vals
+	}
+	v.w.WriteHeader(v.status)
+	v.w.Write(v.body.Bytes())
+	v.body.Reset()
+}
+
+// isJSON reports whether contentType is JSON, like application/json or
+// application/problem+json.
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
+
+// validationMessage leaves the offending schema out of schema errors, which
+// would otherwise be printed in full.
+func validationMessage(err error) string {
+	var prefix string
+	var reqErr *openapi3filter.RequestError
+	if errors.As(err, &reqErr) {
+		switch {
+		case reqErr.Parameter != nil:
+			prefix = fmt.Sprintf("parameter %q: ", reqErr.Parameter.Name)
+		case reqErr.RequestBody != nil:
+			prefix = "request body: "
+		}
+	}
+
+	var schemaErr *openapi3.SchemaError
+	if !errors.As(err, &schemaErr) {
+		return err.Error()
+	}
+	if pointer := schemaErr.JSONPointer(); len(pointer) > 0 {
+		return prefix + "/" + strings.Join(pointer, "/") + ": " + schemaErr.Reason
+	}
+	return prefix + schemaErr.Reason
+}
+
+// ndjsonBodyDecoder reads newline-delimited JSON as an array of its values.
+func ndjsonBodyDecoder(body io.Reader, _ http.Header, _ *openapi3.SchemaRef, _ openapi3filter.EncodingFn) (any, error) {
+	values := []any{}
+	dec := json.NewDecoder(body)
+	for {
+		var v any
+		err := dec.Decode(&v)
+		if err == io.EOF {
+			return values, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+}
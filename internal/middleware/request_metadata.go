@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	nanoid "github.com/matoous/go-nanoid/v2"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// RequestMetadata attaches a request ID and the client IP to the request context.
+// An incoming X-Request-ID is reused so IDs can be correlated across services.
+func RequestMetadata(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(requestIDHeader)
+		if requestID == "" || len(requestID) > 64 {
+			id, err := nanoid.New()
+			if err != nil {
+				log.Println("Error generating request ID:", err)
+			}
+			requestID = id
+		}
+		w.Header().Set(requestIDHeader, requestID)
+
+		ctx := context.WithValue(r.Context(), utils.RequestIDKey, requestID)
+		ctx = context.WithValue(ctx, utils.ClientIPKey, utils.ClientIP(r))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+const (
+	AuditBlacklistAdd     = "blacklist.add"
+	AuditBlacklistRemove  = "blacklist.remove"
+	AuditBlacklistImport  = "blacklist.import"
+	AuditReservedIDAdd    = "reserved_id.add"
+	AuditReservedIDRemove = "reserved_id.remove"
+	AuditLinkUpdate       = "link.update"
+	AuditLinkDelete       = "link.delete"
+	AuditLinkTransfer     = "link.transfer"
+	AuditMemberJoin       = "workspace.member_join"
+	AuditMemberRoleUpdate = "workspace.member_role_update"
+	AuditMemberRemove     = "workspace.member_remove"
+	AuditTagRename        = "tag.rename"
+	AuditTagDelete        = "tag.delete"
+)
+
+type AuditLog struct {
+	ActorUID   string                 `json:"actor_uid" firestore:"actor_uid"`
+	Action     string                 `json:"action" firestore:"action"`
+	TargetType string                 `json:"target_type" firestore:"target_type"`
+	TargetID   string                 `json:"target_id" firestore:"target_id"`
+	Before     map[string]interface{} `json:"before,omitempty" firestore:"before"`
+	After      map[string]interface{} `json:"after,omitempty" firestore:"after"`
+	IP         string                 `json:"ip" firestore:"ip"`
+	RequestID  string                 `json:"request_id" firestore:"request_id"`
+	Timestamp  time.Time              `json:"timestamp" firestore:"timestamp"`
+}
+
+const (
+	AuditTargetBlacklist  = "blacklist"
+	AuditTargetReservedID = "reserved_id"
+	AuditTargetShortlink  = "shortlink"
+	AuditTargetMember     = "workspace_member"
+	AuditTargetTag        = "tag"
+)
@@ -1,6 +1,31 @@
 package models
 
+import "time"
+
+// SourceManual marks entries added by an admin, either one by one or through
+// a bulk import. Feed entries use the feed name as their source.
+const SourceManual = "manual"
+
+// Blacklist rule types.
+const (
+	BlacklistDomain       = "domain"        // exact hostname
+	BlacklistDomainSuffix = "domain_suffix" // hostname and all of its subdomains
+	BlacklistURL          = "url"           // exact host and path
+	BlacklistURLPrefix    = "url_prefix"    // host and path prefix, matched per path segment
+	BlacklistRegex        = "regex"         // regular expression on the full URL
+)
+
+// Blacklist rule actions. Rules without an action block.
+const (
+	BlacklistActionBlock   = "block"   // refuse new links and disable existing ones
+	BlacklistActionPreview = "preview" // keep links working but always show the preview page
+)
+
 type BlacklistItem struct {
-	Type  string `json:"type"`
-	Value string `json:"value"`
+	ID        string    `json:"id,omitempty" firestore:"-"`
+	Type      string    `json:"type" firestore:"type"`
+	Value     string    `json:"value" firestore:"value"`
+	Action    string    `json:"action,omitempty" firestore:"action,omitempty"`
+	Source    string    `json:"source,omitempty" firestore:"source"`
+	CreatedAt time.Time `json:"created_at,omitempty" firestore:"created_at"`
 }
@@ -2,9 +2,33 @@ package models
 
 import "time"
 
+// ClickSourceQR marks clicks that came from scanning a link's QR code, whose
+// URL carries ?src=qr.
+const ClickSourceQR = "qr"
+
 type ClickLog struct {
 	ShortID    string    `json:"short_id,omitempty" firestore:"short_id"`
 	Timestamp  time.Time `json:"timestamp" firestore:"timestamp"`
 	IP         string    `json:"ip" firestore:"ip"`
 	UserAgent  string    `json:"user_agent" firestore:"user_agent"`
+	// Source is where the click came from, such as ClickSourceQR; empty for
+	// plain visits of the short URL.
+	Source     string    `json:"source,omitempty" firestore:"source,omitempty"`
+	// RuleID is the ID of the redirect rule that picked the destination;
+	// empty when the visitor went to the link's URL.
+	RuleID     string    `json:"rule_id,omitempty" firestore:"rule_id,omitempty"`
+}
+
+// IsClickSource reports whether source is recorded on click logs. Other src
+// values in short URLs are ignored.
+func IsClickSource(source string) bool {
+	return source == ClickSourceQR
+}
+
+// ClickCounts are the clicks of a link not yet added to its click_count, and
+// the time of the last of them.
+type ClickCounts struct {
+	ShortID       string
+	Clicks        int64
+	LastClickedAt time.Time
 }
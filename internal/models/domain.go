@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Domain is a custom domain links can be created on, e.g. go.example.com.
+// It is owned by a user or, with WorkspaceID, by a workspace, and can only be
+// used once the owner has proven control of it with a DNS TXT record.
+type Domain struct {
+	Host              string    `firestore:"host"`
+	OwnerUID          string    `firestore:"owner_uid"`
+	WorkspaceID       string    `firestore:"workspace_id"`
+	VerificationToken string    `firestore:"verification_token"`
+	Verified          bool      `firestore:"verified"`
+	VerifiedAt        time.Time `firestore:"verified_at"`
+	CreatedAt         time.Time `firestore:"created_at"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+const (
+	NotificationLinkDisabled = "link.disabled"
+	NotificationLinkEnabled  = "link.enabled"
+)
+
+// Notification is a message for a single user, e.g. that one of their links
+// was disabled because its destination got blacklisted.
+type Notification struct {
+	ID        string    `json:"id" firestore:"-"`
+	UID       string    `json:"-" firestore:"uid"`
+	Type      string    `json:"type" firestore:"type"`
+	ShortID   string    `json:"short_id,omitempty" firestore:"short_id"`
+	Message   string    `json:"message" firestore:"message"`
+	CreatedAt time.Time `json:"created_at" firestore:"created_at"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Devices a redirect rule can target, told apart by the User-Agent.
+const (
+	DeviceIOS     = "ios"
+	DeviceAndroid = "android"
+	DeviceDesktop = "desktop"
+)
+
+// RedirectRule sends the visitors of a link who meet all of its conditions
+// to URL instead of the link's own URL. The rules of a link are tried in
+// order and the first one that matches wins; a condition left empty matches
+// everyone.
+type RedirectRule struct {
+	// ID names the rule in the click logs; it is unique within the link.
+	ID  string `firestore:"id"`
+	URL string `firestore:"url"`
+	// Devices are DeviceIOS, DeviceAndroid or DeviceDesktop.
+	Devices []string `firestore:"devices"`
+	// Countries are upper-case ISO 3166-1 alpha-2 codes, looked up from the
+	// visitor's IP address.
+	Countries []string `firestore:"countries"`
+	// Languages are lower-case language tags matched against the visitor's
+	// preferred language; "en" also matches "en-gb".
+	Languages []string      `firestore:"languages"`
+	Schedule  *RuleSchedule `firestore:"schedule"`
+}
+
+// RuleSchedule limits a rule to a period and to some days and hours.
+type RuleSchedule struct {
+	// StartsAt and EndsAt bound the period; zero if open-ended.
+	StartsAt time.Time `firestore:"starts_at"`
+	EndsAt   time.Time `firestore:"ends_at"`
+	// Days are "mon" to "sun"; From and To are times of day such as "09:00".
+	// A To before From spans midnight. Both are in Timezone, UTC if empty.
+	Days     []string `firestore:"days"`
+	From     string   `firestore:"from"`
+	To       string   `firestore:"to"`
+	Timezone string   `firestore:"timezone"`
+}
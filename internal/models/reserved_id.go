@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Reserved ID match modes.
+const (
+	ReservedMatchExact    = "exact"    // the whole ID
+	ReservedMatchContains = "contains" // anywhere in the ID
+)
+
+// Reserved ID sources besides SourceManual. Words from a word file use
+// "file:" followed by the file name.
+const SourceBuiltin = "builtin"
+
+// ReservedID is a word that may not be used as a short ID. Matching ignores
+// case and common leetspeak substitutions.
+type ReservedID struct {
+	Word  string `json:"word" firestore:"word"`
+	Match string `json:"match" firestore:"match"`
+	// WorkspaceID reserves the word for one workspace, e.g. a trademark:
+	// links in that workspace may use it, everyone else is refused.
+	WorkspaceID string    `json:"workspace_id,omitempty" firestore:"workspace_id,omitempty"`
+	Source      string    `json:"source,omitempty" firestore:"source"`
+	CreatedAt   time.Time `json:"created_at,omitempty" firestore:"created_at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// ShortenBatch records the response to a batch shorten request, so that a
+// retry with the same idempotency key returns the links created the first
+// time instead of creating them again.
+type ShortenBatch struct {
+	UID            string `firestore:"uid"`
+	IdempotencyKey string `firestore:"idempotency_key"`
+	// RequestHash is the SHA-256 of the request items; a key may only be
+	// reused for the same items.
+	RequestHash string `firestore:"request_hash"`
+	// Response is the JSON-encoded response, empty while the batch that
+	// claimed the key is still being created.
+	Response  string    `firestore:"response"`
+	CreatedAt time.Time `firestore:"created_at"`
+}
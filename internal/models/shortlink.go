@@ -1,11 +1,113 @@
 package models
 
-import "time"
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// Reasons a shortlink can be disabled by the service.
+const (
+	DisabledReasonBlacklisted = "blacklisted"
+	DisabledReasonUnsafe      = "unsafe"
+)
+
+// DisabledRuleSafeBrowsing marks links disabled by the Safe Browsing rescan.
+const DisabledRuleSafeBrowsing = "safebrowsing"
 
 type Shortlink struct {
-	ShortID   string    `firestore:"short_id"`
-	URL       string    `firestore:"url"`
-	CreatedAt time.Time `firestore:"created_at"`
-	CreatedBy string    `firestore:"created_by"`
+	ShortID     string    `firestore:"short_id"`
+	URL         string    `firestore:"url"`
+	CreatedAt   time.Time `firestore:"created_at"`
+	CreatedBy   string    `firestore:"created_by"`
 	IsPrivate   bool      `firestore:"is_private"`
-}
\ No newline at end of file
+	WorkspaceID string    `firestore:"workspace_id"`
+	Title       string    `firestore:"title"`
+	Notes       string    `firestore:"notes"`
+	// Domain is the custom domain the link lives on, empty for the
+	// service's own domain.
+	Domain string `firestore:"domain"`
+	// CanonicalURL is URL canonicalised with urlcanon; links with the same
+	// canonical URL lead to the same place.
+	CanonicalURL string `firestore:"canonical_url"`
+	// Preview makes /r/{short_id} show an interstitial page with the
+	// destination instead of redirecting right away.
+	Preview bool `firestore:"preview"`
+	// ExpiresAt is when the link stops redirecting; zero if it never does.
+	ExpiresAt time.Time `firestore:"expires_at"`
+	// Tags are lower-case and unique, see NormalizeTags.
+	Tags []string `firestore:"tags"`
+	// DestinationHost is the host of CanonicalURL, so links can be listed by
+	// destination.
+	DestinationHost string `firestore:"destination_host"`
+	// Rules send some visitors elsewhere; URL is where the others go.
+	Rules []RedirectRule `firestore:"rules"`
+
+	// ClickCount and LastClickedAt are updated with every tracked click so
+	// links can be sorted by them.
+	ClickCount    int64     `firestore:"click_count"`
+	LastClickedAt time.Time `firestore:"last_clicked_at"`
+
+	// A disabled link no longer redirects. DisabledRule records what disabled
+	// it (e.g. "domain:evil.com") so removing that rule can re-enable it.
+	Disabled       bool      `firestore:"disabled"`
+	DisabledReason string    `firestore:"disabled_reason"`
+	DisabledRule   string    `firestore:"disabled_rule"`
+	DisabledAt     time.Time `firestore:"disabled_at"`
+	// ThreatType is the Safe Browsing threat type, e.g. MALWARE, of links
+	// disabled by a rescan.
+	ThreatType string `firestore:"threat_type"`
+}
+
+// LinkKey identifies a link: its short ID on the service's own domain and
+// "shortID@domain" on a custom domain, so short IDs only need to be unique
+// per domain. It is also the link's document ID.
+func LinkKey(domain, shortID string) string {
+	if domain == "" {
+		return shortID
+	}
+	return shortID + "@" + domain
+}
+
+// SplitLinkKey is the inverse of LinkKey.
+func SplitLinkKey(key string) (shortID, domain string) {
+	shortID, domain, _ = strings.Cut(key, "@")
+	return shortID, domain
+}
+
+func (l *Shortlink) Key() string {
+	return LinkKey(l.Domain, l.ShortID)
+}
+
+// NormalizeTags lower-cases and trims tags and drops empty and repeated
+// ones, keeping their order.
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// Destinations returns the link's URL followed by the other URLs its
+// redirect rules send visitors to, each once.
+func (l *Shortlink) Destinations() []string {
+	destinations := []string{l.URL}
+	for _, rule := range l.Rules {
+		if !slices.Contains(destinations, rule.URL) {
+			destinations = append(destinations, rule.URL)
+		}
+	}
+	return destinations
+}
+
+// Expired reports whether the link had an expiry time before now.
+func (l *Shortlink) Expired(now time.Time) bool {
+	return !l.ExpiresAt.IsZero() && !now.Before(l.ExpiresAt)
+}
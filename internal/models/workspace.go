@@ -0,0 +1,78 @@
+package models
+
+import "time"
+
+type WorkspaceRole string
+
+const (
+	RoleOwner  WorkspaceRole = "owner"
+	RoleEditor WorkspaceRole = "editor"
+	RoleViewer WorkspaceRole = "viewer"
+)
+
+type Permission string
+
+const (
+	PermissionView   Permission = "view"   // read links, analytics and exports
+	PermissionEdit   Permission = "edit"   // create and modify links
+	PermissionManage Permission = "manage" // manage members, invitations and ownership
+)
+
+// Can reports whether the role grants the given permission.
+func (r WorkspaceRole) Can(p Permission) bool {
+	switch r {
+	case RoleOwner:
+		return true
+	case RoleEditor:
+		return p == PermissionView || p == PermissionEdit
+	case RoleViewer:
+		return p == PermissionView
+	}
+	return false
+}
+
+// Outranks reports whether the role grants more than other.
+func (r WorkspaceRole) Outranks(other WorkspaceRole) bool {
+	return r.rank() > other.rank()
+}
+
+func (r WorkspaceRole) rank() int {
+	switch r {
+	case RoleOwner:
+		return 3
+	case RoleEditor:
+		return 2
+	case RoleViewer:
+		return 1
+	}
+	return 0
+}
+
+func (r WorkspaceRole) IsValid() bool {
+	return r == RoleOwner || r == RoleEditor || r == RoleViewer
+}
+
+type Workspace struct {
+	ID        string    `firestore:"id"`
+	Name      string    `firestore:"name"`
+	CreatedBy string    `firestore:"created_by"`
+	CreatedAt time.Time `firestore:"created_at"`
+}
+
+type WorkspaceMember struct {
+	WorkspaceID string        `firestore:"workspace_id"`
+	UID         string        `firestore:"uid"`
+	Email       string        `firestore:"email"`
+	Role        WorkspaceRole `firestore:"role"`
+	JoinedAt    time.Time     `firestore:"joined_at"`
+}
+
+type WorkspaceInvitation struct {
+	ID          string        `firestore:"id"`
+	WorkspaceID string        `firestore:"workspace_id"`
+	Email       string        `firestore:"email"`
+	Role        WorkspaceRole `firestore:"role"`
+	InvitedBy   string        `firestore:"invited_by"`
+	CreatedAt   time.Time     `firestore:"created_at"`
+	ExpiresAt   time.Time     `firestore:"expires_at"`
+}
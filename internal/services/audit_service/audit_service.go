@@ -0,0 +1,73 @@
+package audit_service
+
+import (
+	"context"
+	"time"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	firestoreService "github.com/mfmahendr/url-shortener-backend/internal/services/firestore"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/validators"
+)
+
+type (
+	// Recorder is the write side of the audit trail, used by services that
+	// change policy or ownership.
+	Recorder interface {
+		Record(ctx context.Context, action, targetType, targetID string, before, after map[string]interface{}) error
+	}
+
+	AuditService interface {
+		Recorder
+		ListAuditLogs(ctx context.Context, q dto.AuditLogsQuery) (*dto.AuditLogsResponse, error)
+	}
+
+	AuditServiceImpl struct {
+		firestore firestoreService.AuditLog
+	}
+)
+
+func New(fs firestoreService.AuditLog) AuditService {
+	return &AuditServiceImpl{firestore: fs}
+}
+
+// Record appends an entry for the request in ctx. The actor, client IP and
+// request ID are taken from the context set up by the middleware chain.
+func (s *AuditServiceImpl) Record(ctx context.Context, action, targetType, targetID string, before, after map[string]interface{}) error {
+	actor, _ := ctx.Value(utils.UserKey).(string)
+	ip, _ := ctx.Value(utils.ClientIPKey).(string)
+	requestID, _ := ctx.Value(utils.RequestIDKey).(string)
+
+	return s.firestore.AddAuditLog(ctx, &models.AuditLog{
+		ActorUID:   actor,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Before:     before,
+		After:      after,
+		IP:         ip,
+		RequestID:  requestID,
+		Timestamp:  time.Now(),
+	})
+}
+
+func (s *AuditServiceImpl) ListAuditLogs(ctx context.Context, q dto.AuditLogsQuery) (*dto.AuditLogsResponse, error) {
+	if err := validators.Validate.Struct(q); err != nil {
+		return nil, shortlink_errors.ErrValidateRequest
+	}
+
+	logs, nextCursor, err := s.firestore.ListAuditLogs(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	if logs == nil {
+		logs = []models.AuditLog{}
+	}
+
+	return &dto.AuditLogsResponse{
+		Logs:       logs,
+		NextCursor: nextCursor,
+	}, nil
+}
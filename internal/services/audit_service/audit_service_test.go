@@ -0,0 +1,75 @@
+package audit_service_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/audit_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/validators"
+)
+
+// *--- MOCK DEFINITIONS ---* //
+// Firestore audit log SERVICE
+type MockAuditLog struct{ mock.Mock }
+
+func (m *MockAuditLog) AddAuditLog(ctx context.Context, entry *models.AuditLog) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (m *MockAuditLog) ListAuditLogs(ctx context.Context, q dto.AuditLogsQuery) ([]models.AuditLog, string, error) {
+	args := m.Called(ctx, q)
+	return args.Get(0).([]models.AuditLog), args.String(1), args.Error(2)
+}
+
+func TestMain(m *testing.M) {
+	validators.Init()
+	os.Exit(m.Run())
+}
+
+// *--- TEST CASES ---* //
+func TestRecord(t *testing.T) {
+	store := new(MockAuditLog)
+	svc := audit_service.New(store)
+
+	ctx := context.WithValue(context.Background(), utils.UserKey, "admin1")
+	ctx = context.WithValue(ctx, utils.ClientIPKey, "203.0.113.7")
+	ctx = context.WithValue(ctx, utils.RequestIDKey, "req-1")
+
+	store.On("AddAuditLog", mock.Anything, mock.MatchedBy(func(e *models.AuditLog) bool {
+		return e.ActorUID == "admin1" &&
+			e.IP == "203.0.113.7" &&
+			e.RequestID == "req-1" &&
+			e.Action == models.AuditBlacklistAdd &&
+			e.TargetID == "domain:bad.com" &&
+			!e.Timestamp.IsZero()
+	})).Return(nil).Once()
+
+	err := svc.Record(ctx, models.AuditBlacklistAdd, models.AuditTargetBlacklist, "domain:bad.com", nil, map[string]interface{}{"value": "bad.com"})
+	require.NoError(t, err)
+	store.AssertExpectations(t)
+}
+
+func TestListAuditLogs(t *testing.T) {
+	store := new(MockAuditLog)
+	svc := audit_service.New(store)
+
+	t.Run("Empty result is an empty list", func(t *testing.T) {
+		store.On("ListAuditLogs", mock.Anything, mock.Anything).Return([]models.AuditLog(nil), "", nil).Once()
+
+		resp, err := svc.ListAuditLogs(context.Background(), dto.AuditLogsQuery{})
+		require.NoError(t, err)
+		assert.NotNil(t, resp.Logs)
+		assert.Empty(t, resp.Logs)
+	})
+
+	store.AssertExpectations(t)
+}
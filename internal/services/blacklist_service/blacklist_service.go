@@ -0,0 +1,247 @@
+package blacklist_service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/audit_service"
+	firestoreService "github.com/mfmahendr/url-shortener-backend/internal/services/firestore"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+)
+
+type (
+	BlacklistService interface {
+		Add(ctx context.Context, req dto.BlacklistItemRequest) (*models.BlacklistItem, error)
+		Remove(ctx context.Context, req dto.BlacklistItemRequest, reenable bool) error
+		Import(ctx context.Context, r io.Reader, format string) (*dto.BlacklistImportResponse, error)
+		Export(ctx context.Context, w io.Writer, format string) error
+		// RequiresPreview reports whether a preview rule matches targetURL.
+		RequiresPreview(ctx context.Context, targetURL string) (bool, error)
+	}
+
+	BlacklistServiceImpl struct {
+		store    firestoreService.BlacklistStore
+		audit    audit_service.Recorder
+		index    *Index
+		enforcer LinkEnforcer
+	}
+)
+
+func New(store firestoreService.BlacklistStore, audit audit_service.Recorder, index *Index, enforcer LinkEnforcer) BlacklistService {
+	return &BlacklistServiceImpl{store: store, audit: audit, index: index, enforcer: enforcer}
+}
+
+// Add stores a single manual rule. "*.evil.com" given as a domain becomes a
+// domain_suffix rule. Existing links matching a blocking rule are disabled in
+// the background; preview rules only affect redirects.
+func (s *BlacklistServiceImpl) Add(ctx context.Context, req dto.BlacklistItemRequest) (*models.BlacklistItem, error) {
+	item, err := toRule(req)
+	if err != nil {
+		return nil, err
+	}
+	item.Source, item.CreatedAt = models.SourceManual, time.Now()
+
+	if err := s.store.AddBlacklistItem(ctx, item); err != nil {
+		return nil, err
+	}
+	if item.ID, err = firestoreService.BlacklistItemID(item); err != nil {
+		return nil, err
+	}
+	s.index.Invalidate()
+	if item.Action != models.BlacklistActionPreview {
+		s.enforce(ctx, []models.BlacklistItem{item})
+	}
+	return &item, nil
+}
+
+// Remove deletes a manual rule. With reenable, the links it disabled are
+// re-enabled in the background unless another rule still blocks them.
+func (s *BlacklistServiceImpl) Remove(ctx context.Context, req dto.BlacklistItemRequest, reenable bool) error {
+	item, err := toRule(req)
+	if err != nil {
+		return err
+	}
+
+	if err := s.store.RemoveBlacklistItem(ctx, item); err != nil {
+		return err
+	}
+	s.index.Invalidate()
+	if reenable {
+		s.reenable(ctx, item)
+	}
+	return nil
+}
+
+// enforce and reenable run detached from the request: a scan over every
+// shortlink can take much longer than the admin is willing to wait.
+func (s *BlacklistServiceImpl) enforce(ctx context.Context, rules []models.BlacklistItem) {
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		n, err := s.enforcer.DisableMatching(ctx, rules)
+		if err != nil {
+			log.Printf("Failed to disable links matching new blacklist rules (%d disabled so far): %v", n, err)
+			return
+		}
+		log.Printf("Disabled %d links matching %d new blacklist rules", n, len(rules))
+	}()
+}
+
+func (s *BlacklistServiceImpl) reenable(ctx context.Context, rule models.BlacklistItem) {
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		n, err := s.enforcer.ReenableMatching(ctx, []models.BlacklistItem{rule})
+		if err != nil {
+			log.Printf("Failed to re-enable links disabled by %s (%d re-enabled so far): %v", RuleKey(rule), n, err)
+			return
+		}
+		log.Printf("Re-enabled %d links disabled by %s", n, RuleKey(rule))
+	}()
+}
+
+func toRule(req dto.BlacklistItemRequest) (models.BlacklistItem, error) {
+	entry := newEntry(0, strings.ToLower(req.Type), req.Value)
+	if req.Type == "" || entry.Err != nil {
+		return models.BlacklistItem{}, shortlink_errors.ErrValidateRequest
+	}
+
+	item := models.BlacklistItem{Type: entry.Type, Value: entry.Value}
+	switch strings.ToLower(req.Action) {
+	case "", models.BlacklistActionBlock:
+	case models.BlacklistActionPreview:
+		item.Action = models.BlacklistActionPreview
+	default:
+		return models.BlacklistItem{}, shortlink_errors.ErrValidateRequest
+	}
+	return firestoreService.NormalizeBlacklistItem(item)
+}
+
+func (s *BlacklistServiceImpl) RequiresPreview(ctx context.Context, targetURL string) (bool, error) {
+	_, ok, err := s.index.MatchPreview(ctx, targetURL)
+	return ok, err
+}
+
+// Import adds every valid entry as a manual blacklist item and reports the
+// outcome of each line. Entries that are already blacklisted are left as they are.
+func (s *BlacklistServiceImpl) Import(ctx context.Context, r io.Reader, format string) (*dto.BlacklistImportResponse, error) {
+	entries, err := Parse(r, format)
+	if err != nil {
+		if errors.Is(err, shortlink_errors.ErrValidateRequest) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to read import: %w", err)
+	}
+
+	resp := &dto.BlacklistImportResponse{Results: make([]dto.BlacklistImportResult, len(entries))}
+	seen := make(map[string]bool, len(entries))
+	var items []models.BlacklistItem
+	var itemIdx []int
+	now := time.Now()
+
+	for i, e := range entries {
+		resp.Results[i] = dto.BlacklistImportResult{Line: e.Line, Type: e.Type, Value: e.Value}
+		if e.Err != nil {
+			resp.Results[i].Status, resp.Results[i].Error = "invalid", e.Err.Error()
+			resp.Invalid++
+			continue
+		}
+
+		item, err := firestoreService.NormalizeBlacklistItem(models.BlacklistItem{Type: e.Type, Value: e.Value})
+		if err != nil {
+			resp.Results[i].Status, resp.Results[i].Error = "invalid", err.Error()
+			resp.Invalid++
+			continue
+		}
+		key := item.Type + ":" + item.Value
+		if seen[key] {
+			resp.Results[i].Status = "duplicate"
+			resp.Skipped++
+			continue
+		}
+		seen[key] = true
+
+		item.Source, item.CreatedAt = models.SourceManual, now
+		items = append(items, item)
+		itemIdx = append(itemIdx, i)
+	}
+
+	results, err := s.store.AddBlacklistItems(ctx, items)
+	if err != nil {
+		return nil, err
+	}
+	var added []models.BlacklistItem
+	for j, itemErr := range results {
+		res := &resp.Results[itemIdx[j]]
+		switch {
+		case itemErr == nil:
+			res.Status = "added"
+			resp.Added++
+			added = append(added, items[j])
+		case errors.Is(itemErr, shortlink_errors.ErrResourceExists):
+			res.Status = "exists"
+			resp.Skipped++
+		default:
+			res.Status, res.Error = "failed", itemErr.Error()
+			resp.Invalid++
+		}
+	}
+
+	if resp.Added > 0 {
+		s.index.Invalidate()
+		s.enforce(ctx, added)
+		after := map[string]interface{}{"format": format, "added": resp.Added, "skipped": resp.Skipped, "invalid": resp.Invalid}
+		if err := s.audit.Record(ctx, models.AuditBlacklistImport, models.AuditTargetBlacklist, "import", nil, after); err != nil {
+			log.Printf("Failed to record audit log for %s: %v", models.AuditBlacklistImport, err)
+		}
+	}
+
+	return resp, nil
+}
+
+func (s *BlacklistServiceImpl) Export(ctx context.Context, w io.Writer, format string) error {
+	items, err := s.store.ListBlacklisted(ctx)
+	if err != nil {
+		return err
+	}
+	if items == nil {
+		items = []models.BlacklistItem{}
+	}
+
+	switch format {
+	case FormatCSV:
+		csvWriter := csv.NewWriter(w)
+		if err := csvWriter.Write([]string{"type", "value", "source", "created_at"}); err != nil {
+			return err
+		}
+		for _, item := range items {
+			createdAt := ""
+			if !item.CreatedAt.IsZero() {
+				createdAt = item.CreatedAt.Format(time.RFC3339)
+			}
+			if err := csvWriter.Write([]string{item.Type, item.Value, item.Source, createdAt}); err != nil {
+				return err
+			}
+		}
+		csvWriter.Flush()
+		return csvWriter.Error()
+	case FormatJSON:
+		return json.NewEncoder(w).Encode(items)
+	case FormatList:
+		for _, item := range items {
+			if _, err := fmt.Fprintln(w, item.Value); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return shortlink_errors.ErrValidateRequest
+	}
+}
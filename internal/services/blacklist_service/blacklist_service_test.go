@@ -0,0 +1,351 @@
+package blacklist_service_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/blacklist_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/validators"
+)
+
+// *--- MOCK DEFINITIONS ---* //
+// Firestore blacklist store SERVICE
+type MockBlacklistStore struct{ mock.Mock }
+
+func (m *MockBlacklistStore) ListBlacklisted(ctx context.Context) ([]models.BlacklistItem, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.BlacklistItem), args.Error(1)
+}
+
+func (m *MockBlacklistStore) ListBlacklistedBySource(ctx context.Context, source string) ([]models.BlacklistItem, error) {
+	args := m.Called(ctx, source)
+	return args.Get(0).([]models.BlacklistItem), args.Error(1)
+}
+
+func (m *MockBlacklistStore) AddBlacklistItem(ctx context.Context, item models.BlacklistItem) error {
+	args := m.Called(ctx, item)
+	return args.Error(0)
+}
+
+func (m *MockBlacklistStore) RemoveBlacklistItem(ctx context.Context, item models.BlacklistItem) error {
+	args := m.Called(ctx, item)
+	return args.Error(0)
+}
+
+func (m *MockBlacklistStore) AddBlacklistItems(ctx context.Context, items []models.BlacklistItem) ([]error, error) {
+	args := m.Called(ctx, items)
+	return args.Get(0).([]error), args.Error(1)
+}
+
+func (m *MockBlacklistStore) RemoveBlacklistItems(ctx context.Context, items []models.BlacklistItem) error {
+	args := m.Called(ctx, items)
+	return args.Error(0)
+}
+
+// Audit RECORDER
+type MockRecorder struct{ mock.Mock }
+
+func (m *MockRecorder) Record(ctx context.Context, action, targetType, targetID string, before, after map[string]interface{}) error {
+	args := m.Called(ctx, action, targetType, targetID, before, after)
+	return args.Error(0)
+}
+
+// Link ENFORCER
+type MockLinkEnforcer struct{ mock.Mock }
+
+func (m *MockLinkEnforcer) DisableMatching(ctx context.Context, rules []models.BlacklistItem) (int, error) {
+	args := m.Called(ctx, rules)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockLinkEnforcer) ReenableMatching(ctx context.Context, rules []models.BlacklistItem) (int, error) {
+	args := m.Called(ctx, rules)
+	return args.Int(0), args.Error(1)
+}
+
+// waitCall blocks until the background job has called the enforcer.
+func waitCall(t *testing.T, called <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-called:
+	case <-time.After(time.Second):
+		t.Fatal("enforcement job did not run")
+	}
+}
+
+func TestMain(m *testing.M) {
+	validators.Init()
+	os.Exit(m.Run())
+}
+
+// *--- TEST CASES ---* //
+func TestParse(t *testing.T) {
+	t.Run("List", func(t *testing.T) {
+		input := "# comment\nEvil.com\n\nhttps://bad.example.org/phish\nnot a domain!\n"
+		entries, err := blacklist_service.Parse(strings.NewReader(input), blacklist_service.FormatList)
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+
+		assert.Equal(t, blacklist_service.Entry{Line: 2, Type: "domain", Value: "evil.com"}, entries[0])
+		assert.Equal(t, "url", entries[1].Type)
+		assert.Equal(t, 5, entries[2].Line)
+		assert.Error(t, entries[2].Err)
+	})
+
+	t.Run("Hosts", func(t *testing.T) {
+		input := "127.0.0.1 localhost\n0.0.0.0 ads.example.com tracker.example.com # inline\n"
+		entries, err := blacklist_service.Parse(strings.NewReader(input), blacklist_service.FormatHosts)
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "ads.example.com", entries[0].Value)
+		assert.Equal(t, "tracker.example.com", entries[1].Value)
+		assert.Equal(t, 2, entries[1].Line)
+	})
+
+	t.Run("CSV with header", func(t *testing.T) {
+		input := "type,value\ndomain,evil.com\nurl,https://bad.example.org/x\nip,1.2.3.4\n"
+		entries, err := blacklist_service.Parse(strings.NewReader(input), blacklist_service.FormatCSV)
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+		assert.Equal(t, "evil.com", entries[0].Value)
+		assert.Equal(t, "url", entries[1].Type)
+		assert.Error(t, entries[2].Err)
+	})
+
+	t.Run("CSV with commented header", func(t *testing.T) {
+		input := "# URLhaus dump\n# id,dateadded,url,url_status\n\"1\",\"2024-01-01\",\"http://bad.example.org/a.exe\",\"online\"\n"
+		entries, err := blacklist_service.Parse(strings.NewReader(input), blacklist_service.FormatCSV)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "url", entries[0].Type)
+		assert.Equal(t, "http://bad.example.org/a.exe", entries[0].Value)
+	})
+
+	t.Run("CSV without header", func(t *testing.T) {
+		input := "domain,evil.com\nother.com\n"
+		entries, err := blacklist_service.Parse(strings.NewReader(input), blacklist_service.FormatCSV)
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "evil.com", entries[0].Value)
+		assert.Equal(t, "other.com", entries[1].Value)
+	})
+
+	t.Run("Unknown format", func(t *testing.T) {
+		_, err := blacklist_service.Parse(strings.NewReader(""), "xml")
+		assert.Equal(t, shortlink_errors.ErrValidateRequest, err)
+	})
+}
+
+func TestAdd(t *testing.T) {
+	store := new(MockBlacklistStore)
+	enforcer := new(MockLinkEnforcer)
+	svc := blacklist_service.New(store, new(MockRecorder), blacklist_service.NewIndex(store), enforcer)
+
+	t.Run("Wildcard domain becomes a suffix rule", func(t *testing.T) {
+		store.On("AddBlacklistItem", mock.Anything, mock.MatchedBy(func(item models.BlacklistItem) bool {
+			return item.Type == models.BlacklistDomainSuffix && item.Value == "evil.com" && item.Source == models.SourceManual
+		})).Return(nil).Once()
+		called := make(chan struct{})
+		enforcer.On("DisableMatching", mock.Anything, mock.MatchedBy(func(rules []models.BlacklistItem) bool {
+			return len(rules) == 1 && rules[0].Type == models.BlacklistDomainSuffix && rules[0].Value == "evil.com"
+		})).Return(2, nil).Run(func(mock.Arguments) { close(called) }).Once()
+
+		item, err := svc.Add(context.Background(), dto.BlacklistItemRequest{Type: "domain", Value: "*.Evil.com"})
+		require.NoError(t, err)
+		assert.Equal(t, models.BlacklistDomainSuffix, item.Type)
+		waitCall(t, called)
+	})
+
+	t.Run("Preview rule does not disable links", func(t *testing.T) {
+		store.On("AddBlacklistItem", mock.Anything, mock.MatchedBy(func(item models.BlacklistItem) bool {
+			return item.Value == "sketchy.net" && item.Action == models.BlacklistActionPreview
+		})).Return(nil).Once()
+
+		item, err := svc.Add(context.Background(), dto.BlacklistItemRequest{Type: "domain", Value: "sketchy.net", Action: "preview"})
+		require.NoError(t, err)
+		assert.Equal(t, models.BlacklistActionPreview, item.Action)
+	})
+
+	t.Run("Unknown action is rejected", func(t *testing.T) {
+		_, err := svc.Add(context.Background(), dto.BlacklistItemRequest{Type: "domain", Value: "evil.com", Action: "redirect"})
+		assert.Equal(t, shortlink_errors.ErrValidateRequest, err)
+	})
+
+	t.Run("Invalid regex is rejected", func(t *testing.T) {
+		_, err := svc.Add(context.Background(), dto.BlacklistItemRequest{Type: "regex", Value: "(unclosed"})
+		assert.Equal(t, shortlink_errors.ErrValidateRequest, err)
+	})
+
+	t.Run("Unknown type is rejected", func(t *testing.T) {
+		_, err := svc.Add(context.Background(), dto.BlacklistItemRequest{Type: "ip", Value: "1.2.3.4"})
+		assert.Equal(t, shortlink_errors.ErrValidateRequest, err)
+	})
+
+	store.AssertExpectations(t)
+	enforcer.AssertExpectations(t)
+}
+
+func TestRemove(t *testing.T) {
+	rule := models.BlacklistItem{Type: models.BlacklistDomain, Value: "evil.com"}
+
+	t.Run("Re-enables links on request", func(t *testing.T) {
+		store := new(MockBlacklistStore)
+		enforcer := new(MockLinkEnforcer)
+		svc := blacklist_service.New(store, new(MockRecorder), blacklist_service.NewIndex(store), enforcer)
+
+		store.On("RemoveBlacklistItem", mock.Anything, rule).Return(nil).Once()
+		called := make(chan struct{})
+		enforcer.On("ReenableMatching", mock.Anything, []models.BlacklistItem{rule}).
+			Return(1, nil).Run(func(mock.Arguments) { close(called) }).Once()
+
+		require.NoError(t, svc.Remove(context.Background(), dto.BlacklistItemRequest{Type: "domain", Value: "Evil.com"}, true))
+		waitCall(t, called)
+		store.AssertExpectations(t)
+		enforcer.AssertExpectations(t)
+	})
+
+	t.Run("Leaves links disabled by default", func(t *testing.T) {
+		store := new(MockBlacklistStore)
+		enforcer := new(MockLinkEnforcer)
+		svc := blacklist_service.New(store, new(MockRecorder), blacklist_service.NewIndex(store), enforcer)
+
+		store.On("RemoveBlacklistItem", mock.Anything, rule).Return(nil).Once()
+
+		require.NoError(t, svc.Remove(context.Background(), dto.BlacklistItemRequest{Type: "domain", Value: "evil.com"}, false))
+		enforcer.AssertNotCalled(t, "ReenableMatching", mock.Anything, mock.Anything)
+	})
+}
+
+func TestImport(t *testing.T) {
+	store := new(MockBlacklistStore)
+	audit := new(MockRecorder)
+	enforcer := new(MockLinkEnforcer)
+	svc := blacklist_service.New(store, audit, blacklist_service.NewIndex(store), enforcer)
+
+	input := "evil.com\nhttps://Bad.example.org/phish/\nevil.com\nexisting.com\n!!!\n"
+	store.On("AddBlacklistItems", mock.Anything, mock.MatchedBy(func(items []models.BlacklistItem) bool {
+		return len(items) == 3 &&
+			items[1].Value == "bad.example.org/phish" &&
+			items[0].Source == models.SourceManual
+	})).Return([]error{nil, nil, shortlink_errors.ErrResourceExists}, nil).Once()
+	audit.On("Record", mock.Anything, models.AuditBlacklistImport, models.AuditTargetBlacklist, "import", mock.Anything, mock.Anything).
+		Return(nil).Once()
+	called := make(chan struct{})
+	enforcer.On("DisableMatching", mock.Anything, mock.MatchedBy(func(rules []models.BlacklistItem) bool {
+		// only the rules that were actually added
+		return len(rules) == 2 && rules[0].Value == "evil.com" && rules[1].Value == "bad.example.org/phish"
+	})).Return(0, nil).Run(func(mock.Arguments) { close(called) }).Once()
+
+	resp, err := svc.Import(context.Background(), strings.NewReader(input), blacklist_service.FormatList)
+	require.NoError(t, err)
+	assert.Equal(t, 2, resp.Added)
+	assert.Equal(t, 2, resp.Skipped)
+	assert.Equal(t, 1, resp.Invalid)
+
+	statuses := make([]string, 0, len(resp.Results))
+	for _, r := range resp.Results {
+		statuses = append(statuses, r.Status)
+	}
+	assert.Equal(t, []string{"added", "added", "duplicate", "exists", "invalid"}, statuses)
+
+	waitCall(t, called)
+	store.AssertExpectations(t)
+	audit.AssertExpectations(t)
+	enforcer.AssertExpectations(t)
+}
+
+func TestExport(t *testing.T) {
+	store := new(MockBlacklistStore)
+	svc := blacklist_service.New(store, new(MockRecorder), blacklist_service.NewIndex(store), new(MockLinkEnforcer))
+
+	store.On("ListBlacklisted", mock.Anything).Return([]models.BlacklistItem{
+		{Type: "domain", Value: "evil.com", Source: models.SourceManual},
+		{Type: "url", Value: "bad.example.org/phish", Source: "urlhaus"},
+	}, nil)
+
+	var buf bytes.Buffer
+	require.NoError(t, svc.Export(context.Background(), &buf, blacklist_service.FormatCSV))
+	assert.Equal(t, "type,value,source,created_at\ndomain,evil.com,manual,\nurl,bad.example.org/phish,urlhaus,\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, svc.Export(context.Background(), &buf, blacklist_service.FormatList))
+	assert.Equal(t, "evil.com\nbad.example.org/phish\n", buf.String())
+
+	assert.Equal(t, shortlink_errors.ErrValidateRequest, svc.Export(context.Background(), &buf, "xml"))
+}
+
+func TestSyncFeed(t *testing.T) {
+	t.Run("Adds new and removes stale feed entries", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("0.0.0.0 new.example.com\n0.0.0.0 kept.example.com\n"))
+		}))
+		defer server.Close()
+
+		store := new(MockBlacklistStore)
+		enforcer := new(MockLinkEnforcer)
+		syncer := blacklist_service.NewFeedSyncer(store, enforcer, blacklist_service.FeedConfig{}, nil)
+		feed := blacklist_service.Feed{Name: "urlhaus", Format: blacklist_service.FormatHosts, Location: server.URL}
+
+		store.On("ListBlacklistedBySource", mock.Anything, "urlhaus").Return([]models.BlacklistItem{
+			{Type: "domain", Value: "kept.example.com", Source: "urlhaus"},
+			{Type: "domain", Value: "gone.example.com", Source: "urlhaus"},
+		}, nil).Once()
+		store.On("AddBlacklistItems", mock.Anything, mock.MatchedBy(func(items []models.BlacklistItem) bool {
+			return len(items) == 1 && items[0].Value == "new.example.com" && items[0].Source == "urlhaus"
+		})).Return([]error{nil}, nil).Once()
+		store.On("RemoveBlacklistItems", mock.Anything, []models.BlacklistItem{
+			{Type: "domain", Value: "gone.example.com", Source: "urlhaus"},
+		}).Return(nil).Once()
+		enforcer.On("DisableMatching", mock.Anything, mock.MatchedBy(func(rules []models.BlacklistItem) bool {
+			return len(rules) == 1 && rules[0].Value == "new.example.com"
+		})).Return(3, nil).Once()
+		enforcer.On("ReenableMatching", mock.Anything, []models.BlacklistItem{
+			{Type: "domain", Value: "gone.example.com", Source: "urlhaus"},
+		}).Return(1, nil).Once()
+
+		res, err := syncer.SyncFeed(context.Background(), feed)
+		require.NoError(t, err)
+		assert.Equal(t, 1, res.Added)
+		assert.Equal(t, 1, res.Removed)
+		assert.Equal(t, 3, res.DisabledLinks)
+		assert.Equal(t, 1, res.ReenabledLinks)
+		store.AssertExpectations(t)
+		enforcer.AssertExpectations(t)
+	})
+
+	t.Run("Empty feed leaves entries untouched", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "feed.txt")
+		require.NoError(t, os.WriteFile(path, []byte("# nothing today\n"), 0o644))
+
+		store := new(MockBlacklistStore)
+		syncer := blacklist_service.NewFeedSyncer(store, new(MockLinkEnforcer), blacklist_service.FeedConfig{}, nil)
+
+		_, err := syncer.SyncFeed(context.Background(), blacklist_service.Feed{Name: "local", Format: blacklist_service.FormatList, Location: path})
+		assert.Error(t, err)
+		store.AssertNotCalled(t, "RemoveBlacklistItems", mock.Anything, mock.Anything)
+	})
+}
+
+func TestLoadFeedConfig(t *testing.T) {
+	t.Setenv("BLACKLIST_FEEDS", "urlhaus=hosts:https://urlhaus.example/hostfile,manual=list:/tmp/x,broken")
+	t.Setenv("BLACKLIST_FEED_SYNC_INTERVAL", "30m")
+
+	cfg := blacklist_service.LoadFeedConfig()
+	require.Len(t, cfg.Feeds, 1)
+	assert.Equal(t, blacklist_service.Feed{Name: "urlhaus", Format: "hosts", Location: "https://urlhaus.example/hostfile"}, cfg.Feeds[0])
+	assert.Equal(t, "30m0s", cfg.Interval.String())
+}
@@ -0,0 +1,176 @@
+package blacklist_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	firestoreService "github.com/mfmahendr/url-shortener-backend/internal/services/firestore"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/notification_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/urlcanon"
+)
+
+const enforcementPageSize = 500
+
+// LinkEnforcer applies blacklist changes to links that already exist;
+// Shorten only checks new links.
+type LinkEnforcer interface {
+	DisableMatching(ctx context.Context, rules []models.BlacklistItem) (int, error)
+	ReenableMatching(ctx context.Context, rules []models.BlacklistItem) (int, error)
+}
+
+type Enforcer struct {
+	links    firestoreService.LinkStatus
+	notifier notification_service.Notifier
+	index    *Index
+}
+
+func NewEnforcer(links firestoreService.LinkStatus, notifier notification_service.Notifier, index *Index) *Enforcer {
+	return &Enforcer{links: links, notifier: notifier, index: index}
+}
+
+// RuleKey identifies a normalized rule on the links it disabled.
+func RuleKey(item models.BlacklistItem) string {
+	return item.Type + ":" + item.Value
+}
+
+// DisableMatching scans every shortlink and disables those with a
+// destination, their URL or the URL of one of their redirect rules, matching
+// one of rules. It returns how many links were disabled; their owners are
+// notified.
+func (e *Enforcer) DisableMatching(ctx context.Context, rules []models.BlacklistItem) (int, error) {
+	if len(rules) == 0 {
+		return 0, nil
+	}
+	ruleSet := buildRuleSet(rules)
+
+	disabled := 0
+	after := ""
+	for {
+		links, err := e.links.ListShortlinksPage(ctx, after, enforcementPageSize)
+		if err != nil {
+			return disabled, err
+		}
+
+		var notifications []models.Notification
+		for _, link := range links {
+			if link.Disabled {
+				continue
+			}
+			item, ok := matchDestinations(ruleSet, link.Destinations())
+			if !ok {
+				continue
+			}
+
+			err := e.links.SetShortlinkDisabled(ctx, link.Key(), true, models.DisabledReasonBlacklisted, RuleKey(*item))
+			if errors.Is(err, shortlink_errors.ErrNotFound) {
+				continue // deleted while we were scanning
+			}
+			if err != nil {
+				return disabled, err
+			}
+			disabled++
+			notifications = append(notifications, models.Notification{
+				UID:     link.CreatedBy,
+				Type:    models.NotificationLinkDisabled,
+				ShortID: link.Key(),
+				Message: fmt.Sprintf("Your short link %q was disabled because its destination is blacklisted.", link.Key()),
+			})
+		}
+		e.notify(ctx, notifications)
+
+		if len(links) < enforcementPageSize {
+			return disabled, nil
+		}
+		after = links[len(links)-1].Key()
+	}
+}
+
+// ReenableMatching re-enables the links that were disabled by rules, which
+// must already be removed from the store. A link whose destination is still
+// blocked by another rule stays disabled under that rule instead.
+func (e *Enforcer) ReenableMatching(ctx context.Context, rules []models.BlacklistItem) (int, error) {
+	e.index.Invalidate()
+
+	enabled := 0
+	for _, rule := range rules {
+		links, err := e.links.ListShortlinksByDisabledRule(ctx, RuleKey(rule))
+		if err != nil {
+			return enabled, err
+		}
+
+		var notifications []models.Notification
+		for _, link := range links {
+			item, blocked, err := e.matchIndex(ctx, link.Destinations())
+			if err != nil {
+				return enabled, err
+			}
+
+			if blocked {
+				err = e.links.SetShortlinkDisabled(ctx, link.Key(), true, models.DisabledReasonBlacklisted, RuleKey(*item))
+			} else {
+				err = e.links.SetShortlinkDisabled(ctx, link.Key(), false, "", "")
+			}
+			if errors.Is(err, shortlink_errors.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return enabled, err
+			}
+			if blocked {
+				continue
+			}
+
+			enabled++
+			notifications = append(notifications, models.Notification{
+				UID:     link.CreatedBy,
+				Type:    models.NotificationLinkEnabled,
+				ShortID: link.Key(),
+				Message: fmt.Sprintf("Your short link %q was re-enabled because its destination is no longer blacklisted.", link.Key()),
+			})
+		}
+		e.notify(ctx, notifications)
+	}
+	return enabled, nil
+}
+
+func matchDestinations(rules *ruleSet, destinations []string) (*models.BlacklistItem, bool) {
+	for _, destination := range destinations {
+		parsed, err := urlcanon.Parse(destination, urlcanon.Options{})
+		if err != nil {
+			continue
+		}
+		if item, ok := rules.match(parsed); ok {
+			return item, true
+		}
+	}
+	return nil, false
+}
+
+// matchIndex returns the first rule of the whole blacklist blocking one of
+// destinations.
+func (e *Enforcer) matchIndex(ctx context.Context, destinations []string) (*models.BlacklistItem, bool, error) {
+	for _, destination := range destinations {
+		item, blocked, err := e.index.Match(ctx, destination)
+		if err != nil && !errors.Is(err, shortlink_errors.ErrValidateRequest) {
+			return nil, false, err
+		}
+		if blocked {
+			return item, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// notify is best effort: the links have already been updated.
+func (e *Enforcer) notify(ctx context.Context, notifications []models.Notification) {
+	if len(notifications) == 0 {
+		return
+	}
+	if err := e.notifier.Notify(ctx, notifications); err != nil {
+		log.Printf("Failed to notify owners about %d link status changes: %v", len(notifications), err)
+	}
+}
@@ -0,0 +1,136 @@
+package blacklist_service_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/blacklist_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+)
+
+// *--- MOCK DEFINITIONS ---* //
+// Firestore link status SERVICE
+type MockLinkStatus struct{ mock.Mock }
+
+func (m *MockLinkStatus) ListShortlinksPage(ctx context.Context, afterShortID string, limit int) ([]models.Shortlink, error) {
+	args := m.Called(ctx, afterShortID, limit)
+	return args.Get(0).([]models.Shortlink), args.Error(1)
+}
+
+func (m *MockLinkStatus) ListShortlinksByDisabledRule(ctx context.Context, rule string) ([]models.Shortlink, error) {
+	args := m.Called(ctx, rule)
+	return args.Get(0).([]models.Shortlink), args.Error(1)
+}
+
+func (m *MockLinkStatus) SetShortlinkDisabled(ctx context.Context, shortID string, disabled bool, reason, rule string) error {
+	args := m.Called(ctx, shortID, disabled, reason, rule)
+	return args.Error(0)
+}
+
+func (m *MockLinkStatus) FlagShortlinkUnsafe(ctx context.Context, shortID, threatType string) error {
+	args := m.Called(ctx, shortID, threatType)
+	return args.Error(0)
+}
+
+// NOTIFIER
+type MockNotifier struct{ mock.Mock }
+
+func (m *MockNotifier) Notify(ctx context.Context, notifications []models.Notification) error {
+	args := m.Called(ctx, notifications)
+	return args.Error(0)
+}
+
+// *--- TEST CASES ---* //
+func TestDisableMatching(t *testing.T) {
+	t.Run("Disables matching links and notifies owners", func(t *testing.T) {
+		links := new(MockLinkStatus)
+		notifier := new(MockNotifier)
+		enforcer := blacklist_service.NewEnforcer(links, notifier, blacklist_service.NewIndex(new(MockBlacklistStore)))
+
+		links.On("ListShortlinksPage", mock.Anything, "", 500).Return([]models.Shortlink{
+			{ShortID: "bad1", URL: "https://login.evil.com/x", CreatedBy: "alice"},
+			{ShortID: "good", URL: "https://example.com", CreatedBy: "bob"},
+			{ShortID: "done", URL: "https://evil.com", CreatedBy: "carol", Disabled: true},
+			{ShortID: "gone", URL: "https://evil.com/y", CreatedBy: "dave"},
+			{ShortID: "rule", URL: "https://example.com", CreatedBy: "erin", Rules: []models.RedirectRule{
+				{ID: "ios", URL: "https://apps.evil.com/app", Devices: []string{models.DeviceIOS}},
+			}},
+		}, nil).Once()
+		links.On("SetShortlinkDisabled", mock.Anything, "bad1", true, models.DisabledReasonBlacklisted, "domain_suffix:evil.com").Return(nil).Once()
+		links.On("SetShortlinkDisabled", mock.Anything, "gone", true, models.DisabledReasonBlacklisted, "domain_suffix:evil.com").Return(shortlink_errors.ErrNotFound).Once()
+		links.On("SetShortlinkDisabled", mock.Anything, "rule", true, models.DisabledReasonBlacklisted, "domain_suffix:evil.com").Return(nil).Once()
+		notifier.On("Notify", mock.Anything, mock.MatchedBy(func(n []models.Notification) bool {
+			return len(n) == 2 && n[0].UID == "alice" && n[0].ShortID == "bad1" && n[0].Type == models.NotificationLinkDisabled &&
+				n[1].ShortID == "rule"
+		})).Return(nil).Once()
+
+		n, err := enforcer.DisableMatching(context.Background(), []models.BlacklistItem{
+			{Type: models.BlacklistDomainSuffix, Value: "evil.com"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+		links.AssertExpectations(t)
+		notifier.AssertExpectations(t)
+	})
+
+	t.Run("Walks every page", func(t *testing.T) {
+		links := new(MockLinkStatus)
+		enforcer := blacklist_service.NewEnforcer(links, new(MockNotifier), blacklist_service.NewIndex(new(MockBlacklistStore)))
+
+		fullPage := make([]models.Shortlink, 500)
+		for i := range fullPage {
+			fullPage[i] = models.Shortlink{ShortID: fmt.Sprintf("id%03d", i), URL: "https://example.com"}
+		}
+		links.On("ListShortlinksPage", mock.Anything, "", 500).Return(fullPage, nil).Once()
+		links.On("ListShortlinksPage", mock.Anything, "id499", 500).Return([]models.Shortlink{}, nil).Once()
+
+		n, err := enforcer.DisableMatching(context.Background(), []models.BlacklistItem{
+			{Type: models.BlacklistDomain, Value: "evil.com"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 0, n)
+		links.AssertExpectations(t)
+	})
+}
+
+func TestReenableMatching(t *testing.T) {
+	store := new(MockBlacklistStore)
+	links := new(MockLinkStatus)
+	notifier := new(MockNotifier)
+	enforcer := blacklist_service.NewEnforcer(links, notifier, blacklist_service.NewIndex(store))
+
+	// the removed rule is gone, a broader one is still in place
+	store.On("ListBlacklisted", mock.Anything).Return([]models.BlacklistItem{
+		{Type: models.BlacklistDomainSuffix, Value: "still-bad.com"},
+	}, nil)
+	links.On("ListShortlinksByDisabledRule", mock.Anything, "domain:evil.com").Return([]models.Shortlink{
+		{ShortID: "back", URL: "https://evil.com/x", CreatedBy: "alice", Disabled: true},
+		{ShortID: "rule", URL: "https://evil.com/y", CreatedBy: "carol", Disabled: true, Rules: []models.RedirectRule{
+			{ID: "de", URL: "https://de.still-bad.com", Languages: []string{"de"}},
+		}},
+	}, nil).Once()
+	links.On("ListShortlinksByDisabledRule", mock.Anything, "domain:www.still-bad.com").Return([]models.Shortlink{
+		{ShortID: "stays", URL: "https://www.still-bad.com", CreatedBy: "bob", Disabled: true},
+	}, nil).Once()
+	links.On("SetShortlinkDisabled", mock.Anything, "back", false, "", "").Return(nil).Once()
+	links.On("SetShortlinkDisabled", mock.Anything, "rule", true, models.DisabledReasonBlacklisted, "domain_suffix:still-bad.com").Return(nil).Once()
+	links.On("SetShortlinkDisabled", mock.Anything, "stays", true, models.DisabledReasonBlacklisted, "domain_suffix:still-bad.com").Return(nil).Once()
+	notifier.On("Notify", mock.Anything, mock.MatchedBy(func(n []models.Notification) bool {
+		return len(n) == 1 && n[0].UID == "alice" && n[0].Type == models.NotificationLinkEnabled
+	})).Return(nil).Once()
+
+	n, err := enforcer.ReenableMatching(context.Background(), []models.BlacklistItem{
+		{Type: models.BlacklistDomain, Value: "evil.com"},
+		{Type: models.BlacklistDomain, Value: "www.still-bad.com"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	links.AssertExpectations(t)
+	notifier.AssertExpectations(t)
+}
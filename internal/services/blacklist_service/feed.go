@@ -0,0 +1,251 @@
+package blacklist_service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	firestoreService "github.com/mfmahendr/url-shortener-backend/internal/services/firestore"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/lease"
+)
+
+const defaultFeedSyncInterval = 6 * time.Hour
+
+// Feed is an external blacklist such as a URLhaus host file or a PhishTank
+// CSV dump. Location is either a local path or an http(s) URL.
+type Feed struct {
+	Name     string
+	Format   string
+	Location string
+}
+
+type FeedConfig struct {
+	Feeds    []Feed
+	Interval time.Duration
+}
+
+type FeedSyncResult struct {
+	Added   int
+	Removed int
+	Invalid int
+
+	DisabledLinks  int
+	ReenabledLinks int
+}
+
+type FeedSyncer struct {
+	store    firestoreService.BlacklistStore
+	enforcer LinkEnforcer
+	config   FeedConfig
+	client   *http.Client
+	// lease makes only one instance run each sync.
+	lease *lease.Lease
+}
+
+// LoadFeedConfig reads the feeds from BLACKLIST_FEEDS, a comma-separated list
+// of name=format:location entries, e.g.
+//
+//	urlhaus=hosts:https://urlhaus.abuse.ch/downloads/hostfile/,phishtank=csv:/data/phishtank.csv
+//
+// BLACKLIST_FEED_SYNC_INTERVAL sets how often they are synced (default 6h).
+func LoadFeedConfig() FeedConfig {
+	cfg := FeedConfig{Interval: defaultFeedSyncInterval}
+	if v := os.Getenv("BLACKLIST_FEED_SYNC_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.Interval = d
+		} else {
+			log.Printf("Invalid BLACKLIST_FEED_SYNC_INTERVAL %q, using %s", v, defaultFeedSyncInterval)
+		}
+	}
+
+	for _, raw := range strings.Split(os.Getenv("BLACKLIST_FEEDS"), ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		feed, err := parseFeed(raw)
+		if err != nil {
+			log.Printf("Ignoring blacklist feed %q: %v", raw, err)
+			continue
+		}
+		cfg.Feeds = append(cfg.Feeds, feed)
+	}
+	return cfg
+}
+
+func parseFeed(raw string) (Feed, error) {
+	name, rest, ok := strings.Cut(raw, "=")
+	if !ok || name == "" {
+		return Feed{}, fmt.Errorf("expected name=format:location")
+	}
+	format, location, ok := strings.Cut(rest, ":")
+	if !ok || location == "" {
+		return Feed{}, fmt.Errorf("expected name=format:location")
+	}
+	if format != FormatList && format != FormatHosts && format != FormatCSV {
+		return Feed{}, fmt.Errorf("unsupported format %q", format)
+	}
+	// a feed must never share its source with admin-managed entries
+	if name == models.SourceManual {
+		return Feed{}, fmt.Errorf("feed name %q is reserved", name)
+	}
+	return Feed{Name: name, Format: format, Location: location}, nil
+}
+
+func NewFeedSyncer(store firestoreService.BlacklistStore, enforcer LinkEnforcer, config FeedConfig, lease *lease.Lease) *FeedSyncer {
+	return &FeedSyncer{
+		store:    store,
+		enforcer: enforcer,
+		config:   config,
+		client:   &http.Client{Timeout: time.Minute},
+		lease:    lease,
+	}
+}
+
+// Run syncs every feed once and then on each interval until ctx is done.
+// With several instances, each sync runs on the one holding the lease.
+func (f *FeedSyncer) Run(ctx context.Context) {
+	if len(f.config.Feeds) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(f.lease.Every(f.config.Interval))
+	defer ticker.Stop()
+	for {
+		if _, err := f.lease.Do(ctx, "blacklist-feeds", f.config.Interval, f.SyncAll); err != nil {
+			log.Printf("Failed to take the blacklist feed lease: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (f *FeedSyncer) SyncAll(ctx context.Context) {
+	for _, feed := range f.config.Feeds {
+		res, err := f.SyncFeed(ctx, feed)
+		if err != nil {
+			log.Printf("Blacklist feed %s sync failed: %v", feed.Name, err)
+			continue
+		}
+		log.Printf("Blacklist feed %s synced: %d added, %d removed, %d invalid lines, %d links disabled, %d re-enabled",
+			feed.Name, res.Added, res.Removed, res.Invalid, res.DisabledLinks, res.ReenabledLinks)
+	}
+}
+
+// SyncFeed makes the entries with source feed.Name match the feed content.
+// Entries from other sources, including manual ones, are never touched.
+// Links matching new entries are disabled, and links disabled by entries the
+// feed dropped are re-enabled: feeds are not curated by hand, so there is
+// nobody to make that call.
+func (f *FeedSyncer) SyncFeed(ctx context.Context, feed Feed) (*FeedSyncResult, error) {
+	body, err := f.open(ctx, feed.Location)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	entries, err := Parse(body, feed.Format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse feed: %w", err)
+	}
+
+	res := &FeedSyncResult{}
+	wanted := make(map[string]models.BlacklistItem, len(entries))
+	for _, e := range entries {
+		if e.Err != nil {
+			res.Invalid++
+			continue
+		}
+		item, err := firestoreService.NormalizeBlacklistItem(models.BlacklistItem{Type: e.Type, Value: e.Value})
+		if err != nil {
+			res.Invalid++
+			continue
+		}
+		wanted[item.Type+":"+item.Value] = item
+	}
+	// an empty download is far more likely an upstream hiccup than a feed
+	// that was cleared, so keep what we have
+	if len(wanted) == 0 {
+		return nil, fmt.Errorf("feed has no valid entries")
+	}
+
+	existing, err := f.store.ListBlacklistedBySource(ctx, feed.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var stale []models.BlacklistItem
+	for _, item := range existing {
+		key := item.Type + ":" + item.Value
+		if _, ok := wanted[key]; ok {
+			delete(wanted, key)
+			continue
+		}
+		stale = append(stale, item)
+	}
+
+	now := time.Now()
+	toAdd := make([]models.BlacklistItem, 0, len(wanted))
+	for _, item := range wanted {
+		item.Source, item.CreatedAt = feed.Name, now
+		toAdd = append(toAdd, item)
+	}
+
+	results, err := f.store.AddBlacklistItems(ctx, toAdd)
+	if err != nil {
+		return nil, err
+	}
+	var added []models.BlacklistItem
+	for i, itemErr := range results {
+		if itemErr == nil {
+			res.Added++
+			added = append(added, toAdd[i])
+		}
+	}
+
+	if err := f.store.RemoveBlacklistItems(ctx, stale); err != nil {
+		return nil, err
+	}
+	res.Removed = len(stale)
+
+	// the blacklist itself is in sync at this point, so enforcement failures
+	// are only logged
+	if res.DisabledLinks, err = f.enforcer.DisableMatching(ctx, added); err != nil {
+		log.Printf("Blacklist feed %s: failed to disable matching links: %v", feed.Name, err)
+	}
+	if res.ReenabledLinks, err = f.enforcer.ReenableMatching(ctx, stale); err != nil {
+		log.Printf("Blacklist feed %s: failed to re-enable links: %v", feed.Name, err)
+	}
+
+	return res, nil
+}
+
+func (f *FeedSyncer) open(ctx context.Context, location string) (io.ReadCloser, error) {
+	if !strings.HasPrefix(location, "http://") && !strings.HasPrefix(location, "https://") {
+		return os.Open(location)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download feed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to download feed: status %d", resp.StatusCode)
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,281 @@
+package blacklist_service
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	firestoreService "github.com/mfmahendr/url-shortener-backend/internal/services/firestore"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/urlcanon"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/validators"
+)
+
+const indexRefreshInterval = time.Minute
+
+// Index keeps every blacklist rule in memory so a lookup costs a handful of
+// map reads instead of Firestore round trips. It reloads the rules from
+// Firestore once they are older than indexRefreshInterval, or right away
+// after Invalidate.
+type Index struct {
+	store firestoreService.BlacklistStore
+	rules atomic.Pointer[ruleSet]
+	mu    sync.Mutex // serialises reloads
+}
+
+type ruleSet struct {
+	loadedAt    time.Time
+	domains     map[string]models.BlacklistItem
+	suffixes    map[string]models.BlacklistItem
+	urls        map[string]models.BlacklistItem
+	urlPrefixes map[string]models.BlacklistItem
+	regexes     []compiledRegex
+	// previews holds the rules with the preview action; they never block.
+	previews *ruleSet
+}
+
+type compiledRegex struct {
+	re   *regexp.Regexp
+	item models.BlacklistItem
+}
+
+func NewIndex(store firestoreService.BlacklistStore) *Index {
+	return &Index{store: store}
+}
+
+// IsBlacklisted implements firestore_service.BlacklistChecker.
+func (i *Index) IsBlacklisted(ctx context.Context, inputURL string) (bool, error) {
+	_, ok, err := i.Match(ctx, inputURL)
+	return ok, err
+}
+
+// Match returns the first rule that blocks inputURL. inputURL may also be a
+// bare hostname.
+func (i *Index) Match(ctx context.Context, inputURL string) (*models.BlacklistItem, bool, error) {
+	parsed, err := parseMatchInput(inputURL)
+	if err != nil {
+		return nil, false, err
+	}
+
+	rules, err := i.current(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	item, ok := rules.match(parsed)
+	return item, ok, nil
+}
+
+func parseMatchInput(inputURL string) (*url.URL, error) {
+	if inputURL == "" {
+		return nil, shortlink_errors.ErrValidateRequest
+	}
+
+	parsed, err := urlcanon.Parse(inputURL, urlcanon.Options{})
+	if err != nil {
+		if err := validators.Validate.Var(inputURL, "hostname_rfc1123"); err != nil {
+			return nil, shortlink_errors.ErrValidateRequest
+		}
+		host, err := urlcanon.Host(inputURL)
+		if err != nil {
+			return nil, shortlink_errors.ErrValidateRequest
+		}
+		parsed = &url.URL{Host: host}
+	}
+	return parsed, nil
+}
+
+// MatchPreview returns the first preview rule matching inputURL: links to
+// such destinations always show the preview page.
+func (i *Index) MatchPreview(ctx context.Context, inputURL string) (*models.BlacklistItem, bool, error) {
+	parsed, err := parseMatchInput(inputURL)
+	if err != nil {
+		return nil, false, err
+	}
+
+	rules, err := i.current(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	item, ok := rules.previews.match(parsed)
+	return item, ok, nil
+}
+
+// Invalidate makes the next lookup reload the rules.
+func (i *Index) Invalidate() {
+	if rules := i.rules.Load(); rules != nil {
+		stale := *rules
+		stale.loadedAt = time.Time{}
+		i.rules.Store(&stale)
+	}
+}
+
+// Refresh reloads all rules from Firestore.
+func (i *Index) Refresh(ctx context.Context) error {
+	items, err := i.store.ListBlacklisted(ctx)
+	if err != nil {
+		return err
+	}
+	i.rules.Store(buildRuleSet(items))
+	return nil
+}
+
+func (i *Index) current(ctx context.Context) (*ruleSet, error) {
+	rules := i.rules.Load()
+	if rules != nil && time.Since(rules.loadedAt) < indexRefreshInterval {
+		return rules, nil
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	// another request may have reloaded while we waited
+	if latest := i.rules.Load(); latest != rules && latest != nil && time.Since(latest.loadedAt) < indexRefreshInterval {
+		return latest, nil
+	}
+
+	if err := i.Refresh(ctx); err != nil {
+		if rules != nil {
+			log.Printf("Failed to refresh blacklist index, using previous rules: %v", err)
+			return rules, nil
+		}
+		return nil, err
+	}
+	return i.rules.Load(), nil
+}
+
+// RuleMatcher matches URLs against a fixed set of rules that do not come
+// from Firestore, such as a local rule file.
+type RuleMatcher struct {
+	rules *ruleSet
+}
+
+func NewRuleMatcher(items []models.BlacklistItem) *RuleMatcher {
+	return &RuleMatcher{rules: buildRuleSet(items)}
+}
+
+// Match works like Index.Match.
+func (m *RuleMatcher) Match(inputURL string) (*models.BlacklistItem, bool, error) {
+	parsed, err := parseMatchInput(inputURL)
+	if err != nil {
+		return nil, false, err
+	}
+	item, ok := m.rules.match(parsed)
+	return item, ok, nil
+}
+
+// buildRuleSet compiles the blocking rules of items; preview rules end up in
+// the previews set.
+func buildRuleSet(items []models.BlacklistItem) *ruleSet {
+	var block, preview []models.BlacklistItem
+	for _, item := range items {
+		if item.Action == models.BlacklistActionPreview {
+			preview = append(preview, item)
+		} else {
+			block = append(block, item)
+		}
+	}
+
+	rules := compileRules(block)
+	rules.previews = compileRules(preview)
+	return rules
+}
+
+func compileRules(items []models.BlacklistItem) *ruleSet {
+	rules := &ruleSet{
+		loadedAt:    time.Now(),
+		domains:     make(map[string]models.BlacklistItem),
+		suffixes:    make(map[string]models.BlacklistItem),
+		urls:        make(map[string]models.BlacklistItem),
+		urlPrefixes: make(map[string]models.BlacklistItem),
+	}
+
+	for _, raw := range items {
+		item, err := firestoreService.NormalizeBlacklistItem(raw)
+		if err != nil {
+			log.Printf("Skipping invalid blacklist rule %s %q", raw.Type, raw.Value)
+			continue
+		}
+
+		switch item.Type {
+		case models.BlacklistDomain:
+			rules.domains[item.Value] = item
+		case models.BlacklistDomainSuffix:
+			rules.suffixes[item.Value] = item
+		case models.BlacklistURL:
+			rules.urls[item.Value] = item
+		case models.BlacklistURLPrefix:
+			rules.urlPrefixes[item.Value] = item
+		case models.BlacklistRegex:
+			re, err := regexp.Compile(item.Value)
+			if err != nil {
+				log.Printf("Skipping invalid blacklist regex %q: %v", item.Value, err)
+				continue
+			}
+			rules.regexes = append(rules.regexes, compiledRegex{re: re, item: item})
+		}
+	}
+	return rules
+}
+
+// match expects parsed to be canonicalised with urlcanon, or to hold only a
+// host. Regex rules see the canonical URL.
+func (r *ruleSet) match(parsed *url.URL) (*models.BlacklistItem, bool) {
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+
+	if item, ok := r.domains[host]; ok {
+		return &item, true
+	}
+
+	// evil.com, then com: every parent domain may carry a suffix rule
+	for h := host; h != ""; {
+		if item, ok := r.suffixes[h]; ok {
+			return &item, true
+		}
+		dot := strings.IndexByte(h, '.')
+		if dot < 0 {
+			break
+		}
+		h = h[dot+1:]
+	}
+
+	path := strings.TrimSuffix(parsed.EscapedPath(), "/")
+	key := host + path
+	if item, ok := r.urls[key]; ok {
+		return &item, true
+	}
+
+	// host, host/a, host/a/b, ...: prefixes only match whole path segments
+	prefix := host
+	if item, ok := r.urlPrefixes[prefix]; ok {
+		return &item, true
+	}
+	for _, segment := range strings.Split(strings.TrimPrefix(path, "/"), "/") {
+		if segment == "" {
+			continue
+		}
+		prefix += "/" + segment
+		if item, ok := r.urlPrefixes[prefix]; ok {
+			return &item, true
+		}
+	}
+
+	target := parsed.Host
+	if parsed.Scheme != "" {
+		target = parsed.String()
+	}
+	for _, rx := range r.regexes {
+		if rx.re.MatchString(target) {
+			item := rx.item
+			return &item, true
+		}
+	}
+
+	return nil, false
+}
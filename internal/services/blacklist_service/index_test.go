@@ -0,0 +1,128 @@
+package blacklist_service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/blacklist_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+)
+
+func TestIndexMatch(t *testing.T) {
+	store := new(MockBlacklistStore)
+	store.On("ListBlacklisted", mock.Anything).Return([]models.BlacklistItem{
+		{Type: models.BlacklistDomain, Value: "exact.com"},
+		{Type: models.BlacklistDomainSuffix, Value: "evil.com"},
+		{Type: models.BlacklistURL, Value: "example.org/phish"},
+		{Type: models.BlacklistURLPrefix, Value: "files.example.net/malware"},
+		{Type: models.BlacklistRegex, Value: `^https?://[^/]+/login-[0-9]+\.php`},
+		{Type: models.BlacklistRegex, Value: "(broken"},
+	}, nil).Once()
+	index := blacklist_service.NewIndex(store)
+
+	cases := []struct {
+		url     string
+		blocked bool
+	}{
+		{"https://exact.com/any", true},
+		{"https://sub.exact.com", false},
+		{"https://evil.com", true},
+		{"https://a.b.EVIL.com/path", true},
+		{"https://notevil.com", false},
+		{"https://example.org/phish/", true},
+		{"https://example.org/phishing", false},
+		{"https://example.org/safe/../phish#x", true},
+		{"https://EXACT.com:443/", true},
+		{"https://files.example.net/malware", true},
+		{"https://files.example.net/malware/x/y.exe", true},
+		{"https://files.example.net/malware-free", false},
+		{"http://anything.test/login-42.php?x=1", true},
+		{"http://anything.test/login-abc.php", false},
+		{"a.evil.com", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.url, func(t *testing.T) {
+			blocked, err := index.IsBlacklisted(context.Background(), tc.url)
+			require.NoError(t, err)
+			assert.Equal(t, tc.blocked, blocked)
+		})
+	}
+
+	t.Run("Match reports the rule", func(t *testing.T) {
+		item, ok, err := index.Match(context.Background(), "https://x.evil.com")
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, models.BlacklistDomainSuffix, item.Type)
+	})
+
+	t.Run("Empty input", func(t *testing.T) {
+		_, err := index.IsBlacklisted(context.Background(), "")
+		assert.Equal(t, shortlink_errors.ErrValidateRequest, err)
+	})
+
+	// rules are loaded once and served from memory afterwards
+	store.AssertNumberOfCalls(t, "ListBlacklisted", 1)
+}
+
+func TestIndexPreviewRules(t *testing.T) {
+	store := new(MockBlacklistStore)
+	store.On("ListBlacklisted", mock.Anything).Return([]models.BlacklistItem{
+		{Type: models.BlacklistDomain, Value: "evil.com"},
+		{Type: models.BlacklistDomainSuffix, Value: "sketchy.net", Action: models.BlacklistActionPreview},
+	}, nil).Once()
+	index := blacklist_service.NewIndex(store)
+
+	blocked, err := index.IsBlacklisted(context.Background(), "https://a.sketchy.net/x")
+	require.NoError(t, err)
+	assert.False(t, blocked, "preview rules must not block")
+
+	item, ok, err := index.MatchPreview(context.Background(), "https://a.sketchy.net/x")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, models.BlacklistActionPreview, item.Action)
+
+	_, ok, err = index.MatchPreview(context.Background(), "https://evil.com")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestIndexInvalidate(t *testing.T) {
+	store := new(MockBlacklistStore)
+	store.On("ListBlacklisted", mock.Anything).Return([]models.BlacklistItem{}, nil).Once()
+	store.On("ListBlacklisted", mock.Anything).Return([]models.BlacklistItem{
+		{Type: models.BlacklistDomain, Value: "new.com"},
+	}, nil).Once()
+	index := blacklist_service.NewIndex(store)
+
+	blocked, err := index.IsBlacklisted(context.Background(), "https://new.com")
+	require.NoError(t, err)
+	assert.False(t, blocked)
+
+	index.Invalidate()
+	blocked, err = index.IsBlacklisted(context.Background(), "https://new.com")
+	require.NoError(t, err)
+	assert.True(t, blocked)
+
+	store.AssertExpectations(t)
+}
+
+func TestIndexKeepsRulesWhenReloadFails(t *testing.T) {
+	store := new(MockBlacklistStore)
+	store.On("ListBlacklisted", mock.Anything).Return([]models.BlacklistItem{
+		{Type: models.BlacklistDomain, Value: "evil.com"},
+	}, nil).Once()
+	store.On("ListBlacklisted", mock.Anything).Return([]models.BlacklistItem(nil), shortlink_errors.ErrFailedRetrieveData).Once()
+	index := blacklist_service.NewIndex(store)
+
+	require.NoError(t, index.Refresh(context.Background()))
+	index.Invalidate()
+
+	blocked, err := index.IsBlacklisted(context.Background(), "https://evil.com")
+	require.NoError(t, err)
+	assert.True(t, blocked)
+}
@@ -0,0 +1,255 @@
+package blacklist_service
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"io"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/validators"
+)
+
+const (
+	FormatList  = "list"
+	FormatHosts = "hosts"
+	FormatCSV   = "csv"
+	FormatJSON  = "json"
+
+	maxRegexLength = 512
+)
+
+// Entry is one blacklist candidate read from an import or a feed. Err is set
+// when the line could not be turned into a valid domain or URL.
+type Entry struct {
+	Line  int
+	Type  string
+	Value string
+	Err   error
+}
+
+// names that show up in hosts files but must never be blacklisted
+var hostsIgnored = map[string]bool{
+	"localhost":             true,
+	"localhost.localdomain": true,
+	"local":                 true,
+	"broadcasthost":         true,
+	"ip6-localhost":         true,
+	"ip6-loopback":          true,
+	"0.0.0.0":               true,
+}
+
+// Parse reads entries in one of the supported formats:
+//   - list: one domain or URL per line
+//   - hosts: hosts-file lines such as "0.0.0.0 evil.com"
+//   - csv: either a header with type/value, url or domain columns, or rows
+//     whose first column is the value (optionally preceded by its type)
+//
+// Without an explicit type, values containing "://" are URL rules, "*.evil.com"
+// is a suffix rule and anything else is an exact domain.
+//
+// Blank lines and lines starting with '#' are skipped.
+func Parse(r io.Reader, format string) ([]Entry, error) {
+	switch format {
+	case FormatList:
+		return parseLines(r, func(line string) []string { return []string{line} })
+	case FormatHosts:
+		return parseLines(r, hostsNames)
+	case FormatCSV:
+		return parseCSV(r)
+	default:
+		return nil, shortlink_errors.ErrValidateRequest
+	}
+}
+
+func parseLines(r io.Reader, values func(line string) []string) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, v := range values(line) {
+			entries = append(entries, newEntry(lineNo, "", v))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func hostsNames(line string) []string {
+	if i := strings.Index(line, "#"); i >= 0 {
+		line = line[:i]
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+	// "<ip> name [name...]"; some lists drop the address entirely
+	if net.ParseIP(fields[0]) != nil {
+		fields = fields[1:]
+		if len(fields) == 0 {
+			return []string{line}
+		}
+	}
+
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if !hostsIgnored[strings.ToLower(f)] {
+			names = append(names, f)
+		}
+	}
+	return names
+}
+
+type csvColumns struct {
+	typeCol, valueCol int
+	valueType         string
+}
+
+func parseCSV(r io.Reader) ([]Entry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	var entries []Entry
+	var cols *csvColumns
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		lineNo, _ := reader.FieldPos(0)
+		if err != nil {
+			entries = append(entries, Entry{Line: lineNo, Err: shortlink_errors.ErrValidateRequest})
+			continue
+		}
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+
+		// commented lines are skipped, but feeds like URLhaus keep their
+		// header in a comment ("# id,dateadded,url,...")
+		if strings.HasPrefix(record[0], "#") {
+			record[0] = strings.TrimSpace(strings.TrimPrefix(record[0], "#"))
+			if cols == nil {
+				cols = csvHeader(record)
+			}
+			continue
+		}
+		if cols == nil && len(entries) == 0 {
+			if cols = csvHeader(record); cols != nil {
+				continue
+			}
+		}
+
+		entries = append(entries, csvEntry(lineNo, record, cols))
+	}
+	return entries, nil
+}
+
+// csvHeader returns the column layout if record is a header row. Rows holding
+// a domain or URL are data, even when another column is called "domain".
+func csvHeader(record []string) *csvColumns {
+	cols := &csvColumns{typeCol: -1, valueCol: -1}
+	for i, name := range record {
+		if strings.Contains(name, ".") || strings.Contains(name, "://") {
+			return nil
+		}
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "type":
+			cols.typeCol = i
+		case "value":
+			cols.valueCol, cols.valueType = i, ""
+		case "url":
+			if cols.valueCol < 0 || cols.valueType == "domain" {
+				cols.valueCol, cols.valueType = i, "url"
+			}
+		case "domain", "host", "hostname":
+			if cols.valueCol < 0 {
+				cols.valueCol, cols.valueType = i, "domain"
+			}
+		}
+	}
+	if cols.valueCol < 0 {
+		return nil
+	}
+	return cols
+}
+
+func csvEntry(lineNo int, record []string, cols *csvColumns) Entry {
+	if cols == nil {
+		if len(record) >= 2 && isBlacklistType(record[0]) {
+			return newEntry(lineNo, strings.ToLower(record[0]), record[1])
+		}
+		return newEntry(lineNo, "", record[0])
+	}
+
+	if cols.valueCol >= len(record) {
+		return Entry{Line: lineNo, Err: shortlink_errors.ErrValidateRequest}
+	}
+	entryType := cols.valueType
+	if cols.typeCol >= 0 && cols.typeCol < len(record) {
+		entryType = strings.ToLower(strings.TrimSpace(record[cols.typeCol]))
+	}
+	return newEntry(lineNo, entryType, record[cols.valueCol])
+}
+
+func isBlacklistType(s string) bool {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case models.BlacklistDomain, models.BlacklistDomainSuffix, models.BlacklistURL, models.BlacklistURLPrefix, models.BlacklistRegex:
+		return true
+	}
+	return false
+}
+
+// newEntry validates value; when entryType is empty it is guessed from the value.
+func newEntry(lineNo int, entryType, value string) Entry {
+	value = strings.TrimSpace(value)
+	if entryType == "" {
+		entryType = models.BlacklistDomain
+		if strings.Contains(value, "://") {
+			entryType = models.BlacklistURL
+		}
+	}
+	// "*.evil.com" is the wildcard spelling of a suffix rule
+	if entryType == models.BlacklistDomain && strings.HasPrefix(value, "*.") {
+		entryType = models.BlacklistDomainSuffix
+	}
+
+	entry := Entry{Line: lineNo, Type: entryType, Value: value}
+	switch entryType {
+	case models.BlacklistDomain, models.BlacklistDomainSuffix:
+		entry.Value = strings.TrimPrefix(strings.TrimSuffix(strings.ToLower(value), "."), "*.")
+		if err := validators.Validate.Var(entry.Value, "required,hostname_rfc1123"); err != nil {
+			entry.Err = shortlink_errors.ErrValidateRequest
+		}
+	case models.BlacklistURL, models.BlacklistURLPrefix:
+		parsed, err := url.Parse(value)
+		if err != nil || parsed.Host == "" {
+			entry.Err = shortlink_errors.ErrValidateRequest
+		}
+	case models.BlacklistRegex:
+		if len(value) == 0 || len(value) > maxRegexLength {
+			entry.Err = shortlink_errors.ErrValidateRequest
+		} else if _, err := regexp.Compile(value); err != nil {
+			entry.Err = shortlink_errors.ErrValidateRequest
+		}
+	default:
+		entry.Err = shortlink_errors.ErrValidateRequest
+	}
+	return entry
+}
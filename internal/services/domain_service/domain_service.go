@@ -0,0 +1,424 @@
+package domain_service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	firestoreService "github.com/mfmahendr/url-shortener-backend/internal/services/firestore"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/urlcanon"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/validators"
+)
+
+const (
+	// VerificationRecordPrefix is prepended to a domain to get the name of
+	// its verification TXT record.
+	VerificationRecordPrefix = "_url-shortener."
+	// VerificationValuePrefix is followed by the domain's token in the
+	// TXT record.
+	VerificationValuePrefix = "url-shortener-verification="
+
+	// hostCacheTTL is how long the router remembers whether a host is a
+	// custom domain.
+	hostCacheTTL = time.Minute
+	// maxCachedHosts bounds the cache, which is keyed by the Host header of
+	// any request and so by whatever clients send.
+	maxCachedHosts = 10000
+
+	defaultVerificationTTL = 72 * time.Hour
+)
+
+type (
+	// TXTResolver looks up DNS TXT records; *net.Resolver implements it.
+	TXTResolver interface {
+		LookupTXT(ctx context.Context, name string) ([]string, error)
+	}
+
+	// DomainChecker is used when creating links on a custom domain.
+	DomainChecker interface {
+		// CheckUsable makes sure the user in ctx may create links in
+		// workspaceID ("" for personal links) on host, and returns the
+		// canonical host.
+		CheckUsable(ctx context.Context, host, workspaceID string) (string, error)
+	}
+
+	// HostResolver is used by the router to tell custom domains from the
+	// service's own hosts.
+	HostResolver interface {
+		// CustomDomain returns the canonical form of host if it is a
+		// verified custom domain, otherwise "".
+		CustomDomain(ctx context.Context, host string) (string, error)
+	}
+
+	DomainService interface {
+		DomainChecker
+		HostResolver
+		Register(ctx context.Context, req dto.DomainRequest) (*dto.DomainDTO, error)
+		List(ctx context.Context, workspaceID string) ([]dto.DomainDTO, error)
+		Verify(ctx context.Context, host string) (*dto.DomainDTO, error)
+		Delete(ctx context.Context, host string) error
+	}
+
+	Config struct {
+		// ShortDomains are the service's own hosts; they cannot be
+		// registered and are never looked up as custom domains.
+		ShortDomains []string
+		// VerificationTTL is how long an unverified registration holds its
+		// host; after that anyone may register the host again.
+		VerificationTTL time.Duration
+	}
+
+	DomainServiceImpl struct {
+		store        firestoreService.DomainStore
+		workspaces   firestoreService.WorkspaceMembership
+		resolver     TXTResolver
+		shortDomains map[string]bool
+		pendingTTL   time.Duration
+
+		mu    sync.Mutex
+		hosts map[string]cachedHost
+	}
+
+	cachedHost struct {
+		custom    bool
+		expiresAt time.Time
+	}
+)
+
+// LoadConfig reads SHORT_DOMAINS (comma separated hosts of this shortener)
+// and DOMAIN_VERIFICATION_TTL.
+func LoadConfig() Config {
+	cfg := Config{VerificationTTL: defaultVerificationTTL}
+	for _, d := range strings.Split(os.Getenv("SHORT_DOMAINS"), ",") {
+		if d = strings.TrimSpace(d); d != "" {
+			cfg.ShortDomains = append(cfg.ShortDomains, d)
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("DOMAIN_VERIFICATION_TTL")); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.VerificationTTL = d
+		} else {
+			log.Printf("Invalid DOMAIN_VERIFICATION_TTL %q, using %s", v, defaultVerificationTTL)
+		}
+	}
+	return cfg
+}
+
+// NewResolver returns the system DNS resolver.
+func NewResolver() TXTResolver {
+	return net.DefaultResolver
+}
+
+func New(store firestoreService.DomainStore, workspaces firestoreService.WorkspaceMembership, resolver TXTResolver, config Config) *DomainServiceImpl {
+	shortDomains := make(map[string]bool, len(config.ShortDomains))
+	for _, d := range config.ShortDomains {
+		if host, err := urlcanon.Host(d); err == nil {
+			shortDomains[host] = true
+		}
+	}
+	if config.VerificationTTL <= 0 {
+		config.VerificationTTL = defaultVerificationTTL
+	}
+	return &DomainServiceImpl{
+		store:        store,
+		workspaces:   workspaces,
+		resolver:     resolver,
+		shortDomains: shortDomains,
+		pendingTTL:   config.VerificationTTL,
+		hosts:        make(map[string]cachedHost),
+	}
+}
+
+// Register adds an unverified domain for the caller or, with WorkspaceID,
+// for a workspace the caller manages. The response holds the TXT record to
+// publish before calling Verify. An unverified registration does not hold
+// the host for good: the same owner can register it again for a new token,
+// and anyone can once it is older than the verification TTL.
+func (s *DomainServiceImpl) Register(ctx context.Context, req dto.DomainRequest) (*dto.DomainDTO, error) {
+	host, err := urlcanon.Host(req.Host)
+	if err != nil {
+		return nil, shortlink_errors.ErrValidateRequest
+	}
+	req.Host = host
+	if err := validators.Validate.Struct(req); err != nil || s.shortDomains[host] {
+		return nil, shortlink_errors.ErrValidateRequest
+	}
+
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.WorkspaceID != "" {
+		if err := s.checkWorkspacePermission(ctx, req.WorkspaceID, user, models.PermissionManage); err != nil {
+			return nil, err
+		}
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	domain := models.Domain{
+		Host:              host,
+		OwnerUID:          user,
+		WorkspaceID:       req.WorkspaceID,
+		VerificationToken: token,
+		CreatedAt:         time.Now(),
+	}
+	replace := func(existing *models.Domain) bool {
+		if existing.Verified {
+			return false
+		}
+		sameOwner := existing.WorkspaceID == domain.WorkspaceID &&
+			(domain.WorkspaceID != "" || existing.OwnerUID == user)
+		return sameOwner || time.Since(existing.CreatedAt) > s.pendingTTL
+	}
+	if err := s.store.CreateDomain(ctx, domain, replace); err != nil {
+		return nil, err
+	}
+	return toDomainDTO(&domain), nil
+}
+
+// List returns the caller's personal domains or those of a workspace.
+func (s *DomainServiceImpl) List(ctx context.Context, workspaceID string) ([]dto.DomainDTO, error) {
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if workspaceID != "" {
+		if err := s.checkWorkspacePermission(ctx, workspaceID, user, models.PermissionView); err != nil {
+			return nil, err
+		}
+	}
+
+	domains, err := s.store.ListDomains(ctx, user, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]dto.DomainDTO, 0, len(domains))
+	for i := range domains {
+		result = append(result, *toDomainDTO(&domains[i]))
+	}
+	return result, nil
+}
+
+// Verify looks up the domain's TXT record and marks the domain verified if
+// it holds the token. ErrDomainVerification is returned otherwise.
+func (s *DomainServiceImpl) Verify(ctx context.Context, host string) (*dto.DomainDTO, error) {
+	domain, err := s.authorize(ctx, host, models.PermissionManage)
+	if err != nil {
+		return nil, err
+	}
+	if domain.Verified {
+		return toDomainDTO(domain), nil
+	}
+
+	records, err := s.resolver.LookupTXT(ctx, VerificationRecordPrefix+domain.Host)
+	if err != nil {
+		log.Printf("TXT lookup for %s failed: %v", domain.Host, err)
+		return nil, shortlink_errors.ErrDomainVerification
+	}
+	want := VerificationValuePrefix + domain.VerificationToken
+	found := false
+	for _, record := range records {
+		if strings.TrimSpace(record) == want {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, shortlink_errors.ErrDomainVerification
+	}
+
+	domain.Verified, domain.VerifiedAt = true, time.Now()
+	if err := s.store.SetDomainVerified(ctx, domain.Host, domain.VerifiedAt); err != nil {
+		return nil, err
+	}
+	s.forget(domain.Host)
+	return toDomainDTO(domain), nil
+}
+
+// Delete removes the domain. Its links stay stored but no longer resolve.
+func (s *DomainServiceImpl) Delete(ctx context.Context, host string) error {
+	domain, err := s.authorize(ctx, host, models.PermissionManage)
+	if err != nil {
+		return err
+	}
+	if err := s.store.DeleteDomain(ctx, domain.Host); err != nil {
+		return err
+	}
+	s.forget(domain.Host)
+	return nil
+}
+
+func (s *DomainServiceImpl) CheckUsable(ctx context.Context, host, workspaceID string) (string, error) {
+	host, err := urlcanon.Host(host)
+	if err != nil {
+		return "", shortlink_errors.ErrValidateRequest
+	}
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	domain, err := s.store.GetDomain(ctx, host)
+	if err != nil {
+		return "", err
+	}
+	if domain.WorkspaceID != workspaceID || (workspaceID == "" && domain.OwnerUID != user) {
+		return "", shortlink_errors.ErrForbidden
+	}
+	if !domain.Verified {
+		return "", shortlink_errors.ErrDomainNotVerified
+	}
+	return domain.Host, nil
+}
+
+// CustomDomain answers from a short-lived cache, so redirects on the
+// service's own hosts do not each cost a lookup.
+func (s *DomainServiceImpl) CustomDomain(ctx context.Context, host string) (string, error) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host, err := urlcanon.Host(host)
+	if err != nil || s.shortDomains[host] {
+		return "", nil
+	}
+
+	s.mu.Lock()
+	cached, ok := s.hosts[host]
+	s.mu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		if cached.custom {
+			return host, nil
+		}
+		return "", nil
+	}
+
+	domain, err := s.store.GetDomain(ctx, host)
+	if err != nil && !errors.Is(err, shortlink_errors.ErrNotFound) {
+		return "", err
+	}
+	custom := err == nil && domain.Verified
+
+	s.remember(host, custom)
+	if custom {
+		return host, nil
+	}
+	return "", nil
+}
+
+// remember caches whether host is a custom domain. When the cache is full,
+// expired entries are dropped first and, if that frees nothing, all of them.
+func (s *DomainServiceImpl) remember(host string, custom bool) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.hosts[host]; !ok && len(s.hosts) >= maxCachedHosts {
+		for h, cached := range s.hosts {
+			if !now.Before(cached.expiresAt) {
+				delete(s.hosts, h)
+			}
+		}
+		if len(s.hosts) >= maxCachedHosts {
+			clear(s.hosts)
+		}
+	}
+	s.hosts[host] = cachedHost{custom: custom, expiresAt: now.Add(hostCacheTTL)}
+}
+
+func (s *DomainServiceImpl) forget(host string) {
+	s.mu.Lock()
+	delete(s.hosts, host)
+	s.mu.Unlock()
+}
+
+// authorize loads a domain and makes sure the user in ctx holds perm on it:
+// personal domains belong to their owner, workspace domains follow the
+// member's role.
+func (s *DomainServiceImpl) authorize(ctx context.Context, host string, perm models.Permission) (*models.Domain, error) {
+	host, err := urlcanon.Host(host)
+	if err != nil {
+		return nil, shortlink_errors.ErrValidateRequest
+	}
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	domain, err := s.store.GetDomain(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if domain.WorkspaceID == "" {
+		if domain.OwnerUID != user {
+			return nil, shortlink_errors.ErrForbidden
+		}
+		return domain, nil
+	}
+	if err := s.checkWorkspacePermission(ctx, domain.WorkspaceID, user, perm); err != nil {
+		return nil, err
+	}
+	return domain, nil
+}
+
+func (s *DomainServiceImpl) checkWorkspacePermission(ctx context.Context, workspaceID, uid string, perm models.Permission) error {
+	member, err := s.workspaces.GetMember(ctx, workspaceID, uid)
+	if err != nil {
+		if errors.Is(err, shortlink_errors.ErrNotFound) {
+			return shortlink_errors.ErrForbidden
+		}
+		return err
+	}
+	if !member.Role.Can(perm) {
+		return shortlink_errors.ErrForbidden
+	}
+	return nil
+}
+
+func (s *DomainServiceImpl) currentUser(ctx context.Context) (string, error) {
+	user, ok := ctx.Value(utils.UserKey).(string)
+	if !ok || user == "" {
+		return "", shortlink_errors.ErrForbidden
+	}
+	return user, nil
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func toDomainDTO(d *models.Domain) *dto.DomainDTO {
+	result := &dto.DomainDTO{
+		Host:        d.Host,
+		WorkspaceID: d.WorkspaceID,
+		Verified:    d.Verified,
+		CreatedAt:   d.CreatedAt,
+		Verification: dto.DomainVerification{
+			Type:  "TXT",
+			Name:  VerificationRecordPrefix + d.Host,
+			Value: VerificationValuePrefix + d.VerificationToken,
+		},
+	}
+	if d.Verified {
+		verifiedAt := d.VerifiedAt
+		result.VerifiedAt = &verifiedAt
+	}
+	return result
+}
@@ -0,0 +1,243 @@
+package domain_service_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/domain_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/validators"
+)
+
+// *--- MOCK DEFINITIONS ---* //
+// Firestore domain STORE
+type MockDomainStore struct{ mock.Mock }
+
+// CreateDomain is given the domain already stored under the host, if any.
+func (m *MockDomainStore) CreateDomain(ctx context.Context, domain models.Domain, replace func(existing *models.Domain) bool) error {
+	args := m.Called(ctx, domain)
+	if existing, _ := args.Get(0).(*models.Domain); existing != nil && !replace(existing) {
+		return shortlink_errors.ErrResourceExists
+	}
+	return args.Error(1)
+}
+
+func (m *MockDomainStore) GetDomain(ctx context.Context, host string) (*models.Domain, error) {
+	args := m.Called(ctx, host)
+	return args.Get(0).(*models.Domain), args.Error(1)
+}
+
+func (m *MockDomainStore) ListDomains(ctx context.Context, ownerUID, workspaceID string) ([]models.Domain, error) {
+	args := m.Called(ctx, ownerUID, workspaceID)
+	return args.Get(0).([]models.Domain), args.Error(1)
+}
+
+func (m *MockDomainStore) SetDomainVerified(ctx context.Context, host string, verifiedAt time.Time) error {
+	args := m.Called(ctx, host, verifiedAt)
+	return args.Error(0)
+}
+
+func (m *MockDomainStore) DeleteDomain(ctx context.Context, host string) error {
+	args := m.Called(ctx, host)
+	return args.Error(0)
+}
+
+// Firestore workspace MEMBERSHIP
+type MockWorkspaceMembership struct{ mock.Mock }
+
+func (m *MockWorkspaceMembership) GetMember(ctx context.Context, workspaceID, uid string) (*models.WorkspaceMember, error) {
+	args := m.Called(ctx, workspaceID, uid)
+	return args.Get(0).(*models.WorkspaceMember), args.Error(1)
+}
+
+// fakeResolver answers TXT lookups from a map.
+type fakeResolver map[string][]string
+
+func (r fakeResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	records, ok := r[name]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	return records, nil
+}
+
+func TestMain(m *testing.M) {
+	validators.Init()
+	os.Exit(m.Run())
+}
+
+func userCtx(uid string) context.Context {
+	return context.WithValue(context.Background(), utils.UserKey, uid)
+}
+
+// *--- TEST CASES ---* //
+func TestRegister(t *testing.T) {
+	store := new(MockDomainStore)
+	members := new(MockWorkspaceMembership)
+	svc := domain_service.New(store, members, fakeResolver{}, domain_service.Config{ShortDomains: []string{"sho.rt"}})
+
+	t.Run("Personal domain is registered unverified with a TXT token", func(t *testing.T) {
+		store.On("CreateDomain", mock.Anything, mock.MatchedBy(func(d models.Domain) bool {
+			return d.Host == "go.brand.com" && d.OwnerUID == "user1" && !d.Verified && len(d.VerificationToken) == 32
+		})).Return((*models.Domain)(nil), nil).Once()
+
+		domain, err := svc.Register(userCtx("user1"), dto.DomainRequest{Host: "Go.Brand.com."})
+
+		require.NoError(t, err)
+		assert.Equal(t, "go.brand.com", domain.Host)
+		assert.False(t, domain.Verified)
+		assert.Equal(t, "_url-shortener.go.brand.com", domain.Verification.Name)
+		assert.Regexp(t, `^url-shortener-verification=[0-9a-f]{32}$`, domain.Verification.Value)
+	})
+
+	t.Run("Invalid hosts and the service's own domains are refused", func(t *testing.T) {
+		for _, host := range []string{"localhost", "not a host", "SHO.RT"} {
+			_, err := svc.Register(userCtx("user1"), dto.DomainRequest{Host: host})
+			assert.Equal(t, shortlink_errors.ErrValidateRequest, err, host)
+		}
+	})
+
+	t.Run("Unverified registrations hold the host until they expire", func(t *testing.T) {
+		svc := domain_service.New(store, members, fakeResolver{}, domain_service.Config{VerificationTTL: time.Hour})
+		for _, tc := range []struct {
+			name     string
+			existing models.Domain
+			user     string
+			want     error
+		}{
+			{"verified", models.Domain{OwnerUID: "user1", Verified: true, CreatedAt: time.Now().Add(-48 * time.Hour)}, "user2", shortlink_errors.ErrResourceExists},
+			{"pending for someone else", models.Domain{OwnerUID: "user1", CreatedAt: time.Now()}, "user2", shortlink_errors.ErrResourceExists},
+			{"pending for the same owner", models.Domain{OwnerUID: "user1", CreatedAt: time.Now()}, "user1", nil},
+			{"expired", models.Domain{OwnerUID: "user1", CreatedAt: time.Now().Add(-2 * time.Hour)}, "user2", nil},
+		} {
+			existing := tc.existing
+			existing.Host = "taken.brand.com"
+			store.On("CreateDomain", mock.Anything, mock.MatchedBy(func(d models.Domain) bool {
+				return d.Host == "taken.brand.com" && d.OwnerUID == tc.user
+			})).Return(&existing, nil).Once()
+
+			_, err := svc.Register(userCtx(tc.user), dto.DomainRequest{Host: "taken.brand.com"})
+			assert.Equal(t, tc.want, err, tc.name)
+		}
+	})
+
+	t.Run("Workspace domains need the manage permission", func(t *testing.T) {
+		members.On("GetMember", mock.Anything, "ws1", "editor1").
+			Return(&models.WorkspaceMember{Role: models.RoleEditor}, nil).Once()
+
+		_, err := svc.Register(userCtx("editor1"), dto.DomainRequest{Host: "go.team.com", WorkspaceID: "ws1"})
+
+		assert.Equal(t, shortlink_errors.ErrForbidden, err)
+	})
+}
+
+func TestVerify(t *testing.T) {
+	pending := func() *models.Domain {
+		return &models.Domain{Host: "go.brand.com", OwnerUID: "user1", VerificationToken: "abc123"}
+	}
+
+	t.Run("Matching TXT record verifies the domain", func(t *testing.T) {
+		store := new(MockDomainStore)
+		resolver := fakeResolver{"_url-shortener.go.brand.com": {"v=spf1 -all", "url-shortener-verification=abc123"}}
+		svc := domain_service.New(store, nil, resolver, domain_service.Config{})
+		store.On("GetDomain", mock.Anything, "go.brand.com").Return(pending(), nil).Once()
+		store.On("SetDomainVerified", mock.Anything, "go.brand.com", mock.Anything).Return(nil).Once()
+
+		domain, err := svc.Verify(userCtx("user1"), "go.brand.com")
+
+		require.NoError(t, err)
+		assert.True(t, domain.Verified)
+		assert.NotNil(t, domain.VerifiedAt)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("Missing or wrong TXT record fails", func(t *testing.T) {
+		store := new(MockDomainStore)
+		for _, resolver := range []fakeResolver{
+			{},
+			{"_url-shortener.go.brand.com": {"url-shortener-verification=wrong"}},
+		} {
+			svc := domain_service.New(store, nil, resolver, domain_service.Config{})
+			store.On("GetDomain", mock.Anything, "go.brand.com").Return(pending(), nil).Once()
+
+			_, err := svc.Verify(userCtx("user1"), "go.brand.com")
+
+			assert.Equal(t, shortlink_errors.ErrDomainVerification, err)
+		}
+		store.AssertNotCalled(t, "SetDomainVerified", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Only the owner can verify a personal domain", func(t *testing.T) {
+		store := new(MockDomainStore)
+		svc := domain_service.New(store, nil, fakeResolver{}, domain_service.Config{})
+		store.On("GetDomain", mock.Anything, "go.brand.com").Return(pending(), nil).Once()
+
+		_, err := svc.Verify(userCtx("someone-else"), "go.brand.com")
+
+		assert.Equal(t, shortlink_errors.ErrForbidden, err)
+	})
+}
+
+func TestCheckUsable(t *testing.T) {
+	store := new(MockDomainStore)
+	svc := domain_service.New(store, nil, fakeResolver{}, domain_service.Config{})
+	store.On("GetDomain", mock.Anything, "go.brand.com").
+		Return(&models.Domain{Host: "go.brand.com", OwnerUID: "user1", Verified: true}, nil)
+	store.On("GetDomain", mock.Anything, "go.team.com").
+		Return(&models.Domain{Host: "go.team.com", OwnerUID: "user1", WorkspaceID: "ws1", Verified: true}, nil)
+	store.On("GetDomain", mock.Anything, "new.brand.com").
+		Return(&models.Domain{Host: "new.brand.com", OwnerUID: "user1"}, nil)
+
+	host, err := svc.CheckUsable(userCtx("user1"), "GO.brand.com", "")
+	require.NoError(t, err)
+	assert.Equal(t, "go.brand.com", host)
+
+	_, err = svc.CheckUsable(userCtx("user2"), "go.brand.com", "")
+	assert.Equal(t, shortlink_errors.ErrForbidden, err, "someone else's personal domain")
+
+	_, err = svc.CheckUsable(userCtx("user1"), "go.brand.com", "ws1")
+	assert.Equal(t, shortlink_errors.ErrForbidden, err, "personal domain in a workspace")
+
+	_, err = svc.CheckUsable(userCtx("user2"), "go.team.com", "ws1")
+	assert.NoError(t, err, "workspace domain, membership is checked by the caller")
+
+	_, err = svc.CheckUsable(userCtx("user1"), "go.team.com", "")
+	assert.Equal(t, shortlink_errors.ErrForbidden, err, "workspace domain for a personal link")
+
+	_, err = svc.CheckUsable(userCtx("user1"), "new.brand.com", "")
+	assert.Equal(t, shortlink_errors.ErrDomainNotVerified, err)
+}
+
+func TestCustomDomain(t *testing.T) {
+	store := new(MockDomainStore)
+	svc := domain_service.New(store, nil, fakeResolver{}, domain_service.Config{ShortDomains: []string{"sho.rt"}})
+	store.On("GetDomain", mock.Anything, "go.brand.com").
+		Return(&models.Domain{Host: "go.brand.com", Verified: true}, nil).Once()
+	store.On("GetDomain", mock.Anything, "pending.brand.com").
+		Return(&models.Domain{Host: "pending.brand.com"}, nil).Once()
+	store.On("GetDomain", mock.Anything, "unknown.com").
+		Return((*models.Domain)(nil), shortlink_errors.ErrNotFound).Once()
+
+	for i := 0; i < 2; i++ { // the second round is answered from the cache
+		host, err := svc.CustomDomain(context.Background(), "Go.Brand.com:8443")
+		require.NoError(t, err)
+		assert.Equal(t, "go.brand.com", host)
+
+		for _, other := range []string{"pending.brand.com", "unknown.com", "sho.rt"} {
+			host, err = svc.CustomDomain(context.Background(), other)
+			require.NoError(t, err)
+			assert.Empty(t, host, other)
+		}
+	}
+	store.AssertExpectations(t)
+}
@@ -0,0 +1,120 @@
+package firestore_service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/pagecursor"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+	"google.golang.org/api/iterator"
+)
+
+// AuditLog is append-only on purpose: there is no update or delete.
+type AuditLog interface {
+	AddAuditLog(ctx context.Context, entry *models.AuditLog) error
+	ListAuditLogs(ctx context.Context, q dto.AuditLogsQuery) ([]models.AuditLog, string, error)
+}
+
+func (s *FirestoreServiceImpl) AddAuditLog(ctx context.Context, entry *models.AuditLog) error {
+	_, _, err := s.client.Collection("audit_logs").Add(ctx, entry)
+	if err != nil {
+		return fmt.Errorf("failed to add audit_logs: %w", err)
+	}
+	return nil
+}
+
+func (s *FirestoreServiceImpl) ListAuditLogs(ctx context.Context, q dto.AuditLogsQuery) ([]models.AuditLog, string, error) {
+	query, err := s.buildAuditLogsQuery(q)
+	if err != nil {
+		return nil, "", err
+	}
+	iter := query.Documents(ctx)
+	defer iter.Stop()
+
+	var logs []models.AuditLog
+	var lastID string
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			fmt.Printf("Error retrieving document: %v\n", err)
+			return nil, "", shortlink_errors.ErrFailedRetrieveData
+		}
+
+		var entry models.AuditLog
+		if err := doc.DataTo(&entry); err != nil {
+			fmt.Printf("Error converting document data to AuditLog: %v\n", err)
+			return nil, "", shortlink_errors.ErrFailedRetrieveData
+		}
+
+		logs = append(logs, entry)
+		lastID = doc.Ref.ID
+	}
+
+	// a short page is the last one
+	if len(logs) < pageLimit(q.Limit) {
+		return logs, "", nil
+	}
+	nextCursor, err := s.cursors.Encode(logs[len(logs)-1].Timestamp, lastID, q.OrderDesc, auditLogsFilterHash(q))
+	if err != nil {
+		return nil, "", shortlink_errors.ErrFailedRetrieveData
+	}
+	return logs, nextCursor, nil
+}
+
+func (s *FirestoreServiceImpl) buildAuditLogsQuery(q dto.AuditLogsQuery) (query firestore.Query, err error) {
+	query = s.client.Collection("audit_logs").Query
+
+	filters := map[string]string{
+		"actor_uid":   q.ActorUID,
+		"action":      q.Action,
+		"target_type": q.TargetType,
+		"target_id":   q.TargetID,
+	}
+	for field, value := range filters {
+		if value != "" {
+			query = query.Where(field, "==", value)
+		}
+	}
+
+	if !q.After.IsZero() {
+		query = query.Where("timestamp", ">", q.After)
+	}
+	if !q.Before.IsZero() {
+		query = query.Where("timestamp", "<", q.Before)
+	}
+
+	// entries with the same timestamp are ordered by document ID, so pages
+	// neither skip nor repeat them
+	direction := firestore.Asc
+	if q.OrderDesc {
+		direction = firestore.Desc
+	}
+	query = query.OrderBy("timestamp", direction).OrderBy(firestore.DocumentID, direction)
+
+	if q.Cursor != "" {
+		cursor, err := s.cursors.Decode(q.Cursor, q.OrderDesc, auditLogsFilterHash(q))
+		if err != nil {
+			return query, shortlink_errors.ErrInvalidCursor
+		}
+		var timestamp time.Time
+		if err := json.Unmarshal(cursor.Value, &timestamp); err != nil {
+			return query, shortlink_errors.ErrInvalidCursor
+		}
+		query = query.StartAfter(timestamp, cursor.ID)
+	}
+	return query.Limit(pageLimit(q.Limit)), nil
+}
+
+// auditLogsFilterHash identifies the filters of an audit log listing, so its
+// cursors cannot be used with another one.
+func auditLogsFilterHash(q dto.AuditLogsQuery) string {
+	return pagecursor.FilterHash(q.ActorUID, q.Action, q.TargetType, q.TargetID, q.After, q.Before)
+}
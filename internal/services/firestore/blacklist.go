@@ -3,12 +3,12 @@ package firestore_service
 import (
 	"context"
 	"log"
-	"net/url"
 	"time"
 
 	"github.com/mfmahendr/url-shortener-backend/internal/models"
 	"github.com/mfmahendr/url-shortener-backend/internal/utils"
 	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/urlcanon"
 	"github.com/mfmahendr/url-shortener-backend/internal/utils/validators"
 	"google.golang.org/api/iterator"
 	"google.golang.org/grpc/codes"
@@ -26,6 +26,7 @@ type (
 		UnblacklistDomain(ctx context.Context, domain string) error
 		UnblacklistURL(ctx context.Context, inputURL string) error
 		ListBlacklisted(ctx context.Context) ([]models.BlacklistItem, error)
+		GetBlacklistItem(ctx context.Context, id string) (*models.BlacklistItem, error)
 }
 )
 
@@ -47,19 +48,19 @@ func (s *FirestoreServiceImpl) BlacklistDomain(ctx context.Context, domain strin
 		"type":       "domain",
 		"value":      domain,
 		"created_at": time.Now(),
-		"source":     "manual",
+		"source":     models.SourceManual,
 	})
 
 	return err
 }
 
 func (s *FirestoreServiceImpl) BlacklistURL(ctx context.Context, inputURL string) error {
-	parsed, err := url.Parse(inputURL)
-	if err != nil || parsed.Host == "" {
+	parsed, err := urlcanon.Parse(inputURL, urlcanon.Options{})
+	if err != nil {
 		return shortlink_errors.ErrValidateRequest
 	}
 
-	normalizedURL := normalizeURLForBlacklist(parsed)
+	normalizedURL := urlcanon.HostPath(parsed)
 
 	exists, err := s.isURLBlacklisted(ctx, inputURL)
 	if err != nil {
@@ -69,11 +70,11 @@ func (s *FirestoreServiceImpl) BlacklistURL(ctx context.Context, inputURL string
 		return shortlink_errors.ErrResourceExists
 	}
 
-	_, err = s.client.Collection("blacklist_items").Doc(utils.GenerateDocID(normalizedURL)).Set(ctx, map[string]interface{}{
+	_, err = s.client.Collection("blacklist_items").Doc(urlRuleDocID(normalizedURL)).Set(ctx, map[string]interface{}{
 		"type":       "url",
 		"value":      normalizedURL,
 		"created_at": time.Now(),
-		"source":     "manual",
+		"source":     models.SourceManual,
 	})
 	return err
 }
@@ -101,14 +102,14 @@ func (s *FirestoreServiceImpl) UnblacklistURL(ctx context.Context, inputURL stri
 		return shortlink_errors.ErrValidateRequest
 	}
 	
-	parsed, err := url.Parse(inputURL)
-	if err != nil || parsed.Host == "" {
+	parsed, err := urlcanon.Parse(inputURL, urlcanon.Options{})
+	if err != nil {
 		return shortlink_errors.ErrValidateRequest
 	}
 
-	normalizedURL := normalizeURLForBlacklist(parsed)
+	normalizedURL := urlcanon.HostPath(parsed)
 
-	doc, err := s.client.Collection("blacklist_items").Doc(utils.GenerateDocID(normalizedURL)).Get(ctx)
+	doc, err := s.client.Collection("blacklist_items").Doc(urlRuleDocID(normalizedURL)).Get(ctx)
 	if err != nil {
 		if status.Code(err) == codes.NotFound {
 			return shortlink_errors.ErrNotFound
@@ -120,7 +121,7 @@ func (s *FirestoreServiceImpl) UnblacklistURL(ctx context.Context, inputURL stri
 		return shortlink_errors.ErrNotFound
 	}
 
-	_, err = s.client.Collection("blacklist_items").Doc(utils.GenerateDocID(normalizedURL)).Delete(ctx)
+	_, err = s.client.Collection("blacklist_items").Doc(urlRuleDocID(normalizedURL)).Delete(ctx)
 	return err
 }
 
@@ -142,18 +143,38 @@ func (s *FirestoreServiceImpl) ListBlacklisted(ctx context.Context) ([]models.Bl
 			log.Println("Unexpected error:", err)
 			return nil, shortlink_errors.ErrFailedRetrieveData
 		}
+		item.ID = doc.Ref.ID
 		list = append(list, item)
 	}
 	return list, nil
 }
 
+// GetBlacklistItem returns the rule stored under id, see BlacklistItemID.
+func (s *FirestoreServiceImpl) GetBlacklistItem(ctx context.Context, id string) (*models.BlacklistItem, error) {
+	doc, err := s.client.Collection("blacklist_items").Doc(id).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, shortlink_errors.ErrNotFound
+		}
+		return nil, shortlink_errors.ErrFailedRetrieveData
+	}
+
+	var item models.BlacklistItem
+	if err := doc.DataTo(&item); err != nil {
+		log.Println("Unexpected error:", err)
+		return nil, shortlink_errors.ErrFailedRetrieveData
+	}
+	item.ID = doc.Ref.ID
+	return &item, nil
+}
+
 func (s *FirestoreServiceImpl) IsBlacklisted(ctx context.Context, inputURL string) (bool, error) {
 	if inputURL == "" {
 		return false, shortlink_errors.ErrValidateRequest
 	}
 
-	parsed, err := url.Parse(inputURL)
-	if err != nil || parsed.Host == "" {
+	parsed, err := urlcanon.Parse(inputURL, urlcanon.Options{})
+	if err != nil {
 		if err := validators.Validate.Var(inputURL, "hostname"); err != nil {
 			return false, shortlink_errors.ErrValidateRequest
 		}
@@ -183,14 +204,19 @@ func (s *FirestoreServiceImpl) isDomainBlacklisted(ctx context.Context, domain s
 		}
 		return false, shortlink_errors.ErrFailedRetrieveData
 	}
-	return doc.Exists(), nil
+	// URL rules stored before they had their own IDs can still sit under a
+	// domain's ID until the backfill moves them
+	return doc.Exists() && doc.Data()["type"] == models.BlacklistDomain, nil
 }
 
 func (s *FirestoreServiceImpl) isURLBlacklisted(ctx context.Context, inputURL string) (bool, error) {
-	parsed, _ := url.Parse(inputURL)
-	normalizedURL := normalizeURLForBlacklist(parsed)
+	parsed, err := urlcanon.Parse(inputURL, urlcanon.Options{})
+	if err != nil {
+		return false, shortlink_errors.ErrValidateRequest
+	}
+	normalizedURL := urlcanon.HostPath(parsed)
 	
-	doc, err := s.client.Collection("blacklist_items").Doc(utils.GenerateDocID(normalizedURL)).Get(ctx)
+	doc, err := s.client.Collection("blacklist_items").Doc(urlRuleDocID(normalizedURL)).Get(ctx)
 	if err != nil {
 		if status.Code(err) == codes.NotFound {
 			return false, nil
@@ -199,3 +225,9 @@ func (s *FirestoreServiceImpl) isURLBlacklisted(ctx context.Context, inputURL st
 	}
 	return doc.Exists(), nil
 }
+
+// urlRuleDocID is the ID of the URL rule for a URL in its HostPath form. It
+// includes the type, as a URL without a path has the same value as a domain.
+func urlRuleDocID(normalizedURL string) string {
+	return utils.GenerateDocID(models.BlacklistURL + ":" + normalizedURL)
+}
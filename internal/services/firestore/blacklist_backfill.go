@@ -0,0 +1,55 @@
+package firestore_service
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MoveLegacyURLRules moves the URL rules stored under the ID of their value
+// alone, as they were before URL rule IDs included the type, to their
+// current IDs. Until then such a rule can hold the ID of the domain rule
+// with the same value. It returns how many rules were moved.
+func (s *FirestoreServiceImpl) MoveLegacyURLRules(ctx context.Context) (int, error) {
+	coll := s.client.Collection("blacklist_items")
+	docs, err := coll.Where("type", "==", models.BlacklistURL).Documents(ctx).GetAll()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read url rules: %w", err)
+	}
+
+	moved := 0
+	for _, doc := range docs {
+		value, _ := doc.Data()["value"].(string)
+		newRef := coll.Doc(urlRuleDocID(value))
+		if doc.Ref.ID == newRef.ID {
+			continue
+		}
+
+		err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+			old, err := tx.Get(doc.Ref)
+			if status.Code(err) == codes.NotFound {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if _, err := tx.Get(newRef); status.Code(err) == codes.NotFound {
+				if err := tx.Create(newRef, old.Data()); err != nil {
+					return err
+				}
+			} else if err != nil {
+				return err
+			}
+			return tx.Delete(doc.Ref)
+		})
+		if err != nil {
+			return moved, fmt.Errorf("failed to move url rule %s: %w", doc.Ref.ID, err)
+		}
+		moved++
+	}
+	return moved, nil
+}
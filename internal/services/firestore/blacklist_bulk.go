@@ -0,0 +1,223 @@
+package firestore_service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"cloud.google.com/go/firestore"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/urlcanon"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// BlacklistStore covers the bulk operations used by imports and feed syncs.
+type BlacklistStore interface {
+	ListBlacklisted(ctx context.Context) ([]models.BlacklistItem, error)
+	ListBlacklistedBySource(ctx context.Context, source string) ([]models.BlacklistItem, error)
+	AddBlacklistItem(ctx context.Context, item models.BlacklistItem) error
+	RemoveBlacklistItem(ctx context.Context, item models.BlacklistItem) error
+	AddBlacklistItems(ctx context.Context, items []models.BlacklistItem) ([]error, error)
+	RemoveBlacklistItems(ctx context.Context, items []models.BlacklistItem) error
+}
+
+// AddBlacklistItem creates a single rule of any type; ErrResourceExists is
+// returned if the same rule is already stored.
+func (s *FirestoreServiceImpl) AddBlacklistItem(ctx context.Context, item models.BlacklistItem) error {
+	docID, value, err := blacklistDocID(item)
+	if err != nil {
+		return err
+	}
+	item.Value = value
+
+	if _, err := s.client.Collection("blacklist_items").Doc(docID).Create(ctx, item); err != nil {
+		if status.Code(err) == codes.AlreadyExists {
+			return shortlink_errors.ErrResourceExists
+		}
+		return fmt.Errorf("failed to add blacklist item: %w", err)
+	}
+	return nil
+}
+
+func (s *FirestoreServiceImpl) RemoveBlacklistItem(ctx context.Context, item models.BlacklistItem) error {
+	docID, _, err := blacklistDocID(item)
+	if err != nil {
+		return err
+	}
+
+	ref := s.client.Collection("blacklist_items").Doc(docID)
+	doc, err := ref.Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return shortlink_errors.ErrNotFound
+		}
+		return shortlink_errors.ErrFailedRetrieveData
+	}
+	// never delete a rule of another type stored under the same ID
+	if doc.Data()["type"] != item.Type {
+		return shortlink_errors.ErrNotFound
+	}
+	if _, err := ref.Delete(ctx); err != nil {
+		return fmt.Errorf("failed to remove blacklist item: %w", err)
+	}
+	return nil
+}
+
+func (s *FirestoreServiceImpl) ListBlacklistedBySource(ctx context.Context, source string) ([]models.BlacklistItem, error) {
+	iter := s.client.Collection("blacklist_items").Where("source", "==", source).Documents(ctx)
+	defer iter.Stop()
+
+	var list []models.BlacklistItem
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			log.Println("Unexpected error:", err)
+			return nil, shortlink_errors.ErrFailedRetrieveData
+		}
+
+		var item models.BlacklistItem
+		if err := doc.DataTo(&item); err != nil {
+			log.Println("Unexpected error:", err)
+			return nil, shortlink_errors.ErrFailedRetrieveData
+		}
+		item.ID = doc.Ref.ID
+		list = append(list, item)
+	}
+	return list, nil
+}
+
+// AddBlacklistItems creates the items with a bulk writer. Existing entries are
+// never overwritten, so a feed cannot take over a manual entry. The returned
+// slice holds one result per item: nil, ErrResourceExists or ErrValidateRequest.
+func (s *FirestoreServiceImpl) AddBlacklistItems(ctx context.Context, items []models.BlacklistItem) ([]error, error) {
+	results := make([]error, len(items))
+	if len(items) == 0 {
+		return results, nil
+	}
+
+	bw := s.client.BulkWriter(ctx)
+	jobs := make([]*firestore.BulkWriterJob, len(items))
+	for i := range items {
+		docID, value, err := blacklistDocID(items[i])
+		if err != nil {
+			results[i] = err
+			continue
+		}
+		items[i].Value = value
+
+		jobs[i], err = bw.Create(s.client.Collection("blacklist_items").Doc(docID), items[i])
+		if err != nil {
+			results[i] = fmt.Errorf("failed to queue blacklist item: %w", err)
+		}
+	}
+	bw.End()
+
+	for i, job := range jobs {
+		if job == nil {
+			continue
+		}
+		if _, err := job.Results(); err != nil {
+			if status.Code(err) == codes.AlreadyExists {
+				results[i] = shortlink_errors.ErrResourceExists
+			} else {
+				results[i] = fmt.Errorf("failed to add blacklist item: %w", err)
+			}
+		}
+	}
+	return results, nil
+}
+
+func (s *FirestoreServiceImpl) RemoveBlacklistItems(ctx context.Context, items []models.BlacklistItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	bw := s.client.BulkWriter(ctx)
+	var jobs []*firestore.BulkWriterJob
+	for _, item := range items {
+		docID, _, err := blacklistDocID(item)
+		if err != nil {
+			continue
+		}
+		job, err := bw.Delete(s.client.Collection("blacklist_items").Doc(docID))
+		if err != nil {
+			return fmt.Errorf("failed to queue blacklist removal: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	bw.End()
+
+	for _, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			return fmt.Errorf("failed to remove blacklist item: %w", err)
+		}
+	}
+	return nil
+}
+
+// NormalizeBlacklistItem returns the item with its value in the form it is
+// stored in: hostnames are lower-cased (international names in punycode) and
+// URLs are canonicalised and reduced to host and path like BlacklistURL does.
+func NormalizeBlacklistItem(item models.BlacklistItem) (models.BlacklistItem, error) {
+	switch item.Type {
+	case models.BlacklistDomain, models.BlacklistDomainSuffix:
+		value := item.Value
+		if item.Type == models.BlacklistDomainSuffix {
+			value = strings.TrimPrefix(strings.TrimPrefix(value, "*"), ".")
+		}
+		host, err := urlcanon.Host(value)
+		if err != nil {
+			return item, shortlink_errors.ErrValidateRequest
+		}
+		item.Value = host
+	case models.BlacklistURL, models.BlacklistURLPrefix:
+		raw := item.Value
+		if !strings.Contains(raw, "://") {
+			// stored values have no scheme
+			raw = "http://" + raw
+		}
+		parsed, err := urlcanon.Parse(raw, urlcanon.Options{})
+		if err != nil {
+			return item, shortlink_errors.ErrValidateRequest
+		}
+		item.Value = urlcanon.HostPath(parsed)
+	case models.BlacklistRegex:
+		if item.Value == "" {
+			return item, shortlink_errors.ErrValidateRequest
+		}
+	default:
+		return item, shortlink_errors.ErrValidateRequest
+	}
+	return item, nil
+}
+
+// blacklistDocID returns the document ID and the stored value for an item.
+// Domain rules keep the key used by BlacklistDomain; every other type
+// includes the type, since e.g. the URL rule for https://evil.com/ has the
+// same value as the domain rule for evil.com.
+func blacklistDocID(item models.BlacklistItem) (string, string, error) {
+	normalized, err := NormalizeBlacklistItem(item)
+	if err != nil {
+		return "", "", err
+	}
+	switch normalized.Type {
+	case models.BlacklistDomain:
+		return utils.GenerateDocID(normalized.Value), normalized.Value, nil
+	default:
+		return utils.GenerateDocID(normalized.Type + ":" + normalized.Value), normalized.Value, nil
+	}
+}
+
+// BlacklistItemID returns the ID a rule is stored under.
+func BlacklistItemID(item models.BlacklistItem) (string, error) {
+	docID, _, err := blacklistDocID(item)
+	return docID, err
+}
@@ -3,21 +3,30 @@ package firestore_service
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"cloud.google.com/go/firestore"
 	"github.com/mfmahendr/url-shortener-backend/internal/dto"
 	"github.com/mfmahendr/url-shortener-backend/internal/models"
 	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
 	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
-type ClickLog interface {
-	AddClickLog(ctx context.Context, doc *models.ClickLog) error
-	GetClickLogs(ctx context.Context, req dto.ClickLogsRequest) ([]models.ClickLog, string, error)
-	StreamClickLogs(ctx context.Context, shortID string) (*firestore.DocumentIterator, error)
-	GetAnalytics(ctx context.Context, shortID string) (int64, []models.ClickLog, error)
-}
+type (
+	ClickLog interface {
+		AddClickLog(ctx context.Context, doc *models.ClickLog) error
+		GetClickLogs(ctx context.Context, req dto.ClickLogsRequest) ([]models.ClickLog, string, error)
+		StreamClickLogs(ctx context.Context, shortID string) (*firestore.DocumentIterator, error)
+		GetAnalytics(ctx context.Context, shortID string) (int64, []models.ClickLog, error)
+	}
+
+	// ClickCounter keeps the click counters of the links, which are written
+	// in batches rather than on every click.
+	ClickCounter interface {
+		AddClickCounts(ctx context.Context, counts []models.ClickCounts) ([]error, error)
+	}
+)
 
 func (s *FirestoreServiceImpl) AddClickLog(ctx context.Context, doc *models.ClickLog) error {
 	_, _, err := s.client.Collection("click_logs").Add(ctx, doc)
@@ -27,13 +36,51 @@ func (s *FirestoreServiceImpl) AddClickLog(ctx context.Context, doc *models.Clic
 	return nil
 }
 
+// AddClickCounts adds the clicks to the click_count of each link and sets its
+// last_clicked_at. The returned slice holds one result per entry; links that
+// no longer exist are skipped without an error.
+func (s *FirestoreServiceImpl) AddClickCounts(ctx context.Context, counts []models.ClickCounts) ([]error, error) {
+	results := make([]error, len(counts))
+	if len(counts) == 0 {
+		return results, nil
+	}
+
+	bw := s.client.BulkWriter(ctx)
+	jobs := make([]*firestore.BulkWriterJob, len(counts))
+	for i, c := range counts {
+		job, err := bw.Update(s.client.Collection("shortlinks").Doc(c.ShortID), []firestore.Update{
+			{Path: "click_count", Value: firestore.Increment(c.Clicks)},
+			{Path: "last_clicked_at", Value: c.LastClickedAt},
+		})
+		if err != nil {
+			results[i] = fmt.Errorf("failed to queue click count: %w", err)
+			continue
+		}
+		jobs[i] = job
+	}
+	bw.End()
+
+	for i, job := range jobs {
+		if job == nil {
+			continue
+		}
+		if _, err := job.Results(); err != nil && status.Code(err) != codes.NotFound {
+			results[i] = fmt.Errorf("failed to update click count: %w", err)
+		}
+	}
+	return results, nil
+}
+
 func (s *FirestoreServiceImpl) GetClickLogs(ctx context.Context, req dto.ClickLogsRequest) ([]models.ClickLog, string, error) {
-	queryFirestore := s.buildClickLogsQuery(req.ShortID, req.ClickLogsQuery)
+	queryFirestore, err := s.buildClickLogsQuery(req.ShortID, req.ClickLogsQuery)
+	if err != nil {
+		return nil, "", err
+	}
 	iter := queryFirestore.Documents(ctx)
 	defer iter.Stop()
 
 	var logs []models.ClickLog
-	var nextCursor string
+	var lastID string
 	for {
 		doc, err := iter.Next()
 		if err == iterator.Done {
@@ -51,9 +98,17 @@ func (s *FirestoreServiceImpl) GetClickLogs(ctx context.Context, req dto.ClickLo
 		}
 
 		logs = append(logs, clickLog)
-		nextCursor = clickLog.Timestamp.Format(time.RFC3339Nano)
+		lastID = doc.Ref.ID
 	}
 
+	// a short page is the last one
+	if len(logs) < pageLimit(req.Limit) {
+		return logs, "", nil
+	}
+	nextCursor, err := s.cursors.Encode(logs[len(logs)-1].Timestamp, lastID, req.OrderDesc, clickLogsFilterHash(req.ShortID, req.ClickLogsQuery))
+	if err != nil {
+		return nil, "", shortlink_errors.ErrFailedRetrieveData
+	}
 	return logs, nextCursor, nil
 }
 
@@ -0,0 +1,128 @@
+package firestore_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DomainStore keeps the custom domains, one document per host.
+type DomainStore interface {
+	// CreateDomain stores domain unless its host is taken by a domain
+	// replace returns false for.
+	CreateDomain(ctx context.Context, domain models.Domain, replace func(existing *models.Domain) bool) error
+	GetDomain(ctx context.Context, host string) (*models.Domain, error)
+	// ListDomains returns the domains of a workspace or, without
+	// workspaceID, the personal domains of ownerUID.
+	ListDomains(ctx context.Context, ownerUID, workspaceID string) ([]models.Domain, error)
+	SetDomainVerified(ctx context.Context, host string, verifiedAt time.Time) error
+	DeleteDomain(ctx context.Context, host string) error
+}
+
+// CreateDomain returns ErrResourceExists if the host is already registered,
+// by anyone, and replace does not allow taking it over. The check and the
+// write happen in one transaction.
+func (s *FirestoreServiceImpl) CreateDomain(ctx context.Context, domain models.Domain, replace func(existing *models.Domain) bool) error {
+	ref := s.client.Collection("domains").Doc(domain.Host)
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err == nil {
+			var existing models.Domain
+			if err := doc.DataTo(&existing); err != nil {
+				return err
+			}
+			if !replace(&existing) {
+				return shortlink_errors.ErrResourceExists
+			}
+		} else if status.Code(err) != codes.NotFound {
+			return err
+		}
+		return tx.Set(ref, domain)
+	})
+	if errors.Is(err, shortlink_errors.ErrResourceExists) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create domain: %w", err)
+	}
+	return nil
+}
+
+func (s *FirestoreServiceImpl) GetDomain(ctx context.Context, host string) (*models.Domain, error) {
+	doc, err := s.client.Collection("domains").Doc(host).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, shortlink_errors.ErrNotFound
+		}
+		return nil, shortlink_errors.ErrFailedRetrieveData
+	}
+
+	var domain models.Domain
+	if err := doc.DataTo(&domain); err != nil {
+		return nil, shortlink_errors.ErrFailedRetrieveData
+	}
+	return &domain, nil
+}
+
+func (s *FirestoreServiceImpl) ListDomains(ctx context.Context, ownerUID, workspaceID string) ([]models.Domain, error) {
+	query := s.client.Collection("domains").Where("workspace_id", "==", workspaceID)
+	if workspaceID == "" {
+		query = query.Where("owner_uid", "==", ownerUID)
+	}
+	iter := query.Documents(ctx)
+	defer iter.Stop()
+
+	var domains []models.Domain
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, shortlink_errors.ErrFailedRetrieveData
+		}
+
+		var domain models.Domain
+		if err := doc.DataTo(&domain); err != nil {
+			return nil, shortlink_errors.ErrFailedRetrieveData
+		}
+		domains = append(domains, domain)
+	}
+	return domains, nil
+}
+
+func (s *FirestoreServiceImpl) SetDomainVerified(ctx context.Context, host string, verifiedAt time.Time) error {
+	_, err := s.client.Collection("domains").Doc(host).Update(ctx, []firestore.Update{
+		{Path: "verified", Value: true},
+		{Path: "verified_at", Value: verifiedAt},
+	})
+	if status.Code(err) == codes.NotFound {
+		return shortlink_errors.ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to verify domain: %w", err)
+	}
+	return nil
+}
+
+func (s *FirestoreServiceImpl) DeleteDomain(ctx context.Context, host string) error {
+	ref := s.client.Collection("domains").Doc(host)
+	if _, err := ref.Get(ctx); err != nil {
+		if status.Code(err) == codes.NotFound {
+			return shortlink_errors.ErrNotFound
+		}
+		return shortlink_errors.ErrFailedRetrieveData
+	}
+	if _, err := ref.Delete(ctx); err != nil {
+		return fmt.Errorf("failed to delete domain: %w", err)
+	}
+	return nil
+}
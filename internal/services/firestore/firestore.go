@@ -6,6 +6,7 @@ import (
 	
 	"cloud.google.com/go/firestore"
 	firebase "firebase.google.com/go/v4"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/pagecursor"
 )
 
 type FirestoreService interface {
@@ -13,7 +14,8 @@ type FirestoreService interface {
 }
 
 type FirestoreServiceImpl struct {
-	client *firestore.Client
+	client  *firestore.Client
+	cursors *pagecursor.Signer
 }
 
 func New(ctx context.Context, firebaseApp *firebase.App) (*FirestoreServiceImpl, error) {
@@ -21,7 +23,11 @@ func New(ctx context.Context, firebaseApp *firebase.App) (*FirestoreServiceImpl,
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize firestore client: %w", err)
 	}
-	return &FirestoreServiceImpl{client: client}, nil
+	cursors, err := pagecursor.LoadSigner()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pagination cursor key: %w", err)
+	}
+	return &FirestoreServiceImpl{client: client, cursors: cursors}, nil
 }
 
 func (s *FirestoreServiceImpl) GetClient() *firestore.Client {
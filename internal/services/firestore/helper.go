@@ -2,20 +2,20 @@ package firestore_service
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"net/url"
-	"strings"
 	"time"
 
 	"cloud.google.com/go/firestore"
 	"google.golang.org/api/iterator"
 
 	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/pagecursor"
 	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
 )
 
 
-func (s *FirestoreServiceImpl) buildClickLogsQuery(shortID string, clickLogsQuery dto.ClickLogsQuery) (query firestore.Query) {
+func (s *FirestoreServiceImpl) buildClickLogsQuery(shortID string, clickLogsQuery dto.ClickLogsQuery) (query firestore.Query, err error) {
 	query = s.client.Collection("click_logs").Where("short_id", "==", shortID)
 
 	// Filter the range of click logs
@@ -25,55 +25,47 @@ func (s *FirestoreServiceImpl) buildClickLogsQuery(shortID string, clickLogsQuer
 	if !clickLogsQuery.Before.IsZero() {
 		query = query.Where("timestamp", "<", clickLogsQuery.Before)
 	}
+	if clickLogsQuery.Source != "" {
+		query = query.Where("source", "==", clickLogsQuery.Source)
+	}
 
+	// logs with the same timestamp are ordered by document ID, so pages
+	// neither skip nor repeat them
+	direction := firestore.Asc
 	if clickLogsQuery.OrderDesc {
-		query = query.OrderBy("timestamp", firestore.Desc)
-	} else {
-		query = query.OrderBy("timestamp", firestore.Asc)
+		direction = firestore.Desc
 	}
+	query = query.OrderBy("timestamp", direction).OrderBy(firestore.DocumentID, direction)
 
 	// pagination query
-	query = buildPaginationQuery(clickLogsQuery.PaginationQuery, query)
-
-	return query
-}
-
-func (s *FirestoreServiceImpl) buildUserLinksQuery(createdBy string, q dto.UserLinksQuery) (query firestore.Query) {
-	query = s.client.Collection("shortlinks").Where("created_by", "==", createdBy)
-
-	switch q.IsPrivate {
-	case "true", "yes":
-		query = query.Where("is_private", "==", true)
-	case "false", "no":
-		query = query.Where("is_private", "==", false)
-	}
-
-	if q.OrderDesc {
-		query = query.OrderBy("created_at", firestore.Desc)
-	} else {
-		query = query.OrderBy("created_at", firestore.Asc)
+	if clickLogsQuery.Cursor != "" {
+		cursor, err := s.cursors.Decode(clickLogsQuery.Cursor, clickLogsQuery.OrderDesc, clickLogsFilterHash(shortID, clickLogsQuery))
+		if err != nil {
+			return query, shortlink_errors.ErrInvalidCursor
+		}
+		var timestamp time.Time
+		if err := json.Unmarshal(cursor.Value, &timestamp); err != nil {
+			return query, shortlink_errors.ErrInvalidCursor
+		}
+		query = query.StartAfter(timestamp, cursor.ID)
 	}
+	query = query.Limit(pageLimit(clickLogsQuery.Limit))
 
-	query = buildPaginationQuery(q.PaginationQuery, query)
-
-	return query
+	return query, nil
 }
 
-func buildPaginationQuery(pq dto.PaginationQuery, query firestore.Query) firestore.Query {
-	// Cursor
-	if pq.Cursor != "" {
-		parsedCursor, err := time.Parse(time.RFC3339Nano, pq.Cursor)
-		if err == nil {
-			query = query.StartAfter(parsedCursor)
-		}
-	}
+// clickLogsFilterHash identifies the filters of a click log listing, so its
+// cursors cannot be used with another one.
+func clickLogsFilterHash(shortID string, q dto.ClickLogsQuery) string {
+	return pagecursor.FilterHash(shortID, q.After, q.Before, q.Source)
+}
 
-	// Limit
-	if pq.Limit <= 0 || pq.Limit > 100 {
-		pq.Limit = 50
+// pageLimit is the page size for a requested limit: 50 by default, at most 100.
+func pageLimit(limit int) int {
+	if limit <= 0 || limit > 100 {
+		return 50
 	}
-	query = query.Limit(pq.Limit)
-	return query
+	return limit
 }
 
 func checkDocumentExists(ctx context.Context, q firestore.Query) (bool, error) {
@@ -85,13 +77,4 @@ func checkDocumentExists(ctx context.Context, q firestore.Query) (bool, error) {
 		return true, fmt.Errorf("failed to check documents: %w", err)
 	}
 	return false, nil
-}
-
-func normalizeURLForBlacklist(u *url.URL) string {
-	host := strings.ToLower(u.Hostname())
-	path := strings.TrimSuffix(u.EscapedPath(), "/")
-	if path == "" {
-		return host
-	}
-	return host + path
 }
\ No newline at end of file
@@ -0,0 +1,107 @@
+package firestore_service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// backfillPageSize is how many links are read at a time by the backfill.
+const backfillPageSize = 500
+
+// BackfillShortlinkDefaults writes the listing fields into the links created
+// before those fields existed. Firestore leaves a document out of every
+// filter and order on a field it lacks, so without them these links are
+// missing from the state filters, the destination filter and the click
+// orders of the link listing.
+//
+// Only missing fields are written, and only if the link did not change since
+// it was read, so a click counted meanwhile is not reset; running it again
+// picks up the links skipped that way. It returns how many links were updated.
+func (s *FirestoreServiceImpl) BackfillShortlinkDefaults(ctx context.Context) (int, error) {
+	updated, after := 0, ""
+	for {
+		query := s.client.Collection("shortlinks").OrderBy(firestore.DocumentID, firestore.Asc).Limit(backfillPageSize)
+		if after != "" {
+			query = query.StartAfter(after)
+		}
+		docs, err := query.Documents(ctx).GetAll()
+		if err != nil {
+			return updated, fmt.Errorf("failed to read shortlinks: %w", err)
+		}
+		if len(docs) == 0 {
+			return updated, nil
+		}
+		after = docs[len(docs)-1].Ref.ID
+
+		n, err := s.backfillShortlinks(ctx, docs)
+		updated += n
+		if err != nil {
+			return updated, err
+		}
+	}
+}
+
+func (s *FirestoreServiceImpl) backfillShortlinks(ctx context.Context, docs []*firestore.DocumentSnapshot) (int, error) {
+	bw := s.client.BulkWriter(ctx)
+	var jobs []*firestore.BulkWriterJob
+	for _, doc := range docs {
+		updates := missingLinkFields(doc.Data())
+		if len(updates) == 0 {
+			continue
+		}
+		job, err := bw.Update(doc.Ref, updates, firestore.LastUpdateTime(doc.UpdateTime))
+		if err != nil {
+			bw.End()
+			return 0, fmt.Errorf("failed to queue shortlink %s: %w", doc.Ref.ID, err)
+		}
+		jobs = append(jobs, job)
+	}
+	bw.End()
+
+	updated := 0
+	for _, job := range jobs {
+		_, err := job.Results()
+		switch status.Code(err) {
+		case codes.OK:
+			updated++
+		case codes.FailedPrecondition, codes.NotFound:
+			// changed or deleted since it was read
+		default:
+			return updated, fmt.Errorf("failed to backfill shortlink: %w", err)
+		}
+	}
+	return updated, nil
+}
+
+// missingLinkFields returns the defaults of the listing fields data lacks.
+func missingLinkFields(data map[string]interface{}) []firestore.Update {
+	var updates []firestore.Update
+	defaults := []firestore.Update{
+		{Path: "disabled", Value: false},
+		{Path: "click_count", Value: 0},
+		{Path: "last_clicked_at", Value: time.Time{}},
+	}
+	for _, d := range defaults {
+		if _, ok := data[d.Path]; !ok {
+			updates = append(updates, d)
+		}
+	}
+
+	if _, ok := data["destination_host"]; !ok {
+		link, _ := data["canonical_url"].(string)
+		if link == "" {
+			link, _ = data["url"].(string)
+		}
+		if u, err := url.Parse(link); err == nil && u.Hostname() != "" {
+			updates = append(updates, firestore.Update{Path: "destination_host", Value: strings.ToLower(u.Hostname())})
+		}
+	}
+	return updates
+}
@@ -0,0 +1,187 @@
+package firestore_service
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/pagecursor"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+)
+
+const (
+	// linkScanChunk is how many links are read at a time when some filters
+	// can only be applied after reading them.
+	linkScanChunk = 200
+
+	// maxLinkScan bounds the links read for one page. A page cut short by it
+	// has fewer links than asked for, and a cursor to continue from.
+	maxLinkScan = 1000
+)
+
+// linkSortFields are the fields behind the sort orders of the link listing.
+var linkSortFields = map[string]string{
+	dto.LinkSortCreated:     "created_at",
+	dto.LinkSortClicks:      "click_count",
+	dto.LinkSortLastClicked: "last_clicked_at",
+}
+
+func (s *FirestoreServiceImpl) buildUserLinksQuery(createdBy string, q dto.UserLinksQuery) (firestore.Query, error) {
+	var query firestore.Query
+	// workspace listings show every link of the workspace, not only the caller's
+	if q.WorkspaceID != "" {
+		query = s.client.Collection("shortlinks").Where("workspace_id", "==", q.WorkspaceID)
+	} else {
+		query = s.client.Collection("shortlinks").Where("created_by", "==", createdBy)
+	}
+
+	switch q.IsPrivate {
+	case "true", "yes":
+		query = query.Where("is_private", "==", true)
+	case "false", "no":
+		query = query.Where("is_private", "==", false)
+	}
+
+	if q.DestinationHost != "" {
+		query = query.Where("destination_host", "==", strings.ToLower(q.DestinationHost))
+	}
+	if q.Tag != "" {
+		query = query.Where("tags", "array-contains", q.Tag)
+	}
+	switch q.State {
+	case dto.LinkStateDisabled:
+		query = query.Where("disabled", "==", true)
+	case dto.LinkStateActive:
+		query = query.Where("disabled", "==", false)
+	}
+
+	sort := linkSort(q)
+	if sort == dto.LinkSortCreated {
+		if !q.CreatedAfter.IsZero() {
+			query = query.Where("created_at", ">", q.CreatedAfter)
+		}
+		if !q.CreatedBefore.IsZero() {
+			query = query.Where("created_at", "<", q.CreatedBefore)
+		}
+	}
+
+	direction := firestore.Asc
+	if q.OrderDesc {
+		direction = firestore.Desc
+	}
+	query = query.OrderBy(linkSortFields[sort], direction).OrderBy(firestore.DocumentID, direction)
+
+	if q.Cursor != "" {
+		value, id, err := s.decodeLinkCursor(createdBy, q)
+		if err != nil {
+			return query, err
+		}
+		query = query.StartAfter(value, id)
+	}
+	return query, nil
+}
+
+func linkSort(q dto.UserLinksQuery) string {
+	if q.Sort == "" {
+		return dto.LinkSortCreated
+	}
+	return q.Sort
+}
+
+// needsLinkScan reports whether some filters of q are applied after reading
+// the links rather than by Firestore.
+func needsLinkScan(q dto.UserLinksQuery) bool {
+	return q.Search != "" || q.State == dto.LinkStateActive || q.State == dto.LinkStateExpired ||
+		(linkSort(q) != dto.LinkSortCreated && (!q.CreatedAfter.IsZero() || !q.CreatedBefore.IsZero()))
+}
+
+// matchesLinkQuery applies the filters of q that Firestore cannot: expiry,
+// substring search and, when sorting by something else, the created range.
+func matchesLinkQuery(link *models.Shortlink, q dto.UserLinksQuery, now time.Time) bool {
+	switch q.State {
+	case dto.LinkStateActive:
+		if link.Expired(now) {
+			return false
+		}
+	case dto.LinkStateExpired:
+		if !link.Expired(now) {
+			return false
+		}
+	}
+
+	if linkSort(q) != dto.LinkSortCreated {
+		if !q.CreatedAfter.IsZero() && !link.CreatedAt.After(q.CreatedAfter) {
+			return false
+		}
+		if !q.CreatedBefore.IsZero() && !link.CreatedAt.Before(q.CreatedBefore) {
+			return false
+		}
+	}
+
+	if q.Search != "" {
+		search := strings.ToLower(q.Search)
+		if !strings.Contains(strings.ToLower(link.ShortID), search) &&
+			!strings.Contains(strings.ToLower(link.URL), search) &&
+			!strings.Contains(strings.ToLower(link.Title), search) {
+			return false
+		}
+	}
+	return true
+}
+
+// linkFilterHash identifies the filters and sort order of a link listing, so
+// its cursors cannot be used with another one.
+func linkFilterHash(createdBy string, q dto.UserLinksQuery) string {
+	owner := "user:" + createdBy
+	if q.WorkspaceID != "" {
+		owner = "workspace:" + q.WorkspaceID
+	}
+	return pagecursor.FilterHash(owner, q.IsPrivate, strings.ToLower(q.DestinationHost), q.Tag,
+		q.CreatedAfter, q.CreatedBefore, q.State, q.Search, linkSort(q))
+}
+
+// encodeLinkCursor makes the cursor of the page ending with link, stored
+// under document id. Links with the same sort value are ordered by id.
+func (s *FirestoreServiceImpl) encodeLinkCursor(createdBy string, q dto.UserLinksQuery, link *models.Shortlink, id string) (string, error) {
+	var value any
+	switch linkSort(q) {
+	case dto.LinkSortClicks:
+		value = link.ClickCount
+	case dto.LinkSortLastClicked:
+		value = link.LastClickedAt
+	default:
+		value = link.CreatedAt
+	}
+
+	cursor, err := s.cursors.Encode(value, id, q.OrderDesc, linkFilterHash(createdBy, q))
+	if err != nil {
+		return "", shortlink_errors.ErrFailedRetrieveData
+	}
+	return cursor, nil
+}
+
+// decodeLinkCursor returns the sort value and document ID of q's cursor,
+// which must have been made for the same listing.
+func (s *FirestoreServiceImpl) decodeLinkCursor(createdBy string, q dto.UserLinksQuery) (any, string, error) {
+	cursor, err := s.cursors.Decode(q.Cursor, q.OrderDesc, linkFilterHash(createdBy, q))
+	if err != nil {
+		return nil, "", shortlink_errors.ErrInvalidCursor
+	}
+
+	if linkSort(q) == dto.LinkSortClicks {
+		var count int64
+		if err := json.Unmarshal(cursor.Value, &count); err != nil {
+			return nil, "", shortlink_errors.ErrInvalidCursor
+		}
+		return count, cursor.ID, nil
+	}
+	var t time.Time
+	if err := json.Unmarshal(cursor.Value, &t); err != nil {
+		return nil, "", shortlink_errors.ErrInvalidCursor
+	}
+	return t, cursor.ID, nil
+}
@@ -0,0 +1,98 @@
+package firestore_service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// LinkStatus is used by the jobs that disable links after the fact, e.g.
+// when their destination gets blacklisted.
+type LinkStatus interface {
+	ListShortlinksPage(ctx context.Context, afterShortID string, limit int) ([]models.Shortlink, error)
+	ListShortlinksByDisabledRule(ctx context.Context, rule string) ([]models.Shortlink, error)
+	SetShortlinkDisabled(ctx context.Context, shortID string, disabled bool, reason, rule string) error
+	FlagShortlinkUnsafe(ctx context.Context, shortID, threatType string) error
+}
+
+// ListShortlinksPage walks the whole collection in document ID order.
+func (s *FirestoreServiceImpl) ListShortlinksPage(ctx context.Context, afterShortID string, limit int) ([]models.Shortlink, error) {
+	query := s.client.Collection("shortlinks").OrderBy(firestore.DocumentID, firestore.Asc).Limit(limit)
+	if afterShortID != "" {
+		query = query.StartAfter(afterShortID)
+	}
+	return collectShortlinks(query.Documents(ctx))
+}
+
+func (s *FirestoreServiceImpl) ListShortlinksByDisabledRule(ctx context.Context, rule string) ([]models.Shortlink, error) {
+	query := s.client.Collection("shortlinks").Where("disabled", "==", true).Where("disabled_rule", "==", rule)
+	return collectShortlinks(query.Documents(ctx))
+}
+
+// SetShortlinkDisabled only touches the status fields, so it cannot undo a
+// concurrent edit of the link.
+func (s *FirestoreServiceImpl) SetShortlinkDisabled(ctx context.Context, shortID string, disabled bool, reason, rule string) error {
+	updates := []firestore.Update{
+		{Path: "disabled", Value: disabled},
+		{Path: "disabled_reason", Value: reason},
+		{Path: "disabled_rule", Value: rule},
+		{Path: "disabled_at", Value: time.Time{}},
+	}
+	if disabled {
+		updates[3].Value = time.Now()
+	}
+
+	return s.updateShortlinkStatus(ctx, shortID, updates)
+}
+
+// FlagShortlinkUnsafe disables a link Safe Browsing reports as unsafe.
+func (s *FirestoreServiceImpl) FlagShortlinkUnsafe(ctx context.Context, shortID, threatType string) error {
+	return s.updateShortlinkStatus(ctx, shortID, []firestore.Update{
+		{Path: "disabled", Value: true},
+		{Path: "disabled_reason", Value: models.DisabledReasonUnsafe},
+		{Path: "disabled_rule", Value: models.DisabledRuleSafeBrowsing},
+		{Path: "disabled_at", Value: time.Now()},
+		{Path: "threat_type", Value: threatType},
+	})
+}
+
+func (s *FirestoreServiceImpl) updateShortlinkStatus(ctx context.Context, shortID string, updates []firestore.Update) error {
+	if _, err := s.client.Collection("shortlinks").Doc(shortID).Update(ctx, updates); err != nil {
+		if status.Code(err) == codes.NotFound {
+			return shortlink_errors.ErrNotFound
+		}
+		return fmt.Errorf("failed to update shortlink status: %w", err)
+	}
+	return nil
+}
+
+func collectShortlinks(iter *firestore.DocumentIterator) ([]models.Shortlink, error) {
+	defer iter.Stop()
+
+	var links []models.Shortlink
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			fmt.Printf("Error retrieving document: %v\n", err)
+			return nil, shortlink_errors.ErrFailedRetrieveData
+		}
+
+		var link models.Shortlink
+		if err := doc.DataTo(&link); err != nil {
+			fmt.Printf("Error converting document data to Shortlink: %v\n", err)
+			return nil, shortlink_errors.ErrFailedRetrieveData
+		}
+		links = append(links, link)
+	}
+	return links, nil
+}
@@ -0,0 +1,52 @@
+package firestore_service
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+)
+
+// ListTaggedLinks returns the personal links of createdBy, or the links of
+// workspaceID when it is set, that have tag; with an empty tag it returns
+// all of them. Only the fields needed to manage and count tags are read.
+func (s *FirestoreServiceImpl) ListTaggedLinks(ctx context.Context, createdBy, workspaceID, tag string) ([]models.Shortlink, error) {
+	query := s.client.Collection("shortlinks").
+		Select("short_id", "domain", "title", "created_by", "workspace_id", "tags", "click_count", "last_clicked_at").
+		Where("workspace_id", "==", workspaceID)
+	if workspaceID == "" {
+		query = query.Where("created_by", "==", createdBy)
+	}
+	if tag != "" {
+		query = query.Where("tags", "array-contains", tag)
+	}
+	return collectShortlinks(query.Documents(ctx))
+}
+
+// SetLinkTags replaces the tags of the links keyed by their document ID,
+// leaving their other fields alone.
+func (s *FirestoreServiceImpl) SetLinkTags(ctx context.Context, tags map[string][]string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	bw := s.client.BulkWriter(ctx)
+	jobs := make([]*firestore.BulkWriterJob, 0, len(tags))
+	for key, linkTags := range tags {
+		job, err := bw.Update(s.client.Collection("shortlinks").Doc(key), []firestore.Update{{Path: "tags", Value: linkTags}})
+		if err != nil {
+			bw.End()
+			return fmt.Errorf("failed to queue tags of %s: %w", key, err)
+		}
+		jobs = append(jobs, job)
+	}
+	bw.End()
+
+	for _, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			return fmt.Errorf("failed to update tags: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package firestore_service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/pagecursor"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+	"google.golang.org/api/iterator"
+)
+
+type Notifications interface {
+	AddNotifications(ctx context.Context, notifications []models.Notification) error
+	ListNotifications(ctx context.Context, uid string, q dto.PaginationQuery) ([]models.Notification, string, error)
+}
+
+func (s *FirestoreServiceImpl) AddNotifications(ctx context.Context, notifications []models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	bw := s.client.BulkWriter(ctx)
+	jobs := make([]*firestore.BulkWriterJob, 0, len(notifications))
+	for _, n := range notifications {
+		job, err := bw.Create(s.client.Collection("notifications").NewDoc(), n)
+		if err != nil {
+			return fmt.Errorf("failed to queue notification: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	bw.End()
+
+	for _, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			return fmt.Errorf("failed to add notification: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListNotifications returns the notifications of uid, ordered by creation.
+func (s *FirestoreServiceImpl) ListNotifications(ctx context.Context, uid string, q dto.PaginationQuery) ([]models.Notification, string, error) {
+	// notifications sent together share their creation time, so they are
+	// ordered by document ID as well, and pages neither skip nor repeat them
+	direction := firestore.Asc
+	if q.OrderDesc {
+		direction = firestore.Desc
+	}
+	query := s.client.Collection("notifications").Where("uid", "==", uid).
+		OrderBy("created_at", direction).OrderBy(firestore.DocumentID, direction)
+
+	if q.Cursor != "" {
+		cursor, err := s.cursors.Decode(q.Cursor, q.OrderDesc, pagecursor.FilterHash(uid))
+		if err != nil {
+			return nil, "", shortlink_errors.ErrInvalidCursor
+		}
+		var createdAt time.Time
+		if err := json.Unmarshal(cursor.Value, &createdAt); err != nil {
+			return nil, "", shortlink_errors.ErrInvalidCursor
+		}
+		query = query.StartAfter(createdAt, cursor.ID)
+	}
+	iter := query.Limit(pageLimit(q.Limit)).Documents(ctx)
+	defer iter.Stop()
+
+	var notifications []models.Notification
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			fmt.Printf("Error retrieving document: %v\n", err)
+			return nil, "", shortlink_errors.ErrFailedRetrieveData
+		}
+
+		var n models.Notification
+		if err := doc.DataTo(&n); err != nil {
+			fmt.Printf("Error converting document data to Notification: %v\n", err)
+			return nil, "", shortlink_errors.ErrFailedRetrieveData
+		}
+		n.ID = doc.Ref.ID
+
+		notifications = append(notifications, n)
+	}
+
+	// a short page is the last one
+	if len(notifications) < pageLimit(q.Limit) {
+		return notifications, "", nil
+	}
+	last := notifications[len(notifications)-1]
+	nextCursor, err := s.cursors.Encode(last.CreatedAt, last.ID, q.OrderDesc, pagecursor.FilterHash(uid))
+	if err != nil {
+		return nil, "", shortlink_errors.ErrFailedRetrieveData
+	}
+	return notifications, nextCursor, nil
+}
@@ -0,0 +1,75 @@
+package firestore_service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ReservedIDStore keeps the admin-managed reserved words, next to the
+// blacklist_items collection.
+type ReservedIDStore interface {
+	ListReservedIDs(ctx context.Context) ([]models.ReservedID, error)
+	AddReservedID(ctx context.Context, item models.ReservedID) error
+	RemoveReservedID(ctx context.Context, word, workspaceID string) error
+}
+
+func (s *FirestoreServiceImpl) ListReservedIDs(ctx context.Context) ([]models.ReservedID, error) {
+	iter := s.client.Collection("reserved_ids").Documents(ctx)
+	defer iter.Stop()
+
+	var items []models.ReservedID
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, shortlink_errors.ErrFailedRetrieveData
+		}
+
+		var item models.ReservedID
+		if err := doc.DataTo(&item); err != nil {
+			return nil, fmt.Errorf("failed to parse reserved ID: %w", err)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// AddReservedID stores item; ErrResourceExists is returned if the word is
+// already reserved for the same workspace (or globally).
+func (s *FirestoreServiceImpl) AddReservedID(ctx context.Context, item models.ReservedID) error {
+	ref := s.client.Collection("reserved_ids").Doc(reservedIDDocID(item.Word, item.WorkspaceID))
+	if _, err := ref.Create(ctx, item); err != nil {
+		if status.Code(err) == codes.AlreadyExists {
+			return shortlink_errors.ErrResourceExists
+		}
+		return fmt.Errorf("failed to add reserved ID: %w", err)
+	}
+	return nil
+}
+
+func (s *FirestoreServiceImpl) RemoveReservedID(ctx context.Context, word, workspaceID string) error {
+	ref := s.client.Collection("reserved_ids").Doc(reservedIDDocID(word, workspaceID))
+	if _, err := ref.Get(ctx); err != nil {
+		if status.Code(err) == codes.NotFound {
+			return shortlink_errors.ErrNotFound
+		}
+		return shortlink_errors.ErrFailedRetrieveData
+	}
+	if _, err := ref.Delete(ctx); err != nil {
+		return fmt.Errorf("failed to remove reserved ID: %w", err)
+	}
+	return nil
+}
+
+func reservedIDDocID(word, workspaceID string) string {
+	return utils.GenerateDocID(workspaceID + "/" + word)
+}
@@ -5,17 +5,29 @@ import (
 	"fmt"
 	"time"
 
+	"cloud.google.com/go/firestore"
 	"github.com/mfmahendr/url-shortener-backend/internal/dto"
 	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils"
 	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
 	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type Shortlink interface {
-	// DeleteShortlink(ctx context.Context, shortID string) error
+	DeleteShortlink(ctx context.Context, shortID string) error
 	ListUserLinks(ctx context.Context, req dto.UserLinksRequest) ([]models.Shortlink, string, error)
 	GetShortlink(ctx context.Context, shortID string) (*models.Shortlink, error)
 	SetShortlink(ctx context.Context, shortID string, doc models.Shortlink) error
+	UpdateShortlink(ctx context.Context, shortID string, update func(*models.Shortlink) error) (*models.Shortlink, error)
+	CreateShortlink(ctx context.Context, doc models.Shortlink) error
+	CreateShortlinks(ctx context.Context, docs []models.Shortlink) ([]error, error)
+	ClaimShortenBatch(ctx context.Context, batch models.ShortenBatch, staleAfter time.Duration) (*models.ShortenBatch, error)
+	SaveShortenBatch(ctx context.Context, batch models.ShortenBatch) error
+	FindLinksByCanonicalURL(ctx context.Context, createdBy, workspaceID, canonicalURL string) ([]models.Shortlink, error)
+	ListTaggedLinks(ctx context.Context, createdBy, workspaceID, tag string) ([]models.Shortlink, error)
+	SetLinkTags(ctx context.Context, tags map[string][]string) error
 }
 
 func (s *FirestoreServiceImpl) SetShortlink(ctx context.Context, shortID string, doc models.Shortlink) error {
@@ -26,6 +38,140 @@ func (s *FirestoreServiceImpl) SetShortlink(ctx context.Context, shortID string,
 	return nil
 }
 
+// UpdateShortlink applies update to the stored link and saves it in one
+// transaction, so fields written in the meantime, like the click count or
+// the disabled state, are not overwritten with stale values. It returns the
+// link as saved.
+func (s *FirestoreServiceImpl) UpdateShortlink(ctx context.Context, shortID string, update func(*models.Shortlink) error) (*models.Shortlink, error) {
+	ref := s.client.Collection("shortlinks").Doc(shortID)
+	var link models.Shortlink
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		docSnap, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+		link = models.Shortlink{}
+		if err := docSnap.DataTo(&link); err != nil {
+			return err
+		}
+		if err := update(&link); err != nil {
+			return err
+		}
+		return tx.Set(ref, link)
+	})
+	if status.Code(err) == codes.NotFound {
+		return nil, shortlink_errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update shortlink: %w", err)
+	}
+	return &link, nil
+}
+
+// CreateShortlink stores doc only if its ID is still free; otherwise it
+// returns ErrIDExists.
+func (s *FirestoreServiceImpl) CreateShortlink(ctx context.Context, doc models.Shortlink) error {
+	_, err := s.client.Collection("shortlinks").Doc(doc.Key()).Create(ctx, doc)
+	if status.Code(err) == codes.AlreadyExists {
+		return shortlink_errors.ErrIDExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create shortlink: %w", err)
+	}
+	return nil
+}
+
+// CreateShortlinks creates the links with a bulk writer. Like
+// CreateShortlink it never overwrites a link; the returned slice holds one
+// result per link: nil, ErrIDExists or a write error.
+func (s *FirestoreServiceImpl) CreateShortlinks(ctx context.Context, docs []models.Shortlink) ([]error, error) {
+	results := make([]error, len(docs))
+	if len(docs) == 0 {
+		return results, nil
+	}
+
+	bw := s.client.BulkWriter(ctx)
+	jobs := make([]*firestore.BulkWriterJob, len(docs))
+	for i := range docs {
+		job, err := bw.Create(s.client.Collection("shortlinks").Doc(docs[i].Key()), docs[i])
+		if err != nil {
+			results[i] = fmt.Errorf("failed to queue shortlink: %w", err)
+			continue
+		}
+		jobs[i] = job
+	}
+	bw.End()
+
+	for i, job := range jobs {
+		if job == nil {
+			continue
+		}
+		if _, err := job.Results(); err != nil {
+			if status.Code(err) == codes.AlreadyExists {
+				results[i] = shortlink_errors.ErrIDExists
+			} else {
+				results[i] = fmt.Errorf("failed to create shortlink: %w", err)
+			}
+		}
+	}
+	return results, nil
+}
+
+// ClaimShortenBatch stores batch, which has no response yet, as the claim of
+// its user and idempotency key, and returns nil. If the key is already taken
+// it returns the stored batch instead, unless that is a claim older than
+// staleAfter that never got a response: it is taken over, as the request
+// that made it is gone.
+func (s *FirestoreServiceImpl) ClaimShortenBatch(ctx context.Context, batch models.ShortenBatch, staleAfter time.Duration) (*models.ShortenBatch, error) {
+	ref := s.client.Collection("shorten_batches").Doc(shortenBatchDocID(batch.UID, batch.IdempotencyKey))
+	var existing *models.ShortenBatch
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		existing = nil
+		docSnap, err := tx.Get(ref)
+		if err == nil {
+			var stored models.ShortenBatch
+			if err := docSnap.DataTo(&stored); err != nil {
+				return err
+			}
+			if stored.Response != "" || time.Since(stored.CreatedAt) < staleAfter {
+				existing = &stored
+				return nil
+			}
+		} else if status.Code(err) != codes.NotFound {
+			return err
+		}
+		return tx.Set(ref, batch)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim shorten batch: %w", err)
+	}
+	return existing, nil
+}
+
+// SaveShortenBatch stores the response of a batch that was claimed with
+// ClaimShortenBatch.
+func (s *FirestoreServiceImpl) SaveShortenBatch(ctx context.Context, batch models.ShortenBatch) error {
+	_, err := s.client.Collection("shorten_batches").Doc(shortenBatchDocID(batch.UID, batch.IdempotencyKey)).Set(ctx, batch)
+	if err != nil {
+		return fmt.Errorf("failed to save shorten batch: %w", err)
+	}
+	return nil
+}
+
+// shortenBatchDocID keys batches by user and idempotency key; keys are
+// chosen by clients, so they are hashed into a valid document ID.
+func shortenBatchDocID(uid, idempotencyKey string) string {
+	return utils.GenerateDocID(uid + "\x00" + idempotencyKey)
+}
+
+func (s *FirestoreServiceImpl) DeleteShortlink(ctx context.Context, shortID string) error {
+	_, err := s.client.Collection("shortlinks").Doc(shortID).Delete(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete shortlink: %w", err)
+	}
+	return nil
+}
+
 // func (s *FirestoreServiceImpl) GetShortlink(ctx context.Context, shortID string) (*firestore.DocumentSnapshot, error) {
 func (s *FirestoreServiceImpl) GetShortlink(ctx context.Context, shortID string) (*models.Shortlink, error) {
 	docSnap, err := s.client.Collection("shortlinks").Doc(shortID).Get(ctx)
@@ -45,32 +191,100 @@ func (s *FirestoreServiceImpl) GetShortlink(ctx context.Context, shortID string)
 	return &shortlink, nil
 }
 
+// ListUserLinks returns a page of the links matching req and the cursor of
+// the next page, or "" after the last page.
 func (s *FirestoreServiceImpl) ListUserLinks(ctx context.Context, req dto.UserLinksRequest) ([]models.Shortlink, string, error) {
-	queryFirestore := s.buildUserLinksQuery(req.CreatedBy, req.UserLinksQuery)
-	iter := queryFirestore.Documents(ctx)
+	query, err := s.buildUserLinksQuery(req.CreatedBy, req.UserLinksQuery)
+	if err != nil {
+		return nil, "", err
+	}
+
+	limit := pageLimit(req.Limit)
+	chunk := limit
+	if needsLinkScan(req.UserLinksQuery) {
+		chunk = linkScanChunk
+	}
+	now := time.Now()
+
+	links := []models.Shortlink{}
+	var last *firestore.DocumentSnapshot
+	var lastLink models.Shortlink
+	for scanned := 0; scanned < maxLinkScan; {
+		page := query
+		if last != nil {
+			page = page.StartAfter(last)
+		}
+		iter := page.Limit(chunk).Documents(ctx)
+		read := 0
+		for {
+			doc, err := iter.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				iter.Stop()
+				fmt.Printf("Error retrieving document: %v\n", err)
+				return nil, "", shortlink_errors.ErrFailedRetrieveData
+			}
+
+			var link models.Shortlink
+			if err := doc.DataTo(&link); err != nil {
+				iter.Stop()
+				fmt.Printf("Error converting document data to Shortlink: %v\n", err)
+				return nil, "", shortlink_errors.ErrFailedRetrieveData
+			}
+			read++
+			scanned++
+			last, lastLink = doc, link
+
+			if !matchesLinkQuery(&link, req.UserLinksQuery, now) {
+				continue
+			}
+			links = append(links, link)
+			if len(links) == limit {
+				iter.Stop()
+				cursor, err := s.encodeLinkCursor(req.CreatedBy, req.UserLinksQuery, &link, doc.Ref.ID)
+				return links, cursor, err
+			}
+		}
+		iter.Stop()
+
+		if read < chunk {
+			return links, "", nil
+		}
+	}
+
+	// too many links did not match; the next page goes on from the last one read
+	cursor, err := s.encodeLinkCursor(req.CreatedBy, req.UserLinksQuery, &lastLink, last.Ref.ID)
+	return links, cursor, err
+}
+
+// FindLinksByCanonicalURL returns up to 10 links of createdBy in workspaceID
+// ("" for personal links) whose canonical URL is canonicalURL.
+func (s *FirestoreServiceImpl) FindLinksByCanonicalURL(ctx context.Context, createdBy, workspaceID, canonicalURL string) ([]models.Shortlink, error) {
+	iter := s.client.Collection("shortlinks").
+		Where("canonical_url", "==", canonicalURL).
+		Where("created_by", "==", createdBy).
+		Where("workspace_id", "==", workspaceID).
+		Limit(10).
+		Documents(ctx)
 	defer iter.Stop()
 
 	var links []models.Shortlink
-	var nextCursor string
 	for {
 		doc, err := iter.Next()
 		if err == iterator.Done {
 			break
 		}
 		if err != nil {
-			fmt.Printf("Error retrieving document: %v\n", err)
-			return nil, "", shortlink_errors.ErrFailedRetrieveData
+			return nil, shortlink_errors.ErrFailedRetrieveData
 		}
 
 		var link models.Shortlink
-		if doc.DataTo(&link) != nil {
-			fmt.Printf("Error converting document data to Shortlink: %v\n", err)
-			return nil, "", shortlink_errors.ErrFailedRetrieveData
+		if err := doc.DataTo(&link); err != nil {
+			return nil, shortlink_errors.ErrFailedRetrieveData
 		}
-
 		links = append(links, link)
-		nextCursor = link.CreatedAt.Format(time.RFC3339Nano)
 	}
-
-	return links, nextCursor, nil
+	return links, nil
 }
@@ -0,0 +1,213 @@
+package firestore_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"cloud.google.com/go/firestore"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type (
+	WorkspaceMembership interface {
+		GetMember(ctx context.Context, workspaceID, uid string) (*models.WorkspaceMember, error)
+	}
+
+	WorkspaceManager interface {
+		WorkspaceMembership
+		CreateWorkspace(ctx context.Context, ws *models.Workspace, owner models.WorkspaceMember) error
+		GetWorkspace(ctx context.Context, workspaceID string) (*models.Workspace, error)
+		ListMemberships(ctx context.Context, uid string) ([]models.WorkspaceMember, error)
+		ListMembers(ctx context.Context, workspaceID string) ([]models.WorkspaceMember, error)
+		SetMember(ctx context.Context, member models.WorkspaceMember) error
+		DeleteMember(ctx context.Context, workspaceID, uid string) error
+		CreateInvitation(ctx context.Context, inv *models.WorkspaceInvitation) error
+		GetInvitation(ctx context.Context, invitationID string) (*models.WorkspaceInvitation, error)
+		AcceptInvitation(ctx context.Context, invitationID string, member models.WorkspaceMember) (*models.WorkspaceMember, error)
+	}
+)
+
+func memberDocID(workspaceID, uid string) string {
+	return workspaceID + "_" + uid
+}
+
+// CreateWorkspace stores the workspace and its first owner atomically.
+func (s *FirestoreServiceImpl) CreateWorkspace(ctx context.Context, ws *models.Workspace, owner models.WorkspaceMember) error {
+	wsRef := s.client.Collection("workspaces").NewDoc()
+	ws.ID = wsRef.ID
+	owner.WorkspaceID = ws.ID
+
+	memberRef := s.client.Collection("workspace_members").Doc(memberDocID(ws.ID, owner.UID))
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		if err := tx.Create(wsRef, ws); err != nil {
+			return err
+		}
+		return tx.Create(memberRef, owner)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create workspace: %w", err)
+	}
+	return nil
+}
+
+func (s *FirestoreServiceImpl) GetWorkspace(ctx context.Context, workspaceID string) (*models.Workspace, error) {
+	doc, err := s.client.Collection("workspaces").Doc(workspaceID).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, shortlink_errors.ErrNotFound
+		}
+		return nil, shortlink_errors.ErrFailedRetrieveData
+	}
+
+	var ws models.Workspace
+	if err := doc.DataTo(&ws); err != nil {
+		return nil, shortlink_errors.ErrFailedRetrieveData
+	}
+	return &ws, nil
+}
+
+func (s *FirestoreServiceImpl) GetMember(ctx context.Context, workspaceID, uid string) (*models.WorkspaceMember, error) {
+	doc, err := s.client.Collection("workspace_members").Doc(memberDocID(workspaceID, uid)).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, shortlink_errors.ErrNotFound
+		}
+		return nil, shortlink_errors.ErrFailedRetrieveData
+	}
+
+	var member models.WorkspaceMember
+	if err := doc.DataTo(&member); err != nil {
+		return nil, shortlink_errors.ErrFailedRetrieveData
+	}
+	return &member, nil
+}
+
+func (s *FirestoreServiceImpl) ListMemberships(ctx context.Context, uid string) ([]models.WorkspaceMember, error) {
+	return s.listMembers(ctx, s.client.Collection("workspace_members").Where("uid", "==", uid))
+}
+
+func (s *FirestoreServiceImpl) ListMembers(ctx context.Context, workspaceID string) ([]models.WorkspaceMember, error) {
+	return s.listMembers(ctx, s.client.Collection("workspace_members").Where("workspace_id", "==", workspaceID))
+}
+
+func (s *FirestoreServiceImpl) listMembers(ctx context.Context, q firestore.Query) ([]models.WorkspaceMember, error) {
+	iter := q.Documents(ctx)
+	defer iter.Stop()
+
+	var members []models.WorkspaceMember
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			log.Println("Unexpected error:", err)
+			return nil, shortlink_errors.ErrFailedRetrieveData
+		}
+
+		var member models.WorkspaceMember
+		if err := doc.DataTo(&member); err != nil {
+			log.Println("Unexpected error:", err)
+			return nil, shortlink_errors.ErrFailedRetrieveData
+		}
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+func (s *FirestoreServiceImpl) SetMember(ctx context.Context, member models.WorkspaceMember) error {
+	_, err := s.client.Collection("workspace_members").Doc(memberDocID(member.WorkspaceID, member.UID)).Set(ctx, member)
+	if err != nil {
+		return fmt.Errorf("failed to set workspace member: %w", err)
+	}
+	return nil
+}
+
+func (s *FirestoreServiceImpl) DeleteMember(ctx context.Context, workspaceID, uid string) error {
+	_, err := s.client.Collection("workspace_members").Doc(memberDocID(workspaceID, uid)).Delete(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete workspace member: %w", err)
+	}
+	return nil
+}
+
+func (s *FirestoreServiceImpl) CreateInvitation(ctx context.Context, inv *models.WorkspaceInvitation) error {
+	ref := s.client.Collection("workspace_invitations").NewDoc()
+	inv.ID = ref.ID
+	if _, err := ref.Create(ctx, inv); err != nil {
+		return fmt.Errorf("failed to create invitation: %w", err)
+	}
+	return nil
+}
+
+func (s *FirestoreServiceImpl) GetInvitation(ctx context.Context, invitationID string) (*models.WorkspaceInvitation, error) {
+	doc, err := s.client.Collection("workspace_invitations").Doc(invitationID).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, shortlink_errors.ErrNotFound
+		}
+		return nil, shortlink_errors.ErrFailedRetrieveData
+	}
+
+	var inv models.WorkspaceInvitation
+	if err := doc.DataTo(&inv); err != nil {
+		return nil, shortlink_errors.ErrFailedRetrieveData
+	}
+	return &inv, nil
+}
+
+// AcceptInvitation adds the member and consumes the invitation in one transaction,
+// so an invitation can only ever be redeemed once. Someone who is already a
+// member only ever gains a role: an invitation for the same or a lower role
+// leaves the membership as it is. It returns the membership from before the
+// invitation, nil if there was none.
+func (s *FirestoreServiceImpl) AcceptInvitation(ctx context.Context, invitationID string, member models.WorkspaceMember) (*models.WorkspaceMember, error) {
+	invRef := s.client.Collection("workspace_invitations").Doc(invitationID)
+	memberRef := s.client.Collection("workspace_members").Doc(memberDocID(member.WorkspaceID, member.UID))
+
+	var before *models.WorkspaceMember
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		before = nil
+		doc, err := tx.Get(invRef)
+		if err != nil {
+			return err
+		}
+		if !doc.Exists() {
+			return shortlink_errors.ErrNotFound
+		}
+
+		existing, err := tx.Get(memberRef)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		joined := member
+		if err == nil && existing.Exists() {
+			before = new(models.WorkspaceMember)
+			if err := existing.DataTo(before); err != nil {
+				return err
+			}
+			if !member.Role.Outranks(before.Role) {
+				return tx.Delete(invRef)
+			}
+			joined.JoinedAt = before.JoinedAt
+		}
+
+		if err := tx.Set(memberRef, joined); err != nil {
+			return err
+		}
+		return tx.Delete(invRef)
+	})
+	if err != nil {
+		if status.Code(err) == codes.NotFound || errors.Is(err, shortlink_errors.ErrNotFound) {
+			return nil, shortlink_errors.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to accept invitation: %w", err)
+	}
+	return before, nil
+}
@@ -0,0 +1,208 @@
+package idgen_service
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	nanoid "github.com/matoous/go-nanoid/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	StrategyNanoID  = "nanoid"
+	StrategyCounter = "counter"
+	StrategyWords   = "words"
+
+	AlphabetBase62 = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	// AlphabetUnambiguous leaves out characters that are easily confused
+	// when read or typed: 0/O/o, 1/l/I and i.
+	AlphabetUnambiguous = "23456789abcdefghjkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ"
+
+	// CounterKey is the Redis key holding the last counter value.
+	CounterKey = "shortid:counter"
+
+	defaultLength = 8
+	// short IDs must be 3 to 30 alphanumeric characters
+	minLength = 3
+	maxLength = 30
+)
+
+var alphanumeric = regexp.MustCompile(`^[a-zA-Z0-9]+$`)
+
+type (
+	// IDGenerator creates candidate short IDs. IDs are not guaranteed to be
+	// unique; callers create the link only if the ID is still free.
+	IDGenerator interface {
+		Generate(ctx context.Context) (string, error)
+	}
+
+	Config struct {
+		Strategy string
+		// Length is the length of nanoid IDs.
+		Length int
+		// Alphabet is used by the nanoid and counter strategies.
+		Alphabet string
+	}
+
+	// NanoID generates random IDs of a fixed length.
+	NanoID struct {
+		Alphabet string
+		Length   int
+	}
+
+	// Counter encodes a Redis INCR sequence with the alphabet, so IDs are
+	// as short as possible but also predictable.
+	Counter struct {
+		redis    *redis.Client
+		alphabet string
+	}
+
+	// Words generates readable IDs such as "BraveOtter42".
+	Words struct{}
+)
+
+// LoadConfig reads ID_STRATEGY (nanoid, counter or words; default nanoid),
+// ID_LENGTH (default 8) and ID_ALPHABET ("base62", "unambiguous" or the
+// characters to use; default base62).
+func LoadConfig() Config {
+	cfg := Config{Strategy: StrategyNanoID, Length: defaultLength, Alphabet: AlphabetBase62}
+
+	switch v := strings.ToLower(strings.TrimSpace(os.Getenv("ID_STRATEGY"))); v {
+	case "":
+	case StrategyNanoID, StrategyCounter, StrategyWords:
+		cfg.Strategy = v
+	default:
+		log.Printf("Invalid ID_STRATEGY %q, using %s", v, StrategyNanoID)
+	}
+	if v := strings.TrimSpace(os.Getenv("ID_LENGTH")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= minLength && n <= maxLength {
+			cfg.Length = n
+		} else {
+			log.Printf("Invalid ID_LENGTH %q, using %d", v, defaultLength)
+		}
+	}
+	switch v := strings.TrimSpace(os.Getenv("ID_ALPHABET")); strings.ToLower(v) {
+	case "", "base62":
+	case "unambiguous":
+		cfg.Alphabet = AlphabetUnambiguous
+	default:
+		if validAlphabet(v) {
+			cfg.Alphabet = v
+		} else {
+			log.Printf("Invalid ID_ALPHABET %q, using base62", v)
+		}
+	}
+	return cfg
+}
+
+// New returns the generator for config.Strategy.
+func New(config Config, rdb *redis.Client) (IDGenerator, error) {
+	if config.Alphabet == "" {
+		config.Alphabet = AlphabetBase62
+	}
+	if !validAlphabet(config.Alphabet) {
+		return nil, fmt.Errorf("invalid short ID alphabet %q", config.Alphabet)
+	}
+
+	switch config.Strategy {
+	case "", StrategyNanoID:
+		return NewNanoID(config.Alphabet, config.Length), nil
+	case StrategyCounter:
+		if rdb == nil {
+			return nil, fmt.Errorf("the counter ID strategy needs redis")
+		}
+		return NewCounter(rdb, config.Alphabet), nil
+	case StrategyWords:
+		return Words{}, nil
+	default:
+		return nil, fmt.Errorf("unknown short ID strategy %q", config.Strategy)
+	}
+}
+
+func NewNanoID(alphabet string, length int) *NanoID {
+	if length < minLength || length > maxLength {
+		length = defaultLength
+	}
+	return &NanoID{Alphabet: alphabet, Length: length}
+}
+
+func (g *NanoID) Generate(ctx context.Context) (string, error) {
+	return nanoid.Generate(g.Alphabet, g.Length)
+}
+
+func NewCounter(rdb *redis.Client, alphabet string) *Counter {
+	return &Counter{redis: rdb, alphabet: alphabet}
+}
+
+func (g *Counter) Generate(ctx context.Context) (string, error) {
+	n, err := g.redis.Incr(ctx, CounterKey).Result()
+	if err != nil {
+		return "", fmt.Errorf("failed to increment short ID counter: %w", err)
+	}
+	return Encode(uint64(n), g.alphabet), nil
+}
+
+// Encode writes n in the base of the alphabet, padded with its first
+// character to the minimum short ID length.
+func Encode(n uint64, alphabet string) string {
+	base := uint64(len(alphabet))
+	var buf []byte
+	for n > 0 {
+		buf = append(buf, alphabet[n%base])
+		n /= base
+	}
+	for len(buf) < minLength {
+		buf = append(buf, alphabet[0])
+	}
+	for i, j := 0, len(buf)-1; i < j; i, j = i+1, j-1 {
+		buf[i], buf[j] = buf[j], buf[i]
+	}
+	return string(buf)
+}
+
+func (Words) Generate(ctx context.Context) (string, error) {
+	adjective, err := randomIndex(len(adjectives))
+	if err != nil {
+		return "", err
+	}
+	noun, err := randomIndex(len(nouns))
+	if err != nil {
+		return "", err
+	}
+	number, err := randomIndex(100)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%s%02d", adjectives[adjective], nouns[noun], number), nil
+}
+
+func randomIndex(n int) (int, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(i.Int64()), nil
+}
+
+// validAlphabet accepts at least two distinct alphanumeric characters, as
+// short IDs may not contain anything else.
+func validAlphabet(alphabet string) bool {
+	if !alphanumeric.MatchString(alphabet) {
+		return false
+	}
+	seen := make(map[rune]bool)
+	for _, r := range alphabet {
+		if seen[r] {
+			return false
+		}
+		seen[r] = true
+	}
+	return len(seen) >= 2
+}
@@ -0,0 +1,96 @@
+package idgen_service_test
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	redismock "github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/services/idgen_service"
+)
+
+// same rule as the short_id validator
+var shortIDFormat = regexp.MustCompile(`^[a-zA-Z0-9]{3,30}$`)
+
+func TestNanoID(t *testing.T) {
+	g := idgen_service.NewNanoID(idgen_service.AlphabetUnambiguous, 10)
+
+	for i := 0; i < 100; i++ {
+		id, err := g.Generate(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, id, 10)
+		assert.False(t, strings.ContainsAny(id, "01lIoOi"), id)
+	}
+}
+
+func TestCounter(t *testing.T) {
+	rdb, rmock := redismock.NewClientMock()
+	rmock.ExpectIncr(idgen_service.CounterKey).SetVal(1)
+	rmock.ExpectIncr(idgen_service.CounterKey).SetVal(62 * 62 * 62)
+	g := idgen_service.NewCounter(rdb, idgen_service.AlphabetBase62)
+
+	first, err := g.Generate(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "001", first)
+
+	later, err := g.Generate(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "1000", later)
+	require.NoError(t, rmock.ExpectationsWereMet())
+}
+
+func TestEncode(t *testing.T) {
+	assert.Equal(t, "00z", idgen_service.Encode(35, idgen_service.AlphabetBase62))
+	assert.Equal(t, "010", idgen_service.Encode(62, idgen_service.AlphabetBase62))
+	assert.Equal(t, "222", idgen_service.Encode(0, idgen_service.AlphabetUnambiguous))
+}
+
+func TestWords(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		id, err := idgen_service.Words{}.Generate(context.Background())
+		require.NoError(t, err)
+		assert.Regexp(t, shortIDFormat, id)
+		assert.Regexp(t, `^[A-Z][a-z]+[A-Z][a-z]+[0-9]{2}$`, id)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		cfg := idgen_service.LoadConfig()
+		assert.Equal(t, idgen_service.Config{Strategy: idgen_service.StrategyNanoID, Length: 8, Alphabet: idgen_service.AlphabetBase62}, cfg)
+	})
+
+	t.Run("Custom values", func(t *testing.T) {
+		t.Setenv("ID_STRATEGY", "Words")
+		t.Setenv("ID_LENGTH", "12")
+		t.Setenv("ID_ALPHABET", "unambiguous")
+		cfg := idgen_service.LoadConfig()
+		assert.Equal(t, idgen_service.Config{Strategy: idgen_service.StrategyWords, Length: 12, Alphabet: idgen_service.AlphabetUnambiguous}, cfg)
+	})
+
+	t.Run("Invalid values fall back to the defaults", func(t *testing.T) {
+		t.Setenv("ID_STRATEGY", "uuid")
+		t.Setenv("ID_LENGTH", "64")
+		t.Setenv("ID_ALPHABET", "ab-c")
+		cfg := idgen_service.LoadConfig()
+		assert.Equal(t, idgen_service.Config{Strategy: idgen_service.StrategyNanoID, Length: 8, Alphabet: idgen_service.AlphabetBase62}, cfg)
+	})
+}
+
+func TestNew(t *testing.T) {
+	_, err := idgen_service.New(idgen_service.Config{Strategy: idgen_service.StrategyCounter}, nil)
+	assert.Error(t, err)
+
+	_, err = idgen_service.New(idgen_service.Config{Alphabet: "aa"}, nil)
+	assert.Error(t, err)
+
+	g, err := idgen_service.New(idgen_service.Config{Alphabet: "abc", Length: 5}, nil)
+	require.NoError(t, err)
+	id, err := g.Generate(context.Background())
+	require.NoError(t, err)
+	assert.Regexp(t, `^[abc]{5}$`, id)
+}
@@ -0,0 +1,25 @@
+package idgen_service
+
+// Word lists for human-readable IDs. Words are short, easy to spell and
+// avoid anything that could read as offensive in combination.
+var adjectives = []string{
+	"Able", "Amber", "Bold", "Brave", "Bright", "Brisk", "Calm", "Clever",
+	"Cosy", "Crisp", "Curly", "Daring", "Eager", "Early", "Fancy", "Fast",
+	"Fluffy", "Fresh", "Gentle", "Giant", "Glad", "Golden", "Grand", "Green",
+	"Happy", "Hidden", "Honest", "Humble", "Jolly", "Kind", "Lively", "Lucky",
+	"Mellow", "Merry", "Mighty", "Misty", "Modern", "Noble", "Polite", "Proud",
+	"Quick", "Quiet", "Rapid", "Rare", "Ready", "Rosy", "Royal", "Shiny",
+	"Silent", "Silver", "Smart", "Snowy", "Solid", "Sunny", "Super", "Swift",
+	"Tidy", "Tiny", "Urban", "Vivid", "Warm", "Wise", "Witty", "Young",
+}
+
+var nouns = []string{
+	"Apple", "Badger", "Beacon", "Bear", "Bird", "Breeze", "Brook", "Cactus",
+	"Canyon", "Cedar", "Cloud", "Comet", "Coral", "Crane", "Delta", "Dolphin",
+	"Eagle", "Falcon", "Fern", "Field", "Forest", "Fox", "Garden", "Harbor",
+	"Hawk", "Island", "Jaguar", "Koala", "Lake", "Lantern", "Leaf", "Lemon",
+	"Lion", "Maple", "Meadow", "Moon", "Mountain", "Ocean", "Orchid", "Otter",
+	"Owl", "Panda", "Pebble", "Pine", "Planet", "Pond", "Rabbit", "River",
+	"Robin", "Rocket", "Sail", "Spark", "Star", "Stone", "Summit", "Tiger",
+	"Tulip", "Valley", "Wave", "Willow", "Wind", "Wolf", "Zebra", "Harvest",
+}
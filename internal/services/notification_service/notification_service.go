@@ -0,0 +1,71 @@
+package notification_service
+
+import (
+	"context"
+	"time"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	firestoreService "github.com/mfmahendr/url-shortener-backend/internal/services/firestore"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/validators"
+)
+
+type (
+	// Notifier is used by background jobs to tell users about changes they
+	// did not make themselves.
+	Notifier interface {
+		Notify(ctx context.Context, notifications []models.Notification) error
+	}
+
+	NotificationService interface {
+		Notifier
+		ListNotifications(ctx context.Context, uid string, q dto.PaginationQuery) (*dto.NotificationsResponse, error)
+	}
+
+	NotificationServiceImpl struct {
+		firestore firestoreService.Notifications
+	}
+)
+
+func New(fs firestoreService.Notifications) NotificationService {
+	return &NotificationServiceImpl{firestore: fs}
+}
+
+// Notify stores the notifications, skipping those without a recipient.
+func (s *NotificationServiceImpl) Notify(ctx context.Context, notifications []models.Notification) error {
+	now := time.Now()
+	valid := make([]models.Notification, 0, len(notifications))
+	for _, n := range notifications {
+		if n.UID == "" {
+			continue
+		}
+		if n.CreatedAt.IsZero() {
+			n.CreatedAt = now
+		}
+		valid = append(valid, n)
+	}
+	return s.firestore.AddNotifications(ctx, valid)
+}
+
+func (s *NotificationServiceImpl) ListNotifications(ctx context.Context, uid string, q dto.PaginationQuery) (*dto.NotificationsResponse, error) {
+	if uid == "" {
+		return nil, shortlink_errors.ErrForbidden
+	}
+	if err := validators.Validate.Struct(q); err != nil {
+		return nil, shortlink_errors.ErrValidateRequest
+	}
+
+	notifications, nextCursor, err := s.firestore.ListNotifications(ctx, uid, q)
+	if err != nil {
+		return nil, err
+	}
+	if notifications == nil {
+		notifications = []models.Notification{}
+	}
+
+	return &dto.NotificationsResponse{
+		Notifications: notifications,
+		NextCursor:    nextCursor,
+	}, nil
+}
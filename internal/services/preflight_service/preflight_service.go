@@ -0,0 +1,255 @@
+package preflight_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/urlcanon"
+)
+
+const (
+	defaultMaxHops = 5
+	defaultTimeout = 5 * time.Second
+	userAgent      = "url-shortener-preflight/1.0"
+)
+
+// carrier-grade NAT range, not covered by netip.Addr.IsPrivate
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+type (
+	// HopCheck vets a URL before the preflight requests it, e.g. against
+	// the blacklist and Safe Browsing.
+	HopCheck func(ctx context.Context, hopURL string) error
+
+	Preflight interface {
+		// Follow checks targetURL and, when enabled, every URL of its
+		// redirect chain. It returns the URLs it checked, in order.
+		Follow(ctx context.Context, targetURL string, check HopCheck) ([]string, error)
+		// FetchTitle returns the title of the page at targetURL.
+		FetchTitle(ctx context.Context, targetURL string) (string, error)
+	}
+
+	Config struct {
+		// Enabled makes Follow request the destination and follow its
+		// redirects. Otherwise only targetURL itself is checked.
+		Enabled bool
+		MaxHops int
+		// Timeout bounds the whole redirect chain.
+		Timeout time.Duration
+		// ShortDomains are the hosts this service answers on; URLs pointing
+		// at them are refused.
+		ShortDomains []string
+		// AllowPrivateNetworks lets the preflight connect to loopback and
+		// private addresses. Only meant for tests and local development.
+		AllowPrivateNetworks bool
+	}
+
+	PreflightImpl struct {
+		client       *http.Client
+		config       Config
+		shortDomains map[string]bool
+	}
+)
+
+// LoadConfig reads PREFLIGHT_ENABLED (default false), PREFLIGHT_MAX_HOPS
+// (default 5), PREFLIGHT_TIMEOUT (default 5s) and SHORT_DOMAINS (comma
+// separated hosts of this shortener).
+func LoadConfig() Config {
+	cfg := Config{MaxHops: defaultMaxHops, Timeout: defaultTimeout}
+
+	if v := strings.TrimSpace(os.Getenv("PREFLIGHT_ENABLED")); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.Enabled = b
+		} else {
+			log.Printf("Invalid PREFLIGHT_ENABLED %q, preflight stays disabled", v)
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("PREFLIGHT_MAX_HOPS")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.MaxHops = n
+		} else {
+			log.Printf("Invalid PREFLIGHT_MAX_HOPS %q, using %d", v, defaultMaxHops)
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("PREFLIGHT_TIMEOUT")); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.Timeout = d
+		} else {
+			log.Printf("Invalid PREFLIGHT_TIMEOUT %q, using %s", v, defaultTimeout)
+		}
+	}
+	for _, d := range strings.Split(os.Getenv("SHORT_DOMAINS"), ",") {
+		if d = strings.TrimSpace(d); d != "" {
+			cfg.ShortDomains = append(cfg.ShortDomains, d)
+		}
+	}
+	return cfg
+}
+
+func New(config Config) *PreflightImpl {
+	if config.MaxHops <= 0 {
+		config.MaxHops = defaultMaxHops
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultTimeout
+	}
+
+	dialer := &net.Dialer{Timeout: config.Timeout}
+	if !config.AllowPrivateNetworks {
+		// checked after DNS resolution, so hostnames resolving to internal
+		// addresses are refused too
+		dialer.Control = refusePrivate
+	}
+	transport := &http.Transport{
+		// no proxy: it would connect on our behalf without the check above
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   config.Timeout,
+		ResponseHeaderTimeout: config.Timeout,
+		DisableKeepAlives:     true,
+	}
+
+	shortDomains := make(map[string]bool, len(config.ShortDomains))
+	for _, d := range config.ShortDomains {
+		if host, err := urlcanon.Host(d); err == nil {
+			shortDomains[host] = true
+		}
+	}
+
+	return &PreflightImpl{
+		client: &http.Client{
+			Transport: transport,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		config:       config,
+		shortDomains: shortDomains,
+	}
+}
+
+// Follow refuses URLs that are not http(s) or point at one of the short
+// domains and runs check on them. With the preflight enabled it then
+// requests each URL and repeats this for the redirect target, up to MaxHops
+// redirects. A destination that cannot be reached ends the chain without an
+// error; only refused addresses, loops and too long chains fail.
+func (p *PreflightImpl) Follow(ctx context.Context, targetURL string, check HopCheck) ([]string, error) {
+	current, err := url.Parse(targetURL)
+	if err != nil || current.Host == "" {
+		return nil, shortlink_errors.ErrValidateRequest
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+
+	var chain []string
+	seen := make(map[string]bool)
+	for hop := 0; ; hop++ {
+		if err := p.checkHop(ctx, current, check); err != nil {
+			return chain, err
+		}
+		chain = append(chain, current.String())
+		if !p.config.Enabled {
+			return chain, nil
+		}
+
+		key, err := urlcanon.Canonicalize(current.String(), urlcanon.Options{})
+		if err != nil {
+			return chain, shortlink_errors.ErrValidateRequest
+		}
+		if seen[key] {
+			return chain, shortlink_errors.ErrRedirectLoop
+		}
+		seen[key] = true
+
+		next, err := p.request(ctx, current)
+		if err != nil {
+			if errors.Is(err, shortlink_errors.ErrPrivateAddress) {
+				return chain, shortlink_errors.ErrPrivateAddress
+			}
+			log.Printf("Preflight could not reach %s, accepting it: %v", current.Redacted(), err)
+			return chain, nil
+		}
+		if next == nil {
+			return chain, nil
+		}
+		if hop+1 > p.config.MaxHops {
+			return chain, shortlink_errors.ErrTooManyRedirects
+		}
+		current = next
+	}
+}
+
+func (p *PreflightImpl) checkHop(ctx context.Context, u *url.URL, check HopCheck) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return shortlink_errors.ErrUnsupportedScheme
+	}
+	if host, err := urlcanon.Host(u.Hostname()); err == nil && p.shortDomains[host] {
+		return shortlink_errors.ErrSelfReference
+	}
+	if check == nil {
+		return nil
+	}
+	return check(ctx, u.String())
+}
+
+// request fetches u and returns where it redirects to, or nil if it does
+// not redirect.
+func (p *PreflightImpl) request(ctx context.Context, u *url.URL) (*url.URL, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return nil, nil
+	}
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return nil, nil
+	}
+	next, err := u.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redirect location %q: %w", location, err)
+	}
+	return next, nil
+}
+
+func refusePrivate(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	ip = ip.Unmap()
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsUnspecified() || ip.IsMulticast() || sharedAddressSpace.Contains(ip) {
+		return shortlink_errors.ErrPrivateAddress
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package preflight_service_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/services/preflight_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+)
+
+// redirectServer answers /final with 200 and redirects every path listed in
+// hops to its target (relative paths stay on the server).
+func redirectServer(t *testing.T, hops map[string]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if target, ok := hops[r.URL.Path]; ok {
+			http.Redirect(w, r, target, http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func testPreflight(config preflight_service.Config) *preflight_service.PreflightImpl {
+	config.Enabled = true
+	config.AllowPrivateNetworks = true // httptest listens on loopback
+	return preflight_service.New(config)
+}
+
+func TestFollow(t *testing.T) {
+	t.Run("Follows the chain and checks every hop", func(t *testing.T) {
+		srv := redirectServer(t, map[string]string{"/a": "/b", "/b": "/final"})
+		var checked []string
+		check := func(_ context.Context, hop string) error {
+			checked = append(checked, hop)
+			return nil
+		}
+
+		chain, err := testPreflight(preflight_service.Config{}).Follow(context.Background(), srv.URL+"/a", check)
+
+		require.NoError(t, err)
+		want := []string{srv.URL + "/a", srv.URL + "/b", srv.URL + "/final"}
+		assert.Equal(t, want, chain)
+		assert.Equal(t, want, checked)
+	})
+
+	t.Run("A hop failing the check rejects the URL", func(t *testing.T) {
+		srv := redirectServer(t, map[string]string{"/a": "/evil"})
+		check := func(_ context.Context, hop string) error {
+			if strings.HasSuffix(hop, "/evil") {
+				return shortlink_errors.ErrForbiddenInput
+			}
+			return nil
+		}
+
+		_, err := testPreflight(preflight_service.Config{}).Follow(context.Background(), srv.URL+"/a", check)
+
+		assert.ErrorIs(t, err, shortlink_errors.ErrForbiddenInput)
+	})
+
+	t.Run("Redirect loops are rejected", func(t *testing.T) {
+		srv := redirectServer(t, map[string]string{"/a": "/b", "/b": "/a"})
+
+		_, err := testPreflight(preflight_service.Config{}).Follow(context.Background(), srv.URL+"/a", nil)
+
+		assert.ErrorIs(t, err, shortlink_errors.ErrRedirectLoop)
+	})
+
+	t.Run("Too many redirects are rejected", func(t *testing.T) {
+		srv := redirectServer(t, map[string]string{"/1": "/2", "/2": "/3", "/3": "/final"})
+
+		_, err := testPreflight(preflight_service.Config{MaxHops: 1}).Follow(context.Background(), srv.URL+"/1", nil)
+
+		assert.ErrorIs(t, err, shortlink_errors.ErrTooManyRedirects)
+	})
+
+	t.Run("Redirects back to the short domain are rejected", func(t *testing.T) {
+		srv := redirectServer(t, map[string]string{"/a": "https://SHO.rt/abc123"})
+
+		_, err := testPreflight(preflight_service.Config{ShortDomains: []string{"sho.rt"}}).Follow(context.Background(), srv.URL+"/a", nil)
+
+		assert.ErrorIs(t, err, shortlink_errors.ErrSelfReference)
+	})
+
+	t.Run("Redirects to other schemes are rejected", func(t *testing.T) {
+		srv := redirectServer(t, map[string]string{"/a": "javascript:alert(1)"})
+
+		_, err := testPreflight(preflight_service.Config{}).Follow(context.Background(), srv.URL+"/a", nil)
+
+		assert.ErrorIs(t, err, shortlink_errors.ErrUnsupportedScheme)
+	})
+
+	t.Run("Private addresses are refused by default", func(t *testing.T) {
+		srv := redirectServer(t, nil)
+		pf := preflight_service.New(preflight_service.Config{Enabled: true})
+
+		_, err := pf.Follow(context.Background(), srv.URL+"/final", nil)
+
+		assert.ErrorIs(t, err, shortlink_errors.ErrPrivateAddress)
+	})
+
+	t.Run("Unreachable destinations are accepted", func(t *testing.T) {
+		srv := redirectServer(t, nil)
+		target := srv.URL + "/final"
+		srv.Close()
+
+		chain, err := testPreflight(preflight_service.Config{}).Follow(context.Background(), target, nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{target}, chain)
+	})
+
+	t.Run("Disabled preflight only checks the URL itself", func(t *testing.T) {
+		pf := preflight_service.New(preflight_service.Config{ShortDomains: []string{"sho.rt"}})
+		checkErr := errors.New("checked")
+
+		_, err := pf.Follow(context.Background(), "https://example.com/", func(context.Context, string) error { return checkErr })
+		assert.ErrorIs(t, err, checkErr)
+
+		_, err = pf.Follow(context.Background(), "https://sho.rt/abc", nil)
+		assert.ErrorIs(t, err, shortlink_errors.ErrSelfReference)
+
+		chain, err := pf.Follow(context.Background(), "http://127.0.0.1:1/", nil)
+		require.NoError(t, err)
+		assert.Len(t, chain, 1)
+	})
+}
+
+func TestFetchTitle(t *testing.T) {
+	pages := map[string]string{
+		"/plain": `<html><head><title>
+			Spring   sale</title></head><body><title>Not this</title></body></html>`,
+		"/og":      `<html><head><title>Shop</title><meta property="og:title" content="Spring sale &amp; more"></head></html>`,
+		"/no-head": `<p>Just text</p>`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/moved":
+			http.Redirect(w, r, "/og", http.StatusMovedPermanently)
+		case "/json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"title": "no"}`))
+		default:
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte(pages[r.URL.Path]))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	pf := testPreflight(preflight_service.Config{})
+
+	for path, want := range map[string]string{
+		"/plain":   "Spring sale",
+		"/og":      "Spring sale & more",
+		"/moved":   "Spring sale & more",
+		"/no-head": "",
+		"/json":    "",
+	} {
+		title, err := pf.FetchTitle(context.Background(), srv.URL+path)
+		require.NoError(t, err, path)
+		assert.Equal(t, want, title, path)
+	}
+
+	t.Run("Private addresses are refused by default", func(t *testing.T) {
+		_, err := preflight_service.New(preflight_service.Config{}).FetchTitle(context.Background(), srv.URL+"/plain")
+
+		assert.ErrorIs(t, err, shortlink_errors.ErrPrivateAddress)
+	})
+}
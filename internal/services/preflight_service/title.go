@@ -0,0 +1,139 @@
+package preflight_service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+)
+
+const (
+	// maxTitleBytes is how much of a page is read looking for its title.
+	maxTitleBytes = 512 << 10
+
+	// MaxTitleLength matches the longest title a link can have.
+	MaxTitleLength = 200
+)
+
+// FetchTitle requests targetURL, following up to MaxHops redirects, and
+// returns the page's OpenGraph title, or its <title> if it has none. It
+// connects under the same restrictions as Follow, whether or not the
+// preflight is enabled. Pages that are not HTML have no title.
+func (p *PreflightImpl) FetchTitle(ctx context.Context, targetURL string) (string, error) {
+	current, err := url.Parse(targetURL)
+	if err != nil || current.Host == "" {
+		return "", shortlink_errors.ErrValidateRequest
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+
+	for hop := 0; ; hop++ {
+		if err := p.checkHop(ctx, current, nil); err != nil {
+			return "", err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, current.String(), nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("User-Agent", userAgent)
+		req.Header.Set("Accept", "text/html")
+
+		resp, err := p.client.Do(req)
+		if err != nil {
+			return "", err
+		}
+
+		switch resp.StatusCode {
+		case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+			http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+			resp.Body.Close()
+			if hop+1 > p.config.MaxHops {
+				return "", shortlink_errors.ErrTooManyRedirects
+			}
+			next, err := current.Parse(resp.Header.Get("Location"))
+			if err != nil {
+				return "", fmt.Errorf("invalid redirect location: %w", err)
+			}
+			current = next
+			continue
+		}
+
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+		if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+			return "", nil
+		}
+		return pageTitle(io.LimitReader(resp.Body, maxTitleBytes)), nil
+	}
+}
+
+// pageTitle reads the head of an HTML document and returns its og:title
+// meta tag or, failing that, its <title>, with whitespace collapsed.
+func pageTitle(r io.Reader) string {
+	var title, ogTitle string
+	inTitle := false
+
+	z := html.NewTokenizer(r)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return cleanTitle(title, ogTitle)
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			switch tok.DataAtom {
+			case atom.Title:
+				inTitle = title == ""
+			case atom.Meta:
+				var property, content string
+				for _, a := range tok.Attr {
+					switch strings.ToLower(a.Key) {
+					case "property", "name":
+						property = strings.ToLower(a.Val)
+					case "content":
+						content = a.Val
+					}
+				}
+				if property == "og:title" && ogTitle == "" {
+					ogTitle = content
+				}
+			case atom.Body:
+				return cleanTitle(title, ogTitle)
+			}
+		case html.TextToken:
+			if inTitle {
+				title += string(z.Text())
+			}
+		case html.EndTagToken:
+			switch z.Token().DataAtom {
+			case atom.Title:
+				inTitle = false
+			case atom.Head:
+				return cleanTitle(title, ogTitle)
+			}
+		}
+	}
+}
+
+func cleanTitle(title, ogTitle string) string {
+	if strings.TrimSpace(ogTitle) != "" {
+		title = ogTitle
+	}
+	title = strings.Join(strings.Fields(strings.ToValidUTF8(title, "")), " ")
+	if utf8.RuneCountInString(title) > MaxTitleLength {
+		title = string([]rune(title)[:MaxTitleLength])
+	}
+	return title
+}
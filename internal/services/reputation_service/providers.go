@@ -0,0 +1,169 @@
+package reputation_service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/blacklist_service"
+	safebrowsing_service "github.com/mfmahendr/url-shortener-backend/internal/services/safebrowsing"
+)
+
+const (
+	// ThreatTypeLocalRule is reported for URLs matched by a rule file.
+	ThreatTypeLocalRule = "LOCAL_RULE"
+
+	ruleFileCheckInterval = time.Minute
+)
+
+type safeBrowsingProvider struct {
+	finder safebrowsing_service.ThreatFinder
+}
+
+// NewSafeBrowsingProvider checks URLs with Google Safe Browsing.
+func NewSafeBrowsingProvider(finder safebrowsing_service.ThreatFinder) Provider {
+	return &safeBrowsingProvider{finder: finder}
+}
+
+func (p *safeBrowsingProvider) Name() string {
+	return ProviderSafeBrowsing
+}
+
+func (p *safeBrowsingProvider) Check(ctx context.Context, targetURL string) (Verdict, error) {
+	threats, err := p.finder.FindThreats(ctx, []string{targetURL})
+	if err != nil {
+		return Verdict{}, err
+	}
+	threat, unsafe := threats[targetURL]
+	return Verdict{Unsafe: unsafe, ThreatType: threat, TTL: safebrowsing_service.CacheTTL}, nil
+}
+
+// CheckBatch looks all URLs up with one FindThreats call.
+func (p *safeBrowsingProvider) CheckBatch(ctx context.Context, targetURLs []string) ([]Verdict, error) {
+	threats, err := p.finder.FindThreats(ctx, targetURLs)
+	if err != nil {
+		return nil, err
+	}
+	verdicts := make([]Verdict, len(targetURLs))
+	for i, u := range targetURLs {
+		threat, unsafe := threats[u]
+		verdicts[i] = Verdict{Unsafe: unsafe, ThreatType: threat, TTL: safebrowsing_service.CacheTTL}
+	}
+	return verdicts, nil
+}
+
+// RuleFileProvider matches URLs against blacklist rules kept in a local
+// file, in any of the blacklist import formats (chosen by extension: .csv,
+// .hosts or a plain list). The file is re-read when it changes.
+type RuleFileProvider struct {
+	path string
+	name string
+
+	mu        sync.Mutex
+	matcher   *blacklist_service.RuleMatcher
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func NewRuleFileProvider(path string) (*RuleFileProvider, error) {
+	if path == "" {
+		return nil, fmt.Errorf("rules provider needs a file path")
+	}
+	p := &RuleFileProvider{path: path, name: ProviderRules + ":" + filepath.Base(path)}
+	if err := p.reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *RuleFileProvider) Name() string {
+	return p.name
+}
+
+func (p *RuleFileProvider) Check(ctx context.Context, targetURL string) (Verdict, error) {
+	_, ok, err := p.current().Match(targetURL)
+	if err != nil {
+		return Verdict{}, err
+	}
+
+	verdict := Verdict{TTL: ruleFileCheckInterval}
+	if ok {
+		verdict.Unsafe = true
+		verdict.ThreatType = ThreatTypeLocalRule
+	}
+	return verdict, nil
+}
+
+// current returns the rules, reloading them if the file changed since the
+// last check. If reloading fails the previous rules stay in use.
+func (p *RuleFileProvider) current() *blacklist_service.RuleMatcher {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if time.Since(p.checkedAt) < ruleFileCheckInterval {
+		return p.matcher
+	}
+	p.checkedAt = time.Now()
+
+	info, err := os.Stat(p.path)
+	if err != nil || info.ModTime().Equal(p.modTime) {
+		return p.matcher
+	}
+	if err := p.reloadLocked(); err != nil {
+		log.Printf("Failed to reload rule file, using previous rules: %v", err)
+	}
+	return p.matcher
+}
+
+func (p *RuleFileProvider) reload() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.checkedAt = time.Now()
+	return p.reloadLocked()
+}
+
+func (p *RuleFileProvider) reloadLocked() error {
+	f, err := os.Open(p.path)
+	if err != nil {
+		return fmt.Errorf("failed to open rule file: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to read rule file: %w", err)
+	}
+
+	entries, err := blacklist_service.Parse(f, ruleFileFormat(p.path))
+	if err != nil {
+		return fmt.Errorf("failed to parse rule file %s: %w", p.path, err)
+	}
+	items := make([]models.BlacklistItem, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Err == nil {
+			items = append(items, models.BlacklistItem{Type: entry.Type, Value: entry.Value})
+		}
+	}
+
+	p.matcher = blacklist_service.NewRuleMatcher(items)
+	p.modTime = info.ModTime()
+	return nil
+}
+
+func ruleFileFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return blacklist_service.FormatCSV
+	case ".hosts":
+		return blacklist_service.FormatHosts
+	default:
+		if filepath.Base(path) == "hosts" {
+			return blacklist_service.FormatHosts
+		}
+		return blacklist_service.FormatList
+	}
+}
@@ -0,0 +1,397 @@
+package reputation_service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	safebrowsing_service "github.com/mfmahendr/url-shortener-backend/internal/services/safebrowsing"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/validators"
+)
+
+const (
+	VoteAny    = "any"
+	VoteAll    = "all"
+	VoteQuorum = "quorum"
+
+	FailOpen   = "open"
+	FailClosed = "closed"
+
+	ProviderSafeBrowsing = "safebrowsing"
+	ProviderRules        = "rules"
+
+	defaultTimeout = 5 * time.Second
+)
+
+type (
+	// Verdict is what a provider, or the composite of all providers, says
+	// about a URL. Provider and ThreatType name the provider that reported
+	// the URL as unsafe; TTL is how long the verdict may be reused.
+	Verdict struct {
+		Unsafe     bool
+		Provider   string
+		ThreatType string
+		TTL        time.Duration
+	}
+
+	// URLChecker decides whether a URL may be shortened.
+	URLChecker interface {
+		Check(ctx context.Context, targetURL string) (Verdict, error)
+	}
+
+	// BatchChecker checks many URLs at once. The verdicts and errors are in
+	// the order of targetURLs.
+	BatchChecker interface {
+		CheckAll(ctx context.Context, targetURLs []string) ([]Verdict, []error)
+	}
+
+	// Provider is one source of reputation data.
+	Provider interface {
+		URLChecker
+		Name() string
+	}
+
+	// BatchProvider is a provider that can look up many URLs in one request.
+	BatchProvider interface {
+		Provider
+		CheckBatch(ctx context.Context, targetURLs []string) ([]Verdict, error)
+	}
+
+	Config struct {
+		// Providers lists "safebrowsing" and "rules:<path>" entries.
+		Providers []string
+		// Voting is VoteAny, VoteAll or VoteQuorum.
+		Voting string
+		// Quorum is the number of unsafe votes VoteQuorum needs; 0 means a
+		// majority of the providers.
+		Quorum int
+		// FailurePolicy decides what a failing provider means: FailOpen
+		// ignores it, FailClosed fails the check unless the URL is already
+		// unsafe without it.
+		FailurePolicy string
+		// Timeout applies to each provider unless ProviderTimeouts has an
+		// entry for its name or kind ("rules").
+		Timeout          time.Duration
+		ProviderTimeouts map[string]time.Duration
+	}
+
+	// Composite asks all providers in parallel and combines their verdicts.
+	Composite struct {
+		providers []Provider
+		config    Config
+	}
+)
+
+// LoadConfig reads REPUTATION_PROVIDERS (default "safebrowsing"),
+// REPUTATION_VOTING (default any), REPUTATION_QUORUM,
+// REPUTATION_FAILURE_POLICY (default closed), REPUTATION_TIMEOUT (default
+// 5s) and REPUTATION_PROVIDER_TIMEOUTS ("name=duration", comma separated).
+func LoadConfig() Config {
+	cfg := Config{
+		Providers:        []string{ProviderSafeBrowsing},
+		Voting:           VoteAny,
+		FailurePolicy:    FailClosed,
+		Timeout:          defaultTimeout,
+		ProviderTimeouts: make(map[string]time.Duration),
+	}
+
+	if v := strings.TrimSpace(os.Getenv("REPUTATION_PROVIDERS")); v != "" {
+		cfg.Providers = nil
+		for _, p := range strings.Split(v, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				cfg.Providers = append(cfg.Providers, p)
+			}
+		}
+	}
+	switch v := strings.ToLower(strings.TrimSpace(os.Getenv("REPUTATION_VOTING"))); v {
+	case "":
+	case VoteAny, VoteAll, VoteQuorum:
+		cfg.Voting = v
+	default:
+		log.Printf("Invalid REPUTATION_VOTING %q, using %s", v, VoteAny)
+	}
+	if v := strings.TrimSpace(os.Getenv("REPUTATION_QUORUM")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.Quorum = n
+		} else {
+			log.Printf("Invalid REPUTATION_QUORUM %q, using a majority", v)
+		}
+	}
+	switch v := strings.ToLower(strings.TrimSpace(os.Getenv("REPUTATION_FAILURE_POLICY"))); v {
+	case "":
+	case FailOpen, FailClosed:
+		cfg.FailurePolicy = v
+	default:
+		log.Printf("Invalid REPUTATION_FAILURE_POLICY %q, using %s", v, FailClosed)
+	}
+	if v := strings.TrimSpace(os.Getenv("REPUTATION_TIMEOUT")); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.Timeout = d
+		} else {
+			log.Printf("Invalid REPUTATION_TIMEOUT %q, using %s", v, defaultTimeout)
+		}
+	}
+	for _, pair := range strings.Split(os.Getenv("REPUTATION_PROVIDER_TIMEOUTS"), ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+		if d, err := time.ParseDuration(strings.TrimSpace(value)); err == nil && d > 0 {
+			cfg.ProviderTimeouts[strings.TrimSpace(name)] = d
+		} else {
+			log.Printf("Invalid timeout %q for reputation provider %s", value, name)
+		}
+	}
+	return cfg
+}
+
+// New builds the providers named in config. Rule files are read right
+// away, so a missing file is reported at startup.
+func New(config Config, safeBrowsing safebrowsing_service.ThreatFinder) (*Composite, error) {
+	var providers []Provider
+	for _, p := range config.Providers {
+		kind, arg, _ := strings.Cut(p, ":")
+		switch kind {
+		case ProviderSafeBrowsing:
+			providers = append(providers, NewSafeBrowsingProvider(safeBrowsing))
+		case ProviderRules:
+			rules, err := NewRuleFileProvider(arg)
+			if err != nil {
+				return nil, err
+			}
+			providers = append(providers, rules)
+		default:
+			return nil, fmt.Errorf("unknown reputation provider %q", p)
+		}
+	}
+	return NewComposite(config, providers), nil
+}
+
+func NewComposite(config Config, providers []Provider) *Composite {
+	if config.Voting == "" {
+		config.Voting = VoteAny
+	}
+	if config.FailurePolicy == "" {
+		config.FailurePolicy = FailClosed
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultTimeout
+	}
+	return &Composite{providers: providers, config: config}
+}
+
+type providerResult struct {
+	verdict Verdict
+	err     error
+}
+
+// Check asks every provider about targetURL, each within its own timeout,
+// and combines the answers according to the voting rule. The verdict of an
+// unsafe URL names the first provider, in configuration order, that
+// reported it; its TTL is the shortest of all answers.
+func (c *Composite) Check(ctx context.Context, targetURL string) (Verdict, error) {
+	if err := validators.Validate.Var(targetURL, "required,url"); err != nil {
+		return Verdict{}, shortlink_errors.ErrValidateRequest
+	}
+
+	results := make([]providerResult, len(c.providers))
+	var wg sync.WaitGroup
+	for i, p := range c.providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.ask(ctx, p, targetURL)
+		}()
+	}
+	wg.Wait()
+
+	return c.combine(targetURL, results)
+}
+
+// CheckAll is Check for many URLs. Providers that can look URLs up in bulk,
+// like Safe Browsing, are asked once per batch of
+// safebrowsing.MaxURLsPerRequest URLs, each batch within the provider's
+// timeout. The verdicts and errors are in the order of targetURLs.
+func (c *Composite) CheckAll(ctx context.Context, targetURLs []string) ([]Verdict, []error) {
+	verdicts := make([]Verdict, len(targetURLs))
+	errs := make([]error, len(targetURLs))
+
+	var valid []string
+	var validIdx []int
+	for i, u := range targetURLs {
+		if err := validators.Validate.Var(u, "required,url"); err != nil {
+			errs[i] = shortlink_errors.ErrValidateRequest
+			continue
+		}
+		valid = append(valid, u)
+		validIdx = append(validIdx, i)
+	}
+
+	// results[p][j] is what provider p says about valid[j]
+	results := make([][]providerResult, len(c.providers))
+	var wg sync.WaitGroup
+	for i, p := range c.providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.askAll(ctx, p, valid)
+		}()
+	}
+	wg.Wait()
+
+	perURL := make([]providerResult, len(c.providers))
+	for j, u := range valid {
+		for i := range c.providers {
+			perURL[i] = results[i][j]
+		}
+		verdicts[validIdx[j]], errs[validIdx[j]] = c.combine(u, perURL)
+	}
+	return verdicts, errs
+}
+
+// combine applies the voting rule and the failure policy to the answers of
+// all providers, in configuration order.
+func (c *Composite) combine(targetURL string, results []providerResult) (Verdict, error) {
+	var (
+		combined  Verdict
+		answered  int
+		unsafe    int
+		firstFail error
+	)
+	for i, res := range results {
+		if res.err != nil {
+			log.Printf("Reputation provider %s failed for %s: %v", c.providers[i].Name(), targetURL, res.err)
+			if firstFail == nil {
+				firstFail = fmt.Errorf("%s: %w", c.providers[i].Name(), res.err)
+			}
+			continue
+		}
+
+		answered++
+		if res.verdict.TTL > 0 && (combined.TTL == 0 || res.verdict.TTL < combined.TTL) {
+			combined.TTL = res.verdict.TTL
+		}
+		if res.verdict.Unsafe {
+			unsafe++
+			if combined.Provider == "" {
+				combined.Provider = res.verdict.Provider
+				combined.ThreatType = res.verdict.ThreatType
+			}
+		}
+	}
+
+	combined.Unsafe = c.isUnsafe(unsafe, answered)
+	if !combined.Unsafe {
+		combined.Provider, combined.ThreatType = "", ""
+	}
+	if firstFail != nil && !combined.Unsafe && c.config.FailurePolicy == FailClosed {
+		return Verdict{}, firstFail
+	}
+	return combined, nil
+}
+
+// ask gives up on providers that do not return within their timeout, even
+// if they ignore the context.
+func (c *Composite) ask(ctx context.Context, p Provider, targetURL string) providerResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeoutFor(p.Name()))
+	defer cancel()
+
+	done := make(chan providerResult, 1)
+	go func() {
+		verdict, err := p.Check(ctx, targetURL)
+		verdict.Provider = p.Name()
+		done <- providerResult{verdict: verdict, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		return res
+	case <-ctx.Done():
+		return providerResult{err: ctx.Err()}
+	}
+}
+
+// askAll asks p about every URL: in batches if it is a BatchProvider, one
+// URL at a time otherwise.
+func (c *Composite) askAll(ctx context.Context, p Provider, targetURLs []string) []providerResult {
+	results := make([]providerResult, len(targetURLs))
+	batcher, ok := p.(BatchProvider)
+	if !ok {
+		for j, u := range targetURLs {
+			results[j] = c.ask(ctx, p, u)
+		}
+		return results
+	}
+
+	for start := 0; start < len(targetURLs); start += safebrowsing_service.MaxURLsPerRequest {
+		end := min(start+safebrowsing_service.MaxURLsPerRequest, len(targetURLs))
+		verdicts, err := c.askBatch(ctx, batcher, targetURLs[start:end])
+		for j := start; j < end; j++ {
+			if err != nil {
+				results[j] = providerResult{err: err}
+				continue
+			}
+			verdicts[j-start].Provider = p.Name()
+			results[j] = providerResult{verdict: verdicts[j-start]}
+		}
+	}
+	return results
+}
+
+// askBatch is ask for a batch of URLs.
+func (c *Composite) askBatch(ctx context.Context, p BatchProvider, targetURLs []string) ([]Verdict, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeoutFor(p.Name()))
+	defer cancel()
+
+	type batchResult struct {
+		verdicts []Verdict
+		err      error
+	}
+	done := make(chan batchResult, 1)
+	go func() {
+		verdicts, err := p.CheckBatch(ctx, targetURLs)
+		done <- batchResult{verdicts: verdicts, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		if res.err == nil && len(res.verdicts) != len(targetURLs) {
+			return nil, fmt.Errorf("%d verdicts for %d URLs", len(res.verdicts), len(targetURLs))
+		}
+		return res.verdicts, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *Composite) isUnsafe(unsafe, answered int) bool {
+	switch c.config.Voting {
+	case VoteAll:
+		return answered > 0 && unsafe == answered
+	case VoteQuorum:
+		quorum := c.config.Quorum
+		if quorum <= 0 {
+			quorum = len(c.providers)/2 + 1
+		}
+		return unsafe >= quorum
+	default:
+		return unsafe > 0
+	}
+}
+
+func (c *Composite) timeoutFor(name string) time.Duration {
+	if d, ok := c.config.ProviderTimeouts[name]; ok {
+		return d
+	}
+	kind, _, _ := strings.Cut(name, ":")
+	if d, ok := c.config.ProviderTimeouts[kind]; ok {
+		return d
+	}
+	return c.config.Timeout
+}
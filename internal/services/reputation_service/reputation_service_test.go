@@ -0,0 +1,246 @@
+package reputation_service_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/services/reputation_service"
+	safebrowsing_service "github.com/mfmahendr/url-shortener-backend/internal/services/safebrowsing"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/validators"
+)
+
+// *--- MOCK DEFINITIONS ---* //
+// reputation PROVIDER
+type MockProvider struct {
+	mock.Mock
+	name string
+}
+
+func (m *MockProvider) Name() string {
+	return m.name
+}
+
+func (m *MockProvider) Check(ctx context.Context, targetURL string) (reputation_service.Verdict, error) {
+	args := m.Called(ctx, targetURL)
+	return args.Get(0).(reputation_service.Verdict), args.Error(1)
+}
+
+func provider(name string, verdict reputation_service.Verdict, err error) *MockProvider {
+	p := &MockProvider{name: name}
+	p.On("Check", mock.Anything, mock.Anything).Return(verdict, err)
+	return p
+}
+
+var (
+	safe   = reputation_service.Verdict{TTL: time.Hour}
+	unsafe = reputation_service.Verdict{Unsafe: true, ThreatType: "MALWARE", TTL: 10 * time.Minute}
+)
+
+const target = "https://example.com/path"
+
+func TestMain(m *testing.M) {
+	validators.Init()
+	os.Exit(m.Run())
+}
+
+// *--- TEST CASES ---* //
+func TestComposite_Voting(t *testing.T) {
+	cases := []struct {
+		name     string
+		config   reputation_service.Config
+		verdicts []reputation_service.Verdict
+		unsafe   bool
+	}{
+		{"any with one unsafe vote", reputation_service.Config{Voting: reputation_service.VoteAny}, []reputation_service.Verdict{safe, unsafe, safe}, true},
+		{"any with only safe votes", reputation_service.Config{Voting: reputation_service.VoteAny}, []reputation_service.Verdict{safe, safe}, false},
+		{"all with a safe vote", reputation_service.Config{Voting: reputation_service.VoteAll}, []reputation_service.Verdict{unsafe, safe}, false},
+		{"all with only unsafe votes", reputation_service.Config{Voting: reputation_service.VoteAll}, []reputation_service.Verdict{unsafe, unsafe}, true},
+		{"majority quorum not reached", reputation_service.Config{Voting: reputation_service.VoteQuorum}, []reputation_service.Verdict{unsafe, safe, safe}, false},
+		{"majority quorum reached", reputation_service.Config{Voting: reputation_service.VoteQuorum}, []reputation_service.Verdict{unsafe, safe, unsafe}, true},
+		{"explicit quorum", reputation_service.Config{Voting: reputation_service.VoteQuorum, Quorum: 1}, []reputation_service.Verdict{safe, safe, unsafe}, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var providers []reputation_service.Provider
+			for i, v := range tc.verdicts {
+				providers = append(providers, provider(string(rune('a'+i)), v, nil))
+			}
+			checker := reputation_service.NewComposite(tc.config, providers)
+
+			verdict, err := checker.Check(context.Background(), target)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.unsafe, verdict.Unsafe)
+		})
+	}
+}
+
+func TestComposite_Verdict(t *testing.T) {
+	t.Run("Names the first provider that reported the URL", func(t *testing.T) {
+		phishing := reputation_service.Verdict{Unsafe: true, ThreatType: "SOCIAL_ENGINEERING", TTL: time.Minute}
+		checker := reputation_service.NewComposite(reputation_service.Config{}, []reputation_service.Provider{
+			provider("safebrowsing", safe, nil),
+			provider("rules:local.txt", phishing, nil),
+			provider("other", unsafe, nil),
+		})
+
+		verdict, err := checker.Check(context.Background(), target)
+
+		require.NoError(t, err)
+		assert.Equal(t, reputation_service.Verdict{
+			Unsafe: true, Provider: "rules:local.txt", ThreatType: "SOCIAL_ENGINEERING", TTL: time.Minute,
+		}, verdict)
+	})
+
+	t.Run("Invalid URL", func(t *testing.T) {
+		checker := reputation_service.NewComposite(reputation_service.Config{}, nil)
+		_, err := checker.Check(context.Background(), "not a url")
+		assert.Error(t, err)
+	})
+}
+
+func TestComposite_Failures(t *testing.T) {
+	failure := errors.New("provider down")
+
+	t.Run("Fail closed returns the error", func(t *testing.T) {
+		checker := reputation_service.NewComposite(reputation_service.Config{FailurePolicy: reputation_service.FailClosed}, []reputation_service.Provider{
+			provider("a", safe, nil),
+			provider("b", reputation_service.Verdict{}, failure),
+		})
+
+		_, err := checker.Check(context.Background(), target)
+
+		assert.ErrorIs(t, err, failure)
+	})
+
+	t.Run("Fail closed still reports an unsafe URL", func(t *testing.T) {
+		checker := reputation_service.NewComposite(reputation_service.Config{FailurePolicy: reputation_service.FailClosed}, []reputation_service.Provider{
+			provider("a", unsafe, nil),
+			provider("b", reputation_service.Verdict{}, failure),
+		})
+
+		verdict, err := checker.Check(context.Background(), target)
+
+		require.NoError(t, err)
+		assert.True(t, verdict.Unsafe)
+	})
+
+	t.Run("Fail open ignores the failing provider", func(t *testing.T) {
+		checker := reputation_service.NewComposite(reputation_service.Config{FailurePolicy: reputation_service.FailOpen}, []reputation_service.Provider{
+			provider("a", safe, nil),
+			provider("b", reputation_service.Verdict{}, failure),
+		})
+
+		verdict, err := checker.Check(context.Background(), target)
+
+		require.NoError(t, err)
+		assert.False(t, verdict.Unsafe)
+	})
+
+	t.Run("Slow providers time out", func(t *testing.T) {
+		slow := &MockProvider{name: "slow"}
+		slow.On("Check", mock.Anything, mock.Anything).After(time.Second).Return(unsafe, nil)
+		checker := reputation_service.NewComposite(reputation_service.Config{
+			FailurePolicy:    reputation_service.FailOpen,
+			ProviderTimeouts: map[string]time.Duration{"slow": 20 * time.Millisecond},
+		}, []reputation_service.Provider{provider("fast", safe, nil), slow})
+
+		start := time.Now()
+		verdict, err := checker.Check(context.Background(), target)
+
+		require.NoError(t, err)
+		assert.False(t, verdict.Unsafe)
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+	})
+}
+
+func TestComposite_CheckAll(t *testing.T) {
+	urls := []string{"https://a.example.com/", "not a url", "https://b.example.com/", "https://c.example.com/"}
+
+	t.Run("Safe Browsing is asked once for all URLs", func(t *testing.T) {
+		finder := &safebrowsing_service.MockSafeBrowsingService{UnsafeURLs: map[string]bool{"https://b.example.com/": true}}
+		rules := provider("rules", safe, nil)
+		checker := reputation_service.NewComposite(reputation_service.Config{}, []reputation_service.Provider{
+			reputation_service.NewSafeBrowsingProvider(finder), rules,
+		})
+
+		verdicts, errs := checker.CheckAll(context.Background(), urls)
+
+		require.Len(t, finder.Requests, 1)
+		assert.Equal(t, []string{urls[0], urls[2], urls[3]}, finder.Requests[0])
+		rules.AssertNumberOfCalls(t, "Check", 3)
+
+		assert.ErrorIs(t, errs[1], shortlink_errors.ErrValidateRequest)
+		for _, i := range []int{0, 2, 3} {
+			require.NoError(t, errs[i])
+			assert.Equal(t, i == 2, verdicts[i].Unsafe, urls[i])
+		}
+		assert.Equal(t, reputation_service.ProviderSafeBrowsing, verdicts[2].Provider)
+	})
+
+	t.Run("A failing batch fails its URLs", func(t *testing.T) {
+		finder := &safebrowsing_service.MockSafeBrowsingService{Err: errors.New("quota")}
+		checker := reputation_service.NewComposite(reputation_service.Config{FailurePolicy: reputation_service.FailClosed}, []reputation_service.Provider{
+			reputation_service.NewSafeBrowsingProvider(finder),
+		})
+
+		_, errs := checker.CheckAll(context.Background(), []string{target})
+
+		assert.Error(t, errs[0])
+	})
+}
+
+func TestProviders(t *testing.T) {
+	t.Run("Safe Browsing provider", func(t *testing.T) {
+		finder := &safebrowsing_service.MockSafeBrowsingService{
+			UnsafeURLs:  map[string]bool{target: true},
+			ThreatTypes: map[string]string{target: "UNWANTED_SOFTWARE"},
+		}
+		p := reputation_service.NewSafeBrowsingProvider(finder)
+
+		verdict, err := p.Check(context.Background(), target)
+
+		require.NoError(t, err)
+		assert.True(t, verdict.Unsafe)
+		assert.Equal(t, "UNWANTED_SOFTWARE", verdict.ThreatType)
+		assert.Equal(t, safebrowsing_service.CacheTTL, verdict.TTL)
+	})
+
+	t.Run("Rule file provider", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "rules.txt")
+		require.NoError(t, os.WriteFile(path, []byte("# local rules\n*.evil.test\nhttps://example.com/phish\n"), 0o600))
+
+		p, err := reputation_service.NewRuleFileProvider(path)
+		require.NoError(t, err)
+		assert.Equal(t, "rules:rules.txt", p.Name())
+
+		for u, blocked := range map[string]bool{
+			"https://a.evil.test/x":      true,
+			"https://example.com/phish":  true,
+			"https://example.com/other":  false,
+			"https://not-evil.test/path": false,
+		} {
+			verdict, err := p.Check(context.Background(), u)
+			require.NoError(t, err)
+			assert.Equal(t, blocked, verdict.Unsafe, u)
+			if blocked {
+				assert.Equal(t, reputation_service.ThreatTypeLocalRule, verdict.ThreatType)
+			}
+		}
+	})
+
+	t.Run("Missing rule file", func(t *testing.T) {
+		_, err := reputation_service.New(reputation_service.Config{Providers: []string{"rules:/does/not/exist.txt"}}, nil)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,158 @@
+package reserved_service
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+)
+
+// builtinWords are the paths and words used by the service itself. They are
+// always reserved, whatever the admins configure.
+var builtinWords = []string{
+	"home", "health", "health-check", "analytics", "click-count", "clicks",
+	"create", "update", "delete", "edit", "view", "list", "search",
+	"settings", "config", "status", "error", "admin", "api", "shorten",
+	"login", "logout", "dashboard", "docs", "metrics",
+}
+
+// leetspeak maps look-alike characters to the letter they stand for. "l" and
+// "1" both become "i", so "1ogin", "L0GIN" and "login" compare equal.
+var leetspeak = map[rune]rune{
+	'0': 'o',
+	'1': 'i',
+	'l': 'i',
+	'!': 'i',
+	'3': 'e',
+	'4': 'a',
+	'@': 'a',
+	'5': 's',
+	'$': 's',
+	'7': 't',
+	'8': 'b',
+	'9': 'g',
+}
+
+type (
+	// Checker refuses short IDs matching a reserved word.
+	Checker interface {
+		// Check returns ErrBlacklistedID if id may not be used for a link in
+		// workspaceID ("" for personal links).
+		Check(ctx context.Context, id, workspaceID string) error
+	}
+
+	// Matcher matches IDs against a fixed list of reserved words.
+	Matcher struct {
+		items    []models.ReservedID
+		exact    map[string][]models.ReservedID
+		contains []containsWord
+	}
+
+	containsWord struct {
+		key  string
+		item models.ReservedID
+	}
+)
+
+func NewMatcher(items []models.ReservedID) *Matcher {
+	m := &Matcher{items: items, exact: make(map[string][]models.ReservedID)}
+	for _, item := range items {
+		key := normalize(item.Word)
+		if key == "" {
+			continue
+		}
+		if item.Match == models.ReservedMatchContains {
+			m.contains = append(m.contains, containsWord{key: key, item: item})
+		} else {
+			m.exact[key] = append(m.exact[key], item)
+		}
+	}
+	return m
+}
+
+// NewBuiltin returns a matcher for the built-in words only.
+func NewBuiltin() *Matcher {
+	items := make([]models.ReservedID, len(builtinWords))
+	for i, word := range builtinWords {
+		items[i] = models.ReservedID{Word: word, Match: models.ReservedMatchExact, Source: models.SourceBuiltin}
+	}
+	return NewMatcher(items)
+}
+
+// Match returns the reserved word that id matches, ignoring words reserved
+// for workspaceID itself.
+func (m *Matcher) Match(id, workspaceID string) (*models.ReservedID, bool) {
+	key := normalize(id)
+	for _, item := range m.exact[key] {
+		if item.WorkspaceID == "" || item.WorkspaceID != workspaceID {
+			return &item, true
+		}
+	}
+	for _, w := range m.contains {
+		if strings.Contains(key, w.key) && (w.item.WorkspaceID == "" || w.item.WorkspaceID != workspaceID) {
+			item := w.item
+			return &item, true
+		}
+	}
+	return nil, false
+}
+
+func (m *Matcher) Check(ctx context.Context, id, workspaceID string) error {
+	if _, ok := m.Match(id, workspaceID); ok {
+		return shortlink_errors.ErrBlacklistedID
+	}
+	return nil
+}
+
+// Items returns the words of the matcher.
+func (m *Matcher) Items() []models.ReservedID {
+	return m.items
+}
+
+// ParseWords reads a word list: one word per line, optionally followed by the
+// ID of the workspace it is reserved for. Empty lines and lines starting with
+// "#" are skipped. The words match anywhere in an ID.
+func ParseWords(r io.Reader, source string) ([]models.ReservedID, error) {
+	var items []models.ReservedID
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) > 2 || normalize(fields[0]) == "" {
+			return nil, fmt.Errorf("line %d: invalid reserved word %q", line, text)
+		}
+		item := models.ReservedID{Word: strings.ToLower(fields[0]), Match: models.ReservedMatchContains, Source: source}
+		if len(fields) == 2 {
+			item.WorkspaceID = fields[1]
+		}
+		items = append(items, item)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// normalize lower-cases s, undoes leetspeak and drops everything that is not
+// a letter or digit.
+func normalize(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if sub, ok := leetspeak[r]; ok {
+			r = sub
+		}
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,220 @@
+package reserved_service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	firestoreService "github.com/mfmahendr/url-shortener-backend/internal/services/firestore"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/validators"
+)
+
+// refreshInterval is how long the stored words and the word file are used
+// before they are checked for changes.
+const refreshInterval = time.Minute
+
+type (
+	ReservedService interface {
+		Checker
+		// List returns the built-in, file and admin-managed words.
+		List(ctx context.Context) ([]models.ReservedID, error)
+		Add(ctx context.Context, req dto.ReservedIDRequest) (*models.ReservedID, error)
+		Remove(ctx context.Context, word, workspaceID string) error
+	}
+
+	Config struct {
+		// WordsFile is a profanity or brand-protection word list, see
+		// ParseWords.
+		WordsFile string
+	}
+
+	ReservedServiceImpl struct {
+		store   firestoreService.ReservedIDStore
+		builtin *Matcher
+		file    *wordFile
+
+		mu     sync.Mutex
+		stored atomic.Pointer[storedWords]
+	}
+
+	storedWords struct {
+		matcher  *Matcher
+		loadedAt time.Time
+	}
+
+	// wordFile is re-read when the file changes.
+	wordFile struct {
+		path string
+
+		mu        sync.Mutex
+		matcher   *Matcher
+		modTime   time.Time
+		checkedAt time.Time
+	}
+)
+
+// LoadConfig reads RESERVED_WORDS_FILE (optional).
+func LoadConfig() Config {
+	return Config{WordsFile: strings.TrimSpace(os.Getenv("RESERVED_WORDS_FILE"))}
+}
+
+func New(store firestoreService.ReservedIDStore, config Config) (*ReservedServiceImpl, error) {
+	s := &ReservedServiceImpl{store: store, builtin: NewBuiltin()}
+	if config.WordsFile != "" {
+		s.file = &wordFile{path: config.WordsFile}
+		if err := s.file.reload(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Check matches id against the built-in words, the word file and the words
+// added by admins, in that order.
+func (s *ReservedServiceImpl) Check(ctx context.Context, id, workspaceID string) error {
+	if err := s.builtin.Check(ctx, id, workspaceID); err != nil {
+		return err
+	}
+	if s.file != nil {
+		if err := s.file.current().Check(ctx, id, workspaceID); err != nil {
+			return err
+		}
+	}
+
+	stored, err := s.current(ctx)
+	if err != nil {
+		return err
+	}
+	return stored.Check(ctx, id, workspaceID)
+}
+
+func (s *ReservedServiceImpl) List(ctx context.Context) ([]models.ReservedID, error) {
+	stored, err := s.store.ListReservedIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	items := append([]models.ReservedID{}, s.builtin.Items()...)
+	if s.file != nil {
+		items = append(items, s.file.current().Items()...)
+	}
+	return append(items, stored...), nil
+}
+
+// Add stores a word; it matches the whole ID unless req.Match is "contains".
+func (s *ReservedServiceImpl) Add(ctx context.Context, req dto.ReservedIDRequest) (*models.ReservedID, error) {
+	if err := validators.Validate.Struct(req); err != nil {
+		return nil, shortlink_errors.ErrValidateRequest
+	}
+
+	item := models.ReservedID{
+		Word:        strings.ToLower(req.Word),
+		Match:       req.Match,
+		WorkspaceID: req.WorkspaceID,
+		Source:      models.SourceManual,
+		CreatedAt:   time.Now(),
+	}
+	if item.Match == "" {
+		item.Match = models.ReservedMatchExact
+	}
+
+	if err := s.store.AddReservedID(ctx, item); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return &item, nil
+}
+
+func (s *ReservedServiceImpl) Remove(ctx context.Context, word, workspaceID string) error {
+	if err := s.store.RemoveReservedID(ctx, strings.ToLower(word), workspaceID); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+func (s *ReservedServiceImpl) invalidate() {
+	s.stored.Store(nil)
+}
+
+func (s *ReservedServiceImpl) current(ctx context.Context) (*Matcher, error) {
+	stored := s.stored.Load()
+	if stored != nil && time.Since(stored.loadedAt) < refreshInterval {
+		return stored.matcher, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// another request may have reloaded while we waited
+	if latest := s.stored.Load(); latest != nil && time.Since(latest.loadedAt) < refreshInterval {
+		return latest.matcher, nil
+	}
+
+	items, err := s.store.ListReservedIDs(ctx)
+	if err != nil {
+		if stored != nil {
+			log.Printf("Failed to refresh reserved IDs, using previous list: %v", err)
+			return stored.matcher, nil
+		}
+		return nil, err
+	}
+	matcher := NewMatcher(items)
+	s.stored.Store(&storedWords{matcher: matcher, loadedAt: time.Now()})
+	return matcher, nil
+}
+
+// current returns the words, reloading them if the file changed since the
+// last check. If reloading fails the previous words stay in use.
+func (f *wordFile) current() *Matcher {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if time.Since(f.checkedAt) < refreshInterval {
+		return f.matcher
+	}
+	f.checkedAt = time.Now()
+
+	info, err := os.Stat(f.path)
+	if err != nil || info.ModTime().Equal(f.modTime) {
+		return f.matcher
+	}
+	if err := f.reloadLocked(); err != nil {
+		log.Printf("Failed to reload reserved word file, using previous words: %v", err)
+	}
+	return f.matcher
+}
+
+func (f *wordFile) reload() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.checkedAt = time.Now()
+	return f.reloadLocked()
+}
+
+func (f *wordFile) reloadLocked() error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return fmt.Errorf("failed to open reserved word file: %w", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to read reserved word file: %w", err)
+	}
+
+	items, err := ParseWords(file, "file:"+filepath.Base(f.path))
+	if err != nil {
+		return fmt.Errorf("failed to parse reserved word file %s: %w", f.path, err)
+	}
+	f.matcher = NewMatcher(items)
+	f.modTime = info.ModTime()
+	return nil
+}
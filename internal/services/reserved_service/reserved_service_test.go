@@ -0,0 +1,157 @@
+package reserved_service_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/reserved_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/validators"
+)
+
+// *--- MOCK DEFINITIONS ---* //
+// Firestore reserved ID STORE
+type MockReservedIDStore struct{ mock.Mock }
+
+func (m *MockReservedIDStore) ListReservedIDs(ctx context.Context) ([]models.ReservedID, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.ReservedID), args.Error(1)
+}
+
+func (m *MockReservedIDStore) AddReservedID(ctx context.Context, item models.ReservedID) error {
+	args := m.Called(ctx, item)
+	return args.Error(0)
+}
+
+func (m *MockReservedIDStore) RemoveReservedID(ctx context.Context, word, workspaceID string) error {
+	args := m.Called(ctx, word, workspaceID)
+	return args.Error(0)
+}
+
+func TestMatcher(t *testing.T) {
+	m := reserved_service.NewMatcher([]models.ReservedID{
+		{Word: "admin", Match: models.ReservedMatchExact},
+		{Word: "shit", Match: models.ReservedMatchContains},
+		{Word: "acme", Match: models.ReservedMatchContains, WorkspaceID: "ws-acme"},
+	})
+
+	tests := []struct {
+		id, workspaceID string
+		reserved        bool
+	}{
+		{"admin", "", true},
+		{"ADMIN", "", true},
+		{"4dm1n", "", true},
+		{"admins", "", false}, // exact words do not match substrings
+		{"bullshit", "", true},
+		{"bull5h1t", "", true},
+		{"Bu11SH1T", "", true},
+		{"acmeSale", "", true},
+		{"acmeSale", "ws-other", true},
+		{"acmeSale", "ws-acme", false}, // reserved for this workspace
+		{"ac3m", "", false},
+		{"hello", "", false},
+	}
+	for _, tt := range tests {
+		_, reserved := m.Match(tt.id, tt.workspaceID)
+		assert.Equal(t, tt.reserved, reserved, "%s in %q", tt.id, tt.workspaceID)
+	}
+}
+
+func TestParseWords(t *testing.T) {
+	input := "# profanity\nDamn\n\nacme ws-acme\n"
+
+	items, err := reserved_service.ParseWords(strings.NewReader(input), "file:words.txt")
+
+	require.NoError(t, err)
+	assert.Equal(t, []models.ReservedID{
+		{Word: "damn", Match: models.ReservedMatchContains, Source: "file:words.txt"},
+		{Word: "acme", Match: models.ReservedMatchContains, WorkspaceID: "ws-acme", Source: "file:words.txt"},
+	}, items)
+
+	_, err = reserved_service.ParseWords(strings.NewReader("too many fields"), "file:words.txt")
+	assert.Error(t, err)
+}
+
+func TestCheck(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "words.txt")
+	require.NoError(t, os.WriteFile(path, []byte("damn\n"), 0o600))
+
+	store := new(MockReservedIDStore)
+	store.On("ListReservedIDs", mock.Anything).Return([]models.ReservedID{
+		{Word: "promo", Match: models.ReservedMatchExact, Source: models.SourceManual},
+	}, nil).Once()
+	svc, err := reserved_service.New(store, reserved_service.Config{WordsFile: path})
+	require.NoError(t, err)
+
+	assert.Equal(t, shortlink_errors.ErrBlacklistedID, svc.Check(ctx, "Dashboard", "")) // built-in
+	assert.Equal(t, shortlink_errors.ErrBlacklistedID, svc.Check(ctx, "metrics", ""))   // root path
+	assert.Equal(t, shortlink_errors.ErrBlacklistedID, svc.Check(ctx, "goddamnit", "")) // word file
+	assert.Equal(t, shortlink_errors.ErrBlacklistedID, svc.Check(ctx, "PR0M0", ""))     // stored
+	assert.NoError(t, svc.Check(ctx, "promotion", ""))
+	store.AssertExpectations(t) // the stored words are cached
+
+	t.Run("Missing word file fails", func(t *testing.T) {
+		_, err := reserved_service.New(store, reserved_service.Config{WordsFile: filepath.Join(t.TempDir(), "missing.txt")})
+		assert.Error(t, err)
+	})
+}
+
+func TestAddRemove(t *testing.T) {
+	validators.Init()
+	ctx := context.Background()
+	store := new(MockReservedIDStore)
+	svc, err := reserved_service.New(store, reserved_service.Config{})
+	require.NoError(t, err)
+
+	store.On("ListReservedIDs", mock.Anything).Return([]models.ReservedID{}, nil).Once()
+	require.NoError(t, svc.Check(ctx, "acme", ""))
+
+	t.Run("Added words apply right away", func(t *testing.T) {
+		store.On("AddReservedID", mock.Anything, mock.MatchedBy(func(item models.ReservedID) bool {
+			return item.Word == "acme" && item.Match == models.ReservedMatchExact &&
+				item.WorkspaceID == "ws-acme" && item.Source == models.SourceManual
+		})).Return(nil).Once()
+		store.On("ListReservedIDs", mock.Anything).Return([]models.ReservedID{
+			{Word: "acme", Match: models.ReservedMatchExact, WorkspaceID: "ws-acme"},
+		}, nil).Once()
+
+		item, err := svc.Add(ctx, dto.ReservedIDRequest{Word: "ACME", WorkspaceID: "ws-acme"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "acme", item.Word)
+		assert.Equal(t, shortlink_errors.ErrBlacklistedID, svc.Check(ctx, "acme", ""))
+		assert.NoError(t, svc.Check(ctx, "acme", "ws-acme"))
+	})
+
+	t.Run("Invalid words are rejected", func(t *testing.T) {
+		for _, req := range []dto.ReservedIDRequest{
+			{Word: "a"},
+			{Word: "no-dashes"},
+			{Word: "word", Match: "prefix"},
+		} {
+			_, err := svc.Add(ctx, req)
+			assert.Equal(t, shortlink_errors.ErrValidateRequest, err, req.Word)
+		}
+	})
+
+	t.Run("Removed words no longer apply", func(t *testing.T) {
+		store.On("RemoveReservedID", mock.Anything, "acme", "ws-acme").Return(nil).Once()
+		store.On("ListReservedIDs", mock.Anything).Return([]models.ReservedID{}, nil).Once()
+
+		require.NoError(t, svc.Remove(ctx, "Acme", "ws-acme"))
+		assert.NoError(t, svc.Check(ctx, "acme", ""))
+	})
+
+	store.AssertExpectations(t)
+}
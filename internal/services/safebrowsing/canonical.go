@@ -0,0 +1,275 @@
+package safebrowsing_service
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Canonicalize returns rawURL in the canonical form the Safe Browsing v4 API
+// hashes: lower-case host without port or user info, resolved path, no
+// fragment, and every byte <= 0x20, >= 0x7f, '#' or '%' percent-escaped.
+func Canonicalize(rawURL string) (string, error) {
+	s := strings.TrimSpace(rawURL)
+	s = strings.NewReplacer("\t", "", "\r", "", "\n", "").Replace(s)
+	if i := strings.IndexByte(s, '#'); i >= 0 {
+		s = s[:i]
+	}
+	s = unescapeFully(s)
+
+	scheme := "http"
+	if i := strings.Index(s, "://"); i > 0 && isScheme(s[:i]) {
+		scheme, s = strings.ToLower(s[:i]), s[i+3:]
+	}
+
+	authority, rest := s, ""
+	if i := strings.IndexAny(s, "/?"); i >= 0 {
+		authority, rest = s[:i], s[i:]
+	}
+	pathPart, query := rest, ""
+	if i := strings.IndexByte(rest, '?'); i >= 0 {
+		pathPart, query = rest[:i], rest[i:]
+	}
+
+	host, err := canonicalHost(authority)
+	if err != nil {
+		return "", err
+	}
+
+	return scheme + "://" + escape(host) + escape(canonicalPath(pathPart)) + escape(query), nil
+}
+
+// Expressions returns the host suffix / path prefix combinations of a
+// canonical URL that are looked up in the threat lists, most specific first.
+func Expressions(canonicalURL string) ([]string, error) {
+	s := canonicalURL
+	if i := strings.Index(s, "://"); i >= 0 {
+		s = s[i+3:]
+	}
+	host, rest := s, "/"
+	if i := strings.IndexByte(s, '/'); i >= 0 {
+		host, rest = s[:i], s[i:]
+	}
+	if host == "" {
+		return nil, fmt.Errorf("no host in %q", canonicalURL)
+	}
+	pathOnly := rest
+	if i := strings.IndexByte(rest, '?'); i >= 0 {
+		pathOnly = rest[:i]
+	}
+
+	// the exact host plus up to four suffixes built from the last five
+	// components; the top-level domain alone is never looked up
+	hosts := []string{host}
+	if net.ParseIP(host) == nil {
+		components := strings.Split(host, ".")
+		for i := max(len(components)-5, 1); i < len(components)-1; i++ {
+			hosts = append(hosts, strings.Join(components[i:], "."))
+		}
+	}
+
+	// the exact path with and without query, then up to four prefixes
+	// starting at the root
+	paths := []string{rest, pathOnly, "/"}
+	segments := strings.Split(strings.Trim(pathOnly, "/"), "/")
+	prefix := "/"
+	for i := 0; i < len(segments)-1 && i < 3; i++ {
+		prefix += segments[i] + "/"
+		paths = append(paths, prefix)
+	}
+
+	seen := make(map[string]bool)
+	var expressions []string
+	for _, h := range hosts {
+		for _, p := range paths {
+			expr := h + p
+			if !seen[expr] {
+				seen[expr] = true
+				expressions = append(expressions, expr)
+			}
+		}
+	}
+	return expressions, nil
+}
+
+// HashExpression returns the SHA-256 hash threat list prefixes are cut from.
+func HashExpression(expr string) [sha256.Size]byte {
+	return sha256.Sum256([]byte(expr))
+}
+
+// unescapeFully decodes percent-escapes until none are left. Unlike
+// net/url it leaves a '%' that does not start a valid escape alone.
+func unescapeFully(s string) string {
+	for {
+		unescaped := unescape(s)
+		if unescaped == s {
+			return s
+		}
+		s = unescaped
+	}
+}
+
+func unescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]) {
+			v, _ := strconv.ParseUint(s[i+1:i+3], 16, 8)
+			b.WriteByte(byte(v))
+			i += 2
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func canonicalHost(authority string) (string, error) {
+	host := authority
+	if i := strings.LastIndexByte(host, '@'); i >= 0 {
+		host = host[i+1:]
+	}
+	if i := strings.LastIndexByte(host, ':'); i >= 0 && isDigits(host[i+1:]) {
+		host = host[:i]
+	}
+
+	host = strings.Trim(host, ".")
+	for strings.Contains(host, "..") {
+		host = strings.ReplaceAll(host, "..", ".")
+	}
+	host = lowerASCII(host)
+	if host == "" {
+		return "", fmt.Errorf("no host in %q", authority)
+	}
+
+	if ip, ok := canonicalIP(host); ok {
+		return ip, nil
+	}
+	return host, nil
+}
+
+// canonicalIP accepts the forms inet_aton does: one to four dot-separated
+// decimal, octal (leading 0) or hex (0x) parts, the last filling the
+// remaining bytes.
+func canonicalIP(host string) (string, bool) {
+	parts := strings.Split(host, ".")
+	if len(parts) > 4 {
+		return "", false
+	}
+
+	var ip uint64
+	for i, part := range parts {
+		base, digits := 10, part
+		switch {
+		case strings.HasPrefix(part, "0x") || strings.HasPrefix(part, "0X"):
+			base, digits = 16, part[2:]
+		case len(part) > 1 && part[0] == '0':
+			base, digits = 8, part[1:]
+		}
+		if digits == "" {
+			return "", false
+		}
+		v, err := strconv.ParseUint(digits, base, 64)
+		if err != nil {
+			return "", false
+		}
+
+		if i < len(parts)-1 {
+			if v > 0xff {
+				return "", false
+			}
+			ip |= v << (8 * (3 - i))
+			continue
+		}
+		remaining := 4 - i
+		if v >= 1<<(8*remaining) {
+			return "", false
+		}
+		ip |= v
+	}
+
+	return fmt.Sprintf("%d.%d.%d.%d", byte(ip>>24), byte(ip>>16), byte(ip>>8), byte(ip)), true
+}
+
+// canonicalPath resolves "." and ".." segments and collapses repeated
+// slashes, keeping a trailing slash.
+func canonicalPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+
+	raw := strings.Split(p, "/")
+	last := raw[len(raw)-1]
+	trailing := last == "" || last == "." || last == ".."
+
+	var segments []string
+	for _, segment := range raw {
+		switch segment {
+		case "", ".":
+		case "..":
+			if len(segments) > 0 {
+				segments = segments[:len(segments)-1]
+			}
+		default:
+			segments = append(segments, segment)
+		}
+	}
+
+	out := "/" + strings.Join(segments, "/")
+	if trailing && len(segments) > 0 {
+		out += "/"
+	}
+	return out
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c <= 0x20 || c >= 0x7f || c == '#' || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// lowerASCII lower-cases like strings.ToLower without rewriting bytes that
+// are not valid UTF-8, which have to survive until they are escaped.
+func lowerASCII(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c >= 'A' && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	return string(b)
+}
+
+func isScheme(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && (c >= '0' && c <= '9' || c == '+' || c == '-' || c == '.')) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isHex(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
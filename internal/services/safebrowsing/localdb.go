@@ -0,0 +1,406 @@
+package safebrowsing_service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/safebrowsing/v4"
+)
+
+const (
+	defaultUpdateInterval = 30 * time.Minute
+	updateRetryInterval   = 5 * time.Minute
+)
+
+var ErrChecksumMismatch = errors.New("threat list checksum mismatch")
+
+// ThreatList identifies one list of the Update API.
+type ThreatList struct {
+	ThreatType      string
+	PlatformType    string
+	ThreatEntryType string
+}
+
+// DefaultThreatLists are the lists for DefaultThreatTypes.
+var DefaultThreatLists = ThreatListsFor(DefaultThreatTypes)
+
+// ThreatListsFor returns the URL lists of the threat types for any platform.
+func ThreatListsFor(threatTypes []string) []ThreatList {
+	lists := make([]ThreatList, len(threatTypes))
+	for i, t := range threatTypes {
+		lists[i] = ThreatList{ThreatType: t, PlatformType: "ANY_PLATFORM", ThreatEntryType: "URL"}
+	}
+	return lists
+}
+
+// UpdateAPI is the part of the Safe Browsing API the local database needs.
+type UpdateAPI interface {
+	FetchUpdates(ctx context.Context, req *safebrowsing.GoogleSecuritySafebrowsingV4FetchThreatListUpdatesRequest) (*safebrowsing.GoogleSecuritySafebrowsingV4FetchThreatListUpdatesResponse, error)
+	FindFullHashes(ctx context.Context, req *safebrowsing.GoogleSecuritySafebrowsingV4FindFullHashesRequest) (*safebrowsing.GoogleSecuritySafebrowsingV4FindFullHashesResponse, error)
+}
+
+type updateAPIClient struct {
+	service *safebrowsing.Service
+}
+
+// NewUpdateAPI wraps the generated client.
+func NewUpdateAPI(service *safebrowsing.Service) UpdateAPI {
+	return &updateAPIClient{service: service}
+}
+
+func (c *updateAPIClient) FetchUpdates(ctx context.Context, req *safebrowsing.GoogleSecuritySafebrowsingV4FetchThreatListUpdatesRequest) (*safebrowsing.GoogleSecuritySafebrowsingV4FetchThreatListUpdatesResponse, error) {
+	return c.service.ThreatListUpdates.Fetch(req).Context(ctx).Do()
+}
+
+func (c *updateAPIClient) FindFullHashes(ctx context.Context, req *safebrowsing.GoogleSecuritySafebrowsingV4FindFullHashesRequest) (*safebrowsing.GoogleSecuritySafebrowsingV4FindFullHashesResponse, error) {
+	return c.service.FullHashes.Find(req).Context(ctx).Do()
+}
+
+type threatListState struct {
+	// prefixes is kept sorted, as removal indices refer to that order.
+	prefixes    []string
+	sizes       map[int]bool
+	clientState string
+}
+
+type fullHashEntry struct {
+	threatType string
+	expires    time.Time
+}
+
+// LocalDatabase keeps the hash prefixes of the threat lists in memory and
+// only asks the API for full hashes when a URL matches one of them, so most
+// lookups neither leave the process nor disclose the URL.
+type LocalDatabase struct {
+	api   UpdateAPI
+	lists []ThreatList
+	now   func() time.Time
+
+	mu         sync.RWMutex
+	states     map[ThreatList]*threatListState
+	ready      bool
+	nextUpdate time.Time
+
+	cacheMu sync.Mutex
+	// fullHashes caches confirmed matches by full hash, negative caches
+	// prefixes the API reported no match for.
+	fullHashes map[string]fullHashEntry
+	negative   map[string]time.Time
+}
+
+func NewLocalDatabase(api UpdateAPI, lists []ThreatList) *LocalDatabase {
+	return &LocalDatabase{
+		api:        api,
+		lists:      lists,
+		now:        time.Now,
+		states:     make(map[ThreatList]*threatListState),
+		fullHashes: make(map[string]fullHashEntry),
+		negative:   make(map[string]time.Time),
+	}
+}
+
+// Ready reports whether every list has been downloaded at least once.
+func (db *LocalDatabase) Ready() bool {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.ready
+}
+
+// Update fetches and applies the changes since the last update. A list
+// whose checksum does not match afterwards is reset, so the next update
+// downloads it again in full.
+func (db *LocalDatabase) Update(ctx context.Context) error {
+	db.mu.RLock()
+	req := &safebrowsing.GoogleSecuritySafebrowsingV4FetchThreatListUpdatesRequest{Client: clientInfo()}
+	for _, list := range db.lists {
+		var state string
+		if s, ok := db.states[list]; ok {
+			state = s.clientState
+		}
+		req.ListUpdateRequests = append(req.ListUpdateRequests, &safebrowsing.GoogleSecuritySafebrowsingV4FetchThreatListUpdatesRequestListUpdateRequest{
+			ThreatType:      list.ThreatType,
+			PlatformType:    list.PlatformType,
+			ThreatEntryType: list.ThreatEntryType,
+			State:           state,
+			Constraints: &safebrowsing.GoogleSecuritySafebrowsingV4FetchThreatListUpdatesRequestListUpdateRequestConstraints{
+				SupportedCompressions: []string{"RAW"},
+			},
+		})
+	}
+	db.mu.RUnlock()
+
+	resp, err := db.api.FetchUpdates(ctx, req)
+	if err != nil {
+		return mapAPIError(err)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.nextUpdate = db.now().Add(defaultUpdateInterval)
+	if wait, err := time.ParseDuration(resp.MinimumWaitDuration); err == nil {
+		db.nextUpdate = db.now().Add(wait)
+	}
+
+	var errs []error
+	for _, update := range resp.ListUpdateResponses {
+		list := ThreatList{ThreatType: update.ThreatType, PlatformType: update.PlatformType, ThreatEntryType: update.ThreatEntryType}
+		state, err := applyListUpdate(db.states[list], update)
+		if err != nil {
+			delete(db.states, list)
+			errs = append(errs, fmt.Errorf("%s/%s: %w", list.ThreatType, list.PlatformType, err))
+			continue
+		}
+		db.states[list] = state
+	}
+
+	db.ready = len(errs) == 0
+	for _, list := range db.lists {
+		if _, ok := db.states[list]; !ok {
+			db.ready = false
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Run keeps the lists up to date, honouring the wait the API asks for.
+func (db *LocalDatabase) Run(ctx context.Context) {
+	for {
+		wait := updateRetryInterval
+		if err := db.Update(ctx); err != nil {
+			log.Printf("Safe Browsing list update failed: %v", err)
+		} else {
+			db.mu.RLock()
+			wait = db.nextUpdate.Sub(db.now())
+			db.mu.RUnlock()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Lookup returns the threat type of every unsafe URL in urls. URLs that
+// cannot be canonicalised are treated as safe.
+func (db *LocalDatabase) Lookup(ctx context.Context, urls []string) (map[string]string, error) {
+	// full hashes of the URLs' expressions that matched a local prefix
+	hits := make(map[string][]string)
+	prefixes := make(map[string]bool)
+	for _, u := range urls {
+		canonical, err := Canonicalize(u)
+		if err != nil {
+			continue
+		}
+		expressions, err := Expressions(canonical)
+		if err != nil {
+			continue
+		}
+		for _, expr := range expressions {
+			hash := HashExpression(expr)
+			if prefix, ok := db.matchPrefix(string(hash[:])); ok {
+				hits[u] = append(hits[u], string(hash[:]))
+				if !db.cachedMatch(string(hash[:])) {
+					prefixes[prefix] = true
+				}
+			}
+		}
+	}
+
+	threats := make(map[string]string)
+	if len(hits) == 0 {
+		return threats, nil
+	}
+
+	if err := db.fetchFullHashes(ctx, prefixes); err != nil {
+		return threats, err
+	}
+
+	db.cacheMu.Lock()
+	defer db.cacheMu.Unlock()
+	now := db.now()
+	for u, hashes := range hits {
+		for _, hash := range hashes {
+			if entry, ok := db.fullHashes[hash]; ok && now.Before(entry.expires) {
+				threats[u] = entry.threatType
+				break
+			}
+		}
+	}
+	return threats, nil
+}
+
+func (db *LocalDatabase) cachedMatch(hash string) bool {
+	db.cacheMu.Lock()
+	defer db.cacheMu.Unlock()
+	entry, ok := db.fullHashes[hash]
+	return ok && db.now().Before(entry.expires)
+}
+
+// matchPrefix returns the prefix of hash found in any of the lists.
+func (db *LocalDatabase) matchPrefix(hash string) (string, bool) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	for _, state := range db.states {
+		for size := range state.sizes {
+			prefix := hash[:size]
+			i := sort.SearchStrings(state.prefixes, prefix)
+			if i < len(state.prefixes) && state.prefixes[i] == prefix {
+				return prefix, true
+			}
+		}
+	}
+	return "", false
+}
+
+// fetchFullHashes asks for the full hashes of the prefixes that are not
+// negatively cached.
+func (db *LocalDatabase) fetchFullHashes(ctx context.Context, prefixes map[string]bool) error {
+	db.cacheMu.Lock()
+	now := db.now()
+	var pending []string
+	for prefix := range prefixes {
+		if expires, ok := db.negative[prefix]; ok && now.Before(expires) {
+			continue
+		}
+		pending = append(pending, prefix)
+	}
+	db.cacheMu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+	sort.Strings(pending)
+
+	db.mu.RLock()
+	req := &safebrowsing.GoogleSecuritySafebrowsingV4FindFullHashesRequest{
+		Client:     clientInfo(),
+		ThreatInfo: &safebrowsing.GoogleSecuritySafebrowsingV4ThreatInfo{},
+	}
+	for _, list := range db.lists {
+		req.ThreatInfo.ThreatTypes = appendUnique(req.ThreatInfo.ThreatTypes, list.ThreatType)
+		req.ThreatInfo.PlatformTypes = appendUnique(req.ThreatInfo.PlatformTypes, list.PlatformType)
+		req.ThreatInfo.ThreatEntryTypes = appendUnique(req.ThreatInfo.ThreatEntryTypes, list.ThreatEntryType)
+		if state, ok := db.states[list]; ok {
+			req.ClientStates = append(req.ClientStates, state.clientState)
+		}
+	}
+	db.mu.RUnlock()
+	for _, prefix := range pending {
+		req.ThreatInfo.ThreatEntries = append(req.ThreatInfo.ThreatEntries, &safebrowsing.GoogleSecuritySafebrowsingV4ThreatEntry{
+			Hash: base64.StdEncoding.EncodeToString([]byte(prefix)),
+		})
+	}
+
+	resp, err := db.api.FindFullHashes(ctx, req)
+	if err != nil {
+		return mapAPIError(err)
+	}
+
+	db.cacheMu.Lock()
+	defer db.cacheMu.Unlock()
+	now = db.now()
+	for _, match := range resp.Matches {
+		if match.Threat == nil {
+			continue
+		}
+		hash, err := base64.StdEncoding.DecodeString(match.Threat.Hash)
+		if err != nil || len(hash) != sha256.Size {
+			continue
+		}
+		ttl, _ := time.ParseDuration(match.CacheDuration)
+		db.fullHashes[string(hash)] = fullHashEntry{threatType: match.ThreatType, expires: now.Add(ttl)}
+	}
+	if ttl, err := time.ParseDuration(resp.NegativeCacheDuration); err == nil {
+		for _, prefix := range pending {
+			db.negative[prefix] = now.Add(ttl)
+		}
+	}
+	return nil
+}
+
+// applyListUpdate returns the state of a list after applying update to it;
+// state is nil for a list that has not been downloaded yet.
+func applyListUpdate(state *threatListState, update *safebrowsing.GoogleSecuritySafebrowsingV4FetchThreatListUpdatesResponseListUpdateResponse) (*threatListState, error) {
+	var prefixes []string
+	if state != nil && update.ResponseType != "FULL_UPDATE" {
+		prefixes = state.prefixes
+	}
+
+	for _, removal := range update.Removals {
+		if removal.RawIndices == nil {
+			return nil, fmt.Errorf("unsupported removal compression %q", removal.CompressionType)
+		}
+		remove := make(map[int64]bool, len(removal.RawIndices.Indices))
+		for _, i := range removal.RawIndices.Indices {
+			if i < 0 || i >= int64(len(prefixes)) {
+				return nil, fmt.Errorf("removal index %d out of range", i)
+			}
+			remove[i] = true
+		}
+		kept := make([]string, 0, len(prefixes)-len(remove))
+		for i, prefix := range prefixes {
+			if !remove[int64(i)] {
+				kept = append(kept, prefix)
+			}
+		}
+		prefixes = kept
+	}
+
+	// never append to the previous state's slice, it is still in use
+	prefixes = append([]string(nil), prefixes...)
+	for _, addition := range update.Additions {
+		if addition.RawHashes == nil {
+			return nil, fmt.Errorf("unsupported addition compression %q", addition.CompressionType)
+		}
+		raw, err := base64.StdEncoding.DecodeString(addition.RawHashes.RawHashes)
+		size := int(addition.RawHashes.PrefixSize)
+		if err != nil || size < 4 || size > sha256.Size || len(raw)%size != 0 {
+			return nil, fmt.Errorf("malformed additions")
+		}
+		for i := 0; i < len(raw); i += size {
+			prefixes = append(prefixes, string(raw[i:i+size]))
+		}
+	}
+	sort.Strings(prefixes)
+
+	if update.Checksum != nil {
+		want, err := base64.StdEncoding.DecodeString(update.Checksum.Sha256)
+		got := sha256.Sum256([]byte(strings.Join(prefixes, "")))
+		if err != nil || !bytes.Equal(want, got[:]) {
+			return nil, ErrChecksumMismatch
+		}
+	}
+
+	sizes := make(map[int]bool)
+	for _, prefix := range prefixes {
+		sizes[len(prefix)] = true
+	}
+	return &threatListState{prefixes: prefixes, sizes: sizes, clientState: update.NewClientState}, nil
+}
+
+func clientInfo() *safebrowsing.GoogleSecuritySafebrowsingV4ClientInfo {
+	return &safebrowsing.GoogleSecuritySafebrowsingV4ClientInfo{
+		ClientId:      "url-shortener",
+		ClientVersion: "1.0",
+	}
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
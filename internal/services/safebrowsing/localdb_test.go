@@ -0,0 +1,154 @@
+package safebrowsing_service_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/safebrowsing/v4"
+
+	safebrowsing_service "github.com/mfmahendr/url-shortener-backend/internal/services/safebrowsing"
+)
+
+// *--- MOCK DEFINITIONS ---* //
+// UPDATE API
+type MockUpdateAPI struct{ mock.Mock }
+
+func (m *MockUpdateAPI) FetchUpdates(ctx context.Context, req *safebrowsing.GoogleSecuritySafebrowsingV4FetchThreatListUpdatesRequest) (*safebrowsing.GoogleSecuritySafebrowsingV4FetchThreatListUpdatesResponse, error) {
+	args := m.Called(ctx, req)
+	resp, _ := args.Get(0).(*safebrowsing.GoogleSecuritySafebrowsingV4FetchThreatListUpdatesResponse)
+	return resp, args.Error(1)
+}
+
+func (m *MockUpdateAPI) FindFullHashes(ctx context.Context, req *safebrowsing.GoogleSecuritySafebrowsingV4FindFullHashesRequest) (*safebrowsing.GoogleSecuritySafebrowsingV4FindFullHashesResponse, error) {
+	args := m.Called(ctx, req)
+	resp, _ := args.Get(0).(*safebrowsing.GoogleSecuritySafebrowsingV4FindFullHashesResponse)
+	return resp, args.Error(1)
+}
+
+// loadFixture decodes a testdata file holding an API response. The lists in
+// full_update.json contain the 4-byte prefixes of "evil.example/",
+// "collide.example/" and three unrelated hashes (MALWARE) and of
+// "phish.example/login.html" (SOCIAL_ENGINEERING); full_hashes.json only
+// confirms evil.example and phish.example.
+func loadFixture[T any](t *testing.T, name string) *T {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	v := new(T)
+	require.NoError(t, json.Unmarshal(data, v))
+	return v
+}
+
+func updatedDatabase(t *testing.T) (*safebrowsing_service.LocalDatabase, *MockUpdateAPI) {
+	t.Helper()
+	api := new(MockUpdateAPI)
+	db := safebrowsing_service.NewLocalDatabase(api, safebrowsing_service.DefaultThreatLists)
+	require.False(t, db.Ready())
+
+	api.On("FetchUpdates", mock.Anything, mock.MatchedBy(func(req *safebrowsing.GoogleSecuritySafebrowsingV4FetchThreatListUpdatesRequest) bool {
+		return len(req.ListUpdateRequests) == 2 && req.ListUpdateRequests[0].State == ""
+	})).Return(loadFixture[safebrowsing.GoogleSecuritySafebrowsingV4FetchThreatListUpdatesResponse](t, "full_update.json"), nil).Once()
+	require.NoError(t, db.Update(context.Background()))
+	require.True(t, db.Ready())
+	return db, api
+}
+
+// *--- TEST CASES ---* //
+func TestLocalDatabase_Lookup(t *testing.T) {
+	fullHashes := func(t *testing.T) *safebrowsing.GoogleSecuritySafebrowsingV4FindFullHashesResponse {
+		return loadFixture[safebrowsing.GoogleSecuritySafebrowsingV4FindFullHashesResponse](t, "full_hashes.json")
+	}
+
+	t.Run("Prefix hits are confirmed with full hashes", func(t *testing.T) {
+		db, api := updatedDatabase(t)
+		api.On("FindFullHashes", mock.Anything, mock.MatchedBy(func(req *safebrowsing.GoogleSecuritySafebrowsingV4FindFullHashesRequest) bool {
+			return len(req.ThreatInfo.ThreatEntries) == 2 && len(req.ClientStates) == 2
+		})).Return(fullHashes(t), nil).Once()
+
+		threats, err := db.Lookup(context.Background(), []string{
+			"http://EVIL.example/some/path?q=1",
+			"https://phish.example/login.html#top",
+			"https://safe.example/",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"http://EVIL.example/some/path?q=1":    "MALWARE",
+			"https://phish.example/login.html#top": "SOCIAL_ENGINEERING",
+		}, threats)
+		api.AssertExpectations(t)
+	})
+
+	t.Run("URLs without prefix hits stay local", func(t *testing.T) {
+		db, api := updatedDatabase(t)
+
+		threats, err := db.Lookup(context.Background(), []string{"https://safe.example/", "https://phish.example/"})
+
+		require.NoError(t, err)
+		assert.Empty(t, threats)
+		api.AssertNotCalled(t, "FindFullHashes", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Prefix collisions are safe and negatively cached", func(t *testing.T) {
+		db, api := updatedDatabase(t)
+		api.On("FindFullHashes", mock.Anything, mock.Anything).Return(fullHashes(t), nil).Once()
+
+		for i := 0; i < 2; i++ {
+			threats, err := db.Lookup(context.Background(), []string{"http://collide.example/"})
+			require.NoError(t, err)
+			assert.Empty(t, threats)
+		}
+		api.AssertNumberOfCalls(t, "FindFullHashes", 1)
+	})
+
+	t.Run("Confirmed matches are cached", func(t *testing.T) {
+		db, api := updatedDatabase(t)
+		api.On("FindFullHashes", mock.Anything, mock.Anything).Return(fullHashes(t), nil).Once()
+
+		for i := 0; i < 2; i++ {
+			threats, err := db.Lookup(context.Background(), []string{"http://evil.example/"})
+			require.NoError(t, err)
+			assert.Equal(t, "MALWARE", threats["http://evil.example/"])
+		}
+		api.AssertNumberOfCalls(t, "FindFullHashes", 1)
+	})
+}
+
+func TestLocalDatabase_Update(t *testing.T) {
+	t.Run("Partial update removes and adds prefixes", func(t *testing.T) {
+		db, api := updatedDatabase(t)
+		api.On("FetchUpdates", mock.Anything, mock.MatchedBy(func(req *safebrowsing.GoogleSecuritySafebrowsingV4FetchThreatListUpdatesRequest) bool {
+			return req.ListUpdateRequests[0].State == "bWFsd2FyZS0x" && req.ListUpdateRequests[1].State == "c2UtMQ=="
+		})).Return(loadFixture[safebrowsing.GoogleSecuritySafebrowsingV4FetchThreatListUpdatesResponse](t, "partial_update.json"), nil).Once()
+		api.On("FindFullHashes", mock.Anything, mock.Anything).Return(&safebrowsing.GoogleSecuritySafebrowsingV4FindFullHashesResponse{}, nil).Once()
+
+		require.NoError(t, db.Update(context.Background()))
+		assert.True(t, db.Ready())
+
+		threats, err := db.Lookup(context.Background(), []string{"http://evil.example/"})
+		require.NoError(t, err)
+		assert.Empty(t, threats)
+		api.AssertNotCalled(t, "FindFullHashes", mock.Anything, mock.Anything)
+
+		_, err = db.Lookup(context.Background(), []string{"http://newevil.example/"})
+		require.NoError(t, err)
+		api.AssertNumberOfCalls(t, "FindFullHashes", 1)
+	})
+
+	t.Run("Checksum mismatch resets the list", func(t *testing.T) {
+		api := new(MockUpdateAPI)
+		db := safebrowsing_service.NewLocalDatabase(api, safebrowsing_service.DefaultThreatLists)
+		api.On("FetchUpdates", mock.Anything, mock.Anything).Return(loadFixture[safebrowsing.GoogleSecuritySafebrowsingV4FetchThreatListUpdatesResponse](t, "bad_checksum.json"), nil).Once()
+
+		err := db.Update(context.Background())
+
+		assert.ErrorIs(t, err, safebrowsing_service.ErrChecksumMismatch)
+		assert.False(t, db.Ready())
+	})
+}
@@ -6,12 +6,31 @@ import (
 
 type MockSafeBrowsingService struct {
 	UnsafeURLs map[string]bool
-	Err        error
+	// ThreatTypes optionally sets the threat type FindThreats reports for an
+	// unsafe URL; MALWARE is used otherwise.
+	ThreatTypes map[string]string
+	Err         error
+
+	// Requests holds the URLs of every FindThreats call.
+	Requests [][]string
 }
 
-func (m *MockSafeBrowsingService) IsUnsafe(ctx context.Context, targetURL string) (bool, error) {
+func (m *MockSafeBrowsingService) FindThreats(ctx context.Context, urls []string) (map[string]string, error) {
+	m.Requests = append(m.Requests, urls)
 	if m.Err != nil {
-		return false, m.Err
+		return nil, m.Err
+	}
+
+	threats := make(map[string]string)
+	for _, u := range urls {
+		if !m.UnsafeURLs[u] {
+			continue
+		}
+		threat := m.ThreatTypes[u]
+		if threat == "" {
+			threat = "MALWARE"
+		}
+		threats[u] = threat
 	}
-	return m.UnsafeURLs[targetURL], nil
+	return threats, nil
 }
@@ -0,0 +1,220 @@
+package safebrowsing_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	firestoreService "github.com/mfmahendr/url-shortener-backend/internal/services/firestore"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/notification_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/lease"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+)
+
+const (
+	defaultRescanInterval        = 24 * time.Hour
+	defaultRescanRequestInterval = time.Second
+)
+
+type RescanConfig struct {
+	// Interval between full passes over the shortlinks; 0 disables rescanning.
+	Interval time.Duration
+	// RequestInterval is the minimum time between two API calls, which keeps
+	// a pass over many links within the Safe Browsing quota.
+	RequestInterval time.Duration
+}
+
+type RescanResult struct {
+	Scanned int
+	Flagged int
+}
+
+// Rescanner periodically checks the destinations of existing links, which
+// are otherwise only checked when a link is created or edited. A Rescanner
+// is not safe for concurrent use; Run is meant to be its only caller.
+type Rescanner struct {
+	links    firestoreService.LinkStatus
+	checker  ThreatFinder
+	notifier notification_service.Notifier
+	config   RescanConfig
+	// lease makes only one instance run each pass.
+	lease *lease.Lease
+
+	// resumeAfter is where the next pass starts when the last one was cut
+	// short, e.g. by the quota.
+	resumeAfter string
+	lastRequest time.Time
+}
+
+// LoadRescanConfig reads SAFE_BROWSING_RESCAN_INTERVAL (default 24h, "0"
+// disables) and SAFE_BROWSING_RESCAN_REQUEST_INTERVAL (default 1s).
+// Rescanning is off without SAFE_BROWSING_API_KEY.
+func LoadRescanConfig() RescanConfig {
+	cfg := RescanConfig{Interval: defaultRescanInterval, RequestInterval: defaultRescanRequestInterval}
+
+	if v := strings.TrimSpace(os.Getenv("SAFE_BROWSING_RESCAN_INTERVAL")); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			cfg.Interval = d
+		} else {
+			log.Printf("Invalid SAFE_BROWSING_RESCAN_INTERVAL %q, using %s", v, defaultRescanInterval)
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("SAFE_BROWSING_RESCAN_REQUEST_INTERVAL")); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			cfg.RequestInterval = d
+		} else {
+			log.Printf("Invalid SAFE_BROWSING_RESCAN_REQUEST_INTERVAL %q, using %s", v, defaultRescanRequestInterval)
+		}
+	}
+	if os.Getenv("SAFE_BROWSING_API_KEY") == "" {
+		cfg.Interval = 0
+	}
+	return cfg
+}
+
+func NewRescanner(links firestoreService.LinkStatus, checker ThreatFinder, notifier notification_service.Notifier, config RescanConfig, lease *lease.Lease) *Rescanner {
+	return &Rescanner{links: links, checker: checker, notifier: notifier, config: config, lease: lease}
+}
+
+// Run scans all links once and then on each interval until ctx is done.
+// With several instances, each pass runs on the one holding the lease.
+func (r *Rescanner) Run(ctx context.Context) {
+	if r.config.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(r.lease.Every(r.config.Interval))
+	defer ticker.Stop()
+	for {
+		_, err := r.lease.Do(ctx, "safebrowsing-rescan", r.config.Interval, func(ctx context.Context) {
+			res, err := r.ScanAll(ctx)
+			if err != nil {
+				log.Printf("Safe Browsing rescan stopped after %d URLs (%d flagged), resuming next run: %v", res.Scanned, res.Flagged, err)
+			} else {
+				log.Printf("Safe Browsing rescan done: %d URLs checked, %d links flagged", res.Scanned, res.Flagged)
+			}
+		})
+		if err != nil {
+			log.Printf("Failed to take the Safe Browsing rescan lease: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ScanAll walks the shortlinks in pages of MaxURLsPerRequest and disables
+// every enabled link with a destination, its URL or one of its redirect rule
+// URLs, that is now reported as unsafe. If a pass fails, the next one picks
+// up at the page that failed.
+func (r *Rescanner) ScanAll(ctx context.Context) (*RescanResult, error) {
+	res := &RescanResult{}
+	after := r.resumeAfter
+	for {
+		links, err := r.links.ListShortlinksPage(ctx, after, MaxURLsPerRequest)
+		if err == nil {
+			err = r.scanPage(ctx, links, res)
+		}
+		if err != nil {
+			r.resumeAfter = after
+			return res, err
+		}
+
+		if len(links) < MaxURLsPerRequest {
+			r.resumeAfter = ""
+			return res, nil
+		}
+		after = links[len(links)-1].Key()
+	}
+}
+
+func (r *Rescanner) scanPage(ctx context.Context, links []models.Shortlink, res *RescanResult) error {
+	seen := make(map[string]bool, len(links))
+	urls := make([]string, 0, len(links))
+	for _, link := range links {
+		if link.Disabled {
+			continue
+		}
+		for _, destination := range link.Destinations() {
+			if !seen[destination] {
+				seen[destination] = true
+				urls = append(urls, destination)
+			}
+		}
+	}
+
+	// links with rules can make a page hold more URLs than one request takes
+	threats := make(map[string]string)
+	for start := 0; start < len(urls); start += MaxURLsPerRequest {
+		batch := urls[start:min(start+MaxURLsPerRequest, len(urls))]
+		if err := r.throttle(ctx); err != nil {
+			return err
+		}
+		found, err := r.checker.FindThreats(ctx, batch)
+		if err != nil {
+			return err
+		}
+		res.Scanned += len(batch)
+		for u, threat := range found {
+			threats[u] = threat
+		}
+	}
+
+	var notifications []models.Notification
+	for _, link := range links {
+		if link.Disabled {
+			continue
+		}
+		threat, unsafe := "", false
+		for _, destination := range link.Destinations() {
+			if threat, unsafe = threats[destination]; unsafe {
+				break
+			}
+		}
+		if !unsafe {
+			continue
+		}
+
+		err := r.links.FlagShortlinkUnsafe(ctx, link.Key(), threat)
+		if errors.Is(err, shortlink_errors.ErrNotFound) {
+			continue // deleted while we were scanning
+		}
+		if err != nil {
+			return err
+		}
+		res.Flagged++
+		notifications = append(notifications, models.Notification{
+			UID:     link.CreatedBy,
+			Type:    models.NotificationLinkDisabled,
+			ShortID: link.Key(),
+			Message: fmt.Sprintf("Your short link %q was disabled because Safe Browsing reports its destination as unsafe (%s).", link.Key(), threat),
+		})
+	}
+
+	if len(notifications) > 0 {
+		if err := r.notifier.Notify(ctx, notifications); err != nil {
+			log.Printf("Failed to notify owners about %d unsafe links: %v", len(notifications), err)
+		}
+	}
+	return nil
+}
+
+func (r *Rescanner) throttle(ctx context.Context) error {
+	if wait := r.config.RequestInterval - time.Since(r.lastRequest); wait > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+	r.lastRequest = time.Now()
+	return nil
+}
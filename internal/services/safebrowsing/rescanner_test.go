@@ -0,0 +1,147 @@
+package safebrowsing_service_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	safebrowsing_service "github.com/mfmahendr/url-shortener-backend/internal/services/safebrowsing"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+)
+
+// *--- MOCK DEFINITIONS ---* //
+// Firestore link status SERVICE
+type MockLinkStatus struct{ mock.Mock }
+
+func (m *MockLinkStatus) ListShortlinksPage(ctx context.Context, afterShortID string, limit int) ([]models.Shortlink, error) {
+	args := m.Called(ctx, afterShortID, limit)
+	return args.Get(0).([]models.Shortlink), args.Error(1)
+}
+
+func (m *MockLinkStatus) ListShortlinksByDisabledRule(ctx context.Context, rule string) ([]models.Shortlink, error) {
+	args := m.Called(ctx, rule)
+	return args.Get(0).([]models.Shortlink), args.Error(1)
+}
+
+func (m *MockLinkStatus) SetShortlinkDisabled(ctx context.Context, shortID string, disabled bool, reason, rule string) error {
+	args := m.Called(ctx, shortID, disabled, reason, rule)
+	return args.Error(0)
+}
+
+func (m *MockLinkStatus) FlagShortlinkUnsafe(ctx context.Context, shortID, threatType string) error {
+	args := m.Called(ctx, shortID, threatType)
+	return args.Error(0)
+}
+
+// NOTIFIER
+type MockNotifier struct{ mock.Mock }
+
+func (m *MockNotifier) Notify(ctx context.Context, notifications []models.Notification) error {
+	args := m.Called(ctx, notifications)
+	return args.Error(0)
+}
+
+func linkPage(from, to int) []models.Shortlink {
+	links := make([]models.Shortlink, 0, to-from)
+	for i := from; i < to; i++ {
+		links = append(links, models.Shortlink{
+			ShortID:   fmt.Sprintf("id%04d", i),
+			URL:       fmt.Sprintf("https://site-%d.example.com", i),
+			CreatedBy: "owner",
+		})
+	}
+	return links
+}
+
+// *--- TEST CASES ---* //
+func TestRescanner_ScanAll(t *testing.T) {
+	t.Run("Flags unsafe links in batches of at most 500 URLs", func(t *testing.T) {
+		links := new(MockLinkStatus)
+		notifier := new(MockNotifier)
+		checker := &safebrowsing_service.MockSafeBrowsingService{
+			UnsafeURLs:  map[string]bool{"https://site-700.example.com": true, "https://site-3.example.com": true},
+			ThreatTypes: map[string]string{"https://site-700.example.com": "SOCIAL_ENGINEERING"},
+		}
+		scanner := safebrowsing_service.NewRescanner(links, checker, notifier, safebrowsing_service.RescanConfig{}, nil)
+
+		first := linkPage(0, 500)
+		first[3].Disabled = true // already disabled, not checked again
+		links.On("ListShortlinksPage", mock.Anything, "", 500).Return(first, nil).Once()
+		links.On("ListShortlinksPage", mock.Anything, "id0499", 500).Return(linkPage(500, 1000), nil).Once()
+		links.On("ListShortlinksPage", mock.Anything, "id0999", 500).Return(linkPage(1000, 1200), nil).Once()
+		links.On("FlagShortlinkUnsafe", mock.Anything, "id0700", "SOCIAL_ENGINEERING").Return(nil).Once()
+		notifier.On("Notify", mock.Anything, mock.MatchedBy(func(n []models.Notification) bool {
+			return len(n) == 1 && n[0].ShortID == "id0700" && n[0].UID == "owner" && n[0].Type == models.NotificationLinkDisabled
+		})).Return(nil).Once()
+
+		res, err := scanner.ScanAll(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1199, res.Scanned)
+		assert.Equal(t, 1, res.Flagged)
+
+		require.Len(t, checker.Requests, 3)
+		for _, req := range checker.Requests {
+			assert.LessOrEqual(t, len(req), safebrowsing_service.MaxURLsPerRequest)
+		}
+		links.AssertExpectations(t)
+		notifier.AssertExpectations(t)
+	})
+
+	t.Run("Checks the URLs of redirect rules", func(t *testing.T) {
+		links := new(MockLinkStatus)
+		notifier := new(MockNotifier)
+		checker := &safebrowsing_service.MockSafeBrowsingService{
+			UnsafeURLs:  map[string]bool{"https://apps.example.net/bad": true},
+			ThreatTypes: map[string]string{"https://apps.example.net/bad": "MALWARE"},
+		}
+		scanner := safebrowsing_service.NewRescanner(links, checker, notifier, safebrowsing_service.RescanConfig{}, nil)
+
+		page := linkPage(0, 2)
+		page[1].Rules = []models.RedirectRule{{ID: "ios", URL: "https://apps.example.net/bad", Devices: []string{models.DeviceIOS}}}
+		links.On("ListShortlinksPage", mock.Anything, "", 500).Return(page, nil).Once()
+		links.On("FlagShortlinkUnsafe", mock.Anything, "id0001", "MALWARE").Return(nil).Once()
+		notifier.On("Notify", mock.Anything, mock.Anything).Return(nil).Once()
+
+		res, err := scanner.ScanAll(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 3, res.Scanned)
+		assert.Equal(t, 1, res.Flagged)
+		links.AssertExpectations(t)
+	})
+
+	t.Run("Resumes where the quota stopped the last pass", func(t *testing.T) {
+		links := new(MockLinkStatus)
+		checker := &quotaChecker{remaining: 1}
+		scanner := safebrowsing_service.NewRescanner(links, checker, new(MockNotifier), safebrowsing_service.RescanConfig{}, nil)
+
+		links.On("ListShortlinksPage", mock.Anything, "", 500).Return(linkPage(0, 500), nil).Once()
+		links.On("ListShortlinksPage", mock.Anything, "id0499", 500).Return(linkPage(500, 600), nil).Twice()
+
+		res, err := scanner.ScanAll(context.Background())
+		assert.ErrorIs(t, err, shortlink_errors.ErrQuotaExceeded)
+		assert.Equal(t, 500, res.Scanned)
+
+		// the next pass starts with the page that failed, not from the top
+		checker.remaining = 1
+		res, err = scanner.ScanAll(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 100, res.Scanned)
+		links.AssertExpectations(t)
+	})
+}
+
+// quotaChecker reports every URL as safe until its quota runs out.
+type quotaChecker struct{ remaining int }
+
+func (q *quotaChecker) FindThreats(ctx context.Context, urls []string) (map[string]string, error) {
+	if q.remaining == 0 {
+		return nil, shortlink_errors.ErrQuotaExceeded
+	}
+	q.remaining--
+	return map[string]string{}, nil
+}
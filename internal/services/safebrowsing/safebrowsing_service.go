@@ -2,89 +2,222 @@ package safebrowsing_service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
-	"github.com/mfmahendr/url-shortener-backend/internal/utils/validators"
 	"github.com/redis/go-redis/v9"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
 	"google.golang.org/api/safebrowsing/v4"
 )
 
-type URLSafetyChecker interface {
-	IsUnsafe(ctx context.Context, targetURL string) (bool, error)
+// MaxURLsPerRequest is the most threat entries a single threatMatches.find
+// call accepts.
+const MaxURLsPerRequest = 500
+
+// CacheTTL is how long a lookup result is reused.
+const CacheTTL = 24 * time.Hour
+
+// ThreatFinder checks many URLs at once; it is used to rescan existing links.
+type ThreatFinder interface {
+	// FindThreats returns the threat type of every unsafe URL in urls.
+	FindThreats(ctx context.Context, urls []string) (map[string]string, error)
+}
+
+// Mode selects how URLs are checked: ModeLookup asks the API about every
+// URL not in the cache, ModeUpdate matches them against a local copy of the
+// threat lists.
+type Mode string
+
+const (
+	ModeLookup Mode = "lookup"
+	ModeUpdate Mode = "update"
+)
+
+// DefaultThreatTypes are checked unless SAFE_BROWSING_THREAT_TYPES says
+// otherwise.
+var DefaultThreatTypes = []string{"MALWARE", "SOCIAL_ENGINEERING"}
+
+type Config struct {
+	Mode Mode
+	// ThreatTypes are the Safe Browsing threat types that make a URL unsafe.
+	ThreatTypes []string
 }
 
 type SafeBrowsingServiceImpl struct {
 	apiKey  string
 	service *safebrowsing.Service
 	redis   *redis.Client
+	threatTypes []string
+	// local is only set in ModeUpdate; until its first update completes,
+	// URLs are checked remotely.
+	local *LocalDatabase
 }
 
-func New(ctx context.Context, apiKey string, redis *redis.Client) URLSafetyChecker {
-	service, _ := safebrowsing.NewService(ctx, option.WithAPIKey(apiKey))
+// LoadConfig reads SAFE_BROWSING_MODE ("lookup" or "update", default
+// lookup) and SAFE_BROWSING_THREAT_TYPES (comma separated, default
+// MALWARE,SOCIAL_ENGINEERING).
+func LoadConfig() Config {
+	cfg := Config{Mode: ModeLookup, ThreatTypes: DefaultThreatTypes}
 
-	return &SafeBrowsingServiceImpl{
+	switch v := Mode(strings.TrimSpace(os.Getenv("SAFE_BROWSING_MODE"))); v {
+	case "", ModeLookup:
+	case ModeUpdate:
+		cfg.Mode = ModeUpdate
+	default:
+		log.Printf("Invalid SAFE_BROWSING_MODE %q, using %s", v, ModeLookup)
+	}
+
+	if v := strings.TrimSpace(os.Getenv("SAFE_BROWSING_THREAT_TYPES")); v != "" {
+		var types []string
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.ToUpper(strings.TrimSpace(t)); t != "" {
+				types = append(types, t)
+			}
+		}
+		cfg.ThreatTypes = types
+	}
+	return cfg
+}
+
+func New(ctx context.Context, apiKey string, config Config, redis *redis.Client) (*SafeBrowsingServiceImpl, error) {
+	service, err := safebrowsing.NewService(ctx, option.WithAPIKey(apiKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Safe Browsing client: %w", err)
+	}
+
+	s := &SafeBrowsingServiceImpl{
 		apiKey:  apiKey,
 		service: service,
 		redis: redis,
+		threatTypes: config.ThreatTypes,
+	}
+	if config.Mode == ModeUpdate {
+		s.local = NewLocalDatabase(NewUpdateAPI(service), ThreatListsFor(config.ThreatTypes))
 	}
+	return s, nil
 }
 
-func (s *SafeBrowsingServiceImpl) IsUnsafe(ctx context.Context, targetURL string) (bool, error) {
-	if err := validators.Validate.Var(targetURL, "required,url"); err != nil {
-		return false, shortlink_errors.ErrValidateRequest
+// Run keeps the local threat lists up to date; it returns immediately in
+// lookup mode.
+func (s *SafeBrowsingServiceImpl) Run(ctx context.Context) {
+	if s.local == nil || s.apiKey == "" {
+		return
+	}
+	s.local.Run(ctx)
+}
+
+// FindThreats looks the URLs up in batches of MaxURLsPerRequest, skipping
+// those with a cached verdict. On error the threats found so far are
+// returned along with it.
+func (s *SafeBrowsingServiceImpl) FindThreats(ctx context.Context, urls []string) (map[string]string, error) {
+	if s.local != nil && s.local.Ready() {
+		return s.local.Lookup(ctx, urls)
 	}
 
-	// check cache
-	cacheKey := "safebrowsing:" + targetURL
-	cached, err := s.redis.Get(ctx, cacheKey).Result()
-	if err == nil {
-		log.Println("Check safebrowsing")
-		return cached == "unsafe", nil
+	threats := make(map[string]string)
+	if len(urls) == 0 {
+		return threats, nil
 	}
 
-	// check API
-	unsafe, err := s.requestSafeBrowsingChecking(targetURL)
+	keys := make([]string, len(urls))
+	for i, u := range urls {
+		keys[i] = cacheKeyFor(u)
+	}
+	cached, err := s.redis.MGet(ctx, keys...).Result()
 	if err != nil {
-		return false, err
+		cached = make([]interface{}, len(urls))
 	}
-	
-	// cache the URL for 24 hours
-	cacheVal := "safe"
-	if unsafe {
-		cacheVal = "unsafe"
+
+	var pending []string
+	for i, u := range urls {
+		switch v, _ := cached[i].(string); v {
+		case "":
+			pending = append(pending, u)
+		case "safe":
+		case "unsafe": // cached before threat types were stored
+			threats[u] = "THREAT_TYPE_UNSPECIFIED"
+		default:
+			threats[u] = v
+		}
 	}
-	_ = s.redis.Set(ctx, cacheKey, cacheVal, 24*time.Hour).Err()	// expires in 24 hours
 
-	return unsafe, nil
+	for start := 0; start < len(pending); start += MaxURLsPerRequest {
+		batch := pending[start:min(start+MaxURLsPerRequest, len(pending))]
+		found, err := s.requestSafeBrowsingChecking(ctx, batch)
+		if err != nil {
+			return threats, err
+		}
+		s.cacheResults(ctx, batch, found)
+		for u, threat := range found {
+			threats[u] = threat
+		}
+	}
+	return threats, nil
+}
+
+// cacheResults caches the URLs for 24 hours: "safe" or their threat type.
+func (s *SafeBrowsingServiceImpl) cacheResults(ctx context.Context, urls []string, threats map[string]string) {
+	pipe := s.redis.Pipeline()
+	for _, u := range urls {
+		cacheVal := "safe"
+		if threat, ok := threats[u]; ok {
+			cacheVal = threat
+		}
+		pipe.Set(ctx, cacheKeyFor(u), cacheVal, CacheTTL)
+	}
+	_, _ = pipe.Exec(ctx)
 }
 
-func (s *SafeBrowsingServiceImpl) requestSafeBrowsingChecking(targetURL string) (bool, error) {
+// cacheKeyFor keys the cache by canonical URL, so that spellings of the same
+// URL share one verdict.
+func cacheKeyFor(targetURL string) string {
+	if canonical, err := Canonicalize(targetURL); err == nil {
+		return "safebrowsing:" + canonical
+	}
+	return "safebrowsing:" + targetURL
+}
+
+func (s *SafeBrowsingServiceImpl) requestSafeBrowsingChecking(ctx context.Context, targetURLs []string) (map[string]string, error) {
+	entries := make([]*safebrowsing.GoogleSecuritySafebrowsingV4ThreatEntry, len(targetURLs))
+	for i, u := range targetURLs {
+		entries[i] = &safebrowsing.GoogleSecuritySafebrowsingV4ThreatEntry{Url: u}
+	}
+
 	req := &safebrowsing.GoogleSecuritySafebrowsingV4FindThreatMatchesRequest{
-		Client: &safebrowsing.GoogleSecuritySafebrowsingV4ClientInfo{
-			ClientId:      "url-shortener",
-			ClientVersion: "1.0",
-		},
+		Client: clientInfo(),
 		ThreatInfo: &safebrowsing.GoogleSecuritySafebrowsingV4ThreatInfo{
-			ThreatTypes:      []string{"MALWARE", "SOCIAL_ENGINEERING"},
+			ThreatTypes:      s.threatTypes,
 			PlatformTypes:    []string{"ANY_PLATFORM"},
 			ThreatEntryTypes: []string{"URL"},
-			ThreatEntries: []*safebrowsing.GoogleSecuritySafebrowsingV4ThreatEntry{
-				{Url: targetURL},
-			},
+			ThreatEntries:    entries,
 		},
 	}
 
-	response, err := s.service.ThreatMatches.Find(req).Do()
+	response, err := s.service.ThreatMatches.Find(req).Context(ctx).Do()
 	if err != nil {
-		return false, err
+		return nil, mapAPIError(err)
 	}
 
-	if len(response.Matches) > 0 {
-		return true, nil
+	threats := make(map[string]string, len(response.Matches))
+	for _, match := range response.Matches {
+		if match.Threat != nil {
+			threats[match.Threat.Url] = match.ThreatType
+		}
 	}
+	return threats, nil
+}
 
-	return false, nil
+func mapAPIError(err error) error {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusTooManyRequests {
+		return shortlink_errors.ErrQuotaExceeded
+	}
+	return err
 }
@@ -25,6 +25,8 @@ func (t *TrackingServiceImpl) GetAnalytics(ctx context.Context, req dto.ClickLog
 			Timestamp: l.Timestamp,
 			IP:        l.IP,
 			UserAgent: l.UserAgent,
+			Source:    l.Source,
+			RuleID:    l.RuleID,
 		})
 		count++
 	}
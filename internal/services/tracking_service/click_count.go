@@ -10,7 +10,7 @@ import (
 )
 
 func (t *TrackingServiceImpl) GetClickCount(ctx context.Context, shortID string) (int64, error) {
-	if err := validators.Validate.Var(shortID, "short_id"); err != nil {
+	if err := validators.Validate.Var(shortID, "link_id"); err != nil {
 		return 0, shortlink_errors.ErrValidateRequest
 	}
 
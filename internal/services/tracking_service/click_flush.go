@@ -0,0 +1,169 @@
+package tracking_service
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	firestoreService "github.com/mfmahendr/url-shortener-backend/internal/services/firestore"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/lease"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultClickFlushInterval = time.Minute
+	// clickFlushBatch is how many links are written at a time.
+	clickFlushBatch = 500
+
+	// pendingClicksKey holds the IDs of the links with pending clicks, whose
+	// count and last click time are kept under pendingClicksPrefix+ID.
+	pendingClicksKey    = "click_counts:pending"
+	pendingClicksPrefix = "click_counts:link:"
+)
+
+type FlushConfig struct {
+	Interval time.Duration
+}
+
+// ClickCountFlusher adds the clicks counted in Redis to the click_count and
+// last_clicked_at of the links. Writing them on every click would exceed
+// what Firestore allows for a single document on popular links.
+type ClickCountFlusher struct {
+	store  firestoreService.ClickCounter
+	redis  *redis.Client
+	config FlushConfig
+	// lease makes only one instance run each flush.
+	lease *lease.Lease
+}
+
+// LoadFlushConfig reads CLICK_COUNT_FLUSH_INTERVAL (default 1m).
+func LoadFlushConfig() FlushConfig {
+	cfg := FlushConfig{Interval: defaultClickFlushInterval}
+	if v := os.Getenv("CLICK_COUNT_FLUSH_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.Interval = d
+		} else {
+			log.Printf("Invalid CLICK_COUNT_FLUSH_INTERVAL %q, using %s", v, defaultClickFlushInterval)
+		}
+	}
+	return cfg
+}
+
+func NewClickCountFlusher(store firestoreService.ClickCounter, redis *redis.Client, config FlushConfig, lease *lease.Lease) *ClickCountFlusher {
+	return &ClickCountFlusher{store: store, redis: redis, config: config, lease: lease}
+}
+
+// Run flushes the pending clicks on each interval until ctx is done.
+func (f *ClickCountFlusher) Run(ctx context.Context) {
+	ticker := time.NewTicker(f.lease.Every(f.config.Interval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		_, err := f.lease.Do(ctx, "click-count-flush", f.config.Interval, func(ctx context.Context) {
+			if n, err := f.Flush(ctx); err != nil {
+				log.Printf("Click count flush stopped after %d links: %v", n, err)
+			}
+		})
+		if err != nil {
+			log.Printf("Failed to take the click count flush lease: %v", err)
+		}
+	}
+}
+
+// Flush writes all pending clicks and returns for how many links. Clicks
+// that could not be written stay pending for the next flush.
+func (f *ClickCountFlusher) Flush(ctx context.Context) (int, error) {
+	flushed := 0
+	for {
+		ids, err := f.redis.SPopN(ctx, pendingClicksKey, clickFlushBatch).Result()
+		if err != nil {
+			return flushed, err
+		}
+		if len(ids) == 0 {
+			return flushed, nil
+		}
+
+		counts, err := takePendingClicks(ctx, f.redis, ids)
+		if err != nil {
+			// the counts are still there, only the IDs have to be put back
+			f.redis.SAdd(ctx, pendingClicksKey, ids)
+			return flushed, err
+		}
+
+		results, err := f.store.AddClickCounts(ctx, counts)
+		if err != nil {
+			results = make([]error, len(counts))
+			for i := range results {
+				results[i] = err
+			}
+		}
+		for i, c := range counts {
+			if results[i] != nil {
+				log.Printf("Failed to flush the click count of %s: %v", c.ShortID, results[i])
+				if err := addPendingClicks(ctx, f.redis, c); err != nil {
+					log.Printf("Lost %d clicks of %s: %v", c.Clicks, c.ShortID, err)
+				}
+				continue
+			}
+			flushed++
+		}
+	}
+}
+
+// addPendingClick counts a click of shortID at at for the next flush.
+func addPendingClick(ctx context.Context, rdb *redis.Client, shortID string, at time.Time) error {
+	_, err := rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HIncrBy(ctx, pendingClicksPrefix+shortID, "clicks", 1)
+		pipe.HSet(ctx, pendingClicksPrefix+shortID, "last", at.UnixNano())
+		pipe.SAdd(ctx, pendingClicksKey, shortID)
+		return nil
+	})
+	return err
+}
+
+// addPendingClicks puts counts back after a failed flush. A click counted
+// meanwhile has a later time, which is kept.
+func addPendingClicks(ctx context.Context, rdb *redis.Client, c models.ClickCounts) error {
+	_, err := rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HIncrBy(ctx, pendingClicksPrefix+c.ShortID, "clicks", c.Clicks)
+		pipe.HSetNX(ctx, pendingClicksPrefix+c.ShortID, "last", c.LastClickedAt.UnixNano())
+		pipe.SAdd(ctx, pendingClicksKey, c.ShortID)
+		return nil
+	})
+	return err
+}
+
+// takePendingClicks reads and removes the pending clicks of ids.
+func takePendingClicks(ctx context.Context, rdb *redis.Client, ids []string) ([]models.ClickCounts, error) {
+	cmds := make([]*redis.MapStringStringCmd, len(ids))
+	_, err := rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, id := range ids {
+			cmds[i] = pipe.HGetAll(ctx, pendingClicksPrefix+id)
+			pipe.Del(ctx, pendingClicksPrefix+id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]models.ClickCounts, 0, len(ids))
+	for i, id := range ids {
+		fields := cmds[i].Val()
+		clicks, _ := strconv.ParseInt(fields["clicks"], 10, 64)
+		if clicks <= 0 {
+			continue
+		}
+		last, _ := strconv.ParseInt(fields["last"], 10, 64)
+		counts = append(counts, models.ClickCounts{ShortID: id, Clicks: clicks, LastClickedAt: time.Unix(0, last)})
+	}
+	return counts, nil
+}
@@ -21,7 +21,7 @@ import (
 )
 
 func (s *TrackingServiceImpl) StreamClickLogs(ctx context.Context, w http.ResponseWriter, req dto.ClickLogsRequest) error {
-	if err := validators.Validate.Var(req.ShortID, "short_id"); err != nil {
+	if err := validators.Validate.Var(req.ShortID, "link_id"); err != nil {
 		return shortlink_errors.ErrValidateRequest
 	}
 
@@ -52,7 +52,7 @@ func (s *TrackingServiceImpl) StreamClickLogs(ctx context.Context, w http.Respon
 
 func (s *TrackingServiceImpl) streamForCSV(w io.Writer, iter *firestore.DocumentIterator) (err error) {
 	csvWriter := csv.NewWriter(w)
-	if err = csvWriter.Write([]string{"timestamp", "ip", "user_agent"}); err != nil {
+	if err = csvWriter.Write([]string{"timestamp", "ip", "user_agent", "source", "rule_id"}); err != nil {
 		return
 	}
 
@@ -79,6 +79,8 @@ func (s *TrackingServiceImpl) streamForCSV(w io.Writer, iter *firestore.Document
 			click.Timestamp.Format(time.RFC3339),
 			click.IP,
 			click.UserAgent,
+			click.Source,
+			click.RuleID,
 		}); err != nil {
 			log.Printf("Error writing to CSV: %v", err)
 			// return fmt.Errorf("failed to write to CSV: %w", err)
@@ -129,10 +131,14 @@ func (s *TrackingServiceImpl) streamForJSON(w io.Writer, iter *firestore.Documen
 			Timestamp string `json:"timestamp"`
 			IP        string `json:"ip"`
 			UserAgent string `json:"user_agent"`
+			Source    string `json:"source,omitempty"`
+			RuleID    string `json:"rule_id,omitempty"`
 		}{
 			Timestamp: click.Timestamp.Format(time.RFC3339),
 			IP:        click.IP,
 			UserAgent: click.UserAgent,
+			Source:    click.Source,
+			RuleID:    click.RuleID,
 		}); err != nil {
 			log.Printf("Error encoding JSON: %v", err)
 			break
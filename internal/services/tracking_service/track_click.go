@@ -10,8 +10,12 @@ import (
 	"github.com/mfmahendr/url-shortener-backend/internal/utils/validators"
 )
 
-func (t *TrackingServiceImpl) TrackClick(ctx context.Context, shortID, ip, userAgent string) error {
-	if err := validators.Validate.Var(shortID, "short_id"); err != nil {
+// TrackClick counts a click of shortID and logs it. source is the src query
+// parameter of the short URL; values other than the known click sources are
+// not recorded. ruleID is the redirect rule that picked the destination,
+// empty when the visitor went to the link's URL.
+func (t *TrackingServiceImpl) TrackClick(ctx context.Context, shortID, ip, userAgent, source, ruleID string) error {
+	if err := validators.Validate.Var(shortID, "link_id"); err != nil {
 		return shortlink_errors.ErrValidateRequest
 	}
 	// redis
@@ -25,6 +29,15 @@ func (t *TrackingServiceImpl) TrackClick(ctx context.Context, shortID, ip, userA
 		IP:        ip,
 		UserAgent: userAgent,
 		Timestamp: time.Now(),
+		RuleID:    ruleID,
+	}
+	// the link's own counters are written by the ClickCountFlusher; a click
+	// lost here only makes them a little low, so it does not fail the click
+	if err := addPendingClick(ctx, t.redis, shortID, clickLog.Timestamp); err != nil {
+		log.Printf("failed to queue click count short_id (%s) err: %v", shortID, err)
+	}
+	if models.IsClickSource(source) {
+		clickLog.Source = source
 	}
 
 	// save to firestore
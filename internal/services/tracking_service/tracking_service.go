@@ -12,7 +12,7 @@ import (
 type (
 	TrackingService interface {
 		GetClickCount(ctx context.Context, shortID string) (int64, error)
-		TrackClick(ctx context.Context, shortID, ip, userAgent string) error
+		TrackClick(ctx context.Context, shortID, ip, userAgent, source, ruleID string) error
 		StreamClickLogs(ctx context.Context, w http.ResponseWriter, req dto.ClickLogsRequest) error
 		GetAnalytics(ctx context.Context, req dto.ClickLogsRequest) (*dto.AnalyticsDTO, error)
 	}
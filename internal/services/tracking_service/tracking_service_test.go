@@ -61,13 +61,55 @@ func TestTrackingService_TrackClick(t *testing.T) {
 		redisMock.ExpectIncr("clicks:" + shortID).SetVal(1)
 		store.On("AddClickLog", ctx, mock.Anything).Return(nil)
 
-		err := svc.TrackClick(ctx, shortID, "127.0.0.1", "Mozilla")
+		err := svc.TrackClick(ctx, shortID, "127.0.0.1", "Mozilla", "", "")
 		assert.NoError(t, err)
 		store.AssertExpectations(t)
 	})
 
+	t.Run("Records known sources only", func(t *testing.T) {
+		ctx := context.Background()
+		store := new(MockClickLogStore)
+		svc := tracking_service.New(store, db)
+		for src, want := range map[string]string{"qr": models.ClickSourceQR, "newsletter": ""} {
+			redisMock.ExpectIncr("clicks:src123").SetVal(1)
+			store.On("AddClickLog", ctx, mock.MatchedBy(func(l *models.ClickLog) bool {
+				return l.ShortID == "src123" && l.Source == want
+			})).Return(nil).Once()
+
+			require.NoError(t, svc.TrackClick(ctx, "src123", "127.0.0.1", "Mozilla", src, ""))
+		}
+		store.AssertExpectations(t)
+	})
+
+	t.Run("Records the matched rule", func(t *testing.T) {
+		ctx := context.Background()
+		store := new(MockClickLogStore)
+		svc := tracking_service.New(store, db)
+
+		redisMock.ExpectIncr("clicks:rule123").SetVal(1)
+		store.On("AddClickLog", ctx, mock.MatchedBy(func(l *models.ClickLog) bool {
+			return l.RuleID == "ios"
+		})).Return(nil)
+
+		require.NoError(t, svc.TrackClick(ctx, "rule123", "127.0.0.1", "iPhone", "", "ios"))
+		store.AssertExpectations(t)
+	})
+
+	t.Run("Failing to queue the link's click count does not fail the click", func(t *testing.T) {
+		ctx := context.Background()
+		store := new(MockClickLogStore)
+		svc := tracking_service.New(store, db)
+
+		// only the total is expected, so queueing for the flush fails
+		redisMock.ExpectIncr("clicks:queue123").SetVal(1)
+		store.On("AddClickLog", ctx, mock.Anything).Return(nil).Once()
+
+		require.NoError(t, svc.TrackClick(ctx, "queue123", "127.0.0.1", "Mozilla", "", ""))
+		store.AssertExpectations(t)
+	})
+
 	t.Run("Invalid ShortID", func(t *testing.T) {
-		err := svc.TrackClick(context.Background(), "", "127.0.0.1", "Mozilla")
+		err := svc.TrackClick(context.Background(), "", "127.0.0.1", "Mozilla", "", "")
 		require.Error(t, err)
 		assert.Equal(t, shortlink_errors.ErrValidateRequest, err)
 	})
@@ -8,7 +8,7 @@ import (
 )
 
 func (s *URLServiceImpl) IsOwner(ctx context.Context, shortID string, uid string) (bool, error) {
-	if err := validators.Validate.Var(shortID, "short_id"); err != nil {
+	if err := validators.Validate.Var(shortID, "link_id"); err != nil {
 		return false, shortlink_errors.ErrValidateRequest
 	}
 
@@ -0,0 +1,221 @@
+package url_service
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+	val "github.com/mfmahendr/url-shortener-backend/internal/utils/validators"
+)
+
+// Tags are managed per scope: the caller's personal links, or the links of a
+// workspace when workspaceID is set.
+
+// ListTags returns every tag of the scope with how many links have it and
+// their clicks, most used first.
+func (s *URLServiceImpl) ListTags(ctx context.Context, workspaceID string) ([]dto.TagSummary, error) {
+	user, err := s.tagScope(ctx, workspaceID, models.PermissionView)
+	if err != nil {
+		return nil, err
+	}
+
+	links, err := s.shortlink.ListTaggedLinks(ctx, user, workspaceID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	byTag := make(map[string]*dto.TagSummary)
+	for i := range links {
+		for _, tag := range links[i].Tags {
+			summary, ok := byTag[tag]
+			if !ok {
+				summary = &dto.TagSummary{Tag: tag}
+				byTag[tag] = summary
+			}
+			addToTagSummary(summary, &links[i])
+		}
+	}
+
+	tags := make([]dto.TagSummary, 0, len(byTag))
+	for _, summary := range byTag {
+		tags = append(tags, *summary)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Links != tags[j].Links {
+			return tags[i].Links > tags[j].Links
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+	return tags, nil
+}
+
+// TagAnalytics adds up the clicks of the links with tag, so the links of a
+// campaign can be looked at together.
+func (s *URLServiceImpl) TagAnalytics(ctx context.Context, workspaceID, tag string) (*dto.TagAnalyticsResponse, error) {
+	tag, err := normalizeTag(tag)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.tagScope(ctx, workspaceID, models.PermissionView)
+	if err != nil {
+		return nil, err
+	}
+
+	links, err := s.shortlink.ListTaggedLinks(ctx, user, workspaceID, tag)
+	if err != nil {
+		return nil, err
+	}
+	if len(links) == 0 {
+		return nil, shortlink_errors.ErrNotFound
+	}
+
+	resp := &dto.TagAnalyticsResponse{
+		TagSummary: dto.TagSummary{Tag: tag},
+		LinkStats:  make([]dto.TagLinkStats, 0, len(links)),
+	}
+	for i := range links {
+		l := &links[i]
+		addToTagSummary(&resp.TagSummary, l)
+		stats := dto.TagLinkStats{ShortID: l.ShortID, Domain: l.Domain, Title: l.Title, ClickCount: l.ClickCount}
+		if !l.LastClickedAt.IsZero() {
+			stats.LastClickedAt = &l.LastClickedAt
+		}
+		resp.LinkStats = append(resp.LinkStats, stats)
+	}
+	sort.SliceStable(resp.LinkStats, func(i, j int) bool {
+		return resp.LinkStats[i].ClickCount > resp.LinkStats[j].ClickCount
+	})
+	return resp, nil
+}
+
+// RenameTag replaces tag with the new name on every link of the scope and
+// returns the summary of those links. Links that already have the new name
+// keep it once, so renaming merges two tags.
+func (s *URLServiceImpl) RenameTag(ctx context.Context, workspaceID, tag string, req dto.RenameTagRequest) (*dto.TagSummary, error) {
+	if err := val.Validate.Struct(req); err != nil {
+		return nil, shortlink_errors.ErrValidateRequest
+	}
+	tag, err := normalizeTag(tag)
+	if err != nil {
+		return nil, err
+	}
+	newTag := strings.ToLower(req.Name)
+
+	summary, err := s.retagLinks(ctx, workspaceID, tag, func(tags []string) []string {
+		renamed := make([]string, len(tags))
+		for i, t := range tags {
+			if t == tag {
+				t = newTag
+			}
+			renamed[i] = t
+		}
+		return models.NormalizeTags(renamed)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.recordTag(ctx, models.AuditTagRename, tag,
+		map[string]interface{}{"tag": tag, "workspace_id": workspaceID, "links": summary.Links},
+		map[string]interface{}{"tag": newTag, "workspace_id": workspaceID, "links": summary.Links},
+	)
+
+	summary.Tag = newTag
+	return summary, nil
+}
+
+// DeleteTag removes tag from every link of the scope; the links stay.
+func (s *URLServiceImpl) DeleteTag(ctx context.Context, workspaceID, tag string) error {
+	tag, err := normalizeTag(tag)
+	if err != nil {
+		return err
+	}
+
+	summary, err := s.retagLinks(ctx, workspaceID, tag, func(tags []string) []string {
+		kept := make([]string, 0, len(tags))
+		for _, t := range tags {
+			if t != tag {
+				kept = append(kept, t)
+			}
+		}
+		return kept
+	})
+	if err != nil {
+		return err
+	}
+	s.recordTag(ctx, models.AuditTagDelete, tag,
+		map[string]interface{}{"tag": tag, "workspace_id": workspaceID, "links": summary.Links}, nil)
+	return nil
+}
+
+// retagLinks applies change to the tags of every link of the scope with tag
+// and returns the summary of the links it changed.
+func (s *URLServiceImpl) retagLinks(ctx context.Context, workspaceID, tag string, change func([]string) []string) (*dto.TagSummary, error) {
+	user, err := s.tagScope(ctx, workspaceID, models.PermissionEdit)
+	if err != nil {
+		return nil, err
+	}
+
+	links, err := s.shortlink.ListTaggedLinks(ctx, user, workspaceID, tag)
+	if err != nil {
+		return nil, err
+	}
+	if len(links) == 0 {
+		return nil, shortlink_errors.ErrNotFound
+	}
+
+	summary := &dto.TagSummary{Tag: tag}
+	updates := make(map[string][]string, len(links))
+	for i := range links {
+		updates[links[i].Key()] = change(links[i].Tags)
+		addToTagSummary(summary, &links[i])
+	}
+	if err := s.shortlink.SetLinkTags(ctx, updates); err != nil {
+		log.Printf("Failed to update tag %q: %v", tag, err)
+		return nil, shortlink_errors.ErrSaveShortlink
+	}
+	return summary, nil
+}
+
+// tagScope returns the caller, who must hold perm in workspaceID if it is set.
+func (s *URLServiceImpl) tagScope(ctx context.Context, workspaceID string, perm models.Permission) (string, error) {
+	user, ok := ctx.Value(utils.UserKey).(string)
+	if !ok || user == "" {
+		return "", shortlink_errors.ErrForbidden
+	}
+	if workspaceID != "" {
+		if err := s.checkWorkspacePermission(ctx, workspaceID, perm); err != nil {
+			return "", err
+		}
+	}
+	return user, nil
+}
+
+func (s *URLServiceImpl) recordTag(ctx context.Context, action, tag string, before, after map[string]interface{}) {
+	if err := s.audit.Record(ctx, action, models.AuditTargetTag, tag, before, after); err != nil {
+		log.Printf("Failed to record audit log for %s %s: %v", action, tag, err)
+	}
+}
+
+func normalizeTag(tag string) (string, error) {
+	if err := val.Validate.Var(tag, "tag"); err != nil {
+		return "", shortlink_errors.ErrValidateRequest
+	}
+	return strings.ToLower(tag), nil
+}
+
+func addToTagSummary(summary *dto.TagSummary, link *models.Shortlink) {
+	summary.Links++
+	summary.TotalClicks += link.ClickCount
+	if link.LastClickedAt.IsZero() {
+		return
+	}
+	if summary.LastClickedAt == nil || link.LastClickedAt.After(*summary.LastClickedAt) {
+		lastClickedAt := link.LastClickedAt
+		summary.LastClickedAt = &lastClickedAt
+	}
+}
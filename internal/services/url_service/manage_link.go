@@ -0,0 +1,248 @@
+package url_service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/urlcanon"
+	val "github.com/mfmahendr/url-shortener-backend/internal/utils/validators"
+)
+
+func (s *URLServiceImpl) UpdateShortlink(ctx context.Context, shortID string, req dto.UpdateShortlinkRequest) (*dto.ShortlinkDTO, error) {
+	if err := val.Validate.Struct(req); err != nil {
+		return nil, shortlink_errors.ErrValidateRequest
+	}
+
+	link, err := s.authorizeCurrentUser(ctx, shortID, models.PermissionEdit)
+	if err != nil {
+		return nil, err
+	}
+	before := shortlinkAuditState(link)
+
+	// checks that go over the network are done before the write, which then
+	// applies only the requested changes to the link as stored
+	var canonicalURL string
+	urlChanged := req.URL != nil && *req.URL != link.URL
+	if urlChanged {
+		canonicalURL, err = urlcanon.Canonicalize(*req.URL, s.config.Canon)
+		if err != nil {
+			return nil, shortlink_errors.ErrValidateRequest
+		}
+		if err := s.validateURL(ctx, *req.URL); err != nil {
+			return nil, err
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.IsZero() && !req.ExpiresAt.After(time.Now()) {
+		return nil, shortlink_errors.ErrValidateRequest
+	}
+	var rules []models.RedirectRule
+	if req.Rules != nil {
+		if rules, err = newRedirectRules(*req.Rules); err != nil {
+			return nil, err
+		}
+		if err := s.checkRuleURLs(ctx, *req.Rules); err != nil {
+			return nil, err
+		}
+	}
+
+	// a new URL re-enables a disabled link only if every destination the link
+	// ends up with passes the checks, including the rule URLs it keeps
+	passed := make(map[string]bool)
+	if urlChanged {
+		passed[*req.URL] = true
+		if req.Rules != nil {
+			for _, r := range *req.Rules {
+				passed[r.URL] = true
+			}
+		} else if link.Disabled {
+			for _, r := range link.Rules {
+				if _, ok := passed[r.URL]; !ok {
+					passed[r.URL] = s.validateURL(ctx, r.URL) == nil
+				}
+			}
+		}
+	}
+
+	updated, err := s.shortlink.UpdateShortlink(ctx, shortID, func(link *models.Shortlink) error {
+		if urlChanged {
+			link.URL, link.CanonicalURL = *req.URL, canonicalURL
+			link.DestinationHost = destinationHost(canonicalURL)
+		}
+		if req.IsPrivate != nil {
+			link.IsPrivate = *req.IsPrivate
+		}
+		if req.Title != nil {
+			link.Title = *req.Title
+		}
+		if req.Notes != nil {
+			link.Notes = *req.Notes
+		}
+		if req.Tags != nil {
+			link.Tags = models.NormalizeTags(*req.Tags)
+		}
+		if req.Preview != nil {
+			link.Preview = *req.Preview
+		}
+		if req.ExpiresAt != nil {
+			link.ExpiresAt = *req.ExpiresAt
+		}
+		if req.Rules != nil {
+			link.Rules = rules
+		}
+		if urlChanged && allPassed(link.Destinations(), passed) {
+			link.Disabled, link.DisabledReason, link.DisabledRule = false, "", ""
+			link.DisabledAt, link.ThreatType = time.Time{}, ""
+		}
+		return nil
+	})
+	if err == shortlink_errors.ErrNotFound {
+		return nil, err
+	}
+	if err != nil {
+		return nil, shortlink_errors.ErrSaveShortlink
+	}
+	s.record(ctx, models.AuditLinkUpdate, shortID, before, shortlinkAuditState(updated))
+
+	return toShortlinkDTO(updated), nil
+}
+
+// allPassed reports whether every destination is marked as passed.
+func allPassed(destinations []string, passed map[string]bool) bool {
+	for _, d := range destinations {
+		if !passed[d] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *URLServiceImpl) DeleteShortlink(ctx context.Context, shortID string) error {
+	link, err := s.authorizeCurrentUser(ctx, shortID, models.PermissionManage)
+	if err != nil {
+		return err
+	}
+
+	if err := s.shortlink.DeleteShortlink(ctx, shortID); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditLinkDelete, shortID, shortlinkAuditState(link), nil)
+
+	return nil
+}
+
+// TransferShortlink moves a link into another workspace and/or hands it to
+// another user. The new owner has to be able to edit links in the workspace
+// the link ends up in, so personal links can only change hands through a
+// shared workspace.
+func (s *URLServiceImpl) TransferShortlink(ctx context.Context, shortID string, req dto.TransferShortlinkRequest) (*dto.ShortlinkDTO, error) {
+	if err := val.Validate.Struct(req); err != nil {
+		return nil, shortlink_errors.ErrValidateRequest
+	}
+
+	link, err := s.authorizeCurrentUser(ctx, shortID, models.PermissionManage)
+	if err != nil {
+		return nil, err
+	}
+	before := shortlinkAuditState(link)
+
+	if req.WorkspaceID != "" && req.WorkspaceID != link.WorkspaceID {
+		if err := s.checkWorkspacePermission(ctx, req.WorkspaceID, models.PermissionEdit); err != nil {
+			return nil, err
+		}
+		// a link on a custom domain can only move to the workspace owning it
+		if link.Domain != "" {
+			if _, err := s.checkDomain(ctx, link.Domain, req.WorkspaceID); err != nil {
+				return nil, err
+			}
+		}
+	}
+	workspaceID := link.WorkspaceID
+	if req.WorkspaceID != "" {
+		workspaceID = req.WorkspaceID
+	}
+
+	if req.NewOwner != "" && req.NewOwner != link.CreatedBy {
+		if workspaceID == "" {
+			return nil, shortlink_errors.ErrValidateRequest
+		}
+		allowed, err := s.hasWorkspacePermission(ctx, workspaceID, req.NewOwner, models.PermissionEdit)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, shortlink_errors.ErrForbidden
+		}
+	}
+
+	updated, err := s.shortlink.UpdateShortlink(ctx, shortID, func(link *models.Shortlink) error {
+		link.WorkspaceID = workspaceID
+		if req.NewOwner != "" {
+			link.CreatedBy = req.NewOwner
+		}
+		return nil
+	})
+	if err == shortlink_errors.ErrNotFound {
+		return nil, err
+	}
+	if err != nil {
+		return nil, shortlink_errors.ErrSaveShortlink
+	}
+	s.record(ctx, models.AuditLinkTransfer, shortID, before, shortlinkAuditState(updated))
+
+	return toShortlinkDTO(updated), nil
+}
+
+// record writes an audit entry for a change that has already been applied, so
+// a failure is logged instead of being reported to the caller.
+func (s *URLServiceImpl) record(ctx context.Context, action, shortID string, before, after map[string]interface{}) {
+	if err := s.audit.Record(ctx, action, models.AuditTargetShortlink, shortID, before, after); err != nil {
+		log.Printf("Failed to record audit log for %s %s: %v", action, shortID, err)
+	}
+}
+
+func shortlinkAuditState(link *models.Shortlink) map[string]interface{} {
+	return map[string]interface{}{
+		"url":          link.URL,
+		"is_private":   link.IsPrivate,
+		"created_by":   link.CreatedBy,
+		"workspace_id": link.WorkspaceID,
+		"domain":       link.Domain,
+		"title":        link.Title,
+		"notes":        link.Notes,
+		"tags":         link.Tags,
+		"preview":      link.Preview,
+		"expires_at":   link.ExpiresAt,
+		"rules":        link.Rules,
+	}
+}
+
+func toShortlinkDTO(l *models.Shortlink) *dto.ShortlinkDTO {
+	link := &dto.ShortlinkDTO{
+		ShortID:        l.ShortID,
+		Domain:         l.Domain,
+		URL:            l.URL,
+		CreatedAt:      l.CreatedAt,
+		IsPrivate:      l.IsPrivate,
+		WorkspaceID:    l.WorkspaceID,
+		Title:          l.Title,
+		Notes:          l.Notes,
+		Preview:        l.Preview,
+		Disabled:       l.Disabled,
+		DisabledReason: l.DisabledReason,
+		ThreatType:     l.ThreatType,
+		Tags:           l.Tags,
+		ClickCount:     l.ClickCount,
+		Rules:          toRedirectRuleDTOs(l.Rules),
+	}
+	if !l.ExpiresAt.IsZero() {
+		link.ExpiresAt = &l.ExpiresAt
+	}
+	if !l.LastClickedAt.IsZero() {
+		link.LastClickedAt = &l.LastClickedAt
+	}
+	return link
+}
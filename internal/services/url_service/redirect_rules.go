@@ -0,0 +1,125 @@
+package url_service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+)
+
+// newRedirectRules checks what the validator cannot about the rules of a
+// link and converts them for storage. Rules without an ID are numbered, and
+// a rule without any condition is refused: the link's URL is the fallback.
+func newRedirectRules(rules []dto.RedirectRule) ([]models.RedirectRule, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	ids := make(map[string]bool, len(rules))
+	for _, r := range rules {
+		if r.ID == "" {
+			continue
+		}
+		if ids[r.ID] {
+			return nil, shortlink_errors.ErrValidateRequest
+		}
+		ids[r.ID] = true
+	}
+
+	converted := make([]models.RedirectRule, 0, len(rules))
+	next := 1
+	for _, r := range rules {
+		if len(r.Devices) == 0 && len(r.Countries) == 0 && len(r.Languages) == 0 && r.Schedule == nil {
+			return nil, shortlink_errors.ErrValidateRequest
+		}
+
+		rule := models.RedirectRule{
+			ID:        r.ID,
+			URL:       r.URL,
+			Devices:   r.Devices,
+			Countries: r.Countries,
+		}
+		for rule.ID == "" {
+			if id := fmt.Sprintf("rule-%d", next); !ids[id] {
+				rule.ID, ids[id] = id, true
+			}
+			next++
+		}
+		for _, l := range r.Languages {
+			rule.Languages = append(rule.Languages, strings.ToLower(l))
+		}
+
+		if r.Schedule != nil {
+			schedule, err := newRuleSchedule(*r.Schedule)
+			if err != nil {
+				return nil, err
+			}
+			rule.Schedule = schedule
+		}
+		converted = append(converted, rule)
+	}
+	return converted, nil
+}
+
+func newRuleSchedule(s dto.RuleSchedule) (*models.RuleSchedule, error) {
+	if (s.From == "") != (s.To == "") || (s.From != "" && s.From == s.To) {
+		return nil, shortlink_errors.ErrValidateRequest
+	}
+	schedule := &models.RuleSchedule{Days: s.Days, From: s.From, To: s.To, Timezone: s.Timezone}
+	if s.StartsAt != nil {
+		schedule.StartsAt = *s.StartsAt
+	}
+	if s.EndsAt != nil {
+		schedule.EndsAt = *s.EndsAt
+	}
+	if !schedule.StartsAt.IsZero() && !schedule.EndsAt.IsZero() && !schedule.EndsAt.After(schedule.StartsAt) {
+		return nil, shortlink_errors.ErrValidateRequest
+	}
+	return schedule, nil
+}
+
+// checkRuleURLs runs the checks of the link's URL on the destinations of
+// its redirect rules.
+func (s *URLServiceImpl) checkRuleURLs(ctx context.Context, rules []dto.RedirectRule) error {
+	checked := make(map[string]bool, len(rules))
+	for _, r := range rules {
+		if checked[r.URL] {
+			continue
+		}
+		checked[r.URL] = true
+		if err := s.validateURL(ctx, r.URL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func toRedirectRuleDTOs(rules []models.RedirectRule) []dto.RedirectRule {
+	if len(rules) == 0 {
+		return nil
+	}
+	converted := make([]dto.RedirectRule, 0, len(rules))
+	for _, r := range rules {
+		rule := dto.RedirectRule{
+			ID:        r.ID,
+			URL:       r.URL,
+			Devices:   r.Devices,
+			Countries: r.Countries,
+			Languages: r.Languages,
+		}
+		if s := r.Schedule; s != nil {
+			rule.Schedule = &dto.RuleSchedule{Days: s.Days, From: s.From, To: s.To, Timezone: s.Timezone}
+			if !s.StartsAt.IsZero() {
+				rule.Schedule.StartsAt = &s.StartsAt
+			}
+			if !s.EndsAt.IsZero() {
+				rule.Schedule.EndsAt = &s.EndsAt
+			}
+		}
+		converted = append(converted, rule)
+	}
+	return converted
+}
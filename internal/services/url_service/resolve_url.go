@@ -2,29 +2,117 @@ package url_service
 
 import (
 	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
 
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
 	"github.com/mfmahendr/url-shortener-backend/internal/utils"
 	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
 	val "github.com/mfmahendr/url-shortener-backend/internal/utils/validators"
 )
 
 func (s *URLServiceImpl) Resolve(ctx context.Context, shortID string) (string, error) {
-	if err := val.Validate.Var(shortID, "short_id"); err != nil {
-		return "", shortlink_errors.ErrValidateRequest
+	shortlink, err := s.resolve(ctx, shortID)
+	if err != nil {
+		return "", err
+	}
+	return shortlink.URL, nil
+}
+
+// ResolveLink is Resolve for callers that also need the link's settings,
+// such as whether it shows a preview page.
+func (s *URLServiceImpl) ResolveLink(ctx context.Context, shortID string) (*dto.ShortlinkDTO, error) {
+	shortlink, err := s.resolve(ctx, shortID)
+	if err != nil {
+		return nil, err
+	}
+	return toShortlinkDTO(shortlink), nil
+}
+
+// Preview returns what the interstitial page shows for a link. The
+// destination is checked again, as its reputation may have changed since
+// the link was created; a failed check becomes a warning too. target is
+// where the visitor is sent, the URL of the link or of one of its redirect
+// rules; empty means the link's URL.
+func (s *URLServiceImpl) Preview(ctx context.Context, shortID, target string) (*dto.LinkPreview, error) {
+	shortlink, err := s.resolve(ctx, shortID)
+	if err != nil {
+		return nil, err
+	}
+	if target == "" || !hasDestination(shortlink, target) {
+		target = shortlink.URL
+	}
+
+	preview := &dto.LinkPreview{
+		ShortID: shortlink.ShortID,
+		URL:     target,
+		Title:   shortlink.Title,
+	}
+	if parsed, err := url.Parse(target); err == nil {
+		preview.Domain = parsed.Hostname()
+		if parsed.Scheme != "https" {
+			preview.Warnings = append(preview.Warnings, "The destination does not use a secure (HTTPS) connection.")
+		}
+	}
+
+	if s.reputation != nil {
+		verdict, err := s.reputation.Check(ctx, target)
+		switch {
+		case err != nil:
+			log.Printf("Reputation check for preview of %s failed: %v", shortID, err)
+			preview.Warnings = append(preview.Warnings, "The destination could not be checked for threats right now.")
+		case verdict.Unsafe:
+			preview.Warnings = append(preview.Warnings, fmt.Sprintf("The destination was reported as unsafe (%s) by %s.", verdict.ThreatType, verdict.Provider))
+		}
+	}
+	return preview, nil
+}
+
+// hasDestination reports whether the link or one of its rules leads to
+// target.
+func hasDestination(link *models.Shortlink, target string) bool {
+	if link.URL == target {
+		return true
+	}
+	for _, rule := range link.Rules {
+		if rule.URL == target {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *URLServiceImpl) resolve(ctx context.Context, shortID string) (*models.Shortlink, error) {
+	if err := val.Validate.Var(shortID, "link_id"); err != nil {
+		return nil, shortlink_errors.ErrValidateRequest
 	}
 
 	shortlink, err := s.shortlink.GetShortlink(ctx, shortID)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	// if private, check ownership
+	// if private, check ownership (or workspace membership)
 	if shortlink.IsPrivate {
-		user, ok := ctx.Value(utils.UserKey).(string)
-		if !ok || user == "" || user != shortlink.CreatedBy {
-			return "", shortlink_errors.ErrForbidden
+		user, _ := ctx.Value(utils.UserKey).(string)
+		allowed, err := s.canAccessLink(ctx, shortlink, user, models.PermissionView)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, shortlink_errors.ErrForbidden
 		}
 	}
 
-	return shortlink.URL, nil
+	if shortlink.Disabled {
+		return nil, shortlink_errors.ErrLinkDisabled
+	}
+	if shortlink.Expired(time.Now()) {
+		return nil, shortlink_errors.ErrLinkExpired
+	}
+
+	return shortlink, nil
 }
\ No newline at end of file
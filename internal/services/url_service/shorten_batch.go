@@ -0,0 +1,465 @@
+package url_service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/url"
+	"sync"
+	"time"
+
+	nanoid "github.com/matoous/go-nanoid/v2"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/reputation_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/urlcanon"
+	val "github.com/mfmahendr/url-shortener-backend/internal/utils/validators"
+)
+
+const (
+	// batchConcurrency bounds the blacklist lookups and preflights of a
+	// batch that run at the same time.
+	batchConcurrency = 8
+
+	// batchClaimTTL is how long an idempotency key stays claimed by a batch
+	// that has not stored its response, e.g. because its instance stopped.
+	batchClaimTTL = 5 * time.Minute
+)
+
+// batchItem is one link of a batch on its way through ShortenBatch.
+type batchItem struct {
+	req          dto.ShortenRequest
+	canonicalURL string
+	generated    bool
+	key          string
+	err          error
+}
+
+// ShortenBatch runs the checks of Shorten on every request and creates the
+// links that pass them. The destinations are checked once per distinct URL,
+// with one reputation lookup for the whole batch, and the links are written
+// with a single bulk write. A failing item does not fail the others.
+//
+// The key is claimed under idempotencyKey (generated when empty) before any
+// link is created, and the response is stored under it: the same user
+// sending the same requests with the key again gets it back instead of new
+// links. A retry sent while the first batch is still running fails with
+// ErrIdempotencyKeyInProgress, and reusing the key for other requests with
+// ErrIdempotencyKeyReused.
+func (s *URLServiceImpl) ShortenBatch(ctx context.Context, idempotencyKey string, reqs []dto.ShortenRequest) (*dto.BatchShortenResponse, error) {
+	user, ok := ctx.Value(utils.UserKey).(string)
+	if !ok {
+		return nil, shortlink_errors.ErrValidateRequest
+	}
+	if len(reqs) == 0 {
+		return nil, shortlink_errors.ErrValidateRequest
+	}
+	if len(reqs) > s.config.MaxBatchItems {
+		return nil, shortlink_errors.ErrBatchTooLarge
+	}
+
+	requestHash, err := hashBatch(reqs)
+	if err != nil {
+		return nil, shortlink_errors.ErrValidateRequest
+	}
+	if idempotencyKey == "" {
+		if idempotencyKey, err = nanoid.New(); err != nil {
+			log.Println("Error generating idempotency key:", err)
+			return nil, shortlink_errors.ErrGenerateID
+		}
+	}
+	stored, err := s.shortlink.ClaimShortenBatch(ctx, models.ShortenBatch{
+		UID:            user,
+		IdempotencyKey: idempotencyKey,
+		RequestHash:    requestHash,
+		CreatedAt:      time.Now(),
+	}, batchClaimTTL)
+	if err != nil {
+		log.Printf("Failed to claim shorten batch %s: %v", idempotencyKey, err)
+		return nil, shortlink_errors.ErrFailedRetrieveData
+	}
+	if stored != nil {
+		return replayBatch(stored, idempotencyKey, requestHash)
+	}
+
+	items := make([]batchItem, len(reqs))
+	for i, req := range reqs {
+		items[i].req = req
+	}
+
+	s.checkBatchRequests(ctx, items)
+	s.checkBatchDestinations(ctx, items)
+	s.checkBatchRules(ctx, items)
+	for i := range items {
+		it := &items[i]
+		if it.err != nil || it.req.CustomID != "" || !it.req.ReuseExisting {
+			continue
+		}
+		existing, err := s.findReusableLink(ctx, it.req, it.canonicalURL)
+		if err != nil {
+			it.err = err
+		} else if existing != nil {
+			it.key = existing.Key()
+		}
+	}
+	s.fetchBatchTitles(ctx, items)
+	s.createBatchLinks(ctx, items)
+
+	resp := &dto.BatchShortenResponse{
+		IdempotencyKey: idempotencyKey,
+		Results:        make([]dto.BatchShortenResult, len(items)),
+	}
+	for i, it := range items {
+		res := &resp.Results[i]
+		res.Index = i
+		if it.err != nil {
+			res.Error, res.Message = shortlink_errors.Code(it.err), it.err.Error()
+			resp.Failed++
+			continue
+		}
+		res.ShortID, res.Domain = models.SplitLinkKey(it.key)
+		resp.Created++
+	}
+
+	s.saveBatch(ctx, user, idempotencyKey, requestHash, resp)
+	return resp, nil
+}
+
+// replayBatch returns the response of the batch stored under idempotencyKey.
+func replayBatch(stored *models.ShortenBatch, idempotencyKey, requestHash string) (*dto.BatchShortenResponse, error) {
+	if stored.RequestHash != requestHash {
+		return nil, shortlink_errors.ErrIdempotencyKeyReused
+	}
+	if stored.Response == "" {
+		return nil, shortlink_errors.ErrIdempotencyKeyInProgress
+	}
+
+	var resp dto.BatchShortenResponse
+	if err := json.Unmarshal([]byte(stored.Response), &resp); err != nil {
+		log.Printf("Stored shorten batch %s is unreadable: %v", idempotencyKey, err)
+		return nil, shortlink_errors.ErrFailedRetrieveData
+	}
+	resp.Replayed = true
+	return &resp, nil
+}
+
+// saveBatch stores the response for retries. The links exist either way, so
+// a failure is only logged; the key then stays claimed until batchClaimTTL.
+func (s *URLServiceImpl) saveBatch(ctx context.Context, user, idempotencyKey, requestHash string, resp *dto.BatchShortenResponse) {
+	data, err := json.Marshal(resp)
+	if err == nil {
+		err = s.shortlink.SaveShortenBatch(ctx, models.ShortenBatch{
+			UID:            user,
+			IdempotencyKey: idempotencyKey,
+			RequestHash:    requestHash,
+			Response:       string(data),
+			CreatedAt:      time.Now(),
+		})
+	}
+	if err != nil {
+		log.Printf("Failed to store shorten batch %s: %v", idempotencyKey, err)
+	}
+}
+
+// checkBatchRequests does the checks of Shorten that come before the
+// destination checks. Domains and workspace permissions are checked once
+// per distinct value, and a custom ID can only be used once in a batch.
+func (s *URLServiceImpl) checkBatchRequests(ctx context.Context, items []batchItem) {
+	type domainResult struct {
+		domain string
+		err    error
+	}
+	domains := make(map[[2]string]domainResult)
+	workspaces := make(map[string]error)
+	customIDs := make(map[string]bool)
+
+	for i := range items {
+		it := &items[i]
+		if err := val.Validate.Struct(it.req); err != nil {
+			it.err = shortlink_errors.ErrValidateRequest
+			continue
+		}
+		if it.canonicalURL, it.err = urlcanon.Canonicalize(it.req.URL, s.config.Canon); it.err != nil {
+			it.err = shortlink_errors.ErrValidateRequest
+			continue
+		}
+
+		if it.req.Domain != "" {
+			k := [2]string{it.req.Domain, it.req.WorkspaceID}
+			res, ok := domains[k]
+			if !ok {
+				res.domain, res.err = s.checkDomain(ctx, it.req.Domain, it.req.WorkspaceID)
+				domains[k] = res
+			}
+			if it.req.Domain, it.err = res.domain, res.err; it.err != nil {
+				continue
+			}
+		}
+
+		if it.req.CustomID != "" {
+			key := models.LinkKey(it.req.Domain, it.req.CustomID)
+			if customIDs[key] {
+				it.err = shortlink_errors.ErrIDExists
+				continue
+			}
+			customIDs[key] = true
+			if it.err = s.validateCustomID(ctx, it.req); it.err != nil {
+				continue
+			}
+		}
+
+		if it.req.WorkspaceID != "" {
+			err, ok := workspaces[it.req.WorkspaceID]
+			if !ok {
+				err = s.checkWorkspacePermission(ctx, it.req.WorkspaceID, models.PermissionEdit)
+				workspaces[it.req.WorkspaceID] = err
+			}
+			if it.err = err; it.err != nil {
+				continue
+			}
+		}
+
+		parsedURL, err := url.Parse(it.req.URL)
+		if err != nil || parsedURL.Host == "" {
+			it.err = shortlink_errors.ErrValidateRequest
+		} else if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+			it.err = shortlink_errors.ErrUnsupportedScheme
+		} else {
+			_, it.err = newRedirectRules(it.req.Rules)
+		}
+	}
+}
+
+// checkBatchRules checks the destinations of the redirect rules of the
+// items that passed the other checks. Few links have rules, so they are
+// checked one link at a time.
+func (s *URLServiceImpl) checkBatchRules(ctx context.Context, items []batchItem) {
+	for i := range items {
+		if it := &items[i]; it.err == nil && len(it.req.Rules) > 0 {
+			it.err = s.checkRuleURLs(ctx, it.req.Rules)
+		}
+	}
+}
+
+// checkBatchDestinations runs checkDestination on every distinct URL of the
+// batch, and the preflight, if any, on every item.
+func (s *URLServiceImpl) checkBatchDestinations(ctx context.Context, items []batchItem) {
+	var urls []string
+	seen := make(map[string]bool)
+	for _, it := range items {
+		if it.err == nil && !seen[it.req.URL] {
+			seen[it.req.URL] = true
+			urls = append(urls, it.req.URL)
+		}
+	}
+	checked := s.checkDestinations(ctx, urls)
+
+	if s.preflight == nil {
+		for i := range items {
+			if items[i].err == nil {
+				items[i].err = checked[items[i].req.URL]
+			}
+		}
+		return
+	}
+
+	// redirect chains are followed per item; hops already checked for the
+	// batch are not checked again
+	var mu sync.Mutex
+	check := func(ctx context.Context, hopURL string) error {
+		mu.Lock()
+		err, ok := checked[hopURL]
+		mu.Unlock()
+		if ok {
+			return err
+		}
+		err = s.checkDestination(ctx, hopURL)
+		mu.Lock()
+		checked[hopURL] = err
+		mu.Unlock()
+		return err
+	}
+
+	var eg errgroup.Group
+	eg.SetLimit(batchConcurrency)
+	for i := range items {
+		if items[i].err != nil {
+			continue
+		}
+		eg.Go(func() error {
+			_, items[i].err = s.preflight.Follow(ctx, items[i].req.URL, check)
+			return nil
+		})
+	}
+	_ = eg.Wait()
+}
+
+// checkDestinations is checkDestination for many URLs. Each URL is looked up
+// in the blacklist once, and the reputation providers are asked about all
+// of them together when they support it.
+func (s *URLServiceImpl) checkDestinations(ctx context.Context, urls []string) map[string]error {
+	results := make(map[string]error, len(urls))
+	if len(urls) == 0 {
+		return results
+	}
+
+	blacklisted := make([]error, len(urls))
+	var eg errgroup.Group
+	eg.SetLimit(batchConcurrency)
+	for i, u := range urls {
+		eg.Go(func() error {
+			isBlacklisted, err := s.blacklist.IsBlacklisted(ctx, u)
+			switch {
+			case err != nil:
+				log.Printf("Error while checking blacklisted items for %s: %v", u, err)
+				blacklisted[i] = err
+			case isBlacklisted:
+				blacklisted[i] = shortlink_errors.ErrForbiddenInput
+			}
+			return nil
+		})
+	}
+
+	var verdicts []reputation_service.Verdict
+	var reputationErrs []error
+	if batch, ok := s.reputation.(reputation_service.BatchChecker); ok {
+		verdicts, reputationErrs = batch.CheckAll(ctx, urls)
+	} else {
+		verdicts = make([]reputation_service.Verdict, len(urls))
+		reputationErrs = make([]error, len(urls))
+		for i, u := range urls {
+			verdicts[i], reputationErrs[i] = s.reputation.Check(ctx, u)
+		}
+	}
+	_ = eg.Wait()
+
+	for i, u := range urls {
+		switch {
+		case blacklisted[i] != nil:
+			results[u] = blacklisted[i]
+		case reputationErrs[i] != nil:
+			log.Printf("Reputation check error for %s: %v", u, reputationErrs[i])
+			results[u] = shortlink_errors.ErrFailedRetrieveData
+		case verdicts[i].Unsafe:
+			log.Printf("This site is unsafe (%s: %s)", verdicts[i].Provider, verdicts[i].ThreatType)
+			results[u] = shortlink_errors.ErrForbiddenInput
+		default:
+			results[u] = nil
+		}
+	}
+	return results
+}
+
+// fetchBatchTitles fetches the titles of the new links asking for one.
+func (s *URLServiceImpl) fetchBatchTitles(ctx context.Context, items []batchItem) {
+	var eg errgroup.Group
+	eg.SetLimit(batchConcurrency)
+	for i := range items {
+		if items[i].err != nil || items[i].key != "" {
+			continue
+		}
+		eg.Go(func() error {
+			s.fetchTitle(ctx, &items[i].req)
+			return nil
+		})
+	}
+	_ = eg.Wait()
+}
+
+// createBatchLinks writes the links of the items that passed every check
+// and are not reused, drawing new IDs for generated ones that collide.
+func (s *URLServiceImpl) createBatchLinks(ctx context.Context, items []batchItem) {
+	var pending []int
+	for i, it := range items {
+		if it.err == nil && it.key == "" {
+			items[i].generated = it.req.CustomID == ""
+			pending = append(pending, i)
+		}
+	}
+
+	for attempt := 0; attempt < maxIDAttempts && len(pending) > 0; attempt++ {
+		var docs []models.Shortlink
+		var docIdx []int
+		for _, i := range pending {
+			it := &items[i]
+			if it.generated {
+				if it.req.CustomID, it.err = s.drawID(ctx, it.req.WorkspaceID); it.err != nil {
+					continue
+				}
+			}
+			doc, err := newShortlink(ctx, it.req, it.canonicalURL)
+			if err != nil {
+				it.err = err
+				continue
+			}
+			docs = append(docs, *doc)
+			docIdx = append(docIdx, i)
+		}
+
+		results, err := s.shortlink.CreateShortlinks(ctx, docs)
+		if err != nil {
+			log.Printf("Error saving shorten batch: %v", err)
+			for _, i := range docIdx {
+				items[i].err = shortlink_errors.ErrSaveShortlink
+			}
+			return
+		}
+
+		pending = nil
+		for j, itemErr := range results {
+			it := &items[docIdx[j]]
+			switch {
+			case itemErr == nil:
+				it.key = docs[j].Key()
+			case errors.Is(itemErr, shortlink_errors.ErrIDExists) && it.generated:
+				log.Printf("Generated ID %s is taken, retrying", it.req.CustomID)
+				pending = append(pending, docIdx[j])
+			case errors.Is(itemErr, shortlink_errors.ErrIDExists):
+				it.err = shortlink_errors.ErrIDExists
+			default:
+				log.Printf("Error saving shortlink %s: %v", docs[j].Key(), itemErr)
+				it.err = shortlink_errors.ErrSaveShortlink
+			}
+		}
+	}
+
+	for _, i := range pending {
+		items[i].err = shortlink_errors.ErrGenerateID
+	}
+}
+
+// drawID generates an ID that is not reserved in workspaceID.
+func (s *URLServiceImpl) drawID(ctx context.Context, workspaceID string) (string, error) {
+	for attempt := 0; attempt < maxIDAttempts; attempt++ {
+		id, err := s.ids.Generate(ctx)
+		if err != nil {
+			log.Println("Error generating ID:", err)
+			return "", shortlink_errors.ErrGenerateID
+		}
+		err = s.reserved.Check(ctx, id, workspaceID)
+		if errors.Is(err, shortlink_errors.ErrBlacklistedID) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return id, nil
+	}
+	return "", shortlink_errors.ErrGenerateID
+}
+
+func hashBatch(reqs []dto.ShortenRequest) (string, error) {
+	data, err := json.Marshal(reqs)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -7,13 +7,13 @@ import (
 	"net/url"
 	"time"
 
-	nanoid "github.com/matoous/go-nanoid/v2"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/mfmahendr/url-shortener-backend/internal/dto"
 	"github.com/mfmahendr/url-shortener-backend/internal/models"
 	"github.com/mfmahendr/url-shortener-backend/internal/utils"
 	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/urlcanon"
 	val "github.com/mfmahendr/url-shortener-backend/internal/utils/validators"
 )
 
@@ -22,32 +22,141 @@ func (s *URLServiceImpl) Shorten(ctx context.Context, req dto.ShortenRequest) (s
 	if err != nil {
 		return "", shortlink_errors.ErrValidateRequest
 	}
+	if _, err := newRedirectRules(req.Rules); err != nil {
+		return "", err
+	}
+
+	canonicalURL, err := urlcanon.Canonicalize(req.URL, s.config.Canon)
+	if err != nil {
+		return "", shortlink_errors.ErrValidateRequest
+	}
+
+	if req.Domain != "" {
+		if req.Domain, err = s.checkDomain(ctx, req.Domain, req.WorkspaceID); err != nil {
+			return "", err
+		}
+	}
+
+	if req.CustomID != "" {
+		if err := s.validateCustomID(ctx, req); err != nil {
+			return "", err
+		}
+	}
+
+	if req.WorkspaceID != "" {
+		if err := s.checkWorkspacePermission(ctx, req.WorkspaceID, models.PermissionEdit); err != nil {
+			return "", err
+		}
+	}
+
+	if err := s.validateURL(ctx, req.URL); err != nil {
+		return "", err
+	}
+	if err := s.checkRuleURLs(ctx, req.Rules); err != nil {
+		return "", err
+	}
+
+	// an explicit custom ID always creates a new link
+	if req.CustomID != "" {
+		s.fetchTitle(ctx, &req)
+		return s.saveShortlink(ctx, req, canonicalURL)
+	}
+
+	if req.ReuseExisting {
+		existing, err := s.findReusableLink(ctx, req, canonicalURL)
+		if err != nil {
+			return "", err
+		}
+		if existing != nil {
+			return existing.Key(), nil
+		}
+	}
+
+	s.fetchTitle(ctx, &req)
+	return s.createWithGeneratedID(ctx, req, canonicalURL)
+}
+
+// fetchTitle sets the title of a link asking for it to the title of its
+// destination page. A page without a title or that cannot be fetched leaves
+// the link without one.
+func (s *URLServiceImpl) fetchTitle(ctx context.Context, req *dto.ShortenRequest) {
+	if !req.FetchTitle || req.Title != "" || s.preflight == nil {
+		return
+	}
+	title, err := s.preflight.FetchTitle(ctx, req.URL)
+	if err != nil {
+		log.Printf("Could not fetch the title of %s: %v", req.URL, err)
+		return
+	}
+	req.Title = title
+}
 
-	// if CustomID is not provided, generate a new ID
-	if req.CustomID == "" {
-		req.CustomID, err = nanoid.Generate("0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ", 8)
+// createWithGeneratedID stores the link under a generated ID, drawing a new
+// one when the ID is reserved or already taken.
+func (s *URLServiceImpl) createWithGeneratedID(ctx context.Context, req dto.ShortenRequest, canonicalURL string) (string, error) {
+	for attempt := 0; attempt < maxIDAttempts; attempt++ {
+		id, err := s.ids.Generate(ctx)
 		if err != nil {
 			log.Println("Error generating ID:", err)
 			return "", shortlink_errors.ErrGenerateID
 		}
-	} else if err := s.validateCustomID(ctx, req.CustomID); err != nil {
-		return "", err
+		if err := s.reserved.Check(ctx, id, req.WorkspaceID); err != nil {
+			if errors.Is(err, shortlink_errors.ErrBlacklistedID) {
+				continue
+			}
+			return "", err
+		}
+
+		req.CustomID = id
+		doc, err := newShortlink(ctx, req, canonicalURL)
+		if err != nil {
+			return "", err
+		}
+		err = s.shortlink.CreateShortlink(ctx, *doc)
+		if errors.Is(err, shortlink_errors.ErrIDExists) {
+			log.Printf("Generated ID %s is taken, retrying", id)
+			continue
+		}
+		if err != nil {
+			return "", shortlink_errors.ErrSaveShortlink
+		}
+		return doc.Key(), nil
 	}
+	return "", shortlink_errors.ErrGenerateID
+}
 
-	if err := s.validateURL(ctx, req.URL); err != nil {
-		return "", err
+// findReusableLink returns an enabled link of the caller in the same
+// workspace and on the same domain, with the same privacy, that leads to
+// canonicalURL. Links with redirect rules are never reused.
+func (s *URLServiceImpl) findReusableLink(ctx context.Context, req dto.ShortenRequest, canonicalURL string) (*models.Shortlink, error) {
+	if len(req.Rules) > 0 {
+		return nil, nil
+	}
+	user, ok := ctx.Value(utils.UserKey).(string)
+	if !ok {
+		return nil, shortlink_errors.ErrValidateRequest
 	}
 
-	return s.saveShortlink(ctx, req)
+	links, err := s.shortlink.FindLinksByCanonicalURL(ctx, user, req.WorkspaceID, canonicalURL)
+	if err != nil {
+		return nil, err
+	}
+	for _, link := range links {
+		if !link.Disabled && !link.Expired(time.Now()) && link.IsPrivate == req.IsPrivate && link.Domain == req.Domain && len(link.Rules) == 0 {
+			return &link, nil
+		}
+	}
+	return nil, nil
 }
 
-func (s *URLServiceImpl) validateCustomID(ctx context.Context, customID string) error {
-	if utils.BlacklistedCustomIDs[customID] { // check reserved keywords
-		return shortlink_errors.ErrBlacklistedID
+func (s *URLServiceImpl) validateCustomID(ctx context.Context, req dto.ShortenRequest) error {
+	if err := s.reserved.Check(ctx, req.CustomID, req.WorkspaceID); err != nil {
+		return err
 	}
 
-	// check if custom ID is already exist
-	_, err := s.shortlink.GetShortlink(ctx, customID)
+	// fail fast before the URL checks; saveShortlink still refuses to
+	// overwrite an ID taken in the meantime
+	_, err := s.shortlink.GetShortlink(ctx, models.LinkKey(req.Domain, req.CustomID))
 	if err == nil {
 		return shortlink_errors.ErrIDExists
 	}
@@ -58,6 +167,15 @@ func (s *URLServiceImpl) validateCustomID(ctx context.Context, customID string)
 	return nil
 }
 
+// checkDomain returns the canonical form of a custom domain the caller may
+// create links on in workspaceID.
+func (s *URLServiceImpl) checkDomain(ctx context.Context, domain, workspaceID string) (string, error) {
+	if s.domains == nil {
+		return "", shortlink_errors.ErrDomainNotVerified
+	}
+	return s.domains.CheckUsable(ctx, domain, workspaceID)
+}
+
 func (s *URLServiceImpl) validateURL(ctx context.Context, targetURL string) error {
 	// check if the URL is valid
 	parsedURL, err := url.Parse(targetURL)
@@ -65,7 +183,22 @@ func (s *URLServiceImpl) validateURL(ctx context.Context, targetURL string) erro
 		log.Println("Invalid request: URL has no host")
 		return shortlink_errors.ErrValidateRequest
 	}
+	// refuses javascript:, data: and the like
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return shortlink_errors.ErrUnsupportedScheme
+	}
 
+	if s.preflight == nil {
+		return s.checkDestination(ctx, targetURL)
+	}
+	// every hop of the redirect chain goes through the same checks
+	_, err = s.preflight.Follow(ctx, targetURL, s.checkDestination)
+	return err
+}
+
+// checkDestination checks a URL against the blacklist and the reputation
+// providers.
+func (s *URLServiceImpl) checkDestination(ctx context.Context, targetURL string) error {
 	eg, ctx := errgroup.WithContext(ctx)
 
 	// check if urls/its domain is blacklisted
@@ -82,15 +215,15 @@ func (s *URLServiceImpl) validateURL(ctx context.Context, targetURL string) erro
 		return nil
 	})
 
-	// check safe Browsing
+	// check URL reputation (Safe Browsing and other providers)
 	eg.Go(func() error {
-		isUnsafe, err := s.safebrowsing.IsUnsafe(ctx, targetURL)
+		verdict, err := s.reputation.Check(ctx, targetURL)
 		if err != nil {
-			log.Printf("SafeBrowsing error: %v", err)
+			log.Printf("Reputation check error: %v", err)
 			return shortlink_errors.ErrFailedRetrieveData
 		}
-		if isUnsafe {
-			log.Println("This site is unsafe")
+		if verdict.Unsafe {
+			log.Printf("This site is unsafe (%s: %s)", verdict.Provider, verdict.ThreatType)
 			return shortlink_errors.ErrForbiddenInput
 		}
 		return nil
@@ -100,24 +233,68 @@ func (s *URLServiceImpl) validateURL(ctx context.Context, targetURL string) erro
 }
 
 // Simpan shortlink (reusable function)
-func (s *URLServiceImpl) saveShortlink(ctx context.Context, req dto.ShortenRequest) (string, error) {
+func (s *URLServiceImpl) saveShortlink(ctx context.Context, req dto.ShortenRequest, canonicalURL string) (string, error) {
+	doc, err := newShortlink(ctx, req, canonicalURL)
+	if err != nil {
+		return "", err
+	}
+
+	err = s.shortlink.CreateShortlink(ctx, *doc)
+	if errors.Is(err, shortlink_errors.ErrIDExists) {
+		return "", shortlink_errors.ErrIDExists
+	}
+	if err != nil {
+		return "", shortlink_errors.ErrSaveShortlink
+	}
+	return doc.Key(), nil
+}
+
+func newShortlink(ctx context.Context, req dto.ShortenRequest, canonicalURL string) (*models.Shortlink, error) {
 	user, ok := ctx.Value(utils.UserKey).(string)
 	if !ok {
-		return "", shortlink_errors.ErrValidateRequest
+		return nil, shortlink_errors.ErrValidateRequest
 	}
 
-	doc := models.Shortlink{
-		ShortID:   req.CustomID,
-		URL:       req.URL,
-		CreatedAt: time.Now(),
-		CreatedBy: user,
-		IsPrivate: req.IsPrivate,
+	now := time.Now()
+	var expiresAt time.Time
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(now) {
+			return nil, shortlink_errors.ErrValidateRequest
+		}
+		expiresAt = *req.ExpiresAt
+	}
+	rules, err := newRedirectRules(req.Rules)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := s.shortlink.SetShortlink(ctx, doc.ShortID, doc); err != nil {
-		return "", shortlink_errors.ErrSaveShortlink
+	return &models.Shortlink{
+		ShortID:         req.CustomID,
+		Domain:          req.Domain,
+		URL:             req.URL,
+		CanonicalURL:    canonicalURL,
+		DestinationHost: destinationHost(canonicalURL),
+		CreatedAt:       now,
+		CreatedBy:       user,
+		IsPrivate:       req.IsPrivate,
+		WorkspaceID:     req.WorkspaceID,
+		Title:           req.Title,
+		Notes:           req.Notes,
+		Tags:            models.NormalizeTags(req.Tags),
+		Preview:         req.Preview,
+		ExpiresAt:       expiresAt,
+		Rules:           rules,
+	}, nil
+}
+
+// destinationHost is the host of a canonical URL, which is already
+// lower-cased and without a default port.
+func destinationHost(canonicalURL string) string {
+	u, err := url.Parse(canonicalURL)
+	if err != nil {
+		return ""
 	}
-	return doc.ShortID, nil
+	return u.Hostname()
 }
 
 func (s *URLServiceImpl) GetUserLinks(ctx context.Context, req dto.UserLinksRequest) (*dto.UserLinksResponse, error) {
@@ -125,6 +302,16 @@ func (s *URLServiceImpl) GetUserLinks(ctx context.Context, req dto.UserLinksRequ
 		return nil, shortlink_errors.ErrValidateRequest
 	}
 
+	if req.WorkspaceID != "" {
+		allowed, err := s.hasWorkspacePermission(ctx, req.WorkspaceID, req.CreatedBy, models.PermissionView)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, shortlink_errors.ErrForbidden
+		}
+	}
+
 	links, nextCursor, err := s.shortlink.ListUserLinks(ctx, req)
 	if err != nil {
 		return nil, err
@@ -132,12 +319,7 @@ func (s *URLServiceImpl) GetUserLinks(ctx context.Context, req dto.UserLinksRequ
 
 	dtoLinks := make([]dto.ShortlinkDTO, 0, len(links))
 	for _, l := range links {
-		dtoLinks = append(dtoLinks, dto.ShortlinkDTO{
-			ShortID:   l.ShortID,
-			URL:       l.URL,
-			CreatedAt: l.CreatedAt,
-			IsPrivate: l.IsPrivate,
-		})
+		dtoLinks = append(dtoLinks, *toShortlinkDTO(&l))
 	}
 
 	return &dto.UserLinksResponse{
@@ -2,30 +2,113 @@ package url_service
 
 import (
 	"context"
+	"log"
+	"os"
+	"strconv"
+	"strings"
 
 	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/audit_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/domain_service"
 	firestore "github.com/mfmahendr/url-shortener-backend/internal/services/firestore"
-	safebrowsing "github.com/mfmahendr/url-shortener-backend/internal/services/safebrowsing"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/idgen_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/preflight_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/reputation_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/reserved_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/urlcanon"
 )
 
 type URLService interface {
 	Shorten(ctx context.Context, req dto.ShortenRequest) (shortID string, err error)
+	ShortenBatch(ctx context.Context, idempotencyKey string, reqs []dto.ShortenRequest) (*dto.BatchShortenResponse, error)
 	Resolve(ctx context.Context, shortID string) (string, error)
+	ResolveLink(ctx context.Context, shortID string) (*dto.ShortlinkDTO, error)
+	Preview(ctx context.Context, shortID, target string) (*dto.LinkPreview, error)
 	IsOwner(ctx context.Context, shortID string, uid string) (bool, error)
+	Authorize(ctx context.Context, shortID string, uid string, perm models.Permission) (bool, error)
 	GetUserLinks(ctx context.Context, req dto.UserLinksRequest) (*dto.UserLinksResponse, error)
+	UpdateShortlink(ctx context.Context, shortID string, req dto.UpdateShortlinkRequest) (*dto.ShortlinkDTO, error)
+	DeleteShortlink(ctx context.Context, shortID string) error
+	TransferShortlink(ctx context.Context, shortID string, req dto.TransferShortlinkRequest) (*dto.ShortlinkDTO, error)
+	ListTags(ctx context.Context, workspaceID string) ([]dto.TagSummary, error)
+	TagAnalytics(ctx context.Context, workspaceID, tag string) (*dto.TagAnalyticsResponse, error)
+	RenameTag(ctx context.Context, workspaceID, tag string, req dto.RenameTagRequest) (*dto.TagSummary, error)
+	DeleteTag(ctx context.Context, workspaceID, tag string) error
+}
+
+const (
+	// maxIDAttempts bounds how often Shorten draws a new ID after a collision.
+	maxIDAttempts = 5
+
+	defaultMaxBatchItems = 1000
+)
+
+type Config struct {
+	// Canon controls how target URLs are canonicalised before they are
+	// compared with the caller's existing links.
+	Canon urlcanon.Options
+	// MaxBatchItems is the most links ShortenBatch creates at once; 0 means
+	// defaultMaxBatchItems.
+	MaxBatchItems int
+}
+
+// LoadConfig reads URL_STRIP_TRACKING_PARAMS (default false): whether
+// tracking parameters such as utm_source are ignored when looking for an
+// existing link to reuse, and BATCH_SHORTEN_MAX_ITEMS (default 1000).
+func LoadConfig() Config {
+	var cfg Config
+	if v := strings.TrimSpace(os.Getenv("BATCH_SHORTEN_MAX_ITEMS")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.MaxBatchItems = n
+		} else {
+			log.Printf("Invalid BATCH_SHORTEN_MAX_ITEMS %q, using %d", v, defaultMaxBatchItems)
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("URL_STRIP_TRACKING_PARAMS")); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.Canon.StripTracking = b
+		} else {
+			log.Printf("Invalid URL_STRIP_TRACKING_PARAMS %q, keeping tracking parameters", v)
+		}
+	}
+	return cfg
 }
 
 type URLServiceImpl struct {
-	shortlink    firestore.Shortlink
-	blacklist    firestore.BlacklistChecker
-	safebrowsing safebrowsing.URLSafetyChecker
+	shortlink  firestore.Shortlink
+	blacklist  firestore.BlacklistChecker
+	reputation reputation_service.URLChecker
+	preflight  preflight_service.Preflight
+	ids        idgen_service.IDGenerator
+	reserved   reserved_service.Checker
+	domains    domain_service.DomainChecker
+	workspaces firestore.WorkspaceMembership
+	audit      audit_service.Recorder
+	config     Config
 	// safebrowsing *safebrowsing.Service
 }
 
-func New(sl firestore.Shortlink, bl firestore.BlacklistChecker, sb safebrowsing.URLSafetyChecker) URLService {
+func New(sl firestore.Shortlink, bl firestore.BlacklistChecker, rc reputation_service.URLChecker, ws firestore.WorkspaceMembership, audit audit_service.Recorder, pf preflight_service.Preflight, ids idgen_service.IDGenerator, reserved reserved_service.Checker, domains domain_service.DomainChecker, config Config) URLService {
+	if ids == nil {
+		ids = idgen_service.NewNanoID(idgen_service.AlphabetBase62, 8)
+	}
+	if reserved == nil {
+		reserved = reserved_service.NewBuiltin()
+	}
+	if config.MaxBatchItems <= 0 {
+		config.MaxBatchItems = defaultMaxBatchItems
+	}
 	return &URLServiceImpl{
-		shortlink:    sl,
-		blacklist:    bl,
-		safebrowsing: sb,
+		shortlink:  sl,
+		blacklist:  bl,
+		reputation: rc,
+		preflight:  pf,
+		ids:        ids,
+		reserved:   reserved,
+		domains:    domains,
+		workspaces: ws,
+		audit:      audit,
+		config:     config,
 	}
 }
@@ -2,7 +2,9 @@ package url_service_test
 
 import (
 	"context"
+	"errors"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -12,9 +14,13 @@ import (
 
 	"github.com/mfmahendr/url-shortener-backend/internal/dto"
 	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/preflight_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/reputation_service"
+	safebrowsing_service "github.com/mfmahendr/url-shortener-backend/internal/services/safebrowsing"
 	"github.com/mfmahendr/url-shortener-backend/internal/services/url_service"
 	"github.com/mfmahendr/url-shortener-backend/internal/utils"
 	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/urlcanon"
 	"github.com/mfmahendr/url-shortener-backend/internal/utils/validators"
 )
 
@@ -27,6 +33,21 @@ func (m *MockShortlink) SetShortlink(ctx context.Context, shortID string, link m
 	return args.Error(0)
 }
 
+// UpdateShortlink applies update to a copy of the link the expectation
+// returns, which stands for the link as stored when the write happens.
+func (m *MockShortlink) UpdateShortlink(ctx context.Context, shortID string, update func(*models.Shortlink) error) (*models.Shortlink, error) {
+	args := m.Called(ctx, shortID)
+	stored, err := args.Get(0).(*models.Shortlink), args.Error(1)
+	if err != nil {
+		return nil, err
+	}
+	link := *stored
+	if err := update(&link); err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
 func (m *MockShortlink) GetShortlink(ctx context.Context, shortID string) (*models.Shortlink, error) {
 	args := m.Called(ctx, shortID)
 	return args.Get(0).(*models.Shortlink), args.Error(1)
@@ -37,6 +58,46 @@ func (m *MockShortlink) ListUserLinks(ctx context.Context, req dto.UserLinksRequ
 	return args.Get(0).([]models.Shortlink), args.Get(1).(string), args.Error(2)
 }
 
+func (m *MockShortlink) DeleteShortlink(ctx context.Context, shortID string) error {
+	args := m.Called(ctx, shortID)
+	return args.Error(0)
+}
+
+func (m *MockShortlink) CreateShortlink(ctx context.Context, link models.Shortlink) error {
+	args := m.Called(ctx, link)
+	return args.Error(0)
+}
+
+func (m *MockShortlink) CreateShortlinks(ctx context.Context, links []models.Shortlink) ([]error, error) {
+	args := m.Called(ctx, links)
+	return args.Get(0).([]error), args.Error(1)
+}
+
+func (m *MockShortlink) ClaimShortenBatch(ctx context.Context, batch models.ShortenBatch, staleAfter time.Duration) (*models.ShortenBatch, error) {
+	args := m.Called(ctx, batch)
+	return args.Get(0).(*models.ShortenBatch), args.Error(1)
+}
+
+func (m *MockShortlink) SaveShortenBatch(ctx context.Context, batch models.ShortenBatch) error {
+	args := m.Called(ctx, batch)
+	return args.Error(0)
+}
+
+func (m *MockShortlink) FindLinksByCanonicalURL(ctx context.Context, createdBy, workspaceID, canonicalURL string) ([]models.Shortlink, error) {
+	args := m.Called(ctx, createdBy, workspaceID, canonicalURL)
+	return args.Get(0).([]models.Shortlink), args.Error(1)
+}
+
+func (m *MockShortlink) ListTaggedLinks(ctx context.Context, createdBy, workspaceID, tag string) ([]models.Shortlink, error) {
+	args := m.Called(ctx, createdBy, workspaceID, tag)
+	return args.Get(0).([]models.Shortlink), args.Error(1)
+}
+
+func (m *MockShortlink) SetLinkTags(ctx context.Context, tags map[string][]string) error {
+	args := m.Called(ctx, tags)
+	return args.Error(0)
+}
+
 // Firestore blacklist checker SERVICE
 type MockBlacklistChecker struct{ mock.Mock }
 
@@ -45,12 +106,49 @@ func (m *MockBlacklistChecker) IsBlacklisted(ctx context.Context, inputURL strin
 	return args.Bool(0), args.Error(1)
 }
 
-// Safebrowsing safety checker SERVICE
-type MockURLSafetyChecker struct{ mock.Mock }
+// Reputation URL checker SERVICE
+type MockURLChecker struct{ mock.Mock }
 
-func (m *MockURLSafetyChecker) IsUnsafe(ctx context.Context, url string) (bool, error) {
+func (m *MockURLChecker) Check(ctx context.Context, url string) (reputation_service.Verdict, error) {
 	args := m.Called(ctx, url)
-	return args.Bool(0), args.Error(1)
+	return args.Get(0).(reputation_service.Verdict), args.Error(1)
+}
+
+// Firestore workspace membership SERVICE
+type MockWorkspaceMembership struct{ mock.Mock }
+
+func (m *MockWorkspaceMembership) GetMember(ctx context.Context, workspaceID, uid string) (*models.WorkspaceMember, error) {
+	args := m.Called(ctx, workspaceID, uid)
+	return args.Get(0).(*models.WorkspaceMember), args.Error(1)
+}
+
+// Audit RECORDER
+type MockRecorder struct{ mock.Mock }
+
+func (m *MockRecorder) Record(ctx context.Context, action, targetType, targetID string, before, after map[string]interface{}) error {
+	args := m.Called(ctx, action, targetType, targetID, before, after)
+	return args.Error(0)
+}
+
+// Destination PREFLIGHT
+type MockPreflight struct{ mock.Mock }
+
+func (m *MockPreflight) Follow(ctx context.Context, targetURL string, check preflight_service.HopCheck) ([]string, error) {
+	args := m.Called(ctx, targetURL)
+	return []string{targetURL}, args.Error(0)
+}
+
+func (m *MockPreflight) FetchTitle(ctx context.Context, targetURL string) (string, error) {
+	args := m.Called(ctx, targetURL)
+	return args.String(0), args.Error(1)
+}
+
+// Custom domain CHECKER
+type MockDomainChecker struct{ mock.Mock }
+
+func (m *MockDomainChecker) CheckUsable(ctx context.Context, host, workspaceID string) (string, error) {
+	args := m.Called(ctx, host, workspaceID)
+	return args.String(0), args.Error(1)
 }
 
 func TestMain(m *testing.M) {
@@ -63,8 +161,9 @@ func TestMain(m *testing.M) {
 func TestIsOwner(t *testing.T) {
 	mockSL := new(MockShortlink)
 	mockBL := new(MockBlacklistChecker)
-	mockSB := new(MockURLSafetyChecker)
-	svc := url_service.New(mockSL, mockBL, mockSB)
+	mockSB := new(MockURLChecker)
+	mockWS := new(MockWorkspaceMembership)
+	svc := url_service.New(mockSL, mockBL, mockSB, mockWS, new(MockRecorder), nil, nil, nil, nil, url_service.Config{})
 
 	t.Run("IsOwner returns true when user is owner", func(t *testing.T) {
 		shortID := "test123"
@@ -104,11 +203,66 @@ func TestIsOwner(t *testing.T) {
 	})
 }
 
+func TestAuthorize(t *testing.T) {
+	mockSL := new(MockShortlink)
+	mockBL := new(MockBlacklistChecker)
+	mockSB := new(MockURLChecker)
+	mockWS := new(MockWorkspaceMembership)
+	svc := url_service.New(mockSL, mockBL, mockSB, mockWS, new(MockRecorder), nil, nil, nil, nil, url_service.Config{})
+
+	personalLink := &models.Shortlink{ShortID: "personal1", CreatedBy: "owner1"}
+	workspaceLink := &models.Shortlink{ShortID: "team1", CreatedBy: "owner1", WorkspaceID: "ws1"}
+
+	t.Run("Creator can access personal link", func(t *testing.T) {
+		mockSL.On("GetShortlink", mock.Anything, "personal1").Return(personalLink, nil).Once()
+
+		allowed, err := svc.Authorize(context.Background(), "personal1", "owner1", models.PermissionEdit)
+		assert.NoError(t, err)
+		assert.True(t, allowed)
+	})
+
+	t.Run("Other user cannot access personal link", func(t *testing.T) {
+		mockSL.On("GetShortlink", mock.Anything, "personal1").Return(personalLink, nil).Once()
+
+		allowed, err := svc.Authorize(context.Background(), "personal1", "stranger", models.PermissionView)
+		assert.NoError(t, err)
+		assert.False(t, allowed)
+	})
+
+	t.Run("Workspace viewer can view but not edit", func(t *testing.T) {
+		viewer := &models.WorkspaceMember{WorkspaceID: "ws1", UID: "viewer1", Role: models.RoleViewer}
+		mockSL.On("GetShortlink", mock.Anything, "team1").Return(workspaceLink, nil).Twice()
+		mockWS.On("GetMember", mock.Anything, "ws1", "viewer1").Return(viewer, nil).Twice()
+
+		allowed, err := svc.Authorize(context.Background(), "team1", "viewer1", models.PermissionView)
+		assert.NoError(t, err)
+		assert.True(t, allowed)
+
+		allowed, err = svc.Authorize(context.Background(), "team1", "viewer1", models.PermissionEdit)
+		assert.NoError(t, err)
+		assert.False(t, allowed)
+	})
+
+	t.Run("Non-member cannot access workspace link", func(t *testing.T) {
+		mockSL.On("GetShortlink", mock.Anything, "team1").Return(workspaceLink, nil).Once()
+		mockWS.On("GetMember", mock.Anything, "ws1", "outsider").
+			Return(&models.WorkspaceMember{}, shortlink_errors.ErrNotFound).Once()
+
+		allowed, err := svc.Authorize(context.Background(), "team1", "outsider", models.PermissionView)
+		assert.NoError(t, err)
+		assert.False(t, allowed)
+	})
+
+	mockSL.AssertExpectations(t)
+	mockWS.AssertExpectations(t)
+}
+
 func TestResolve(t *testing.T) {
 	mockSL := new(MockShortlink)
 	mockBL := new(MockBlacklistChecker)
-	mockSB := new(MockURLSafetyChecker)
-	svc := url_service.New(mockSL, mockBL, mockSB)
+	mockSB := new(MockURLChecker)
+	mockWS := new(MockWorkspaceMembership)
+	svc := url_service.New(mockSL, mockBL, mockSB, mockWS, new(MockRecorder), nil, nil, nil, nil, url_service.Config{})
 
 	t.Run("Public URL resolves successfully", func(t *testing.T) {
 		shortID := "abc123"
@@ -156,15 +310,110 @@ func TestResolve(t *testing.T) {
 		assert.Equal(t, "", url)
 		assert.Equal(t, shortlink_errors.ErrForbidden, err)
 	})
+
+	t.Run("Disabled URL does not resolve", func(t *testing.T) {
+		shortID := "abc123"
+		mockSL.On("GetShortlink", mock.Anything, shortID).Return(&models.Shortlink{
+			ShortID:        shortID,
+			URL:            "https://evil.com",
+			CreatedBy:      "user123",
+			Disabled:       true,
+			DisabledReason: models.DisabledReasonBlacklisted,
+		}, nil).Once()
+
+		url, err := svc.Resolve(context.Background(), shortID)
+		assert.Equal(t, "", url)
+		assert.Equal(t, shortlink_errors.ErrLinkDisabled, err)
+	})
+
+	t.Run("Expired URL does not resolve", func(t *testing.T) {
+		mockSL.On("GetShortlink", mock.Anything, "old1").Return(&models.Shortlink{
+			ShortID:   "old1",
+			URL:       "https://example.com/sale",
+			CreatedBy: "user123",
+			ExpiresAt: time.Now().Add(-time.Minute),
+		}, nil).Once()
+		mockSL.On("GetShortlink", mock.Anything, "new1").Return(&models.Shortlink{
+			ShortID:   "new1",
+			URL:       "https://example.com/sale",
+			CreatedBy: "user123",
+			ExpiresAt: time.Now().Add(time.Hour),
+		}, nil).Once()
+
+		_, err := svc.Resolve(context.Background(), "old1")
+		assert.Equal(t, shortlink_errors.ErrLinkExpired, err)
+
+		url, err := svc.Resolve(context.Background(), "new1")
+		assert.NoError(t, err)
+		assert.Equal(t, "https://example.com/sale", url)
+	})
+}
+
+func TestPreview(t *testing.T) {
+	mockSL := new(MockShortlink)
+	mockSB := new(MockURLChecker)
+	svc := url_service.New(mockSL, new(MockBlacklistChecker), mockSB, new(MockWorkspaceMembership), new(MockRecorder), nil, nil, nil, nil, url_service.Config{})
+
+	t.Run("Shows destination and title", func(t *testing.T) {
+		mockSL.On("GetShortlink", mock.Anything, "safe1").Return(&models.Shortlink{
+			ShortID: "safe1", URL: "https://example.com/page", Title: "Example", Preview: true,
+		}, nil).Once()
+		mockSB.On("Check", mock.Anything, "https://example.com/page").Return(reputation_service.Verdict{}, nil).Once()
+
+		preview, err := svc.Preview(context.Background(), "safe1", "")
+		require.NoError(t, err)
+		assert.Equal(t, &dto.LinkPreview{ShortID: "safe1", URL: "https://example.com/page", Domain: "example.com", Title: "Example"}, preview)
+	})
+
+	t.Run("Warns about insecure and unsafe destinations", func(t *testing.T) {
+		mockSL.On("GetShortlink", mock.Anything, "bad1").Return(&models.Shortlink{
+			ShortID: "bad1", URL: "http://malware.test/",
+		}, nil).Once()
+		mockSB.On("Check", mock.Anything, "http://malware.test/").Return(reputation_service.Verdict{
+			Unsafe: true, Provider: "safebrowsing", ThreatType: "MALWARE",
+		}, nil).Once()
+
+		preview, err := svc.Preview(context.Background(), "bad1", "")
+		require.NoError(t, err)
+		assert.Len(t, preview.Warnings, 2)
+	})
+
+	t.Run("Shows the destination of a redirect rule", func(t *testing.T) {
+		mockSL.On("GetShortlink", mock.Anything, "rule1").Return(&models.Shortlink{
+			ShortID: "rule1", URL: "https://example.com/",
+			Rules: []models.RedirectRule{{ID: "ios", URL: "https://apps.apple.com/app", Devices: []string{"ios"}}},
+		}, nil).Twice()
+		mockSB.On("Check", mock.Anything, "https://apps.apple.com/app").Return(reputation_service.Verdict{}, nil).Once()
+		mockSB.On("Check", mock.Anything, "https://example.com/").Return(reputation_service.Verdict{}, nil).Once()
+
+		preview, err := svc.Preview(context.Background(), "rule1", "https://apps.apple.com/app")
+		require.NoError(t, err)
+		assert.Equal(t, "apps.apple.com", preview.Domain)
+
+		// only destinations of the link are shown
+		preview, err = svc.Preview(context.Background(), "rule1", "https://elsewhere.test/")
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com/", preview.URL)
+	})
+
+	t.Run("Disabled link has no preview", func(t *testing.T) {
+		mockSL.On("GetShortlink", mock.Anything, "off1").Return(&models.Shortlink{
+			ShortID: "off1", URL: "https://example.com", Disabled: true,
+		}, nil).Once()
+
+		_, err := svc.Preview(context.Background(), "off1", "")
+		assert.Equal(t, shortlink_errors.ErrLinkDisabled, err)
+	})
 }
 
 // Shorten
 func TestShorten_SuccessWithCustomID(t *testing.T) {
 	mockSL := new(MockShortlink)
 	mockBL := new(MockBlacklistChecker)
-	mockSB := new(MockURLSafetyChecker)
+	mockSB := new(MockURLChecker)
+	mockWS := new(MockWorkspaceMembership)
 
-	svc := url_service.New(mockSL, mockBL, mockSB)
+	svc := url_service.New(mockSL, mockBL, mockSB, mockWS, new(MockRecorder), nil, nil, nil, nil, url_service.Config{})
 	t.Run("URL with Custom ID has successfully shortened", func(t *testing.T) {
 		ctx := context.WithValue(context.Background(), utils.UserKey, "user123")
 		req := dto.ShortenRequest{
@@ -176,30 +425,239 @@ func TestShorten_SuccessWithCustomID(t *testing.T) {
 		mockBL.On("IsBlacklisted", mock.MatchedBy(func(ctx context.Context) bool {
 			return ctx.Value(utils.UserKey) == "user123"
 		}), req.URL).Return(false, nil) // domain is not blacklisted
-		mockSB.On("IsUnsafe", mock.MatchedBy(func(ctx context.Context) bool {
+		mockSB.On("Check", mock.MatchedBy(func(ctx context.Context) bool {
 			return ctx.Value(utils.UserKey) == "user123"
-		}), req.URL).Return(false, nil) // domain is safe
+		}), req.URL).Return(reputation_service.Verdict{}, nil) // domain is safe
 		mockSL.On("GetShortlink", mock.MatchedBy(func(c context.Context) bool {
 			return c.Value(utils.UserKey) == "user123"
 		}), req.CustomID).Return(&models.Shortlink{}, shortlink_errors.ErrNotFound) // shortlink not already in the database
 
-		mockSL.On("SetShortlink", mock.MatchedBy(func(c context.Context) bool {
+		mockSL.On("CreateShortlink", mock.MatchedBy(func(c context.Context) bool {
 			return c.Value(utils.UserKey) == "user123"
-		}), req.CustomID, mock.Anything).Return(nil) // successful set and save the shortlink
+		}), mock.MatchedBy(func(l models.Shortlink) bool {
+			return l.ShortID == req.CustomID
+		})).Return(nil).Once() // successful create and save the shortlink
 
 		shortID, err := svc.Shorten(ctx, req)
 
 		require.NoError(t, err)
 		require.Equal(t, req.CustomID, shortID)
 	})
+
+	t.Run("Custom ID taken after the check is not overwritten", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), utils.UserKey, "user123")
+		req := dto.ShortenRequest{URL: "https://example.com/race", CustomID: "race123"}
+
+		mockBL.On("IsBlacklisted", mock.Anything, req.URL).Return(false, nil).Once()
+		mockSB.On("Check", mock.Anything, req.URL).Return(reputation_service.Verdict{}, nil).Once()
+		mockSL.On("GetShortlink", mock.Anything, req.CustomID).Return(&models.Shortlink{}, shortlink_errors.ErrNotFound).Once()
+		mockSL.On("CreateShortlink", mock.Anything, mock.MatchedBy(func(l models.Shortlink) bool {
+			return l.ShortID == req.CustomID
+		})).Return(shortlink_errors.ErrIDExists).Once()
+
+		_, err := svc.Shorten(ctx, req)
+
+		assert.Equal(t, shortlink_errors.ErrIDExists, err)
+		mockSL.AssertNotCalled(t, "SetShortlink", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Links expire at the given time, which must be in the future", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), utils.UserKey, "user123")
+		expiresAt := time.Now().Add(24 * time.Hour)
+		req := dto.ShortenRequest{URL: "https://Example.com/sale", CustomID: "sale123", ExpiresAt: &expiresAt}
+
+		mockBL.On("IsBlacklisted", mock.Anything, req.URL).Return(false, nil).Twice()
+		mockSB.On("Check", mock.Anything, req.URL).Return(reputation_service.Verdict{}, nil).Twice()
+		mockSL.On("GetShortlink", mock.Anything, req.CustomID).Return(&models.Shortlink{}, shortlink_errors.ErrNotFound).Twice()
+		mockSL.On("CreateShortlink", mock.Anything, mock.MatchedBy(func(l models.Shortlink) bool {
+			return l.ShortID == req.CustomID && l.ExpiresAt.Equal(expiresAt) && l.DestinationHost == "example.com"
+		})).Return(nil).Once()
+
+		_, err := svc.Shorten(ctx, req)
+		require.NoError(t, err)
+
+		past := time.Now().Add(-time.Hour)
+		req.ExpiresAt = &past
+		_, err = svc.Shorten(ctx, req)
+		assert.Equal(t, shortlink_errors.ErrValidateRequest, err)
+	})
+
+	t.Run("Reserved words are refused in any case or spelling", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), utils.UserKey, "user123")
+
+		for _, customID := range []string{"Admin", "4DM1N", "L0gin"} {
+			_, err := svc.Shorten(ctx, dto.ShortenRequest{URL: "https://example.com/", CustomID: customID})
+			assert.Equal(t, shortlink_errors.ErrBlacklistedID, err, customID)
+		}
+		mockSL.AssertNotCalled(t, "GetShortlink", mock.Anything, "Admin")
+	})
+
+	t.Run("URL reported unsafe by a reputation provider is rejected", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), utils.UserKey, "user123")
+		req := dto.ShortenRequest{URL: "https://phishing.example.com/login"}
+
+		mockBL.On("IsBlacklisted", mock.Anything, req.URL).Return(false, nil).Once()
+		mockSB.On("Check", mock.Anything, req.URL).Return(reputation_service.Verdict{
+			Unsafe: true, Provider: "safebrowsing", ThreatType: "SOCIAL_ENGINEERING",
+		}, nil).Once()
+
+		_, err := svc.Shorten(ctx, req)
+
+		assert.ErrorIs(t, err, shortlink_errors.ErrForbiddenInput)
+	})
+}
+
+func TestShorten_ReuseExisting(t *testing.T) {
+	mockSL := new(MockShortlink)
+	mockBL := new(MockBlacklistChecker)
+	mockSB := new(MockURLChecker)
+	svc := url_service.New(mockSL, mockBL, mockSB, new(MockWorkspaceMembership), new(MockRecorder), nil, nil, nil, nil, url_service.Config{
+		Canon: urlcanon.Options{StripTracking: true},
+	})
+	ctx := context.WithValue(context.Background(), utils.UserKey, "user123")
+	mockBL.On("IsBlacklisted", mock.Anything, mock.Anything).Return(false, nil)
+	mockSB.On("Check", mock.Anything, mock.Anything).Return(reputation_service.Verdict{}, nil)
+
+	const canonical = "https://example.com/a/c?id=1"
+
+	t.Run("Existing link with the same canonical URL is returned", func(t *testing.T) {
+		mockSL.On("FindLinksByCanonicalURL", mock.Anything, "user123", "", canonical).Return([]models.Shortlink{
+			{ShortID: "private1", CanonicalURL: canonical, IsPrivate: true},
+			{ShortID: "disabled1", CanonicalURL: canonical, Disabled: true},
+			{ShortID: "existing1", CanonicalURL: canonical},
+		}, nil).Once()
+
+		shortID, err := svc.Shorten(ctx, dto.ShortenRequest{
+			URL:           "HTTPS://Example.com:443/a/b/../c?id=1&utm_source=newsletter#top",
+			ReuseExisting: true,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "existing1", shortID)
+		mockSL.AssertNotCalled(t, "SetShortlink", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("New link stores the canonical URL when nothing matches", func(t *testing.T) {
+		mockSL.On("FindLinksByCanonicalURL", mock.Anything, "user123", "", canonical).Return([]models.Shortlink{}, nil).Once()
+		mockSL.On("CreateShortlink", mock.Anything, mock.MatchedBy(func(link models.Shortlink) bool {
+			return link.CanonicalURL == canonical && link.URL == "https://example.com/a/c?id=1&utm_source=x"
+		})).Return(nil).Once()
+
+		shortID, err := svc.Shorten(ctx, dto.ShortenRequest{URL: "https://example.com/a/c?id=1&utm_source=x", ReuseExisting: true})
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, shortID)
+	})
+
+	t.Run("Custom ID always creates a new link", func(t *testing.T) {
+		mockSL.On("GetShortlink", mock.Anything, "mine1").Return(&models.Shortlink{}, shortlink_errors.ErrNotFound).Once()
+		mockSL.On("CreateShortlink", mock.Anything, mock.MatchedBy(func(l models.Shortlink) bool { return l.ShortID == "mine1" })).Return(nil).Once()
+
+		shortID, err := svc.Shorten(ctx, dto.ShortenRequest{URL: canonical, CustomID: "mine1", ReuseExisting: true})
+
+		require.NoError(t, err)
+		assert.Equal(t, "mine1", shortID)
+	})
+
+	mockSL.AssertExpectations(t)
+}
+
+// ID GENERATOR returning fixed IDs in order
+func TestShorten_CustomDomain(t *testing.T) {
+	mockSL := new(MockShortlink)
+	mockBL := new(MockBlacklistChecker)
+	mockSB := new(MockURLChecker)
+	mockDC := new(MockDomainChecker)
+
+	svc := url_service.New(mockSL, mockBL, mockSB, new(MockWorkspaceMembership), new(MockRecorder), nil, nil, nil, mockDC, url_service.Config{})
+	ctx := context.WithValue(context.Background(), utils.UserKey, "user123")
+
+	t.Run("Custom IDs are unique per domain", func(t *testing.T) {
+		req := dto.ShortenRequest{URL: "https://example.com/launch", CustomID: "launch", Domain: "Go.Brand.com"}
+
+		mockDC.On("CheckUsable", mock.Anything, "Go.Brand.com", "").Return("go.brand.com", nil).Once()
+		mockBL.On("IsBlacklisted", mock.Anything, req.URL).Return(false, nil).Once()
+		mockSB.On("Check", mock.Anything, req.URL).Return(reputation_service.Verdict{}, nil).Once()
+		mockSL.On("GetShortlink", mock.Anything, "launch@go.brand.com").Return(&models.Shortlink{}, shortlink_errors.ErrNotFound).Once()
+		mockSL.On("CreateShortlink", mock.Anything, mock.MatchedBy(func(l models.Shortlink) bool {
+			return l.ShortID == "launch" && l.Domain == "go.brand.com"
+		})).Return(nil).Once()
+
+		key, err := svc.Shorten(ctx, req)
+
+		require.NoError(t, err)
+		assert.Equal(t, "launch@go.brand.com", key)
+		mockSL.AssertNotCalled(t, "GetShortlink", mock.Anything, "launch")
+	})
+
+	t.Run("Unverified domains are refused", func(t *testing.T) {
+		req := dto.ShortenRequest{URL: "https://example.com/", Domain: "new.brand.com"}
+		mockDC.On("CheckUsable", mock.Anything, req.Domain, "").Return("", shortlink_errors.ErrDomainNotVerified).Once()
+
+		_, err := svc.Shorten(ctx, req)
+
+		assert.Equal(t, shortlink_errors.ErrDomainNotVerified, err)
+	})
+
+	t.Run("Custom domains need the domain service", func(t *testing.T) {
+		plain := url_service.New(mockSL, mockBL, mockSB, new(MockWorkspaceMembership), new(MockRecorder), nil, nil, nil, nil, url_service.Config{})
+
+		_, err := plain.Shorten(ctx, dto.ShortenRequest{URL: "https://example.com/", Domain: "go.brand.com"})
+
+		assert.Equal(t, shortlink_errors.ErrDomainNotVerified, err)
+	})
+}
+
+type sequenceIDs struct{ ids []string }
+
+func (g *sequenceIDs) Generate(ctx context.Context) (string, error) {
+	id := g.ids[0]
+	g.ids = g.ids[1:]
+	return id, nil
+}
+
+func TestShorten_GeneratedID(t *testing.T) {
+	mockSL := new(MockShortlink)
+	mockBL := new(MockBlacklistChecker)
+	mockSB := new(MockURLChecker)
+	mockBL.On("IsBlacklisted", mock.Anything, mock.Anything).Return(false, nil)
+	mockSB.On("Check", mock.Anything, mock.Anything).Return(reputation_service.Verdict{}, nil)
+	ctx := context.WithValue(context.Background(), utils.UserKey, "user123")
+	req := dto.ShortenRequest{URL: "https://example.com/"}
+
+	t.Run("Retries with a new ID when the ID is reserved or taken", func(t *testing.T) {
+		ids := &sequenceIDs{ids: []string{"admin", "taken1", "free1"}}
+		svc := url_service.New(mockSL, mockBL, mockSB, new(MockWorkspaceMembership), new(MockRecorder), nil, ids, nil, nil, url_service.Config{})
+		mockSL.On("CreateShortlink", mock.Anything, mock.MatchedBy(func(l models.Shortlink) bool { return l.ShortID == "taken1" })).
+			Return(shortlink_errors.ErrIDExists).Once()
+		mockSL.On("CreateShortlink", mock.Anything, mock.MatchedBy(func(l models.Shortlink) bool { return l.ShortID == "free1" })).
+			Return(nil).Once()
+
+		shortID, err := svc.Shorten(ctx, req)
+
+		require.NoError(t, err)
+		assert.Equal(t, "free1", shortID)
+		mockSL.AssertNotCalled(t, "SetShortlink", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Gives up after repeated collisions", func(t *testing.T) {
+		ids := &sequenceIDs{ids: []string{"a1a", "b1b", "c1c", "d1d", "e1e"}}
+		svc := url_service.New(mockSL, mockBL, mockSB, new(MockWorkspaceMembership), new(MockRecorder), nil, ids, nil, nil, url_service.Config{})
+		mockSL.On("CreateShortlink", mock.Anything, mock.Anything).Return(shortlink_errors.ErrIDExists).Times(5)
+
+		_, err := svc.Shorten(ctx, req)
+
+		assert.Equal(t, shortlink_errors.ErrGenerateID, err)
+	})
 }
 
 func TestShorten_InvalidURL(t *testing.T) {
 	mockSL := new(MockShortlink)
 	mockBL := new(MockBlacklistChecker)
-	mockSB := new(MockURLSafetyChecker)
+	mockSB := new(MockURLChecker)
+	mockWS := new(MockWorkspaceMembership)
 
-	svc := url_service.New(mockSL, mockBL, mockSB)
+	svc := url_service.New(mockSL, mockBL, mockSB, mockWS, new(MockRecorder), nil, nil, nil, nil, url_service.Config{})
 	t.Run("Invalid URL failed to be shortened", func(t *testing.T) {
 		ctx := context.WithValue(context.Background(), utils.UserKey, "user123")
 		req := dto.ShortenRequest{
@@ -212,14 +670,174 @@ func TestShorten_InvalidURL(t *testing.T) {
 		require.Error(t, err)
 		require.Equal(t, shortlink_errors.ErrValidateRequest, err)
 	})
+
+	t.Run("Only http and https URLs can be shortened", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), utils.UserKey, "user123")
+
+		_, err := svc.Shorten(ctx, dto.ShortenRequest{URL: "ftp://example.com/file.txt"})
+
+		assert.Equal(t, shortlink_errors.ErrUnsupportedScheme, err)
+		mockBL.AssertNotCalled(t, "IsBlacklisted", mock.Anything, mock.Anything)
+	})
+}
+
+func TestShortenBatch(t *testing.T) {
+	ctx := context.WithValue(context.Background(), utils.UserKey, "user123")
+
+	t.Run("Checks each URL once and writes the links together", func(t *testing.T) {
+		mockSL := new(MockShortlink)
+		mockBL := new(MockBlacklistChecker)
+		finder := &safebrowsing_service.MockSafeBrowsingService{UnsafeURLs: map[string]bool{"https://unsafe.example.com/": true}}
+		reputation := reputation_service.NewComposite(reputation_service.Config{}, []reputation_service.Provider{
+			reputation_service.NewSafeBrowsingProvider(finder),
+		})
+		ids := &sequenceIDs{ids: []string{"gen1", "gen2"}}
+		svc := url_service.New(mockSL, mockBL, reputation, new(MockWorkspaceMembership), new(MockRecorder), nil, ids, nil, nil, url_service.Config{})
+
+		reqs := []dto.ShortenRequest{
+			{URL: "https://example.com/a"},
+			{URL: "https://example.com/a", CustomID: "campaign1"},
+			{URL: "https://blocked.example.com/"},
+			{URL: "https://unsafe.example.com/"},
+			{URL: "ftp://example.com/file"},
+			{URL: "https://example.com/b", CustomID: "campaign1"},
+		}
+		mockBL.On("IsBlacklisted", mock.Anything, "https://example.com/a").Return(false, nil).Once()
+		mockBL.On("IsBlacklisted", mock.Anything, "https://blocked.example.com/").Return(true, nil).Once()
+		mockBL.On("IsBlacklisted", mock.Anything, "https://unsafe.example.com/").Return(false, nil).Once()
+		mockSL.On("GetShortlink", mock.Anything, "campaign1").Return(&models.Shortlink{}, shortlink_errors.ErrNotFound).Once()
+		mockSL.On("CreateShortlinks", mock.Anything, mock.MatchedBy(func(links []models.Shortlink) bool {
+			return len(links) == 2 && links[0].ShortID == "gen1" && links[1].ShortID == "campaign1"
+		})).Return([]error{nil, nil}, nil).Once()
+		mockSL.On("ClaimShortenBatch", mock.Anything, mock.MatchedBy(func(b models.ShortenBatch) bool {
+			return b.UID == "user123" && b.IdempotencyKey != "" && b.RequestHash != "" && b.Response == ""
+		})).Return((*models.ShortenBatch)(nil), nil).Once()
+		mockSL.On("SaveShortenBatch", mock.Anything, mock.MatchedBy(func(b models.ShortenBatch) bool {
+			return b.UID == "user123" && b.IdempotencyKey != "" && b.RequestHash != ""
+		})).Return(nil).Once()
+
+		resp, err := svc.ShortenBatch(ctx, "", reqs)
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, resp.IdempotencyKey)
+		assert.Equal(t, 2, resp.Created)
+		assert.Equal(t, 4, resp.Failed)
+		assert.Equal(t, "gen1", resp.Results[0].ShortID)
+		assert.Equal(t, "campaign1", resp.Results[1].ShortID)
+		assert.Equal(t, "forbidden_input", resp.Results[2].Error)
+		assert.Equal(t, "forbidden_input", resp.Results[3].Error)
+		assert.Equal(t, "unsupported_scheme", resp.Results[4].Error)
+		assert.Equal(t, "id_exists", resp.Results[5].Error)
+		for i, res := range resp.Results {
+			assert.Equal(t, i, res.Index)
+		}
+
+		require.Len(t, finder.Requests, 1)
+		assert.ElementsMatch(t, []string{"https://example.com/a", "https://blocked.example.com/", "https://unsafe.example.com/"}, finder.Requests[0])
+		mockBL.AssertExpectations(t)
+		mockSL.AssertExpectations(t)
+	})
+
+	t.Run("Generated IDs that collide are drawn again", func(t *testing.T) {
+		mockSL := new(MockShortlink)
+		mockBL := new(MockBlacklistChecker)
+		mockSB := new(MockURLChecker)
+		ids := &sequenceIDs{ids: []string{"taken1", "free2", "free1"}}
+		svc := url_service.New(mockSL, mockBL, mockSB, new(MockWorkspaceMembership), new(MockRecorder), nil, ids, nil, nil, url_service.Config{})
+
+		mockBL.On("IsBlacklisted", mock.Anything, mock.Anything).Return(false, nil)
+		mockSB.On("Check", mock.Anything, mock.Anything).Return(reputation_service.Verdict{}, nil)
+		mockSL.On("CreateShortlinks", mock.Anything, mock.MatchedBy(func(links []models.Shortlink) bool { return len(links) == 2 })).
+			Return([]error{shortlink_errors.ErrIDExists, nil}, nil).Once()
+		mockSL.On("CreateShortlinks", mock.Anything, mock.MatchedBy(func(links []models.Shortlink) bool {
+			return len(links) == 1 && links[0].ShortID == "free1"
+		})).Return([]error{nil}, nil).Once()
+		mockSL.On("ClaimShortenBatch", mock.Anything, mock.Anything).Return((*models.ShortenBatch)(nil), nil).Once()
+		mockSL.On("SaveShortenBatch", mock.Anything, mock.Anything).Return(nil).Once()
+
+		resp, err := svc.ShortenBatch(ctx, "", []dto.ShortenRequest{{URL: "https://example.com/1"}, {URL: "https://example.com/2"}})
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, resp.Created)
+		assert.Equal(t, "free1", resp.Results[0].ShortID)
+		assert.Equal(t, "free2", resp.Results[1].ShortID)
+	})
+
+	t.Run("Retries with the same key replay the response", func(t *testing.T) {
+		mockSL := new(MockShortlink)
+		mockBL := new(MockBlacklistChecker)
+		mockSB := new(MockURLChecker)
+		ids := &sequenceIDs{ids: []string{"once1"}}
+		svc := url_service.New(mockSL, mockBL, mockSB, new(MockWorkspaceMembership), new(MockRecorder), nil, ids, nil, nil, url_service.Config{})
+		reqs := []dto.ShortenRequest{{URL: "https://example.com/retry"}}
+
+		var saved models.ShortenBatch
+		mockBL.On("IsBlacklisted", mock.Anything, mock.Anything).Return(false, nil)
+		mockSB.On("Check", mock.Anything, mock.Anything).Return(reputation_service.Verdict{}, nil)
+		mockSL.On("ClaimShortenBatch", mock.Anything, mock.Anything).Return((*models.ShortenBatch)(nil), nil).Once()
+		mockSL.On("CreateShortlinks", mock.Anything, mock.Anything).Return([]error{nil}, nil).Once()
+		mockSL.On("SaveShortenBatch", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			saved = args.Get(1).(models.ShortenBatch)
+		}).Return(nil).Once()
+
+		first, err := svc.ShortenBatch(ctx, "retry-key", reqs)
+		require.NoError(t, err)
+		assert.Equal(t, "retry-key", first.IdempotencyKey)
+
+		mockSL.On("ClaimShortenBatch", mock.Anything, mock.Anything).Return(&saved, nil)
+
+		second, err := svc.ShortenBatch(ctx, "retry-key", reqs)
+		require.NoError(t, err)
+		assert.True(t, second.Replayed)
+		assert.Equal(t, first.Results, second.Results)
+
+		_, err = svc.ShortenBatch(ctx, "retry-key", []dto.ShortenRequest{{URL: "https://example.com/other"}})
+		assert.Equal(t, shortlink_errors.ErrIdempotencyKeyReused, err)
+
+		mockSL.AssertNumberOfCalls(t, "CreateShortlinks", 1)
+	})
+
+	t.Run("Retries while the batch is running are refused", func(t *testing.T) {
+		mockSL := new(MockShortlink)
+		mockBL := new(MockBlacklistChecker)
+		mockSB := new(MockURLChecker)
+		svc := url_service.New(mockSL, mockBL, mockSB, new(MockWorkspaceMembership), new(MockRecorder), nil, nil, nil, nil, url_service.Config{})
+		reqs := []dto.ShortenRequest{{URL: "https://example.com/running"}}
+		mockBL.On("IsBlacklisted", mock.Anything, mock.Anything).Return(false, nil)
+		mockSB.On("Check", mock.Anything, mock.Anything).Return(reputation_service.Verdict{}, nil)
+
+		var claim models.ShortenBatch
+		mockSL.On("ClaimShortenBatch", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			claim = args.Get(1).(models.ShortenBatch)
+		}).Return((*models.ShortenBatch)(nil), nil).Once()
+		mockSL.On("CreateShortlinks", mock.Anything, mock.Anything).Return([]error{nil}, nil).Maybe()
+		mockSL.On("SaveShortenBatch", mock.Anything, mock.Anything).Return(nil).Maybe()
+		_, err := svc.ShortenBatch(ctx, "running-key", reqs)
+		require.NoError(t, err)
+
+		// the claim of the first request, before it stored its response
+		mockSL.On("ClaimShortenBatch", mock.Anything, mock.Anything).Return(&claim, nil).Once()
+		_, err = svc.ShortenBatch(ctx, "running-key", reqs)
+		assert.Equal(t, shortlink_errors.ErrIdempotencyKeyInProgress, err)
+		mockSL.AssertNumberOfCalls(t, "CreateShortlinks", 1)
+	})
+
+	t.Run("Batches are limited in size", func(t *testing.T) {
+		svc := url_service.New(new(MockShortlink), new(MockBlacklistChecker), new(MockURLChecker), new(MockWorkspaceMembership), new(MockRecorder), nil, nil, nil, nil, url_service.Config{MaxBatchItems: 2})
+
+		_, err := svc.ShortenBatch(ctx, "", make([]dto.ShortenRequest, 3))
+
+		assert.Equal(t, shortlink_errors.ErrBatchTooLarge, err)
+	})
 }
 
 func TestListUserLinks(t *testing.T) {
 	mockSL := new(MockShortlink)
 	mockBL := new(MockBlacklistChecker)
-	mockSB := new(MockURLSafetyChecker)
+	mockSB := new(MockURLChecker)
+	mockWS := new(MockWorkspaceMembership)
 
-	svc := url_service.New(mockSL, mockBL, mockSB)
+	svc := url_service.New(mockSL, mockBL, mockSB, mockWS, new(MockRecorder), nil, nil, nil, nil, url_service.Config{})
 
 	shortlinks1 := []models.Shortlink{
 		{ShortID: "short1", URL: "https://original1.link", CreatedAt: time.Now(), CreatedBy: "user1", IsPrivate: false},
@@ -268,3 +886,389 @@ func TestListUserLinks(t *testing.T) {
 
 	mockSL.AssertExpectations(t)
 }
+
+func TestUpdateShortlink(t *testing.T) {
+	mockSL := new(MockShortlink)
+	mockBL := new(MockBlacklistChecker)
+	mockSB := new(MockURLChecker)
+	mockWS := new(MockWorkspaceMembership)
+	mockAudit := new(MockRecorder)
+	svc := url_service.New(mockSL, mockBL, mockSB, mockWS, mockAudit, nil, nil, nil, nil, url_service.Config{})
+
+	ctx := context.WithValue(context.Background(), utils.UserKey, "owner1")
+	newURL := "https://new.example.com"
+
+	t.Run("Owner can change the destination", func(t *testing.T) {
+		mockSL.On("GetShortlink", mock.Anything, "link1").
+			Return(&models.Shortlink{ShortID: "link1", URL: "https://old.example.com", CreatedBy: "owner1"}, nil).Once()
+		mockBL.On("IsBlacklisted", mock.Anything, newURL).Return(false, nil).Once()
+		mockSB.On("Check", mock.Anything, newURL).Return(reputation_service.Verdict{}, nil).Once()
+		mockSL.On("UpdateShortlink", mock.Anything, "link1").
+			Return(&models.Shortlink{ShortID: "link1", URL: "https://old.example.com", CreatedBy: "owner1"}, nil).Once()
+		mockAudit.On("Record", mock.Anything, models.AuditLinkUpdate, models.AuditTargetShortlink, "link1",
+			mock.MatchedBy(func(m map[string]interface{}) bool { return m["url"] == "https://old.example.com" }),
+			mock.MatchedBy(func(m map[string]interface{}) bool { return m["url"] == newURL }),
+		).Return(nil).Once()
+
+		link, err := svc.UpdateShortlink(ctx, "link1", dto.UpdateShortlinkRequest{URL: &newURL})
+		require.NoError(t, err)
+		assert.Equal(t, newURL, link.URL)
+	})
+
+	t.Run("Changes made in the meantime are kept", func(t *testing.T) {
+		title := "Launch"
+		mockSL.On("GetShortlink", mock.Anything, "link1").
+			Return(&models.Shortlink{ShortID: "link1", URL: newURL, CreatedBy: "owner1", ClickCount: 3}, nil).Once()
+		mockSL.On("UpdateShortlink", mock.Anything, "link1").Return(&models.Shortlink{
+			ShortID: "link1", URL: newURL, CreatedBy: "owner1", ClickCount: 5,
+			Disabled: true, DisabledReason: models.DisabledReasonBlacklisted,
+		}, nil).Once()
+		mockAudit.On("Record", mock.Anything, models.AuditLinkUpdate, models.AuditTargetShortlink, "link1",
+			mock.Anything, mock.Anything).Return(nil).Once()
+
+		link, err := svc.UpdateShortlink(ctx, "link1", dto.UpdateShortlinkRequest{Title: &title})
+		require.NoError(t, err)
+		assert.Equal(t, title, link.Title)
+		assert.Equal(t, int64(5), link.ClickCount)
+		assert.True(t, link.Disabled)
+	})
+
+	t.Run("Blacklisted destination is rejected", func(t *testing.T) {
+		badURL := "https://blocked.example.com"
+		mockSL.On("GetShortlink", mock.Anything, "link1").
+			Return(&models.Shortlink{ShortID: "link1", URL: newURL, CreatedBy: "owner1"}, nil).Once()
+		mockBL.On("IsBlacklisted", mock.Anything, badURL).Return(true, nil).Once()
+		mockSB.On("Check", mock.Anything, badURL).Return(reputation_service.Verdict{}, nil).Maybe()
+
+		_, err := svc.UpdateShortlink(ctx, "link1", dto.UpdateShortlinkRequest{URL: &badURL})
+		assert.Equal(t, shortlink_errors.ErrForbiddenInput, err)
+	})
+
+	t.Run("A new URL does not re-enable a link disabled by a rule destination", func(t *testing.T) {
+		fixedURL := "https://fixed.example.com"
+		ruleURL := "https://blocked-rule.example.com"
+		disabled := &models.Shortlink{
+			ShortID: "link2", URL: newURL, CreatedBy: "owner1",
+			Rules:    []models.RedirectRule{{ID: "ios", URL: ruleURL, Devices: []string{"ios"}}},
+			Disabled: true, DisabledReason: models.DisabledReasonBlacklisted,
+		}
+		mockSL.On("GetShortlink", mock.Anything, "link2").Return(disabled, nil).Once()
+		mockBL.On("IsBlacklisted", mock.Anything, fixedURL).Return(false, nil).Once()
+		mockSB.On("Check", mock.Anything, fixedURL).Return(reputation_service.Verdict{}, nil).Once()
+		mockBL.On("IsBlacklisted", mock.Anything, ruleURL).Return(true, nil).Once()
+		mockSB.On("Check", mock.Anything, ruleURL).Return(reputation_service.Verdict{}, nil).Maybe()
+		mockSL.On("UpdateShortlink", mock.Anything, "link2").Return(disabled, nil).Once()
+		mockAudit.On("Record", mock.Anything, models.AuditLinkUpdate, models.AuditTargetShortlink, "link2",
+			mock.Anything, mock.Anything).Return(nil).Once()
+
+		link, err := svc.UpdateShortlink(ctx, "link2", dto.UpdateShortlinkRequest{URL: &fixedURL})
+		require.NoError(t, err)
+		assert.Equal(t, fixedURL, link.URL)
+		assert.True(t, link.Disabled)
+		assert.Equal(t, models.DisabledReasonBlacklisted, link.DisabledReason)
+	})
+
+	t.Run("An empty list removes the redirect rules", func(t *testing.T) {
+		mockSL.On("GetShortlink", mock.Anything, "link1").Return(&models.Shortlink{
+			ShortID: "link1", URL: newURL, CreatedBy: "owner1",
+			Rules: []models.RedirectRule{{ID: "ios", URL: "https://apps.apple.com/app", Devices: []string{"ios"}}},
+		}, nil).Once()
+		mockSL.On("UpdateShortlink", mock.Anything, "link1").Return(&models.Shortlink{
+			ShortID: "link1", URL: newURL, CreatedBy: "owner1",
+			Rules: []models.RedirectRule{{ID: "ios", URL: "https://apps.apple.com/app", Devices: []string{"ios"}}},
+		}, nil).Once()
+		mockAudit.On("Record", mock.Anything, models.AuditLinkUpdate, models.AuditTargetShortlink, "link1",
+			mock.Anything, mock.Anything).Return(nil).Once()
+
+		link, err := svc.UpdateShortlink(ctx, "link1", dto.UpdateShortlinkRequest{Rules: &[]dto.RedirectRule{}})
+		require.NoError(t, err)
+		assert.Empty(t, link.Rules)
+	})
+
+	t.Run("Other users cannot edit", func(t *testing.T) {
+		mockSL.On("GetShortlink", mock.Anything, "link1").
+			Return(&models.Shortlink{ShortID: "link1", URL: newURL, CreatedBy: "owner1"}, nil).Once()
+
+		otherCtx := context.WithValue(context.Background(), utils.UserKey, "stranger")
+		_, err := svc.UpdateShortlink(otherCtx, "link1", dto.UpdateShortlinkRequest{URL: &newURL})
+		assert.Equal(t, shortlink_errors.ErrForbidden, err)
+	})
+
+	mockSL.AssertExpectations(t)
+	mockAudit.AssertExpectations(t)
+}
+
+func TestTransferShortlink(t *testing.T) {
+	mockSL := new(MockShortlink)
+	mockBL := new(MockBlacklistChecker)
+	mockSB := new(MockURLChecker)
+	mockWS := new(MockWorkspaceMembership)
+	mockAudit := new(MockRecorder)
+	svc := url_service.New(mockSL, mockBL, mockSB, mockWS, mockAudit, nil, nil, nil, nil, url_service.Config{})
+
+	ctx := context.WithValue(context.Background(), utils.UserKey, "owner1")
+
+	t.Run("Owner moves a personal link into a workspace", func(t *testing.T) {
+		mockSL.On("GetShortlink", mock.Anything, "link1").
+			Return(&models.Shortlink{ShortID: "link1", CreatedBy: "owner1"}, nil).Once()
+		mockWS.On("GetMember", mock.Anything, "ws1", "owner1").
+			Return(&models.WorkspaceMember{WorkspaceID: "ws1", UID: "owner1", Role: models.RoleEditor}, nil).Once()
+		mockWS.On("GetMember", mock.Anything, "ws1", "member1").
+			Return(&models.WorkspaceMember{WorkspaceID: "ws1", UID: "member1", Role: models.RoleEditor}, nil).Once()
+		mockSL.On("UpdateShortlink", mock.Anything, "link1").
+			Return(&models.Shortlink{ShortID: "link1", CreatedBy: "owner1"}, nil).Once()
+		mockAudit.On("Record", mock.Anything, models.AuditLinkTransfer, models.AuditTargetShortlink, "link1", mock.Anything, mock.Anything).
+			Return(nil).Once()
+
+		link, err := svc.TransferShortlink(ctx, "link1", dto.TransferShortlinkRequest{WorkspaceID: "ws1", NewOwner: "member1"})
+		require.NoError(t, err)
+		assert.Equal(t, "ws1", link.WorkspaceID)
+	})
+
+	t.Run("Personal link cannot be handed to another user directly", func(t *testing.T) {
+		mockSL.On("GetShortlink", mock.Anything, "link2").
+			Return(&models.Shortlink{ShortID: "link2", CreatedBy: "owner1"}, nil).Once()
+
+		_, err := svc.TransferShortlink(ctx, "link2", dto.TransferShortlinkRequest{NewOwner: "member1"})
+		assert.Equal(t, shortlink_errors.ErrValidateRequest, err)
+	})
+
+	t.Run("New owner must be able to edit in the workspace", func(t *testing.T) {
+		mockSL.On("GetShortlink", mock.Anything, "link3").
+			Return(&models.Shortlink{ShortID: "link3", CreatedBy: "owner1", WorkspaceID: "ws1"}, nil).Once()
+		mockWS.On("GetMember", mock.Anything, "ws1", "owner1").
+			Return(&models.WorkspaceMember{WorkspaceID: "ws1", UID: "owner1", Role: models.RoleOwner}, nil).Once()
+		mockWS.On("GetMember", mock.Anything, "ws1", "viewer1").
+			Return(&models.WorkspaceMember{WorkspaceID: "ws1", UID: "viewer1", Role: models.RoleViewer}, nil).Once()
+
+		_, err := svc.TransferShortlink(ctx, "link3", dto.TransferShortlinkRequest{NewOwner: "viewer1"})
+		assert.Equal(t, shortlink_errors.ErrForbidden, err)
+	})
+
+	t.Run("Missing target is rejected", func(t *testing.T) {
+		_, err := svc.TransferShortlink(ctx, "link1", dto.TransferShortlinkRequest{})
+		assert.Equal(t, shortlink_errors.ErrValidateRequest, err)
+	})
+
+	mockSL.AssertExpectations(t)
+	mockWS.AssertExpectations(t)
+	mockAudit.AssertExpectations(t)
+}
+
+func TestShorten_Metadata(t *testing.T) {
+	mockSL := new(MockShortlink)
+	mockPF := new(MockPreflight)
+	svc := url_service.New(mockSL, nil, nil, nil, nil, mockPF, nil, nil, nil, url_service.Config{})
+	ctx := context.WithValue(context.Background(), utils.UserKey, "user123")
+
+	t.Run("Tags are stored lower-case once, with the notes", func(t *testing.T) {
+		req := dto.ShortenRequest{
+			URL: "https://example.com/spring", CustomID: "spring1",
+			Notes: "For the newsletter", Tags: []string{"Spring-Sale", "newsletter", "spring-sale"},
+		}
+		mockPF.On("Follow", mock.Anything, req.URL).Return(nil).Once()
+		mockSL.On("GetShortlink", mock.Anything, "spring1").Return(&models.Shortlink{}, shortlink_errors.ErrNotFound).Once()
+		mockSL.On("CreateShortlink", mock.Anything, mock.MatchedBy(func(l models.Shortlink) bool {
+			return l.Notes == req.Notes && assert.ObjectsAreEqual([]string{"spring-sale", "newsletter"}, l.Tags)
+		})).Return(nil).Once()
+
+		_, err := svc.Shorten(ctx, req)
+		require.NoError(t, err)
+	})
+
+	t.Run("Invalid tags are refused", func(t *testing.T) {
+		for _, tags := range [][]string{{"two words"}, {"-dash"}, {strings.Repeat("a", 51)}, make([]string, 21)} {
+			_, err := svc.Shorten(ctx, dto.ShortenRequest{URL: "https://example.com/", Tags: tags})
+			assert.Equal(t, shortlink_errors.ErrValidateRequest, err, tags)
+		}
+	})
+
+	t.Run("The destination's title is fetched when asked for", func(t *testing.T) {
+		req := dto.ShortenRequest{URL: "https://example.com/blog", CustomID: "blog1", FetchTitle: true}
+		mockPF.On("Follow", mock.Anything, req.URL).Return(nil).Once()
+		mockPF.On("FetchTitle", mock.Anything, req.URL).Return("Our blog", nil).Once()
+		mockSL.On("GetShortlink", mock.Anything, "blog1").Return(&models.Shortlink{}, shortlink_errors.ErrNotFound).Once()
+		mockSL.On("CreateShortlink", mock.Anything, mock.MatchedBy(func(l models.Shortlink) bool {
+			return l.Title == "Our blog"
+		})).Return(nil).Once()
+
+		_, err := svc.Shorten(ctx, req)
+		require.NoError(t, err)
+	})
+
+	t.Run("A given title or an unreachable page is not fetched over", func(t *testing.T) {
+		req := dto.ShortenRequest{URL: "https://example.com/own", CustomID: "own1", FetchTitle: true, Title: "Mine"}
+		mockPF.On("Follow", mock.Anything, req.URL).Return(nil).Once()
+		mockSL.On("GetShortlink", mock.Anything, "own1").Return(&models.Shortlink{}, shortlink_errors.ErrNotFound).Once()
+		mockSL.On("CreateShortlink", mock.Anything, mock.MatchedBy(func(l models.Shortlink) bool {
+			return l.ShortID == "own1" && l.Title == "Mine"
+		})).Return(nil).Once()
+
+		_, err := svc.Shorten(ctx, req)
+		require.NoError(t, err)
+
+		req = dto.ShortenRequest{URL: "https://example.com/down", CustomID: "down1", FetchTitle: true}
+		mockPF.On("Follow", mock.Anything, req.URL).Return(nil).Once()
+		mockPF.On("FetchTitle", mock.Anything, req.URL).Return("", errors.New("connection refused")).Once()
+		mockSL.On("GetShortlink", mock.Anything, "down1").Return(&models.Shortlink{}, shortlink_errors.ErrNotFound).Once()
+		mockSL.On("CreateShortlink", mock.Anything, mock.MatchedBy(func(l models.Shortlink) bool {
+			return l.ShortID == "down1" && l.Title == ""
+		})).Return(nil).Once()
+
+		_, err = svc.Shorten(ctx, req)
+		require.NoError(t, err)
+	})
+
+	mockSL.AssertExpectations(t)
+	mockPF.AssertExpectations(t)
+}
+
+func TestShorten_RedirectRules(t *testing.T) {
+	mockSL := new(MockShortlink)
+	mockPF := new(MockPreflight)
+	svc := url_service.New(mockSL, nil, nil, nil, nil, mockPF, nil, nil, nil, url_service.Config{})
+	ctx := context.WithValue(context.Background(), utils.UserKey, "user123")
+
+	t.Run("Rules are numbered and their destinations checked", func(t *testing.T) {
+		req := dto.ShortenRequest{
+			URL: "https://example.com/app", CustomID: "app1",
+			Rules: []dto.RedirectRule{
+				{URL: "https://example.com/de", Languages: []string{"de-DE"}},
+				{ID: "rule-1", URL: "https://apps.apple.com/app/id1", Devices: []string{"ios"}},
+			},
+		}
+		mockPF.On("Follow", mock.Anything, req.URL).Return(nil).Once()
+		mockPF.On("Follow", mock.Anything, "https://example.com/de").Return(nil).Once()
+		mockPF.On("Follow", mock.Anything, "https://apps.apple.com/app/id1").Return(nil).Once()
+		mockSL.On("GetShortlink", mock.Anything, "app1").Return(&models.Shortlink{}, shortlink_errors.ErrNotFound).Once()
+		mockSL.On("CreateShortlink", mock.Anything, mock.MatchedBy(func(l models.Shortlink) bool {
+			return len(l.Rules) == 2 && l.Rules[0].ID == "rule-2" && l.Rules[1].ID == "rule-1" &&
+				assert.ObjectsAreEqual([]string{"de-de"}, l.Rules[0].Languages)
+		})).Return(nil).Once()
+
+		_, err := svc.Shorten(ctx, req)
+		require.NoError(t, err)
+	})
+
+	t.Run("A rule leading to a refused destination fails the link", func(t *testing.T) {
+		req := dto.ShortenRequest{
+			URL:   "https://example.com/ok",
+			Rules: []dto.RedirectRule{{URL: "https://evil.test/", Countries: []string{"US"}}},
+		}
+		mockPF.On("Follow", mock.Anything, req.URL).Return(nil).Once()
+		mockPF.On("Follow", mock.Anything, "https://evil.test/").Return(shortlink_errors.ErrForbiddenInput).Once()
+
+		_, err := svc.Shorten(ctx, req)
+		assert.Equal(t, shortlink_errors.ErrForbiddenInput, err)
+	})
+
+	t.Run("Invalid rules are refused before any check", func(t *testing.T) {
+		starts, ends := time.Now(), time.Now().Add(-time.Hour)
+		for name, rule := range map[string]dto.RedirectRule{
+			"no condition":    {URL: "https://example.com/"},
+			"unknown device":  {URL: "https://example.com/", Devices: []string{"windows"}},
+			"country":         {URL: "https://example.com/", Countries: []string{"usa"}},
+			"language":        {URL: "https://example.com/", Languages: []string{"not a language"}},
+			"rule ID":         {ID: "has space", URL: "https://example.com/", Devices: []string{"ios"}},
+			"URL":             {URL: "example", Devices: []string{"ios"}},
+			"day":             {URL: "https://example.com/", Schedule: &dto.RuleSchedule{Days: []string{"monday"}}},
+			"time of day":     {URL: "https://example.com/", Schedule: &dto.RuleSchedule{From: "9am", To: "17:00"}},
+			"from without to": {URL: "https://example.com/", Schedule: &dto.RuleSchedule{From: "09:00"}},
+			"timezone":        {URL: "https://example.com/", Schedule: &dto.RuleSchedule{Timezone: "Mars/Olympus"}},
+			"period":          {URL: "https://example.com/", Schedule: &dto.RuleSchedule{StartsAt: &starts, EndsAt: &ends}},
+		} {
+			_, err := svc.Shorten(ctx, dto.ShortenRequest{URL: "https://example.com/", Rules: []dto.RedirectRule{rule}})
+			assert.Equal(t, shortlink_errors.ErrValidateRequest, err, name)
+		}
+
+		duplicated := []dto.RedirectRule{
+			{ID: "mobile", URL: "https://example.com/ios", Devices: []string{"ios"}},
+			{ID: "mobile", URL: "https://example.com/android", Devices: []string{"android"}},
+		}
+		_, err := svc.Shorten(ctx, dto.ShortenRequest{URL: "https://example.com/", Rules: duplicated})
+		assert.Equal(t, shortlink_errors.ErrValidateRequest, err)
+	})
+
+	mockSL.AssertExpectations(t)
+	mockPF.AssertExpectations(t)
+}
+
+func TestTags(t *testing.T) {
+	mockSL := new(MockShortlink)
+	mockWS := new(MockWorkspaceMembership)
+	mockAudit := new(MockRecorder)
+	svc := url_service.New(mockSL, nil, nil, mockWS, mockAudit, nil, nil, nil, nil, url_service.Config{})
+	ctx := context.WithValue(context.Background(), utils.UserKey, "owner1")
+
+	clicked := time.Date(2026, time.October, 1, 12, 0, 0, 0, time.UTC)
+	links := func() []models.Shortlink {
+		return []models.Shortlink{
+			{ShortID: "a1", Tags: []string{"spring", "news"}, ClickCount: 3},
+			{ShortID: "b2", Tags: []string{"spring"}, ClickCount: 10, LastClickedAt: clicked},
+			{ShortID: "c3", Domain: "go.example.com", Tags: []string{"summer", "spring"}},
+		}
+	}
+
+	t.Run("Tags are listed with their links and clicks", func(t *testing.T) {
+		mockSL.On("ListTaggedLinks", mock.Anything, "owner1", "", "").Return(links(), nil).Once()
+
+		tags, err := svc.ListTags(ctx, "")
+		require.NoError(t, err)
+		require.Len(t, tags, 3)
+		assert.Equal(t, dto.TagSummary{Tag: "spring", Links: 3, TotalClicks: 13, LastClickedAt: &clicked}, tags[0])
+		assert.Equal(t, "news", tags[1].Tag)
+	})
+
+	t.Run("Tag analytics add up the clicks of its links", func(t *testing.T) {
+		mockSL.On("ListTaggedLinks", mock.Anything, "owner1", "", "spring").Return(links(), nil).Once()
+
+		analytics, err := svc.TagAnalytics(ctx, "", "Spring")
+		require.NoError(t, err)
+		assert.Equal(t, int64(13), analytics.TotalClicks)
+		require.Len(t, analytics.LinkStats, 3)
+		assert.Equal(t, "b2", analytics.LinkStats[0].ShortID)
+	})
+
+	t.Run("Renaming onto an existing tag merges them", func(t *testing.T) {
+		mockSL.On("ListTaggedLinks", mock.Anything, "owner1", "", "summer").Return(links()[2:], nil).Once()
+		mockSL.On("SetLinkTags", mock.Anything, map[string][]string{"c3@go.example.com": {"spring"}}).Return(nil).Once()
+		mockAudit.On("Record", mock.Anything, models.AuditTagRename, models.AuditTargetTag, "summer", mock.Anything, mock.Anything).Return(nil).Once()
+
+		summary, err := svc.RenameTag(ctx, "", "summer", dto.RenameTagRequest{Name: "Spring"})
+		require.NoError(t, err)
+		assert.Equal(t, "spring", summary.Tag)
+		assert.Equal(t, 1, summary.Links)
+	})
+
+	t.Run("Deleting a tag keeps the links", func(t *testing.T) {
+		mockSL.On("ListTaggedLinks", mock.Anything, "owner1", "", "spring").Return(links(), nil).Once()
+		mockSL.On("SetLinkTags", mock.Anything, map[string][]string{
+			"a1": {"news"}, "b2": {}, "c3@go.example.com": {"summer"},
+		}).Return(nil).Once()
+		mockAudit.On("Record", mock.Anything, models.AuditTagDelete, models.AuditTargetTag, "spring", mock.Anything, mock.Anything).Return(nil).Once()
+
+		require.NoError(t, svc.DeleteTag(ctx, "", "spring"))
+	})
+
+	t.Run("Unknown tags are not found", func(t *testing.T) {
+		mockSL.On("ListTaggedLinks", mock.Anything, "owner1", "", "nothing").Return([]models.Shortlink{}, nil).Once()
+
+		assert.Equal(t, shortlink_errors.ErrNotFound, svc.DeleteTag(ctx, "", "nothing"))
+	})
+
+	t.Run("Workspace viewers can look at tags but not change them", func(t *testing.T) {
+		viewer := &models.WorkspaceMember{WorkspaceID: "ws1", UID: "owner1", Role: models.RoleViewer}
+		mockWS.On("GetMember", mock.Anything, "ws1", "owner1").Return(viewer, nil).Twice()
+		mockSL.On("ListTaggedLinks", mock.Anything, "owner1", "ws1", "").Return([]models.Shortlink{}, nil).Once()
+
+		_, err := svc.ListTags(ctx, "ws1")
+		require.NoError(t, err)
+
+		err = svc.DeleteTag(ctx, "ws1", "spring")
+		assert.Equal(t, shortlink_errors.ErrForbidden, err)
+	})
+
+	mockSL.AssertExpectations(t)
+	mockWS.AssertExpectations(t)
+	mockAudit.AssertExpectations(t)
+}
@@ -0,0 +1,318 @@
+package workspace_service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/audit_service"
+	firestoreService "github.com/mfmahendr/url-shortener-backend/internal/services/firestore"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/validators"
+)
+
+const invitationTTL = 7 * 24 * time.Hour
+
+type (
+	WorkspaceService interface {
+		CreateWorkspace(ctx context.Context, req dto.CreateWorkspaceRequest) (*dto.WorkspaceDTO, error)
+		ListWorkspaces(ctx context.Context) ([]dto.WorkspaceDTO, error)
+		ListMembers(ctx context.Context, workspaceID string) ([]dto.WorkspaceMemberDTO, error)
+		InviteMember(ctx context.Context, workspaceID string, req dto.InviteMemberRequest) (*dto.InvitationDTO, error)
+		AcceptInvitation(ctx context.Context, invitationID string) (*dto.WorkspaceDTO, error)
+		UpdateMemberRole(ctx context.Context, workspaceID, uid string, req dto.UpdateMemberRoleRequest) error
+		RemoveMember(ctx context.Context, workspaceID, uid string) error
+	}
+
+	WorkspaceServiceImpl struct {
+		firestore firestoreService.WorkspaceManager
+		audit     audit_service.Recorder
+	}
+)
+
+func New(fs firestoreService.WorkspaceManager, audit audit_service.Recorder) WorkspaceService {
+	return &WorkspaceServiceImpl{firestore: fs, audit: audit}
+}
+
+func (s *WorkspaceServiceImpl) CreateWorkspace(ctx context.Context, req dto.CreateWorkspaceRequest) (*dto.WorkspaceDTO, error) {
+	if err := validators.Validate.Struct(req); err != nil {
+		return nil, shortlink_errors.ErrValidateRequest
+	}
+	user, ok := ctx.Value(utils.UserKey).(string)
+	if !ok || user == "" {
+		return nil, shortlink_errors.ErrForbidden
+	}
+	email, _ := ctx.Value(utils.UserEmailKey).(string)
+
+	now := time.Now()
+	ws := &models.Workspace{
+		Name:      req.Name,
+		CreatedBy: user,
+		CreatedAt: now,
+	}
+	owner := models.WorkspaceMember{
+		UID:      user,
+		Email:    email,
+		Role:     models.RoleOwner,
+		JoinedAt: now,
+	}
+	if err := s.firestore.CreateWorkspace(ctx, ws, owner); err != nil {
+		return nil, err
+	}
+
+	return toWorkspaceDTO(ws, models.RoleOwner), nil
+}
+
+func (s *WorkspaceServiceImpl) ListWorkspaces(ctx context.Context) ([]dto.WorkspaceDTO, error) {
+	user, ok := ctx.Value(utils.UserKey).(string)
+	if !ok || user == "" {
+		return nil, shortlink_errors.ErrForbidden
+	}
+
+	memberships, err := s.firestore.ListMemberships(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	workspaces := make([]dto.WorkspaceDTO, 0, len(memberships))
+	for _, m := range memberships {
+		ws, err := s.firestore.GetWorkspace(ctx, m.WorkspaceID)
+		if err != nil {
+			if errors.Is(err, shortlink_errors.ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		workspaces = append(workspaces, *toWorkspaceDTO(ws, m.Role))
+	}
+	return workspaces, nil
+}
+
+func (s *WorkspaceServiceImpl) ListMembers(ctx context.Context, workspaceID string) ([]dto.WorkspaceMemberDTO, error) {
+	if _, err := s.requirePermission(ctx, workspaceID, models.PermissionView); err != nil {
+		return nil, err
+	}
+
+	members, err := s.firestore.ListMembers(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dto.WorkspaceMemberDTO, 0, len(members))
+	for _, m := range members {
+		result = append(result, dto.WorkspaceMemberDTO{
+			UID:      m.UID,
+			Email:    m.Email,
+			Role:     string(m.Role),
+			JoinedAt: m.JoinedAt,
+		})
+	}
+	return result, nil
+}
+
+func (s *WorkspaceServiceImpl) InviteMember(ctx context.Context, workspaceID string, req dto.InviteMemberRequest) (*dto.InvitationDTO, error) {
+	if err := validators.Validate.Struct(req); err != nil {
+		return nil, shortlink_errors.ErrValidateRequest
+	}
+	inviter, err := s.requirePermission(ctx, workspaceID, models.PermissionManage)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	inv := &models.WorkspaceInvitation{
+		WorkspaceID: workspaceID,
+		Email:       strings.ToLower(req.Email),
+		Role:        models.WorkspaceRole(req.Role),
+		InvitedBy:   inviter.UID,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(invitationTTL),
+	}
+	if err := s.firestore.CreateInvitation(ctx, inv); err != nil {
+		return nil, err
+	}
+
+	return &dto.InvitationDTO{
+		ID:          inv.ID,
+		WorkspaceID: inv.WorkspaceID,
+		Email:       inv.Email,
+		Role:        string(inv.Role),
+		ExpiresAt:   inv.ExpiresAt,
+	}, nil
+}
+
+func (s *WorkspaceServiceImpl) AcceptInvitation(ctx context.Context, invitationID string) (*dto.WorkspaceDTO, error) {
+	user, ok := ctx.Value(utils.UserKey).(string)
+	if !ok || user == "" {
+		return nil, shortlink_errors.ErrForbidden
+	}
+	// only set for verified emails, see the auth middleware
+	email, _ := ctx.Value(utils.UserEmailKey).(string)
+	if email == "" {
+		return nil, shortlink_errors.ErrForbidden
+	}
+
+	inv, err := s.firestore.GetInvitation(ctx, invitationID)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(inv.Email, email) {
+		return nil, shortlink_errors.ErrForbidden
+	}
+	if time.Now().After(inv.ExpiresAt) {
+		return nil, shortlink_errors.ErrInvitationExpired
+	}
+
+	ws, err := s.firestore.GetWorkspace(ctx, inv.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	member := models.WorkspaceMember{
+		WorkspaceID: inv.WorkspaceID,
+		UID:         user,
+		Email:       inv.Email,
+		Role:        inv.Role,
+		JoinedAt:    time.Now(),
+	}
+	before, err := s.firestore.AcceptInvitation(ctx, invitationID, member)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case before == nil:
+		s.record(ctx, models.AuditMemberJoin, member, nil, memberAuditState(&member))
+	case member.Role.Outranks(before.Role):
+		s.record(ctx, models.AuditMemberRoleUpdate, member, memberAuditState(before), memberAuditState(&member))
+	default:
+		// already a member with this role or a higher one, which is kept
+		return toWorkspaceDTO(ws, before.Role), nil
+	}
+
+	return toWorkspaceDTO(ws, inv.Role), nil
+}
+
+func (s *WorkspaceServiceImpl) UpdateMemberRole(ctx context.Context, workspaceID, uid string, req dto.UpdateMemberRoleRequest) error {
+	if err := validators.Validate.Struct(req); err != nil {
+		return shortlink_errors.ErrValidateRequest
+	}
+	if _, err := s.requirePermission(ctx, workspaceID, models.PermissionManage); err != nil {
+		return err
+	}
+
+	member, err := s.firestore.GetMember(ctx, workspaceID, uid)
+	if err != nil {
+		return err
+	}
+
+	newRole := models.WorkspaceRole(req.Role)
+	if member.Role == models.RoleOwner && newRole != models.RoleOwner {
+		if err := s.ensureAnotherOwner(ctx, workspaceID, uid); err != nil {
+			return err
+		}
+	}
+
+	before := memberAuditState(member)
+	member.Role = newRole
+	if err := s.firestore.SetMember(ctx, *member); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditMemberRoleUpdate, *member, before, memberAuditState(member))
+	return nil
+}
+
+// RemoveMember removes uid from the workspace. Managers may remove anyone and
+// every member may remove themselves (leave the workspace).
+func (s *WorkspaceServiceImpl) RemoveMember(ctx context.Context, workspaceID, uid string) error {
+	user, ok := ctx.Value(utils.UserKey).(string)
+	if !ok || user == "" {
+		return shortlink_errors.ErrForbidden
+	}
+	if user != uid {
+		if _, err := s.requirePermission(ctx, workspaceID, models.PermissionManage); err != nil {
+			return err
+		}
+	}
+
+	member, err := s.firestore.GetMember(ctx, workspaceID, uid)
+	if err != nil {
+		return err
+	}
+	if member.Role == models.RoleOwner {
+		if err := s.ensureAnotherOwner(ctx, workspaceID, uid); err != nil {
+			return err
+		}
+	}
+
+	if err := s.firestore.DeleteMember(ctx, workspaceID, uid); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditMemberRemove, *member, memberAuditState(member), nil)
+	return nil
+}
+
+// requirePermission returns the membership of the user in ctx if it grants perm.
+func (s *WorkspaceServiceImpl) requirePermission(ctx context.Context, workspaceID string, perm models.Permission) (*models.WorkspaceMember, error) {
+	user, ok := ctx.Value(utils.UserKey).(string)
+	if !ok || user == "" {
+		return nil, shortlink_errors.ErrForbidden
+	}
+
+	member, err := s.firestore.GetMember(ctx, workspaceID, user)
+	if err != nil {
+		if errors.Is(err, shortlink_errors.ErrNotFound) {
+			return nil, shortlink_errors.ErrForbidden
+		}
+		return nil, err
+	}
+	if !member.Role.Can(perm) {
+		return nil, shortlink_errors.ErrForbidden
+	}
+	return member, nil
+}
+
+func (s *WorkspaceServiceImpl) ensureAnotherOwner(ctx context.Context, workspaceID, uid string) error {
+	members, err := s.firestore.ListMembers(ctx, workspaceID)
+	if err != nil {
+		return err
+	}
+	for _, m := range members {
+		if m.UID != uid && m.Role == models.RoleOwner {
+			return nil
+		}
+	}
+	return shortlink_errors.ErrLastOwner
+}
+
+// record writes an audit entry for a membership change that has already been
+// applied, so a failure is logged instead of being reported to the caller.
+func (s *WorkspaceServiceImpl) record(ctx context.Context, action string, member models.WorkspaceMember, before, after map[string]interface{}) {
+	targetID := member.WorkspaceID + "/" + member.UID
+	if err := s.audit.Record(ctx, action, models.AuditTargetMember, targetID, before, after); err != nil {
+		log.Printf("Failed to record audit log for %s %s: %v", action, targetID, err)
+	}
+}
+
+func memberAuditState(m *models.WorkspaceMember) map[string]interface{} {
+	return map[string]interface{}{
+		"workspace_id": m.WorkspaceID,
+		"uid":          m.UID,
+		"email":        m.Email,
+		"role":         string(m.Role),
+	}
+}
+
+func toWorkspaceDTO(ws *models.Workspace, role models.WorkspaceRole) *dto.WorkspaceDTO {
+	return &dto.WorkspaceDTO{
+		ID:        ws.ID,
+		Name:      ws.Name,
+		Role:      string(role),
+		CreatedBy: ws.CreatedBy,
+		CreatedAt: ws.CreatedAt,
+	}
+}
@@ -0,0 +1,252 @@
+package workspace_service_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/workspace_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/validators"
+)
+
+// *--- MOCK DEFINITIONS ---* //
+// Firestore workspace manager SERVICE
+type MockWorkspaceManager struct{ mock.Mock }
+
+func (m *MockWorkspaceManager) GetMember(ctx context.Context, workspaceID, uid string) (*models.WorkspaceMember, error) {
+	args := m.Called(ctx, workspaceID, uid)
+	return args.Get(0).(*models.WorkspaceMember), args.Error(1)
+}
+
+func (m *MockWorkspaceManager) CreateWorkspace(ctx context.Context, ws *models.Workspace, owner models.WorkspaceMember) error {
+	args := m.Called(ctx, ws, owner)
+	return args.Error(0)
+}
+
+func (m *MockWorkspaceManager) GetWorkspace(ctx context.Context, workspaceID string) (*models.Workspace, error) {
+	args := m.Called(ctx, workspaceID)
+	return args.Get(0).(*models.Workspace), args.Error(1)
+}
+
+func (m *MockWorkspaceManager) ListMemberships(ctx context.Context, uid string) ([]models.WorkspaceMember, error) {
+	args := m.Called(ctx, uid)
+	return args.Get(0).([]models.WorkspaceMember), args.Error(1)
+}
+
+func (m *MockWorkspaceManager) ListMembers(ctx context.Context, workspaceID string) ([]models.WorkspaceMember, error) {
+	args := m.Called(ctx, workspaceID)
+	return args.Get(0).([]models.WorkspaceMember), args.Error(1)
+}
+
+func (m *MockWorkspaceManager) SetMember(ctx context.Context, member models.WorkspaceMember) error {
+	args := m.Called(ctx, member)
+	return args.Error(0)
+}
+
+func (m *MockWorkspaceManager) DeleteMember(ctx context.Context, workspaceID, uid string) error {
+	args := m.Called(ctx, workspaceID, uid)
+	return args.Error(0)
+}
+
+func (m *MockWorkspaceManager) CreateInvitation(ctx context.Context, inv *models.WorkspaceInvitation) error {
+	args := m.Called(ctx, inv)
+	return args.Error(0)
+}
+
+func (m *MockWorkspaceManager) GetInvitation(ctx context.Context, invitationID string) (*models.WorkspaceInvitation, error) {
+	args := m.Called(ctx, invitationID)
+	return args.Get(0).(*models.WorkspaceInvitation), args.Error(1)
+}
+
+func (m *MockWorkspaceManager) AcceptInvitation(ctx context.Context, invitationID string, member models.WorkspaceMember) (*models.WorkspaceMember, error) {
+	args := m.Called(ctx, invitationID, member)
+	return args.Get(0).(*models.WorkspaceMember), args.Error(1)
+}
+
+// Audit RECORDER
+type MockRecorder struct{ mock.Mock }
+
+func (m *MockRecorder) Record(ctx context.Context, action, targetType, targetID string, before, after map[string]interface{}) error {
+	args := m.Called(ctx, action, targetType, targetID, before, after)
+	return args.Error(0)
+}
+
+func TestMain(m *testing.M) {
+	validators.Init()
+	os.Exit(m.Run())
+}
+
+func userCtx(uid, email string) context.Context {
+	ctx := context.WithValue(context.Background(), utils.UserKey, uid)
+	return context.WithValue(ctx, utils.UserEmailKey, email)
+}
+
+// *--- TEST CASES ---* //
+func TestCreateWorkspace(t *testing.T) {
+	store := new(MockWorkspaceManager)
+	audit := new(MockRecorder)
+	svc := workspace_service.New(store, audit)
+
+	t.Run("Creator becomes owner", func(t *testing.T) {
+		store.On("CreateWorkspace", mock.Anything, mock.Anything, mock.MatchedBy(func(m models.WorkspaceMember) bool {
+			return m.UID == "user1" && m.Role == models.RoleOwner
+		})).Return(nil).Once()
+
+		ws, err := svc.CreateWorkspace(userCtx("user1", "user1@example.com"), dto.CreateWorkspaceRequest{Name: "Marketing"})
+		require.NoError(t, err)
+		assert.Equal(t, "Marketing", ws.Name)
+		assert.Equal(t, string(models.RoleOwner), ws.Role)
+	})
+
+	t.Run("Empty name is rejected", func(t *testing.T) {
+		_, err := svc.CreateWorkspace(userCtx("user1", "user1@example.com"), dto.CreateWorkspaceRequest{})
+		assert.Equal(t, shortlink_errors.ErrValidateRequest, err)
+	})
+
+	store.AssertExpectations(t)
+}
+
+func TestInviteMember(t *testing.T) {
+	store := new(MockWorkspaceManager)
+	audit := new(MockRecorder)
+	svc := workspace_service.New(store, audit)
+	req := dto.InviteMemberRequest{Email: "New.Member@example.com", Role: "editor"}
+
+	t.Run("Owner can invite", func(t *testing.T) {
+		store.On("GetMember", mock.Anything, "ws1", "owner1").
+			Return(&models.WorkspaceMember{WorkspaceID: "ws1", UID: "owner1", Role: models.RoleOwner}, nil).Once()
+		store.On("CreateInvitation", mock.Anything, mock.MatchedBy(func(inv *models.WorkspaceInvitation) bool {
+			return inv.Email == "new.member@example.com" && inv.Role == models.RoleEditor
+		})).Return(nil).Once()
+
+		inv, err := svc.InviteMember(userCtx("owner1", "owner1@example.com"), "ws1", req)
+		require.NoError(t, err)
+		assert.Equal(t, "new.member@example.com", inv.Email)
+		assert.True(t, inv.ExpiresAt.After(time.Now()))
+	})
+
+	t.Run("Editor cannot invite", func(t *testing.T) {
+		store.On("GetMember", mock.Anything, "ws1", "editor1").
+			Return(&models.WorkspaceMember{WorkspaceID: "ws1", UID: "editor1", Role: models.RoleEditor}, nil).Once()
+
+		_, err := svc.InviteMember(userCtx("editor1", "editor1@example.com"), "ws1", req)
+		assert.Equal(t, shortlink_errors.ErrForbidden, err)
+	})
+
+	store.AssertExpectations(t)
+}
+
+func TestAcceptInvitation(t *testing.T) {
+	store := new(MockWorkspaceManager)
+	audit := new(MockRecorder)
+	svc := workspace_service.New(store, audit)
+
+	t.Run("Invitation for another email is forbidden", func(t *testing.T) {
+		store.On("GetInvitation", mock.Anything, "inv1").Return(&models.WorkspaceInvitation{
+			ID: "inv1", WorkspaceID: "ws1", Email: "someone@example.com", Role: models.RoleViewer,
+			ExpiresAt: time.Now().Add(time.Hour),
+		}, nil).Once()
+
+		_, err := svc.AcceptInvitation(userCtx("user2", "other@example.com"), "inv1")
+		assert.Equal(t, shortlink_errors.ErrForbidden, err)
+	})
+
+	t.Run("Callers without a verified email are forbidden", func(t *testing.T) {
+		_, err := svc.AcceptInvitation(userCtx("user2", ""), "inv1")
+		assert.Equal(t, shortlink_errors.ErrForbidden, err)
+	})
+
+	t.Run("Expired invitation", func(t *testing.T) {
+		store.On("GetInvitation", mock.Anything, "inv2").Return(&models.WorkspaceInvitation{
+			ID: "inv2", WorkspaceID: "ws1", Email: "user2@example.com", Role: models.RoleViewer,
+			ExpiresAt: time.Now().Add(-time.Hour),
+		}, nil).Once()
+
+		_, err := svc.AcceptInvitation(userCtx("user2", "user2@example.com"), "inv2")
+		assert.Equal(t, shortlink_errors.ErrInvitationExpired, err)
+	})
+
+	t.Run("Members keep a higher role", func(t *testing.T) {
+		store.On("GetInvitation", mock.Anything, "inv3").Return(&models.WorkspaceInvitation{
+			ID: "inv3", WorkspaceID: "ws1", Email: "owner1@example.com", Role: models.RoleViewer,
+			ExpiresAt: time.Now().Add(time.Hour),
+		}, nil).Once()
+		store.On("GetWorkspace", mock.Anything, "ws1").Return(&models.Workspace{ID: "ws1", Name: "Team"}, nil).Once()
+		store.On("AcceptInvitation", mock.Anything, "inv3", mock.Anything).
+			Return(&models.WorkspaceMember{WorkspaceID: "ws1", UID: "owner1", Role: models.RoleOwner}, nil).Once()
+
+		ws, err := svc.AcceptInvitation(userCtx("owner1", "owner1@example.com"), "inv3")
+		require.NoError(t, err)
+		assert.Equal(t, string(models.RoleOwner), ws.Role)
+		audit.AssertNotCalled(t, "Record", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	store.AssertExpectations(t)
+}
+
+func TestRemoveMember(t *testing.T) {
+	store := new(MockWorkspaceManager)
+	audit := new(MockRecorder)
+	svc := workspace_service.New(store, audit)
+
+	t.Run("Last owner cannot leave", func(t *testing.T) {
+		owner := &models.WorkspaceMember{WorkspaceID: "ws1", UID: "owner1", Role: models.RoleOwner}
+		store.On("GetMember", mock.Anything, "ws1", "owner1").Return(owner, nil).Once()
+		store.On("ListMembers", mock.Anything, "ws1").Return([]models.WorkspaceMember{
+			*owner,
+			{WorkspaceID: "ws1", UID: "viewer1", Role: models.RoleViewer},
+		}, nil).Once()
+
+		err := svc.RemoveMember(userCtx("owner1", "owner1@example.com"), "ws1", "owner1")
+		assert.Equal(t, shortlink_errors.ErrLastOwner, err)
+	})
+
+	t.Run("Member can leave", func(t *testing.T) {
+		store.On("GetMember", mock.Anything, "ws1", "viewer1").
+			Return(&models.WorkspaceMember{WorkspaceID: "ws1", UID: "viewer1", Role: models.RoleViewer}, nil).Once()
+		store.On("DeleteMember", mock.Anything, "ws1", "viewer1").Return(nil).Once()
+		audit.On("Record", mock.Anything, models.AuditMemberRemove, models.AuditTargetMember, "ws1/viewer1", mock.Anything, mock.Anything).
+			Return(nil).Once()
+
+		err := svc.RemoveMember(userCtx("viewer1", "viewer1@example.com"), "ws1", "viewer1")
+		assert.NoError(t, err)
+	})
+
+	store.AssertExpectations(t)
+	audit.AssertExpectations(t)
+}
+
+func TestUpdateMemberRole(t *testing.T) {
+	store := new(MockWorkspaceManager)
+	audit := new(MockRecorder)
+	svc := workspace_service.New(store, audit)
+
+	t.Run("Role change is audited", func(t *testing.T) {
+		store.On("GetMember", mock.Anything, "ws1", "owner1").
+			Return(&models.WorkspaceMember{WorkspaceID: "ws1", UID: "owner1", Role: models.RoleOwner}, nil).Once()
+		store.On("GetMember", mock.Anything, "ws1", "viewer1").
+			Return(&models.WorkspaceMember{WorkspaceID: "ws1", UID: "viewer1", Role: models.RoleViewer}, nil).Once()
+		store.On("SetMember", mock.Anything, mock.MatchedBy(func(m models.WorkspaceMember) bool {
+			return m.UID == "viewer1" && m.Role == models.RoleEditor
+		})).Return(nil).Once()
+		audit.On("Record", mock.Anything, models.AuditMemberRoleUpdate, models.AuditTargetMember, "ws1/viewer1",
+			mock.MatchedBy(func(m map[string]interface{}) bool { return m["role"] == "viewer" }),
+			mock.MatchedBy(func(m map[string]interface{}) bool { return m["role"] == "editor" }),
+		).Return(nil).Once()
+
+		err := svc.UpdateMemberRole(userCtx("owner1", "owner1@example.com"), "ws1", "viewer1", dto.UpdateMemberRoleRequest{Role: "editor"})
+		assert.NoError(t, err)
+	})
+
+	store.AssertExpectations(t)
+	audit.AssertExpectations(t)
+}
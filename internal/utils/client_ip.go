@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// trustedProxyHops is the number of proxies in front of the service that
+// append the address they were reached from to X-Forwarded-For.
+var trustedProxyHops int
+
+// SetTrustedProxyHops sets how many proxies in front of the service append
+// to X-Forwarded-For. With 0, the default, the header is ignored.
+func SetTrustedProxyHops(hops int) {
+	trustedProxyHops = max(hops, 0)
+}
+
+// LoadTrustedProxyHops reads TRUSTED_PROXY_HOPS, see SetTrustedProxyHops.
+func LoadTrustedProxyHops() {
+	v := strings.TrimSpace(os.Getenv("TRUSTED_PROXY_HOPS"))
+	if v == "" {
+		return
+	}
+	hops, err := strconv.Atoi(v)
+	if err != nil || hops < 0 {
+		log.Printf("Invalid TRUSTED_PROXY_HOPS %q, X-Forwarded-For is ignored", v)
+		return
+	}
+	SetTrustedProxyHops(hops)
+}
+
+// ClientIP returns the originating client IP. Behind trusted proxies it is
+// the X-Forwarded-For entry appended by the outermost one: every proxy adds
+// the address it was reached from on the right, so those are the entries a
+// client cannot forge. Otherwise, or when the header is too short, it is
+// the address of the connection.
+func ClientIP(r *http.Request) string {
+	if trustedProxyHops > 0 {
+		var hops []string
+		for _, header := range r.Header.Values("X-Forwarded-For") {
+			for _, hop := range strings.Split(header, ",") {
+				hops = append(hops, strings.TrimSpace(hop))
+			}
+		}
+		if len(hops) >= trustedProxyHops {
+			if ip := hops[len(hops)-trustedProxyHops]; net.ParseIP(ip) != nil {
+				return ip
+			}
+		}
+	}
+
+	ip := "unknown"
+	if remote := r.RemoteAddr; remote != "" {
+		if host, _, err := net.SplitHostPort(remote); err == nil {
+			ip = host
+		} else {
+			ip = remote
+		}
+	}
+	return ip
+}
@@ -0,0 +1,29 @@
+package utils_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/utils"
+)
+
+func TestClientIP(t *testing.T) {
+	r := httptest.NewRequest("GET", "/r/abc", nil)
+	r.RemoteAddr = "10.0.0.1:4321"
+	// the client sent 203.0.113.9 itself; the proxies appended the rest
+	r.Header.Add("X-Forwarded-For", "203.0.113.9, 198.51.100.7")
+	r.Header.Add("X-Forwarded-For", "10.0.0.2")
+	defer utils.SetTrustedProxyHops(0)
+
+	for hops, want := range map[int]string{
+		0: "10.0.0.1",
+		1: "10.0.0.2",
+		2: "198.51.100.7",
+		4: "10.0.0.1",
+	} {
+		utils.SetTrustedProxyHops(hops)
+		assert.Equal(t, want, utils.ClientIP(r), hops)
+	}
+}
@@ -4,5 +4,8 @@ type contextKey string
 
 const (
 	UserKey contextKey = "user"
+	UserEmailKey contextKey = "user_email"
 	ExportFormatKey contextKey = "export_format"
-)
\ No newline at end of file
+	RequestIDKey contextKey = "request_id"
+	ClientIPKey contextKey = "client_ip"
+)
@@ -0,0 +1,128 @@
+// Package lease lets several instances share a periodic job: the instance
+// that takes a job's lease in Redis runs the pass, the others skip it.
+package lease
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// ttl is how long a lease outlives an instance that stopped renewing it.
+	ttl = time.Minute
+	// CheckInterval is how often an instance without the lease looks whether
+	// a pass is due.
+	CheckInterval = time.Minute
+)
+
+var (
+	renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+	releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+type Lease struct {
+	client *redis.Client
+	// owner tells this instance's leases apart from those of the others.
+	owner string
+}
+
+func New(client *redis.Client) *Lease {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatalf("failed to generate lease owner ID: %v", err)
+	}
+	return &Lease{client: client, owner: hex.EncodeToString(b)}
+}
+
+// Do runs fn unless another instance is running the job name or ran it less
+// than interval ago, and reports whether fn ran. The lease is renewed while
+// fn runs and fn's context is cancelled if it is lost. After fn returns the
+// lease is kept until interval has passed since fn started, so the job runs
+// once per interval across all instances. A nil Lease always runs fn.
+func (l *Lease) Do(ctx context.Context, name string, interval time.Duration, fn func(ctx context.Context)) (bool, error) {
+	if l == nil {
+		fn(ctx)
+		return true, nil
+	}
+
+	key := "lease:" + name
+	ok, err := l.client.SetNX(ctx, key, l.owner, ttl).Result()
+	if err != nil || !ok {
+		return false, err
+	}
+	started := time.Now()
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan struct{})
+	go l.renew(runCtx, key, cancel, done)
+	fn(runCtx)
+	close(done)
+
+	// the lease outlives runCtx, so it is not cut short when ctx is done
+	ctx = context.WithoutCancel(ctx)
+	if rest := interval - time.Since(started); rest > 0 && runCtx.Err() == nil {
+		err = renewScript.Run(ctx, l.client, []string{key}, l.owner, rest.Milliseconds()).Err()
+	} else {
+		err = releaseScript.Run(ctx, l.client, []string{key}, l.owner).Err()
+	}
+	if err != nil {
+		log.Printf("Failed to keep the %s lease after its pass: %v", name, err)
+	}
+	return true, nil
+}
+
+// Every is how often a job that runs once per interval checks whether it is
+// due: its interval without a lease, otherwise often enough that a pass
+// starts soon after the previous one's lease ran out.
+func (l *Lease) Every(interval time.Duration) time.Duration {
+	if l == nil {
+		return interval
+	}
+	return min(interval, CheckInterval)
+}
+
+// renew extends the lease on key until done is closed, and calls cancel once
+// the lease is taken over or could not be renewed before it ran out.
+func (l *Lease) renew(ctx context.Context, key string, cancel context.CancelFunc, done <-chan struct{}) {
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	renewed := time.Now()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		n, err := renewScript.Run(ctx, l.client, []string{key}, l.owner, ttl.Milliseconds()).Int()
+		switch {
+		case err == nil && n == 1:
+			renewed = time.Now()
+			continue
+		case err == nil:
+			log.Printf("Lease %s was taken over, stopping the pass", key)
+		case time.Since(renewed) >= ttl:
+			log.Printf("Lease %s could not be renewed, stopping the pass: %v", key, err)
+		default:
+			continue
+		}
+		cancel()
+		return
+	}
+}
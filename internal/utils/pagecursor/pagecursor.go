@@ -0,0 +1,120 @@
+// Package pagecursor turns the position of a page in a listing into an
+// opaque cursor. A cursor holds the sort value and document ID of the last
+// item of a page, the sort direction and a hash of the query's filters, and
+// is signed so clients cannot forge one or reuse it for another query.
+package pagecursor
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"sync"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+type (
+	Cursor struct {
+		// Value is the sort value of the last item of the page and ID its
+		// document ID, which orders items with the same value.
+		Value json.RawMessage `json:"v"`
+		ID    string          `json:"id"`
+		Desc  bool            `json:"d,omitempty"`
+		// Filter is the FilterHash of the query the cursor belongs to.
+		Filter string `json:"f"`
+	}
+
+	Signer struct {
+		key []byte
+	}
+)
+
+var (
+	randomKeyOnce sync.Once
+	randomKey     []byte
+)
+
+func NewSigner(key []byte) *Signer {
+	return &Signer{key: key}
+}
+
+// LoadSigner signs with PAGINATION_CURSOR_SECRET, which is required outside
+// development: cursors signed with any other key are rejected by the other
+// instances and after a restart. In development a random key is used instead.
+func LoadSigner() (*Signer, error) {
+	if secret := os.Getenv("PAGINATION_CURSOR_SECRET"); secret != "" {
+		return NewSigner([]byte(secret)), nil
+	}
+	if os.Getenv("APP_ENV") != "development" {
+		return nil, errors.New("PAGINATION_CURSOR_SECRET is not set")
+	}
+
+	randomKeyOnce.Do(func() {
+		randomKey = make([]byte, 32)
+		if _, err := rand.Read(randomKey); err != nil {
+			log.Fatalf("failed to generate pagination cursor key: %v", err)
+		}
+		log.Println("PAGINATION_CURSOR_SECRET is not set, pagination cursors only work until the service restarts")
+	})
+	return NewSigner(randomKey), nil
+}
+
+// Encode makes a cursor after the item with sort value value and document ID
+// id, for a query sorted in direction desc with the given filter hash.
+func (s *Signer) Encode(value any, id string, desc bool, filter string) (string, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(Cursor{Value: raw, ID: id, Desc: desc, Filter: filter})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(s.sign(payload)), nil
+}
+
+// Decode checks the signature of token and that it was made for a query
+// with the same direction and filter hash, and returns its cursor.
+func (s *Signer) Decode(token string, desc bool, filter string) (*Cursor, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, s.sign(payload)) {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(payload, &cursor); err != nil || cursor.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.Desc != desc || cursor.Filter != filter {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+func (s *Signer) sign(payload []byte) []byte {
+	h := hmac.New(sha256.New, s.key)
+	h.Write(payload)
+	return h.Sum(nil)
+}
+
+// FilterHash identifies a query by the values of its filters and sort order.
+func FilterHash(values ...any) string {
+	raw, _ := json.Marshal(values)
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,96 @@
+package pagecursor_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/pagecursor"
+)
+
+func TestSigner(t *testing.T) {
+	signer := pagecursor.NewSigner([]byte("secret"))
+	filter := pagecursor.FilterHash("user1", "created_at")
+	ts := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+
+	token, err := signer.Encode(ts, "doc1", true, filter)
+	require.NoError(t, err)
+
+	t.Run("Round trip", func(t *testing.T) {
+		cursor, err := signer.Decode(token, true, filter)
+		require.NoError(t, err)
+		assert.Equal(t, "doc1", cursor.ID)
+
+		var got time.Time
+		require.NoError(t, json.Unmarshal(cursor.Value, &got))
+		assert.True(t, ts.Equal(got))
+	})
+
+	t.Run("Cursors of another query are refused", func(t *testing.T) {
+		_, err := signer.Decode(token, false, filter)
+		assert.Equal(t, pagecursor.ErrInvalidCursor, err)
+
+		_, err = signer.Decode(token, true, pagecursor.FilterHash("user2", "created_at"))
+		assert.Equal(t, pagecursor.ErrInvalidCursor, err)
+	})
+
+	t.Run("Forged cursors are refused", func(t *testing.T) {
+		payload, _, _ := strings.Cut(token, ".")
+		raw, err := base64.RawURLEncoding.DecodeString(payload)
+		require.NoError(t, err)
+		forged := strings.Replace(string(raw), "doc1", "doc2", 1)
+		tampered := base64.RawURLEncoding.EncodeToString([]byte(forged)) + token[len(payload):]
+
+		_, err = signer.Decode(tampered, true, filter)
+		assert.Equal(t, pagecursor.ErrInvalidCursor, err)
+
+		_, err = pagecursor.NewSigner([]byte("other")).Decode(token, true, filter)
+		assert.Equal(t, pagecursor.ErrInvalidCursor, err)
+	})
+
+	t.Run("Malformed cursors are refused", func(t *testing.T) {
+		for _, token := range []string{"", "2025-06-20T12:00:00Z", "abc.def", "!!.!!"} {
+			_, err := signer.Decode(token, true, filter)
+			assert.Equal(t, pagecursor.ErrInvalidCursor, err, token)
+		}
+	})
+}
+
+func TestLoadSigner(t *testing.T) {
+	t.Run("The secret is required outside development", func(t *testing.T) {
+		t.Setenv("PAGINATION_CURSOR_SECRET", "")
+		t.Setenv("APP_ENV", "production")
+
+		_, err := pagecursor.LoadSigner()
+		assert.Error(t, err)
+	})
+
+	t.Run("Development signs with a random key", func(t *testing.T) {
+		t.Setenv("PAGINATION_CURSOR_SECRET", "")
+		t.Setenv("APP_ENV", "development")
+
+		signer, err := pagecursor.LoadSigner()
+		require.NoError(t, err)
+		assert.NotNil(t, signer)
+	})
+
+	t.Run("Cursors signed with the secret are accepted by every signer", func(t *testing.T) {
+		t.Setenv("PAGINATION_CURSOR_SECRET", "shared")
+		t.Setenv("APP_ENV", "production")
+
+		first, err := pagecursor.LoadSigner()
+		require.NoError(t, err)
+		token, err := first.Encode(1, "a", false, "")
+		require.NoError(t, err)
+
+		second, err := pagecursor.LoadSigner()
+		require.NoError(t, err)
+		_, err = second.Decode(token, false, "")
+		assert.NoError(t, err)
+	})
+}
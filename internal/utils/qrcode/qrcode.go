@@ -0,0 +1,220 @@
+// Package qrcode draws QR codes as PNG or SVG images, optionally with a logo
+// in the middle. The codes are encoded locally; nothing is fetched.
+package qrcode
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	goqr "github.com/skip2/go-qrcode"
+)
+
+const (
+	// logoRatio is how much of the width of a code its logo may take. With
+	// the highest error correction a third of the code can be covered and
+	// still scan; a logo this size, with its padding, covers far less.
+	logoRatio = 0.22
+
+	// logoPadding is the background border around a logo, in modules.
+	logoPadding = 1
+)
+
+var ErrInvalidColor = errors.New("invalid color")
+
+// Options are how a code is drawn.
+type Options struct {
+	// Size is the width and height of the image in pixels. PNG codes are
+	// made larger if they would have less than a pixel per module.
+	Size int
+	// Level is the error correction level: L, M, Q or H.
+	Level string
+	// Foreground and Background are the colors of the dark and light
+	// modules.
+	Foreground color.RGBA
+	Background color.RGBA
+	// Logo is drawn in the middle of the code when set. Codes with a logo
+	// use level H whatever Level says, so they still scan.
+	Logo image.Image
+}
+
+// PNG writes a code for content as a PNG image.
+func PNG(w io.Writer, content string, opts Options) error {
+	modules, err := encode(content, opts)
+	if err != nil {
+		return err
+	}
+	n := len(modules)
+	size := max(opts.Size, n)
+
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			c := opts.Background
+			if modules[y*n/size][x*n/size] {
+				c = opts.Foreground
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+
+	if opts.Logo != nil {
+		box, inner := logoBox(size, float64(size)/float64(n))
+		draw.Draw(img, box, image.NewUniform(opts.Background), image.Point{}, draw.Src)
+		logo := fit(opts.Logo.Bounds(), inner)
+		draw.Draw(img, logo, scale(opts.Logo, logo.Dx(), logo.Dy()), image.Point{}, draw.Over)
+	}
+
+	return png.Encode(w, img)
+}
+
+// SVG writes a code for content as an SVG image drawn in modules, so it
+// scales to any size.
+func SVG(w io.Writer, content string, opts Options) error {
+	modules, err := encode(content, opts)
+	if err != nil {
+		return err
+	}
+	n := len(modules)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		opts.Size, opts.Size, n, n)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="%s"/>`, n, n, hex(opts.Background))
+
+	// dark modules next to each other in a row are drawn as one rectangle
+	b.WriteString(`<path fill="` + hex(opts.Foreground) + `" d="`)
+	for y, row := range modules {
+		for x := 0; x < n; x++ {
+			if !row[x] {
+				continue
+			}
+			start := x
+			for x+1 < n && row[x+1] {
+				x++
+			}
+			fmt.Fprintf(&b, "M%d %dh%dv1h-%dz", start, y, x-start+1, x-start+1)
+		}
+	}
+	b.WriteString(`"/>`)
+
+	if opts.Logo != nil {
+		var logo bytes.Buffer
+		if err := png.Encode(&logo, opts.Logo); err != nil {
+			return fmt.Errorf("failed to encode logo: %w", err)
+		}
+		box, inner := logoBox(n, 1)
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`,
+			box.Min.X, box.Min.Y, box.Dx(), box.Dy(), hex(opts.Background))
+		fmt.Fprintf(&b, `<image x="%d" y="%d" width="%d" height="%d" preserveAspectRatio="xMidYMid meet" href="data:image/png;base64,%s"/>`,
+			inner.Min.X, inner.Min.Y, inner.Dx(), inner.Dy(), base64.StdEncoding.EncodeToString(logo.Bytes()))
+	}
+	b.WriteString(`</svg>`)
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// ParseColor reads a color written as #rgb or #rrggbb.
+func ParseColor(s string) (color.RGBA, error) {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) != 6 {
+		return color.RGBA{}, ErrInvalidColor
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return color.RGBA{}, ErrInvalidColor
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
+
+// LoadLogo reads a PNG or JPEG logo from path.
+func LoadLogo(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	logo, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode logo %s: %w", path, err)
+	}
+	return logo, nil
+}
+
+// encode returns the modules of a code for content, dark ones true,
+// including the quiet zone around it.
+func encode(content string, opts Options) ([][]bool, error) {
+	level := goqr.Medium
+	switch strings.ToUpper(opts.Level) {
+	case "L":
+		level = goqr.Low
+	case "Q":
+		level = goqr.High
+	case "H":
+		level = goqr.Highest
+	}
+	if opts.Logo != nil {
+		level = goqr.Highest
+	}
+
+	code, err := goqr.New(content, level)
+	if err != nil {
+		return nil, err
+	}
+	return code.Bitmap(), nil
+}
+
+// logoBox returns where the logo of a code size wide goes: box is cleared
+// to the background, and inner, inside its padding, holds the logo.
+func logoBox(size int, moduleSize float64) (box, inner image.Rectangle) {
+	side := int(float64(size) * logoRatio)
+	offset := (size - side) / 2
+	box = image.Rect(offset, offset, offset+side, offset+side)
+
+	pad := int(logoPadding * moduleSize)
+	return box, box.Inset(pad)
+}
+
+// fit returns the largest rectangle with the proportions of src that fits
+// in the middle of area.
+func fit(src, area image.Rectangle) image.Rectangle {
+	w, h := area.Dx(), area.Dy()
+	if src.Dx()*h > src.Dy()*w {
+		h = max(1, src.Dy()*w/src.Dx())
+	} else {
+		w = max(1, src.Dx()*h/src.Dy())
+	}
+	origin := area.Min.Add(image.Pt((area.Dx()-w)/2, (area.Dy()-h)/2))
+	return image.Rectangle{Min: origin, Max: origin.Add(image.Pt(w, h))}
+}
+
+// scale resizes img to w by h pixels, taking the nearest pixel.
+func scale(img image.Image, w, h int) image.Image {
+	src := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dst.Set(x, y, img.At(src.Min.X+x*src.Dx()/w, src.Min.Y+y*src.Dy()/h))
+		}
+	}
+	return dst
+}
+
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
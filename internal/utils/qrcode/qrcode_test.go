@@ -0,0 +1,94 @@
+package qrcode_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/qrcode"
+)
+
+var (
+	black = color.RGBA{A: 0xff}
+	white = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	red   = color.RGBA{R: 0xff, A: 0xff}
+)
+
+func TestPNG(t *testing.T) {
+	content := "https://sho.rt/r/abc?src=qr"
+
+	t.Run("Draws the code at the requested size", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, qrcode.PNG(&buf, content, qrcode.Options{Size: 296, Foreground: red, Background: white}))
+
+		img, err := png.Decode(&buf)
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 296, 296), img.Bounds())
+
+		// a version 3 code is 29 modules and a quiet zone of 4 on each side,
+		// so the top left corner is background and the finder starts at 32px
+		assert.Equal(t, white, color.RGBAModel.Convert(img.At(5, 5)))
+		assert.Equal(t, red, color.RGBAModel.Convert(img.At(35, 35)))
+	})
+
+	t.Run("Covers the middle with the logo", func(t *testing.T) {
+		logo := image.NewRGBA(image.Rect(0, 0, 10, 10))
+		for y := 0; y < 10; y++ {
+			for x := 0; x < 10; x++ {
+				logo.SetRGBA(x, y, red)
+			}
+		}
+
+		var buf bytes.Buffer
+		require.NoError(t, qrcode.PNG(&buf, content, qrcode.Options{Size: 300, Foreground: black, Background: white, Logo: logo}))
+
+		img, err := png.Decode(&buf)
+		require.NoError(t, err)
+		assert.Equal(t, red, color.RGBAModel.Convert(img.At(150, 150)))
+	})
+
+	t.Run("Grows tiny codes to a pixel per module", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, qrcode.PNG(&buf, content, qrcode.Options{Size: 10, Foreground: black, Background: white}))
+
+		img, err := png.Decode(&buf)
+		require.NoError(t, err)
+		assert.Equal(t, 37, img.Bounds().Dx())
+	})
+}
+
+func TestSVG(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, qrcode.SVG(&buf, "https://sho.rt/r/abc?src=qr", qrcode.Options{Size: 512, Level: "H", Foreground: red, Background: white}))
+
+	svg := buf.String()
+	assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="512" height="512" viewBox="0 0 41 41"`), svg)
+	assert.Contains(t, svg, `<rect width="41" height="41" fill="#ffffff"/>`)
+	assert.Contains(t, svg, `<path fill="#ff0000" d="M4 4h7v1h-7z`)
+	assert.NotContains(t, svg, "<image")
+	assert.True(t, strings.HasSuffix(svg, "</svg>"))
+}
+
+func TestParseColor(t *testing.T) {
+	for in, want := range map[string]color.RGBA{
+		"#ff0000": red,
+		"FF0000":  red,
+		"#f00":    red,
+		"000":     black,
+	} {
+		got, err := qrcode.ParseColor(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+
+	for _, in := range []string{"", "#ff00", "#gg0000", "red"} {
+		_, err := qrcode.ParseColor(in)
+		assert.ErrorIs(t, err, qrcode.ErrInvalidColor, in)
+	}
+}
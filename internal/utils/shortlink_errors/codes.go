@@ -0,0 +1,39 @@
+package shortlink_errors
+
+import "errors"
+
+// codes are the stable identifiers of the errors, for responses that report
+// several errors at once.
+var codes = []struct {
+	err  error
+	code string
+}{
+	{ErrValidateRequest, "invalid_request"},
+	{ErrUnsupportedScheme, "unsupported_scheme"},
+	{ErrNotFound, "not_found"},
+	{ErrForbidden, "forbidden"},
+	{ErrForbiddenInput, "forbidden_input"},
+	{ErrBlacklistedID, "blacklisted_id"},
+	{ErrIDExists, "id_exists"},
+	{ErrResourceExists, "resource_exists"},
+	{ErrGenerateID, "id_generation_failed"},
+	{ErrDomainNotVerified, "domain_not_verified"},
+	{ErrPrivateAddress, "private_address"},
+	{ErrSelfReference, "self_reference"},
+	{ErrRedirectLoop, "redirect_loop"},
+	{ErrTooManyRedirects, "too_many_redirects"},
+	{ErrQuotaExceeded, "quota_exceeded"},
+	{ErrSaveShortlink, "save_failed"},
+	{ErrLinkExpired, "link_expired"},
+	{ErrFailedRetrieveData, "lookup_failed"},
+}
+
+// Code returns the code of err, or "internal_error" for errors without one.
+func Code(err error) string {
+	for _, c := range codes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	return "internal_error"
+}
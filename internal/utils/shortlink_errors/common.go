@@ -7,4 +7,10 @@ var (
 	ErrNotFound        = errors.New("no data found")
 	ErrForbidden       = errors.New("forbidden access")
 	ErrResourceExists  = errors.New("resource is already exist")
+	ErrInvalidCursor   = errors.New("invalid pagination cursor")
+	ErrNoQRLogo        = errors.New("no QR code logo is configured")
+	ErrNoPublicBaseURL = errors.New("no public base URL is configured")
+
+	ErrIdempotencyKeyReused     = errors.New("idempotency key was already used for a different request")
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still in progress")
 )
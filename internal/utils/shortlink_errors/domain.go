@@ -0,0 +1,8 @@
+package shortlink_errors
+
+import "errors"
+
+var (
+	ErrDomainNotVerified  = errors.New("domain is not verified")
+	ErrDomainVerification = errors.New("domain verification record not found")
+)
@@ -6,4 +6,15 @@ var (
 	ErrSaveShortlink      = errors.New("failed to save short link")
 	ErrFailedRetrieveData = errors.New("failed to retrieve data from database")
 	ErrForbiddenInput     = errors.New("forbidden input")
+	ErrLinkDisabled       = errors.New("short link has been disabled")
+	ErrLinkExpired        = errors.New("short link has expired")
+	ErrQuotaExceeded      = errors.New("safe browsing quota exceeded")
+
+	ErrUnsupportedScheme = errors.New("only http and https URLs can be shortened")
+	ErrPrivateAddress    = errors.New("destination resolves to a private network address")
+	ErrSelfReference     = errors.New("destination points back to this URL shortener")
+	ErrRedirectLoop      = errors.New("destination redirects in a loop")
+	ErrTooManyRedirects  = errors.New("destination redirects too many times")
+
+	ErrBatchTooLarge = errors.New("too many items in batch")
 )
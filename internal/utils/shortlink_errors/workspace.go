@@ -0,0 +1,8 @@
+package shortlink_errors
+
+import "errors"
+
+var (
+	ErrInvitationExpired = errors.New("invitation has expired")
+	ErrLastOwner         = errors.New("workspace must keep at least one owner")
+)
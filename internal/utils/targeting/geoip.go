@@ -0,0 +1,45 @@
+package targeting
+
+import (
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// GeoIP looks countries up in a local MaxMind database, such as
+// GeoLite2-Country or GeoIP2-City. Nothing is sent over the network.
+type GeoIP struct {
+	db *maxminddb.Reader
+}
+
+// OpenGeoIP opens the database at path.
+func OpenGeoIP(path string) (*GeoIP, error) {
+	db, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &GeoIP{db: db}, nil
+}
+
+// Country returns the ISO 3166-1 alpha-2 code of the country of ip, or ""
+// when the address is not in the database.
+func (g *GeoIP) Country(ip string) string {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return ""
+	}
+
+	var record struct {
+		Country struct {
+			ISOCode string `maxminddb:"iso_code"`
+		} `maxminddb:"country"`
+	}
+	if err := g.db.Lookup(addr, &record); err != nil {
+		return ""
+	}
+	return record.Country.ISOCode
+}
+
+func (g *GeoIP) Close() error {
+	return g.db.Close()
+}
@@ -0,0 +1,186 @@
+// Package targeting picks the redirect rule of a link that applies to a
+// visitor, from their device, country, language and the time of the visit.
+package targeting
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+)
+
+var weekdays = [...]string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// locations caches the time zones of schedules by name.
+var locations sync.Map
+
+// Visitor is what rules are matched against.
+type Visitor struct {
+	// Device is models.DeviceIOS, DeviceAndroid or DeviceDesktop.
+	Device string
+	// Country is an upper-case ISO 3166-1 alpha-2 code, empty if unknown.
+	Country string
+	// Language is the lower-case preferred language, empty if unknown.
+	Language string
+	Time     time.Time
+}
+
+// CountryLookup finds the country of an IP address, returning "" when it
+// is unknown.
+type CountryLookup interface {
+	Country(ip string) string
+}
+
+// NewVisitor describes the visitor sending r from ip. Without geo the
+// country is unknown.
+func NewVisitor(r *http.Request, ip string, geo CountryLookup) Visitor {
+	v := Visitor{
+		Device:   Device(r.UserAgent()),
+		Language: Language(r.Header.Get("Accept-Language")),
+		Time:     time.Now(),
+	}
+	if geo != nil {
+		v.Country = geo.Country(ip)
+	}
+	return v
+}
+
+// Device tells iOS and Android devices apart from the rest by their
+// User-Agent.
+func Device(userAgent string) string {
+	switch {
+	case strings.Contains(userAgent, "iPhone"), strings.Contains(userAgent, "iPad"), strings.Contains(userAgent, "iPod"):
+		return models.DeviceIOS
+	case strings.Contains(userAgent, "Android"):
+		return models.DeviceAndroid
+	}
+	return models.DeviceDesktop
+}
+
+// Language returns the lower-case language with the highest quality in an
+// Accept-Language header, the first one listed on a tie.
+func Language(acceptLanguage string) string {
+	type tag struct {
+		name    string
+		quality float64
+	}
+	var tags []tag
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || name == "*" {
+			continue
+		}
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		if quality > 0 {
+			tags = append(tags, tag{name, quality})
+		}
+	}
+	if len(tags) == 0 {
+		return ""
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].quality > tags[j].quality })
+	return tags[0].name
+}
+
+// Match returns the first of rules that v meets.
+func Match(rules []dto.RedirectRule, v Visitor) (dto.RedirectRule, bool) {
+	for _, rule := range rules {
+		if matches(rule, v) {
+			return rule, true
+		}
+	}
+	return dto.RedirectRule{}, false
+}
+
+func matches(rule dto.RedirectRule, v Visitor) bool {
+	if len(rule.Devices) > 0 && !slices.Contains(rule.Devices, v.Device) {
+		return false
+	}
+	if len(rule.Countries) > 0 && (v.Country == "" || !slices.Contains(rule.Countries, v.Country)) {
+		return false
+	}
+	if len(rule.Languages) > 0 && !matchLanguage(rule.Languages, v.Language) {
+		return false
+	}
+	return rule.Schedule == nil || inSchedule(*rule.Schedule, v.Time)
+}
+
+// matchLanguage reports whether language is one of languages or a variant
+// of one, such as en-gb of en.
+func matchLanguage(languages []string, language string) bool {
+	if language == "" {
+		return false
+	}
+	for _, l := range languages {
+		l = strings.ToLower(l)
+		if language == l || strings.HasPrefix(language, l+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+func inSchedule(s dto.RuleSchedule, now time.Time) bool {
+	if s.StartsAt != nil && now.Before(*s.StartsAt) {
+		return false
+	}
+	if s.EndsAt != nil && !now.Before(*s.EndsAt) {
+		return false
+	}
+
+	local := now.In(location(s.Timezone))
+	if len(s.Days) > 0 && !slices.Contains(s.Days, weekdays[local.Weekday()]) {
+		return false
+	}
+	if s.From == "" || s.To == "" {
+		return true
+	}
+
+	from, to := minuteOfDay(s.From), minuteOfDay(s.To)
+	minute := local.Hour()*60 + local.Minute()
+	if from <= to {
+		return from <= minute && minute < to
+	}
+	// the window spans midnight, e.g. 22:00 to 06:00
+	return minute >= from || minute < to
+}
+
+// location loads a time zone once, falling back to UTC for names that are
+// unknown, which validated rules do not have.
+func location(name string) *time.Location {
+	if name == "" {
+		return time.UTC
+	}
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		loc = time.UTC
+	}
+	locations.Store(name, loc)
+	return loc
+}
+
+// minuteOfDay converts a time of day such as "09:30" to minutes since
+// midnight.
+func minuteOfDay(clock string) int {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0
+	}
+	return t.Hour()*60 + t.Minute()
+}
@@ -0,0 +1,104 @@
+package targeting_test
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/targeting"
+)
+
+type countries map[string]string
+
+func (c countries) Country(ip string) string { return c[ip] }
+
+func TestNewVisitor(t *testing.T) {
+	r := httptest.NewRequest("GET", "/r/abc", nil)
+	r.Header.Set("User-Agent", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)")
+	r.Header.Set("Accept-Language", "fr-CH, fr;q=0.9, en;q=0.8")
+
+	v := targeting.NewVisitor(r, "192.0.2.1", countries{"192.0.2.1": "CH"})
+	assert.Equal(t, models.DeviceIOS, v.Device)
+	assert.Equal(t, "CH", v.Country)
+	assert.Equal(t, "fr-ch", v.Language)
+
+	assert.Empty(t, targeting.NewVisitor(r, "192.0.2.1", nil).Country)
+}
+
+func TestDevice(t *testing.T) {
+	for ua, want := range map[string]string{
+		"Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X)":                  models.DeviceIOS,
+		"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36":    models.DeviceAndroid,
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36":   models.DeviceDesktop,
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_0) AppleWebKit/605.1": models.DeviceDesktop,
+		"": models.DeviceDesktop,
+	} {
+		assert.Equal(t, want, targeting.Device(ua), ua)
+	}
+}
+
+func TestLanguage(t *testing.T) {
+	for header, want := range map[string]string{
+		"de-DE,de;q=0.9,en;q=0.8": "de-de",
+		"en;q=0.5, pt-BR":         "pt-br",
+		"*, nl;q=0.1":             "nl",
+		"fr;q=0, es;q=0.3":        "es",
+		"":                        "",
+	} {
+		assert.Equal(t, want, targeting.Language(header), header)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	rules := []dto.RedirectRule{
+		{ID: "ios", URL: "https://apps.apple.com/app", Devices: []string{models.DeviceIOS}},
+		{ID: "android-de", URL: "https://play.google.com/de", Devices: []string{models.DeviceAndroid}, Countries: []string{"DE"}},
+		{ID: "english", URL: "https://example.com/en", Languages: []string{"en"}},
+	}
+	now := time.Now()
+
+	for name, tc := range map[string]struct {
+		visitor targeting.Visitor
+		want    string
+	}{
+		"first match wins":         {targeting.Visitor{Device: models.DeviceIOS, Language: "en-us", Time: now}, "ios"},
+		"all conditions must hold": {targeting.Visitor{Device: models.DeviceAndroid, Country: "FR", Language: "de", Time: now}, ""},
+		"country":                  {targeting.Visitor{Device: models.DeviceAndroid, Country: "DE", Time: now}, "android-de"},
+		"unknown country":          {targeting.Visitor{Device: models.DeviceAndroid, Time: now}, ""},
+		"language variants":        {targeting.Visitor{Device: models.DeviceDesktop, Language: "en-gb", Time: now}, "english"},
+		"other languages":          {targeting.Visitor{Device: models.DeviceDesktop, Language: "eno", Time: now}, ""},
+	} {
+		rule, ok := targeting.Match(rules, tc.visitor)
+		assert.Equal(t, tc.want != "", ok, name)
+		assert.Equal(t, tc.want, rule.ID, name)
+	}
+}
+
+func TestMatch_Schedule(t *testing.T) {
+	// a Monday, 23:30 in Jakarta
+	monday := time.Date(2026, time.October, 19, 16, 30, 0, 0, time.UTC)
+	later := monday.Add(time.Hour)
+
+	for name, tc := range map[string]struct {
+		schedule dto.RuleSchedule
+		want     bool
+	}{
+		"period":                  {dto.RuleSchedule{StartsAt: &monday, EndsAt: &later}, true},
+		"period not started":      {dto.RuleSchedule{StartsAt: &later}, false},
+		"period over":             {dto.RuleSchedule{EndsAt: &monday}, false},
+		"days in the time zone":   {dto.RuleSchedule{Days: []string{"tue"}, Timezone: "Pacific/Auckland"}, true},
+		"other days":              {dto.RuleSchedule{Days: []string{"sat", "sun"}}, false},
+		"hours in UTC":            {dto.RuleSchedule{From: "09:00", To: "17:00"}, true},
+		"hours in the time zone":  {dto.RuleSchedule{From: "09:00", To: "17:00", Timezone: "Asia/Jakarta"}, false},
+		"hours spanning midnight": {dto.RuleSchedule{From: "22:00", To: "06:00", Timezone: "Asia/Jakarta"}, true},
+		"end of the window":       {dto.RuleSchedule{From: "09:00", To: "16:30"}, false},
+	} {
+		rules := []dto.RedirectRule{{ID: "scheduled", URL: "https://example.com/", Schedule: &tc.schedule}}
+		_, ok := targeting.Match(rules, targeting.Visitor{Time: monday})
+		assert.Equal(t, tc.want, ok, name)
+	}
+}
@@ -0,0 +1,178 @@
+// Package urlcanon turns URLs into a canonical form, so that URLs which lead
+// to the same resource compare equal. It is used to find duplicate links and
+// to match URLs against blacklist rules.
+package urlcanon
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+var ErrInvalidURL = errors.New("invalid URL")
+
+type Options struct {
+	// StripTracking removes tracking query parameters such as utm_source
+	// and fbclid.
+	StripTracking bool
+}
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// trackingParams are removed with Options.StripTracking, along with every
+// parameter starting with "utm_".
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"gclsrc":  true,
+	"dclid":   true,
+	"msclkid": true,
+	"yclid":   true,
+	"igshid":  true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"_ga":     true,
+	"_gl":     true,
+	"_hsenc":  true,
+	"_hsmi":   true,
+	"mkt_tok": true,
+}
+
+// Canonicalize returns the canonical form of rawURL, see Parse.
+func Canonicalize(rawURL string, opts Options) (string, error) {
+	u, err := Parse(rawURL, opts)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+// Parse parses an absolute URL and canonicalises it: the scheme and host
+// are lower-cased, international domain names converted to punycode, the
+// default port and the fragment removed and "." and ".." path segments
+// resolved. An empty path becomes "/". The order of the query parameters
+// is kept.
+func Parse(rawURL string, opts Options) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return nil, ErrInvalidURL
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	host, err := Host(u.Hostname())
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]" // IPv6 literal
+	}
+	if port := u.Port(); port != "" && port != defaultPorts[u.Scheme] {
+		host = net.JoinHostPort(strings.Trim(host, "[]"), port)
+	}
+	u.Host = host
+
+	path := removeDotSegments(u.EscapedPath())
+	if path == "" {
+		path = "/"
+	}
+	if u.Path, err = url.PathUnescape(path); err != nil {
+		return nil, ErrInvalidURL
+	}
+	u.RawPath = path
+
+	if opts.StripTracking {
+		u.RawQuery = stripTracking(u.RawQuery)
+	}
+	u.ForceQuery = false
+	u.Fragment, u.RawFragment = "", ""
+	u.User = nil
+	return u, nil
+}
+
+// Host lower-cases a hostname, drops a trailing dot and converts an
+// international domain name to punycode.
+func Host(host string) (string, error) {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" {
+		return "", ErrInvalidURL
+	}
+	for i := 0; i < len(host); i++ {
+		if host[i] >= 0x80 {
+			ascii, err := idna.Lookup.ToASCII(host)
+			if err != nil {
+				return "", ErrInvalidURL
+			}
+			return ascii, nil
+		}
+	}
+	return host, nil
+}
+
+// HostPath returns the host and path of u without a trailing slash, e.g.
+// "example.com/a/b". Blacklist URL rules are stored in this form.
+func HostPath(u *url.URL) string {
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	path := strings.TrimSuffix(u.EscapedPath(), "/")
+	if path == "" {
+		return host
+	}
+	return host + path
+}
+
+// removeDotSegments implements RFC 3986 section 5.2.4.
+func removeDotSegments(path string) string {
+	if !strings.Contains(path, ".") {
+		return path
+	}
+
+	var out []string
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		last := i == len(segments)-1
+		switch seg {
+		case ".":
+			if last {
+				out = append(out, "")
+			}
+		case "..":
+			if len(out) > 1 {
+				out = out[:len(out)-1]
+			}
+			if last {
+				out = append(out, "")
+			}
+		default:
+			out = append(out, seg)
+		}
+	}
+
+	cleaned := strings.Join(out, "/")
+	if strings.HasPrefix(path, "/") && !strings.HasPrefix(cleaned, "/") {
+		cleaned = "/" + cleaned
+	}
+	return cleaned
+}
+
+func stripTracking(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	var kept []string
+	for _, pair := range strings.Split(rawQuery, "&") {
+		key, _, _ := strings.Cut(pair, "=")
+		if name, err := url.QueryUnescape(key); err == nil {
+			name = strings.ToLower(name)
+			if trackingParams[name] || strings.HasPrefix(name, "utm_") {
+				continue
+			}
+		}
+		kept = append(kept, pair)
+	}
+	return strings.Join(kept, "&")
+}
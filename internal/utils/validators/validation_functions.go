@@ -2,10 +2,33 @@ package validators
 
 import (
 	"regexp"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 )
 
 func CustomIDFormat(fl validator.FieldLevel) bool {
 		return regexp.MustCompile(`^[a-zA-Z0-9]{3,30}$`).MatchString(fl.Field().String())
-}
\ No newline at end of file
+}
+
+// LinkIDFormat accepts a short ID optionally followed by "@" and the custom
+// domain the link lives on, see models.LinkKey.
+func LinkIDFormat(fl validator.FieldLevel) bool {
+	shortID, domain, found := strings.Cut(fl.Field().String(), "@")
+	if !regexp.MustCompile(`^[a-zA-Z0-9]{3,30}$`).MatchString(shortID) {
+		return false
+	}
+	return !found || Validate.Var(domain, "fqdn") == nil
+}
+
+// TagFormat accepts link tags: up to 50 letters, digits, "_", "-" and ".",
+// starting with a letter or digit.
+func TagFormat(fl validator.FieldLevel) bool {
+	return regexp.MustCompile(`^[\p{L}\p{N}][\p{L}\p{N}_.-]{0,49}$`).MatchString(fl.Field().String())
+}
+
+// RuleIDFormat accepts redirect rule IDs: up to 32 letters, digits, "_" and
+// "-".
+func RuleIDFormat(fl validator.FieldLevel) bool {
+	return regexp.MustCompile(`^[a-zA-Z0-9_-]{1,32}$`).MatchString(fl.Field().String())
+}
@@ -21,4 +21,7 @@ func Init() {
 
 func registerCustomValidations() {
 	Validate.RegisterValidation("short_id", CustomIDFormat)
+	Validate.RegisterValidation("link_id", LinkIDFormat)
+	Validate.RegisterValidation("tag", TagFormat)
+	Validate.RegisterValidation("rule_id", RuleIDFormat)
 }
\ No newline at end of file
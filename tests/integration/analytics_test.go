@@ -29,9 +29,10 @@ func TestAnalytics(t *testing.T) {
 	rateLimiter.SetLimit(5, 5*time.Second)
 
 	// services and controller
-	urlSvc := url_service.New(fsService, fsService, nil)
+	urlSvc := url_service.New(fsService, fsService, nil, fsService, nil, nil, nil, nil, nil, url_service.Config{})
 	trackingSvc := tracking_service.New(fsService, tcEnv.rdClient)
-	controller := controllers.New(urlSvc, trackingSvc, fsService, rateLimiter)
+	controller := controllers.New(urlSvc, trackingSvc, fsService, nil, nil, nil, nil, nil, nil, rateLimiter, nil)
+	handler := validated(t, controller.Router)
 	controller.Router.GET("/u/analytics/:short_id",
 		rateLimiter.Apply(authMiddleware.RequireAuth(controller.Analytics)),
 	)
@@ -65,7 +66,7 @@ func TestAnalytics(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer "+ownerToken)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusOK, rec.Code)
 		assert.Contains(t, rec.Body.String(), `"short_id":"`+shortID+`"`)
@@ -76,7 +77,7 @@ func TestAnalytics(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/u/analytics/"+shortID, nil)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusUnauthorized, rec.Code)
 		assert.Contains(t, rec.Body.String(), "Missing or invalid Authorization header")
@@ -87,7 +88,7 @@ func TestAnalytics(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer "+anotherToken)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusForbidden, rec.Code)
 		assert.Contains(t, rec.Body.String(), "Forbidden")
@@ -98,7 +99,7 @@ func TestAnalytics(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer "+ownerToken)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusBadRequest, rec.Code)
 		assert.Contains(t, rec.Body.String(), shortlink_errors.ErrValidateRequest.Error())
@@ -109,7 +110,7 @@ func TestAnalytics(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer "+ownerToken)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusNotFound, rec.Code)
 		assert.Contains(t, rec.Body.String(), shortlink_errors.ErrNotFound.Error())
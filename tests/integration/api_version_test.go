@@ -0,0 +1,100 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/controllers"
+	"github.com/mfmahendr/url-shortener-backend/internal/middleware"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/audit_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/blacklist_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/notification_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/reputation_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/tracking_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/url_service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionedAPI(t *testing.T) {
+	ctx := context.Background()
+	tcEnv = GetSharedTestContainerEnv(ctx, t)
+	require.NotNil(t, tcEnv, "tcEnv should be initialized")
+
+	authMiddleware := middleware.NewAuthMiddleware(tcEnv.FsApp)
+	rateLimiter := middleware.NewRateLimiter(tcEnv.rdClient)
+	rateLimiter.SetLimit(100, time.Second)
+
+	auditSvc := audit_service.New(fsService)
+	notificationSvc := notification_service.New(fsService)
+	index := blacklist_service.NewIndex(fsService)
+	blacklistSvc := blacklist_service.New(fsService, auditSvc, index, blacklist_service.NewEnforcer(fsService, notificationSvc, index))
+	noProviders := reputation_service.NewComposite(reputation_service.Config{}, nil)
+	urlSvc := url_service.New(fsService, fsService, noProviders, fsService, auditSvc, nil, nil, nil, nil, url_service.Config{})
+	trackingSvc := tracking_service.New(fsService, tcEnv.rdClient)
+	controller := controllers.New(urlSvc, trackingSvc, fsService, blacklistSvc, nil, nil, nil, auditSvc, nil, rateLimiter, nil)
+	handler := validated(t, controller.Router)
+	sunset := time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)
+	controller.RegisterRoutes(*authMiddleware, controllers.RouteConfig{LegacySunset: sunset})
+
+	claims := map[string]interface{}{
+		"admin": true,
+	}
+	_, token, err := createTestUserAndToken(ctx, authMiddleware.AuthClient, "api-version@url-shortener.com", &claims)
+	require.NoError(t, err)
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = "192.0.2.42:12345"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("Versioned routes are not deprecated", func(t *testing.T) {
+		rec := do(http.MethodPost, "/api/v1/links", `{"url": "https://example.com/v1", "custom_id": "versioned1"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get("Deprecation"))
+
+		rec = do(http.MethodGet, "/api/v1/links/versioned1/clicks/count", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("Legacy routes announce their successor", func(t *testing.T) {
+		rec := do(http.MethodGet, "/u/click-count/versioned1", "")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.True(t, strings.HasPrefix(rec.Header().Get("Deprecation"), "@"))
+		assert.Equal(t, "Fri, 30 Apr 2027 00:00:00 GMT", rec.Header().Get("Sunset"))
+		assert.Equal(t, `</api/v1/links/versioned1/clicks/count>; rel="successor-version"`, rec.Header().Get("Link"))
+	})
+
+	t.Run("Blacklist rules are removed by ID", func(t *testing.T) {
+		rec := do(http.MethodPost, "/api/v1/admin/blacklist", `{"type": "domain", "value": "versioned-api.example.com"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var added map[string]string
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&added))
+		require.NotEmpty(t, added["id"])
+
+		rec = do(http.MethodDelete, "/api/v1/admin/blacklist/"+added["id"], "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		rec = do(http.MethodDelete, "/api/v1/admin/blacklist/"+added["id"], "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("The legacy blacklist removal points to the collection", func(t *testing.T) {
+		rec := do(http.MethodDelete, "/admin/blacklist?type=domain&value=never-added.example.com", "")
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, `</api/v1/admin/blacklist>; rel="successor-version"`, rec.Header().Get("Link"))
+	})
+}
@@ -0,0 +1,139 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/controllers"
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/middleware"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/audit_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/url_service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLog(t *testing.T) {
+	ctx := context.Background()
+	tcEnv = GetSharedTestContainerEnv(ctx, t)
+	require.NotNil(t, tcEnv, "tcEnv should be initialized")
+
+	authMiddleware := middleware.NewAuthMiddleware(tcEnv.FsApp)
+	auditSvc := audit_service.New(fsService)
+	urlSvc := url_service.New(fsService, fsService, nil, fsService, auditSvc, nil, nil, nil, nil, url_service.Config{})
+	controller := controllers.New(urlSvc, nil, fsService, nil, nil, nil, nil, auditSvc, nil, nil, nil)
+
+	handler := middleware.RequestMetadata(validated(t, controller.Router))
+	controller.Router.POST("/admin/blacklist", authMiddleware.RequireAdminAuth(controller.AddToBlacklist))
+	controller.Router.GET("/admin/audit", authMiddleware.RequireAdminAuth(controller.GetAuditLogs))
+	controller.Router.PATCH("/u/shortlinks/:short_id", authMiddleware.RequireAuth(controller.UpdateShortlink))
+	controller.Router.DELETE("/u/shortlinks/:short_id", authMiddleware.RequireAuth(controller.DeleteShortlink))
+
+	claims := map[string]interface{}{"admin": true}
+	adminUID, adminToken, err := createTestUserAndToken(ctx, authMiddleware.AuthClient, "audit.admin@url-shortener.com", &claims)
+	require.NoError(t, err)
+	userUID, userToken, err := createTestUserAndToken(ctx, authMiddleware.AuthClient, "audit.user@url-shortener.com", nil)
+	require.NoError(t, err)
+
+	doRequest := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Request-ID", "audit-test-request")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	fetchLogs := func(t *testing.T, query string) []models.AuditLog {
+		rec := doRequest(http.MethodGet, "/admin/audit?"+query, adminToken, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var resp dto.AuditLogsResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		return resp.Logs
+	}
+
+	t.Run("blacklist changes are recorded", func(t *testing.T) {
+		rec := doRequest(http.MethodPost, "/admin/blacklist", adminToken, `{"type": "domain", "value": "audited-domain.com"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		logs := fetchLogs(t, "action="+models.AuditBlacklistAdd+"&actor_uid="+adminUID)
+		require.Len(t, logs, 1)
+		assert.Equal(t, "domain:audited-domain.com", logs[0].TargetID)
+		assert.Equal(t, "audit-test-request", logs[0].RequestID)
+		assert.Equal(t, "audited-domain.com", logs[0].After["value"])
+	})
+
+	t.Run("link edits and deletes are recorded", func(t *testing.T) {
+		shortID := "auditedlink1"
+		err := fsService.SetShortlink(ctx, shortID, models.Shortlink{
+			ShortID:   shortID,
+			URL:       "https://before.example.com",
+			CreatedBy: userUID,
+			CreatedAt: time.Now(),
+		})
+		require.NoError(t, err)
+
+		rec := doRequest(http.MethodPatch, "/u/shortlinks/"+shortID, userToken, `{"is_private": true}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		rec = doRequest(http.MethodDelete, "/u/shortlinks/"+shortID, userToken, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		logs := fetchLogs(t, "target_type="+models.AuditTargetShortlink+"&target_id="+shortID)
+		require.Len(t, logs, 2)
+		// newest first by default
+		assert.Equal(t, models.AuditLinkDelete, logs[0].Action)
+		assert.Equal(t, models.AuditLinkUpdate, logs[1].Action)
+		assert.Equal(t, false, logs[1].Before["is_private"])
+		assert.Equal(t, true, logs[1].After["is_private"])
+	})
+
+	t.Run("cursors page through entries with equal timestamps", func(t *testing.T) {
+		// entries made in the same instant must not be skipped or repeated across pages
+		at := time.Now().Add(-time.Minute)
+		for i := 0; i < 5; i++ {
+			require.NoError(t, fsService.AddAuditLog(ctx, &models.AuditLog{
+				ActorUID:   adminUID,
+				Action:     models.AuditBlacklistRemove,
+				TargetType: models.AuditTargetBlacklist,
+				TargetID:   "domain:paged.example.com",
+				Timestamp:  at,
+			}))
+		}
+
+		query := url.Values{"target_id": {"domain:paged.example.com"}, "limit": {"2"}}
+		seen := 0
+		for i := 0; i < 5; i++ {
+			rec := doRequest(http.MethodGet, "/admin/audit?"+query.Encode(), adminToken, "")
+			require.Equal(t, http.StatusOK, rec.Code)
+			var resp dto.AuditLogsResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			seen += len(resp.Logs)
+			if resp.NextCursor == "" {
+				break
+			}
+			query.Set("cursor", resp.NextCursor)
+		}
+		assert.Equal(t, 5, seen)
+
+		// a cursor only works with the filters it was returned for
+		query.Set("target_id", "domain:other.example.com")
+		rec := doRequest(http.MethodGet, "/admin/audit?"+query.Encode(), adminToken, "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec = doRequest(http.MethodGet, "/admin/audit?cursor="+url.QueryEscape(at.Format(time.RFC3339Nano)), adminToken, "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("non-admin cannot read the audit log", func(t *testing.T) {
+		rec := doRequest(http.MethodGet, "/admin/audit", userToken, "")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+}
@@ -5,11 +5,21 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/mfmahendr/url-shortener-backend/internal/controllers"
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
 	"github.com/mfmahendr/url-shortener-backend/internal/middleware"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/audit_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/blacklist_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/notification_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/url_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils"
 	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
 
 	"github.com/stretchr/testify/assert"
@@ -23,11 +33,21 @@ func TestBlacklist(t *testing.T) {
 
 	// Middleware + controller setup
 	authMiddleware := middleware.NewAuthMiddleware(tcEnv.FsApp)
-	controller := controllers.New(nil, nil, fsService, nil)
+	auditSvc := audit_service.New(fsService)
+	notificationSvc := notification_service.New(fsService)
+	index := blacklist_service.NewIndex(fsService)
+	enforcer := blacklist_service.NewEnforcer(fsService, notificationSvc, index)
+	urlSvc := url_service.New(fsService, index, nil, fsService, auditSvc, nil, nil, nil, nil, url_service.Config{})
+	controller := controllers.New(urlSvc, nil, fsService, blacklist_service.New(fsService, auditSvc, index, enforcer), nil, nil, nil, auditSvc, notificationSvc, nil, nil)
+	handler := validated(t, controller.Router)
 
 	controller.Router.POST("/admin/blacklist", authMiddleware.RequireAdminAuth(controller.AddToBlacklist))
 	controller.Router.DELETE("/admin/blacklist", authMiddleware.RequireAdminAuth(controller.RemoveFromBlacklist))
 	controller.Router.GET("/admin/blacklist", authMiddleware.RequireAdminAuth(controller.FetchBlacklistItems))
+	controller.Router.POST("/admin/blacklist/import", authMiddleware.RequireAdminAuth(controller.ImportBlacklist))
+	controller.Router.GET("/admin/blacklist/export", authMiddleware.RequireAdminAuth(controller.ExportBlacklist))
+	controller.Router.GET("/r/:short_id", authMiddleware.OptionalAuth(controller.Redirect))
+	controller.Router.GET("/u/notifications", authMiddleware.RequireAuth(controller.GetNotifications))
 
 	// Create user and set admin user claim
 	claims := map[string]interface{}{
@@ -35,7 +55,7 @@ func TestBlacklist(t *testing.T) {
 	}
 	_, token, err := createTestUserAndToken(ctx, authMiddleware.AuthClient, "admin@url-shortener.com", &claims)
 	require.NoError(t, err)
-	_, anotherToken, err := createTestUserAndToken(ctx, authMiddleware.AuthClient, "anotheruser@url-shortener.com", nil)
+	anotherUID, anotherToken, err := createTestUserAndToken(ctx, authMiddleware.AuthClient, "anotheruser@url-shortener.com", nil)
 	require.NoError(t, err)
 
 	t.Run("Successfully blacklist a valid domain", func(t *testing.T) {
@@ -45,7 +65,7 @@ func TestBlacklist(t *testing.T) {
 		req.Header.Set("Content-Type", "application/json")
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusOK, rec.Code)
 		assert.Contains(t, rec.Body.String(), `"status":"added"`)
@@ -60,7 +80,7 @@ func TestBlacklist(t *testing.T) {
 		req.Header.Set("Content-Type", "application/json")
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusOK, rec.Code)
 		assert.Contains(t, rec.Body.String(), `"status":"added"`)
@@ -75,7 +95,7 @@ func TestBlacklist(t *testing.T) {
 		req.Header.Set("Content-Type", "application/json")
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusForbidden, rec.Code)
 		assert.Contains(t, strings.ToLower(rec.Body.String()), "forbidden")
@@ -88,7 +108,7 @@ func TestBlacklist(t *testing.T) {
 		req.Header.Set("Content-Type", "application/json")
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusConflict, rec.Code)
 		assert.Contains(t, rec.Body.String(), shortlink_errors.ErrResourceExists.Error())
@@ -101,7 +121,7 @@ func TestBlacklist(t *testing.T) {
 		req.Header.Set("Content-Type", "application/json")
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusBadRequest, rec.Code)
 		assert.Contains(t, rec.Body.String(), shortlink_errors.ErrValidateRequest.Error())
@@ -114,7 +134,7 @@ func TestBlacklist(t *testing.T) {
 		req.Header.Set("Content-Type", "application/json")
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusBadRequest, rec.Code)
 	})
@@ -126,7 +146,7 @@ func TestBlacklist(t *testing.T) {
 		req.Header.Set("Content-Type", "application/json")
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusBadRequest, rec.Code)
 	})
@@ -137,7 +157,7 @@ func TestBlacklist(t *testing.T) {
 		req.Header.Set("Content-Type", "application/json")
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusUnauthorized, rec.Code)
 	})
@@ -150,7 +170,7 @@ func TestBlacklist(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer "+token)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusOK, rec.Code)
 		assert.Contains(t, rec.Body.String(), `"status":"removed"`)
@@ -166,7 +186,7 @@ func TestBlacklist(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer "+token)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusOK, rec.Code)
 		assert.Contains(t, rec.Body.String(), `"status":"removed"`)
@@ -177,7 +197,7 @@ func TestBlacklist(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer "+token)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusNotFound, rec.Code)
 		assert.Contains(t, rec.Body.String(), shortlink_errors.ErrNotFound.Error())
@@ -188,7 +208,7 @@ func TestBlacklist(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer "+token)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusBadRequest, rec.Code)
 		assert.Contains(t, rec.Body.String(), shortlink_errors.ErrValidateRequest.Error())
@@ -204,7 +224,7 @@ func TestBlacklist(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer "+token)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusOK, rec.Code)
 
@@ -231,8 +251,242 @@ func TestBlacklist(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/admin/blacklist", nil)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusUnauthorized, rec.Code)
 	})
+
+	t.Run("Bulk import reports every line", func(t *testing.T) {
+		body := "type,value\ndomain,imported-domain-one.com\nurl,https://imported.example.com/phish\ndomain,imported-domain-one.com\ndomain,not valid!\n"
+		req := httptest.NewRequest(http.MethodPost, "/admin/blacklist/import", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "text/csv")
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var resp dto.BlacklistImportResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, 2, resp.Added)
+		assert.Equal(t, 1, resp.Skipped)
+		assert.Equal(t, 1, resp.Invalid)
+		require.Len(t, resp.Results, 4)
+		assert.Equal(t, 5, resp.Results[3].Line)
+
+		blacklisted, err := fsService.IsBlacklisted(ctx, "https://imported-domain-one.com")
+		require.NoError(t, err)
+		assert.True(t, blacklisted)
+	})
+
+	t.Run("Bulk import keeps existing entries", func(t *testing.T) {
+		body := "0.0.0.0 imported-domain-one.com\n0.0.0.0 imported-domain-two.com\n"
+		req := httptest.NewRequest(http.MethodPost, "/admin/blacklist/import?format=hosts", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var resp dto.BlacklistImportResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, "exists", resp.Results[0].Status)
+		assert.Equal(t, "added", resp.Results[1].Status)
+	})
+
+	t.Run("Export as list", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/admin/blacklist/export?format=list", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "imported-domain-two.com\n")
+		assert.Contains(t, rec.Body.String(), "imported.example.com/phish\n")
+	})
+
+	t.Run("Feed sync only replaces its own entries", func(t *testing.T) {
+		feedPath := filepath.Join(t.TempDir(), "feed.txt")
+		require.NoError(t, os.WriteFile(feedPath, []byte("feed-domain-one.com\nimported-domain-two.com\n"), 0o644))
+		feed := blacklist_service.Feed{Name: "testfeed", Format: blacklist_service.FormatList, Location: feedPath}
+		syncer := blacklist_service.NewFeedSyncer(fsService, enforcer, blacklist_service.FeedConfig{}, nil)
+
+		res, err := syncer.SyncFeed(ctx, feed)
+		require.NoError(t, err)
+		assert.Equal(t, 1, res.Added)
+
+		require.NoError(t, os.WriteFile(feedPath, []byte("feed-domain-two.com\n"), 0o644))
+		res, err = syncer.SyncFeed(ctx, feed)
+		require.NoError(t, err)
+		assert.Equal(t, 1, res.Added)
+		assert.Equal(t, 1, res.Removed)
+
+		items, err := fsService.ListBlacklistedBySource(ctx, "testfeed")
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, "feed-domain-two.com", items[0].Value)
+
+		// the manual entry that was also in the feed survives
+		blacklisted, err := fsService.IsBlacklisted(ctx, "imported-domain-two.com")
+		require.NoError(t, err)
+		assert.True(t, blacklisted)
+	})
+
+	t.Run("URL and domain rules with the same value are kept apart", func(t *testing.T) {
+		domainRule := models.BlacklistItem{Type: models.BlacklistDomain, Value: "same-value.example.com", Source: models.SourceManual}
+		urlRule := models.BlacklistItem{Type: models.BlacklistURL, Value: "https://same-value.example.com/", Source: models.SourceManual}
+		require.NoError(t, fsService.AddBlacklistItem(ctx, domainRule))
+		require.NoError(t, fsService.AddBlacklistItem(ctx, urlRule))
+
+		// removing the URL rule leaves the domain rule in place
+		require.NoError(t, fsService.RemoveBlacklistItem(ctx, urlRule))
+		blocked, err := fsService.IsBlacklisted(ctx, "https://same-value.example.com/other")
+		require.NoError(t, err)
+		assert.True(t, blocked)
+		assert.Equal(t, shortlink_errors.ErrNotFound, fsService.RemoveBlacklistItem(ctx, urlRule))
+	})
+
+	t.Run("URL rules stored under their value alone are moved", func(t *testing.T) {
+		// stored the way URL rules were before their IDs included the type
+		_, err := fsService.GetClient().Collection("blacklist_items").Doc(utils.GenerateDocID("legacy-rule.example.com")).Set(ctx, map[string]interface{}{
+			"type":       models.BlacklistURL,
+			"value":      "legacy-rule.example.com",
+			"created_at": time.Now(),
+			"source":     models.SourceManual,
+		})
+		require.NoError(t, err)
+
+		_, err = fsService.MoveLegacyURLRules(ctx)
+		require.NoError(t, err)
+
+		// the domain's ID is free again and the URL rule is still there
+		require.NoError(t, fsService.AddBlacklistItem(ctx, models.BlacklistItem{Type: models.BlacklistDomain, Value: "legacy-rule.example.com", Source: models.SourceManual}))
+		assert.NoError(t, fsService.UnblacklistURL(ctx, "https://legacy-rule.example.com"))
+	})
+
+	t.Run("Wildcard, prefix and regex rules", func(t *testing.T) {
+		rules := []string{
+			`{"type": "domain", "value": "*.wildcard-blocked.com"}`,
+			`{"type": "url_prefix", "value": "https://prefix-blocked.com/downloads"}`,
+			`{"type": "regex", "value": "^https?://[^/]+/wp-login-[0-9]+\\.php"}`,
+		}
+		for _, body := range rules {
+			req := httptest.NewRequest(http.MethodPost, "/admin/blacklist", strings.NewReader(body))
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		}
+
+		cases := map[string]bool{
+			"https://wildcard-blocked.com":                 true,
+			"https://deep.sub.wildcard-blocked.com/x":      true,
+			"https://prefix-blocked.com/downloads/a.exe":   true,
+			"https://prefix-blocked.com/downloads-archive": false,
+			"http://any-site.example/wp-login-12.php":      true,
+			"https://unrelated.example.com":                false,
+		}
+		for u, expected := range cases {
+			blocked, err := index.IsBlacklisted(ctx, u)
+			require.NoError(t, err)
+			assert.Equal(t, expected, blocked, u)
+		}
+	})
+
+	t.Run("Existing links are disabled and can be re-enabled", func(t *testing.T) {
+		require.NoError(t, fsService.SetShortlink(ctx, "soon-blocked", models.Shortlink{
+			ShortID:   "soon-blocked",
+			URL:       "https://www.retro-blocked.com/page",
+			CreatedAt: time.Now(),
+			CreatedBy: anotherUID,
+		}))
+		require.NoError(t, fsService.SetShortlink(ctx, "unaffected", models.Shortlink{
+			ShortID:   "unaffected",
+			URL:       "https://example.com/page",
+			CreatedAt: time.Now(),
+			CreatedBy: anotherUID,
+		}))
+
+		body := `{"type": "domain_suffix", "value": "retro-blocked.com"}`
+		req := httptest.NewRequest(http.MethodPost, "/admin/blacklist", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		// enforcement runs in the background
+		require.Eventually(t, func() bool {
+			link, err := fsService.GetShortlink(ctx, "soon-blocked")
+			return err == nil && link.Disabled
+		}, 10*time.Second, 100*time.Millisecond)
+
+		link, err := fsService.GetShortlink(ctx, "soon-blocked")
+		require.NoError(t, err)
+		assert.Equal(t, models.DisabledReasonBlacklisted, link.DisabledReason)
+		assert.Equal(t, "domain_suffix:retro-blocked.com", link.DisabledRule)
+		other, err := fsService.GetShortlink(ctx, "unaffected")
+		require.NoError(t, err)
+		assert.False(t, other.Disabled)
+
+		req = httptest.NewRequest(http.MethodGet, "/r/soon-blocked", nil)
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusGone, rec.Code)
+
+		req = httptest.NewRequest(http.MethodGet, "/u/notifications", nil)
+		req.Header.Set("Authorization", "Bearer "+anotherToken)
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var notifications dto.NotificationsResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &notifications))
+		require.NotEmpty(t, notifications.Notifications)
+		assert.Equal(t, models.NotificationLinkDisabled, notifications.Notifications[0].Type)
+		assert.Equal(t, "soon-blocked", notifications.Notifications[0].ShortID)
+
+		req = httptest.NewRequest(http.MethodDelete, "/admin/blacklist?type=domain_suffix&value=retro-blocked.com&reenable=true", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		require.Eventually(t, func() bool {
+			link, err := fsService.GetShortlink(ctx, "soon-blocked")
+			return err == nil && !link.Disabled
+		}, 10*time.Second, 100*time.Millisecond)
+	})
+
+	t.Run("Notifications sent together are paged without gaps", func(t *testing.T) {
+		batch := make([]models.Notification, 5)
+		for i := range batch {
+			batch[i] = models.Notification{UID: "paged-user", Type: models.NotificationLinkDisabled, ShortID: "paged-link"}
+		}
+		// Notify gives them all the same creation time
+		require.NoError(t, notificationSvc.Notify(ctx, batch))
+
+		seen := make(map[string]bool)
+		q := dto.PaginationQuery{Limit: 2, OrderDesc: true}
+		for i := 0; i < 5; i++ {
+			page, next, err := fsService.ListNotifications(ctx, "paged-user", q)
+			require.NoError(t, err)
+			for _, n := range page {
+				seen[n.ID] = true
+			}
+			if next == "" {
+				break
+			}
+			q.Cursor = next
+		}
+		assert.Len(t, seen, 5)
+
+		// a cursor only works for the user and order it was returned for
+		_, _, err := fsService.ListNotifications(ctx, "other-user", q)
+		assert.Equal(t, shortlink_errors.ErrInvalidCursor, err)
+		_, _, err = fsService.ListNotifications(ctx, "paged-user", dto.PaginationQuery{Limit: 2, Cursor: q.Cursor})
+		assert.Equal(t, shortlink_errors.ErrInvalidCursor, err)
+	})
 }
@@ -31,9 +31,10 @@ func TestGetClickCount(t *testing.T) {
 	rateLimiter.SetLimit(5, 5*time.Second)
 
 	// services and controller
-	urlSvc := url_service.New(fsService, fsService, nil)
+	urlSvc := url_service.New(fsService, fsService, nil, fsService, nil, nil, nil, nil, nil, url_service.Config{})
 	trackingSvc := tracking_service.New(fsService, tcEnv.rdClient)
-	controller := controllers.New(urlSvc, trackingSvc, fsService, rateLimiter)
+	controller := controllers.New(urlSvc, trackingSvc, fsService, nil, nil, nil, nil, nil, nil, rateLimiter, nil)
+	handler := validated(t, controller.Router)
 	controller.Router.GET("/u/click-count/:short_id",
 		rateLimiter.Apply(authMiddleware.RequireAuth(controller.GetClickCount)),
 	)
@@ -62,7 +63,7 @@ func TestGetClickCount(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer "+token)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusOK, rec.Code)
 
@@ -77,7 +78,7 @@ func TestGetClickCount(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/u/click-count/"+shortID, nil)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusUnauthorized, rec.Code)
 		assert.Contains(t, rec.Body.String(), "Missing or invalid Authorization header")
@@ -90,7 +91,7 @@ func TestGetClickCount(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer "+otherToken)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusForbidden, rec.Code)
 		assert.Contains(t, rec.Body.String(), "Forbidden")
@@ -101,7 +102,7 @@ func TestGetClickCount(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer "+token)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusNotFound, rec.Code)
 	})
@@ -111,7 +112,7 @@ func TestGetClickCount(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer "+token)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusBadRequest, rec.Code)
 		assert.Contains(t, rec.Body.String(), shortlink_errors.ErrValidateRequest.Error())
@@ -129,9 +130,10 @@ func TestExportAllClickCount(t *testing.T) {
 	rateLimiter.SetLimit(5, 5*time.Second)
 
 	// controller setup
-	urlSvc := url_service.New(fsService, fsService, nil)
+	urlSvc := url_service.New(fsService, fsService, nil, fsService, nil, nil, nil, nil, nil, url_service.Config{})
 	trackingSvc := tracking_service.New(fsService, tcEnv.rdClient)
-	controller := controllers.New(urlSvc, trackingSvc, fsService, rateLimiter)
+	controller := controllers.New(urlSvc, trackingSvc, fsService, nil, nil, nil, nil, nil, nil, rateLimiter, nil)
+	handler := validated(t, controller.Router)
 	controller.Router.GET("/u/click-count/:short_id/export",
 		rateLimiter.Apply(authMiddleware.RequireAuth(controller.ExportAllClickCount)),
 	)
@@ -165,7 +167,7 @@ func TestExportAllClickCount(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer "+ownerToken)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusOK, rec.Code)
 		assert.Equal(t, "text/csv", rec.Header().Get("Content-Type"))
@@ -178,7 +180,7 @@ func TestExportAllClickCount(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer "+ownerToken)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusOK, rec.Code)
 		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
@@ -190,7 +192,7 @@ func TestExportAllClickCount(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/u/click-count/"+shortID+"/export", nil)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusUnauthorized, rec.Code)
 		assert.Contains(t, rec.Body.String(), "Missing or invalid Authorization header")
@@ -201,7 +203,7 @@ func TestExportAllClickCount(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer "+otherToken)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusForbidden, rec.Code)
 		assert.Contains(t, rec.Body.String(), "Forbidden")
@@ -212,7 +214,7 @@ func TestExportAllClickCount(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer "+ownerToken)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusBadRequest, rec.Code)
 		assert.Contains(t, rec.Body.String(), shortlink_errors.ErrValidateRequest.Error())
@@ -223,7 +225,7 @@ func TestExportAllClickCount(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer "+ownerToken)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
 		assert.Contains(t, rec.Body.String(), "Unsupported format")
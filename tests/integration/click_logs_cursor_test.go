@@ -0,0 +1,98 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/controllers"
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/middleware"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/tracking_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/url_service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClickLogsCursor(t *testing.T) {
+	ctx := context.Background()
+	tcEnv = GetSharedTestContainerEnv(ctx, t)
+	require.NotNil(t, tcEnv, "tcEnv should be initialized")
+
+	authMiddleware := middleware.NewAuthMiddleware(tcEnv.FsApp)
+	urlSvc := url_service.New(fsService, fsService, nil, fsService, nil, nil, nil, nil, nil, url_service.Config{})
+	trackingSvc := tracking_service.New(fsService, tcEnv.rdClient)
+	controller := controllers.New(urlSvc, trackingSvc, fsService, nil, nil, nil, nil, nil, nil, nil, nil)
+	handler := validated(t, controller.Router)
+	controller.Router.GET("/u/analytics/:short_id", authMiddleware.RequireAuth(controller.Analytics))
+
+	ownerUID, ownerToken, err := createTestUserAndToken(ctx, authMiddleware.AuthClient, "cursor-owner@example.com", nil)
+	require.NoError(t, err)
+
+	shortID := "cursorTest123"
+	require.NoError(t, fsService.SetShortlink(ctx, shortID, models.Shortlink{
+		ShortID:   shortID,
+		URL:       "https://example.com/cursor",
+		CreatedBy: ownerUID,
+		CreatedAt: time.Now(),
+	}))
+
+	// clicks in the same instant must not be skipped or repeated across pages
+	clickedAt := time.Now().Add(-time.Minute)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, fsService.AddClickLog(ctx, &models.ClickLog{
+			ShortID:   shortID,
+			Timestamp: clickedAt,
+			IP:        "192.0.2.1",
+			UserAgent: "cursor-test",
+		}))
+	}
+
+	list := func(query url.Values) (int, dto.AnalyticsDTO) {
+		req := httptest.NewRequest(http.MethodGet, "/u/analytics/"+shortID+"?"+query.Encode(), nil)
+		req.Header.Set("Authorization", "Bearer "+ownerToken)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		var resp dto.AnalyticsDTO
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		}
+		return rec.Code, resp
+	}
+
+	t.Run("Cursors page through clicks with equal timestamps", func(t *testing.T) {
+		seen := 0
+		query := url.Values{"limit": {"2"}}
+		for i := 0; i < 5; i++ {
+			code, resp := list(query)
+			require.Equal(t, http.StatusOK, code)
+			seen += len(resp.Clicks)
+			if resp.NextCursor == "" {
+				break
+			}
+			query.Set("cursor", resp.NextCursor)
+		}
+		assert.Equal(t, 5, seen)
+	})
+
+	t.Run("Cursors of another query are refused", func(t *testing.T) {
+		_, resp := list(url.Values{"limit": {"1"}})
+		require.NotEmpty(t, resp.NextCursor)
+
+		code, _ := list(url.Values{"limit": {"1"}, "order": {"desc"}, "cursor": {resp.NextCursor}})
+		assert.Equal(t, http.StatusBadRequest, code)
+
+		code, _ = list(url.Values{"limit": {"1"}, "after": {clickedAt.Add(-time.Hour).Format(time.RFC3339)}, "cursor": {resp.NextCursor}})
+		assert.Equal(t, http.StatusBadRequest, code)
+
+		code, _ = list(url.Values{"cursor": {clickedAt.Format(time.RFC3339Nano)}})
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+}
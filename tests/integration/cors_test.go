@@ -0,0 +1,38 @@
+package integration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORS(t *testing.T) {
+	t.Setenv("ALLOWED_ORIGINS", "https://app.example.com")
+	handler := middleware.CORS(http.NotFoundHandler())
+
+	t.Run("Preflights allow Idempotency-Key", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/api/v1/link-batches", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Headers", "authorization, idempotency-key")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Get("Access-Control-Allow-Headers"), "Idempotency-Key")
+	})
+
+	t.Run("Replay and deprecation headers are exposed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/u/shortlinks", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		exposed := rec.Header().Get("Access-Control-Expose-Headers")
+		for _, h := range []string{"Idempotent-Replayed", "Deprecation", "Sunset", "Link"} {
+			assert.Contains(t, exposed, h)
+		}
+	})
+}
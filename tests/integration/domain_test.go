@@ -0,0 +1,136 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/controllers"
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/middleware"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/domain_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/reputation_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/tracking_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/url_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTXTResolver answers TXT lookups from a map instead of DNS.
+type fakeTXTResolver map[string][]string
+
+func (f fakeTXTResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	return f[name], nil
+}
+
+func TestCustomDomains(t *testing.T) {
+	ctx := context.Background()
+	tcEnv = GetSharedTestContainerEnv(ctx, t)
+	require.NotNil(t, tcEnv, "tcEnv should be initialized")
+
+	authMiddleware := middleware.NewAuthMiddleware(tcEnv.FsApp)
+	resolver := fakeTXTResolver{}
+	domainSvc := domain_service.New(fsService, fsService, resolver, domain_service.Config{ShortDomains: []string{"sho.rt"}})
+	noProviders := reputation_service.NewComposite(reputation_service.Config{}, nil)
+	urlSvc := url_service.New(fsService, fsService, noProviders, fsService, nil, nil, nil, nil, domainSvc, url_service.Config{})
+	trackingSvc := tracking_service.New(fsService, tcEnv.rdClient)
+	controller := controllers.New(urlSvc, trackingSvc, fsService, nil, nil, domainSvc, nil, nil, nil, nil, nil)
+	handler := validated(t, controller.Router)
+
+	controller.Router.GET("/u/domains", authMiddleware.RequireAuth(controller.ListDomains))
+	controller.Router.POST("/u/domains", authMiddleware.RequireAuth(controller.RegisterDomain))
+	controller.Router.POST("/u/domains/:host/verify", authMiddleware.RequireAuth(controller.VerifyDomain))
+	controller.Router.POST("/u/shorten", authMiddleware.RequireAuth(controller.Shorten))
+	controller.Router.GET("/r/:short_id", authMiddleware.OptionalAuth(controller.Redirect))
+
+	_, ownerToken, err := createTestUserAndToken(ctx, authMiddleware.AuthClient, "domain-owner@url-shortener.com", nil)
+	require.NoError(t, err)
+	_, otherToken, err := createTestUserAndToken(ctx, authMiddleware.AuthClient, "domain-other@url-shortener.com", nil)
+	require.NoError(t, err)
+
+	do := func(method, target, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	var registered dto.DomainDTO
+	t.Run("Owner registers a domain", func(t *testing.T) {
+		rec := do(http.MethodPost, "/u/domains", ownerToken, `{"host": "Go.Brand.com"}`)
+
+		require.Equal(t, http.StatusCreated, rec.Code)
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&registered))
+		assert.Equal(t, "go.brand.com", registered.Host)
+		assert.False(t, registered.Verified)
+		assert.Equal(t, "_url-shortener.go.brand.com", registered.Verification.Name)
+	})
+
+	t.Run("The service's own hosts cannot be registered", func(t *testing.T) {
+		rec := do(http.MethodPost, "/u/domains", ownerToken, `{"host": "sho.rt"}`)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("Links cannot use an unverified domain", func(t *testing.T) {
+		rec := do(http.MethodPost, "/u/shorten", ownerToken, `{"url": "https://example.com/", "domain": "go.brand.com"}`)
+
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Contains(t, rec.Body.String(), shortlink_errors.ErrDomainNotVerified.Error())
+	})
+
+	t.Run("Verification fails without the TXT record", func(t *testing.T) {
+		rec := do(http.MethodPost, "/u/domains/go.brand.com/verify", ownerToken, "")
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	})
+
+	t.Run("Verification succeeds with the TXT record", func(t *testing.T) {
+		resolver[registered.Verification.Name] = []string{registered.Verification.Value}
+
+		rec := do(http.MethodPost, "/u/domains/go.brand.com/verify", ownerToken, "")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"verified":true`)
+	})
+
+	t.Run("Other users cannot use the domain", func(t *testing.T) {
+		rec := do(http.MethodPost, "/u/shorten", otherToken, `{"url": "https://example.com/", "domain": "go.brand.com"}`)
+
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("Short IDs are unique per domain", func(t *testing.T) {
+		rec := do(http.MethodPost, "/u/shorten", ownerToken, `{"url": "https://example.com/brand", "custom_id": "domainlaunch", "domain": "go.brand.com"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"domain":"go.brand.com"`)
+
+		rec = do(http.MethodPost, "/u/shorten", otherToken, `{"url": "https://example.com/plain", "custom_id": "domainlaunch"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("The host selects the link to redirect to", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/r/domainlaunch", nil)
+		req.Host = "go.brand.com"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "https://example.com/brand", rec.Header().Get("Location"))
+
+		req = httptest.NewRequest(http.MethodGet, "/r/domainlaunch", nil)
+		req.Host = "sho.rt"
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "https://example.com/plain", rec.Header().Get("Location"))
+	})
+}
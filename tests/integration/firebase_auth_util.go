@@ -11,9 +11,19 @@ import (
 )
 
 func createTestUserAndToken(ctx context.Context, authClient *auth.Client, email string, claims *map[string]any) (uid string, customToken string, err error) {
+	return createTestUser(ctx, authClient, email, false, claims)
+}
+
+// createVerifiedTestUserAndToken is createTestUserAndToken for a user whose
+// email is verified, as accepting an invitation requires.
+func createVerifiedTestUserAndToken(ctx context.Context, authClient *auth.Client, email string, claims *map[string]any) (uid string, customToken string, err error) {
+	return createTestUser(ctx, authClient, email, true, claims)
+}
+
+func createTestUser(ctx context.Context, authClient *auth.Client, email string, verified bool, claims *map[string]any) (uid string, customToken string, err error) {
 	userRecord, err := authClient.CreateUser(ctx, (&auth.UserToCreate{}).
 		Email(email).
-		EmailVerified(false).
+		EmailVerified(verified).
 		Password("password123").
 		Disabled(false))
 	if err != nil {
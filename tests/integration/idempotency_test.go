@@ -0,0 +1,193 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mfmahendr/url-shortener-backend/internal/controllers"
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/middleware"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/reputation_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/tracking_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/url_service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+	tcEnv = GetSharedTestContainerEnv(ctx, t)
+	require.NotNil(t, tcEnv, "tcEnv should be initialized")
+
+	authMiddleware := middleware.NewAuthMiddleware(tcEnv.FsApp)
+	noProviders := reputation_service.NewComposite(reputation_service.Config{}, nil)
+	urlSvc := url_service.New(fsService, fsService, noProviders, fsService, nil, nil, nil, nil, nil, url_service.Config{})
+	trackingSvc := tracking_service.New(fsService, tcEnv.rdClient)
+	idempotency := middleware.NewIdempotency(tcEnv.rdClient, middleware.IdempotencyConfig{Window: time.Minute})
+	controller := controllers.New(urlSvc, trackingSvc, fsService, nil, nil, nil, nil, nil, nil, nil, idempotency)
+	handler := validated(t, controller.Router)
+
+	controller.Router.POST("/u/shorten", authMiddleware.RequireAuth(idempotency.Apply(controller.Shorten)))
+
+	_, token, err := createTestUserAndToken(ctx, authMiddleware.AuthClient, "idempotency@url-shortener.com", nil)
+	require.NoError(t, err)
+	_, otherToken, err := createTestUserAndToken(ctx, authMiddleware.AuthClient, "idempotency-other@url-shortener.com", nil)
+	require.NoError(t, err)
+
+	shorten := func(token, key, body string) (*httptest.ResponseRecorder, dto.ShortenResponse) {
+		req := httptest.NewRequest(http.MethodPost, "/u/shorten", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		var resp dto.ShortenResponse
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		}
+		return rec, resp
+	}
+
+	body := `{"url": "https://example.com/idempotent"}`
+
+	var first dto.ShortenResponse
+	t.Run("Retries with the same key get the first response", func(t *testing.T) {
+		var rec *httptest.ResponseRecorder
+		rec, first = shorten(token, "retry-1", body)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Empty(t, rec.Header().Get("Idempotent-Replayed"))
+
+		rec, retried := shorten(token, "retry-1", body)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, "true", rec.Header().Get("Idempotent-Replayed"))
+		assert.Equal(t, first.ShortID, retried.ShortID)
+	})
+
+	t.Run("Requests without a key are not deduplicated", func(t *testing.T) {
+		rec, resp := shorten(token, "", body)
+
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.NotEqual(t, first.ShortID, resp.ShortID)
+	})
+
+	t.Run("A key cannot be reused for a different body", func(t *testing.T) {
+		rec, _ := shorten(token, "retry-1", `{"url": "https://example.com/something-else"}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	})
+
+	t.Run("Keys are scoped to the user", func(t *testing.T) {
+		rec, resp := shorten(otherToken, "retry-1", body)
+
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Empty(t, rec.Header().Get("Idempotent-Replayed"))
+		assert.NotEqual(t, first.ShortID, resp.ShortID)
+	})
+
+	t.Run("Failed requests are replayed too", func(t *testing.T) {
+		rec, _ := shorten(token, "retry-2", `{"url": "ftp://example.com/idempotent"}`)
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec, _ = shorten(token, "retry-2", `{"url": "ftp://example.com/idempotent"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, "true", rec.Header().Get("Idempotent-Replayed"))
+	})
+
+	t.Run("A panicking request releases its key", func(t *testing.T) {
+		uid, panicToken, err := createTestUserAndToken(ctx, authMiddleware.AuthClient, "idempotency-panic@url-shortener.com", nil)
+		require.NoError(t, err)
+		controller.Router.POST("/u/panic", authMiddleware.RequireAuth(idempotency.Apply(
+			func(http.ResponseWriter, *http.Request, httprouter.Params) { panic("handler failed") },
+		)))
+
+		req := httptest.NewRequest(http.MethodPost, "/u/panic", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+panicToken)
+		req.Header.Set("Idempotency-Key", "retry-3")
+		assert.Panics(t, func() { handler.ServeHTTP(httptest.NewRecorder(), req) })
+
+		n, err := tcEnv.rdClient.Exists(ctx, "idempotency:"+uid+":retry-3").Result()
+		require.NoError(t, err)
+		assert.Zero(t, n)
+	})
+
+	t.Run("Keys of running requests expire before the window", func(t *testing.T) {
+		uid, lockToken, err := createTestUserAndToken(ctx, authMiddleware.AuthClient, "idempotency-lock@url-shortener.com", nil)
+		require.NoError(t, err)
+		redisKey := "idempotency:" + uid + ":retry-4"
+		var pendingTTL time.Duration
+		window := middleware.NewIdempotency(tcEnv.rdClient, middleware.IdempotencyConfig{Window: time.Hour})
+		controller.Router.POST("/u/lock", authMiddleware.RequireAuth(window.Apply(
+			func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+				pendingTTL, _ = tcEnv.rdClient.TTL(r.Context(), redisKey).Result()
+				w.WriteHeader(http.StatusNoContent)
+			},
+		)))
+
+		req := httptest.NewRequest(http.MethodPost, "/u/lock", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+lockToken)
+		req.Header.Set("Idempotency-Key", "retry-4")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.Positive(t, pendingTTL)
+		assert.LessOrEqual(t, pendingTTL, 2*time.Minute)
+		storedTTL, err := tcEnv.rdClient.TTL(ctx, redisKey).Result()
+		require.NoError(t, err)
+		assert.Greater(t, storedTTL, 2*time.Minute, "the response is kept for the whole window")
+	})
+
+	t.Run("Keys stay claimed while their request runs", func(t *testing.T) {
+		uid, slowToken, err := createTestUserAndToken(ctx, authMiddleware.AuthClient, "idempotency-slow@url-shortener.com", nil)
+		require.NoError(t, err)
+		redisKey := "idempotency:" + uid + ":retry-5"
+		var claimed int64
+		// the claim lasts as long as the window, so it has to be renewed
+		short := middleware.NewIdempotency(tcEnv.rdClient, middleware.IdempotencyConfig{Window: 3 * time.Second})
+		controller.Router.POST("/u/slow", authMiddleware.RequireAuth(short.Apply(
+			func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+				time.Sleep(4 * time.Second)
+				claimed, _ = tcEnv.rdClient.Exists(r.Context(), redisKey).Result()
+				w.WriteHeader(http.StatusNoContent)
+			},
+		)))
+
+		req := httptest.NewRequest(http.MethodPost, "/u/slow", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+slowToken)
+		req.Header.Set("Idempotency-Key", "retry-5")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.EqualValues(t, 1, claimed)
+	})
+
+	t.Run("A key claimed by another request is not overwritten", func(t *testing.T) {
+		uid, lostToken, err := createTestUserAndToken(ctx, authMiddleware.AuthClient, "idempotency-lost@url-shortener.com", nil)
+		require.NoError(t, err)
+		redisKey := "idempotency:" + uid + ":retry-6"
+		other := `{"fingerprint":"other","done":false}`
+		controller.Router.POST("/u/lost", authMiddleware.RequireAuth(idempotency.Apply(
+			func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+				// the claim ran out and another request took the key
+				tcEnv.rdClient.Set(r.Context(), redisKey, other, time.Minute)
+				w.WriteHeader(http.StatusNoContent)
+			},
+		)))
+
+		req := httptest.NewRequest(http.MethodPost, "/u/lost", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+lostToken)
+		req.Header.Set("Idempotency-Key", "retry-6")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		stored, err := tcEnv.rdClient.Get(ctx, redisKey).Result()
+		require.NoError(t, err)
+		assert.Equal(t, other, stored)
+	})
+}
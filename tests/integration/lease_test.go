@@ -0,0 +1,55 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/lease"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLease(t *testing.T) {
+	ctx := context.Background()
+	tcEnv = GetSharedTestContainerEnv(ctx, t)
+	require.NotNil(t, tcEnv, "tcEnv should be initialized")
+
+	first, second := lease.New(tcEnv.rdClient), lease.New(tcEnv.rdClient)
+
+	t.Run("Only one instance runs a pass", func(t *testing.T) {
+		var ran bool
+		ok, err := first.Do(ctx, "test-running", time.Hour, func(ctx context.Context) {
+			// the other instance tries while this pass is running
+			ranSecond, err := second.Do(ctx, "test-running", time.Hour, func(context.Context) {})
+			require.NoError(t, err)
+			ran = ranSecond
+		})
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.False(t, ran)
+	})
+
+	t.Run("A finished pass holds the lease for its interval", func(t *testing.T) {
+		ok, err := first.Do(ctx, "test-interval", 2*time.Second, func(context.Context) {})
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		ok, err = second.Do(ctx, "test-interval", 2*time.Second, func(context.Context) {})
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		time.Sleep(2100 * time.Millisecond)
+		ok, err = second.Do(ctx, "test-interval", 2*time.Second, func(context.Context) {})
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("Without a lease every pass runs", func(t *testing.T) {
+		var none *lease.Lease
+		ok, err := none.Do(ctx, "test-none", time.Hour, func(context.Context) {})
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, time.Hour, none.Every(time.Hour))
+	})
+}
@@ -0,0 +1,180 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/controllers"
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/middleware"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/tracking_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/url_service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchUserLinks(t *testing.T) {
+	ctx := context.Background()
+	tcEnv = GetSharedTestContainerEnv(ctx, t)
+	require.NotNil(t, tcEnv, "tcEnv should be initialized")
+
+	authMiddleware := middleware.NewAuthMiddleware(tcEnv.FsApp)
+	urlSvc := url_service.New(fsService, nil, nil, fsService, nil, nil, nil, nil, nil, url_service.Config{})
+	trackingSvc := tracking_service.New(fsService, tcEnv.rdClient)
+	controller := controllers.New(urlSvc, trackingSvc, fsService, nil, nil, nil, nil, nil, nil, nil, nil)
+	handler := validated(t, controller.Router)
+	controller.Router.GET("/u/shortlinks", authMiddleware.RequireAuth(controller.GetShortlinks))
+
+	userID, token, err := createTestUserAndToken(ctx, authMiddleware.AuthClient, "search.user@example.com", nil)
+	require.NoError(t, err)
+
+	// all links share a creation time, so pages must not be cut on it alone
+	createdAt := time.Now().Add(-time.Hour)
+	links := []models.Shortlink{
+		{ShortID: "srch_a", URL: "https://shop.example.com/spring", DestinationHost: "shop.example.com", Title: "Spring sale", Tags: []string{"spring"}},
+		{ShortID: "srch_b", URL: "https://shop.example.com/summer", DestinationHost: "shop.example.com", Title: "Summer sale", Tags: []string{"summer"}},
+		{ShortID: "srch_c", URL: "https://blog.example.org/post", DestinationHost: "blog.example.org", Title: "Blog post"},
+		{ShortID: "srch_d", URL: "https://blog.example.org/old", DestinationHost: "blog.example.org", Disabled: true, DisabledReason: models.DisabledReasonBlacklisted},
+		{ShortID: "srch_e", URL: "https://shop.example.com/winter", DestinationHost: "shop.example.com", ExpiresAt: time.Now().Add(-time.Minute)},
+	}
+	for _, l := range links {
+		l.CreatedBy, l.CreatedAt = userID, createdAt
+		require.NoError(t, fsService.SetShortlink(ctx, l.ShortID, l))
+	}
+
+	// clicks: srch_c three times, srch_b once (last)
+	for _, id := range []string{"srch_c", "srch_c", "srch_c", "srch_b"} {
+		require.NoError(t, trackingSvc.TrackClick(ctx, id, "192.0.2.1", "test-agent", "", ""))
+	}
+	// the link counters are only written by the flush
+	flusher := tracking_service.NewClickCountFlusher(fsService, tcEnv.rdClient, tracking_service.FlushConfig{}, nil)
+	_, err = flusher.Flush(ctx)
+	require.NoError(t, err)
+
+	list := func(query url.Values) (int, dto.UserLinksResponse) {
+		req := httptest.NewRequest(http.MethodGet, "/u/shortlinks?"+query.Encode(), nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		var resp dto.UserLinksResponse
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		}
+		return rec.Code, resp
+	}
+	ids := func(resp dto.UserLinksResponse) []string {
+		var ids []string
+		for _, l := range resp.Links {
+			ids = append(ids, l.ShortID)
+		}
+		return ids
+	}
+
+	t.Run("Filter by destination host, tag and state", func(t *testing.T) {
+		code, resp := list(url.Values{"destination_host": {"SHOP.example.com"}})
+		require.Equal(t, http.StatusOK, code)
+		assert.ElementsMatch(t, []string{"srch_a", "srch_b", "srch_e"}, ids(resp))
+
+		_, resp = list(url.Values{"tag": {"spring"}})
+		assert.Equal(t, []string{"srch_a"}, ids(resp))
+
+		_, resp = list(url.Values{"state": {"disabled"}})
+		assert.Equal(t, []string{"srch_d"}, ids(resp))
+
+		_, resp = list(url.Values{"state": {"expired"}})
+		assert.Equal(t, []string{"srch_e"}, ids(resp))
+
+		_, resp = list(url.Values{"state": {"active"}})
+		assert.ElementsMatch(t, []string{"srch_a", "srch_b", "srch_c"}, ids(resp))
+	})
+
+	t.Run("Filter by creation date", func(t *testing.T) {
+		_, resp := list(url.Values{"created_after": {createdAt.Add(time.Minute).Format(time.RFC3339)}})
+		assert.Empty(t, resp.Links)
+
+		_, resp = list(url.Values{"created_before": {time.Now().Format(time.RFC3339)}, "sort": {"clicks"}})
+		assert.Len(t, resp.Links, len(links))
+
+		code, _ := list(url.Values{"created_after": {"yesterday"}})
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("Search the short ID, URL and title", func(t *testing.T) {
+		_, resp := list(url.Values{"q": {"SALE"}})
+		assert.ElementsMatch(t, []string{"srch_a", "srch_b"}, ids(resp))
+
+		_, resp = list(url.Values{"q": {"winter"}})
+		assert.Equal(t, []string{"srch_e"}, ids(resp))
+
+		_, resp = list(url.Values{"q": {"srch_c"}})
+		assert.Equal(t, []string{"srch_c"}, ids(resp))
+	})
+
+	t.Run("Sort by clicks and last click", func(t *testing.T) {
+		_, resp := list(url.Values{"sort": {"clicks"}, "order": {"desc"}, "limit": {"2"}})
+		require.Equal(t, []string{"srch_c", "srch_b"}, ids(resp))
+		assert.Equal(t, int64(3), resp.Links[0].ClickCount)
+		require.NotNil(t, resp.Links[0].LastClickedAt)
+
+		_, resp = list(url.Values{"sort": {"last_clicked"}, "order": {"desc"}, "limit": {"1"}})
+		assert.Equal(t, []string{"srch_b"}, ids(resp))
+	})
+
+	t.Run("Cursors page through links with equal sort values", func(t *testing.T) {
+		var seen []string
+		query := url.Values{"limit": {"2"}}
+		for i := 0; i < 5; i++ {
+			code, resp := list(query)
+			require.Equal(t, http.StatusOK, code)
+			seen = append(seen, ids(resp)...)
+			if resp.NextCursor == "" {
+				break
+			}
+			query.Set("cursor", resp.NextCursor)
+		}
+		assert.ElementsMatch(t, []string{"srch_a", "srch_b", "srch_c", "srch_d", "srch_e"}, seen)
+	})
+
+	t.Run("Cursors of another sort order are refused", func(t *testing.T) {
+		_, resp := list(url.Values{"limit": {"1"}})
+		require.NotEmpty(t, resp.NextCursor)
+
+		code, _ := list(url.Values{"limit": {"1"}, "sort": {"clicks"}, "cursor": {resp.NextCursor}})
+		assert.Equal(t, http.StatusBadRequest, code)
+
+		code, _ = list(url.Values{"cursor": {"2025-06-20T12:00:00Z"}})
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("Links without the listing fields are backfilled", func(t *testing.T) {
+		// stored the way links were before the listing fields existed
+		_, err := fsService.GetClient().Collection("shortlinks").Doc("srch_f").Set(ctx, map[string]interface{}{
+			"short_id":   "srch_f",
+			"url":        "https://Legacy.example.net/page",
+			"created_by": userID,
+			"created_at": createdAt,
+		})
+		require.NoError(t, err)
+
+		_, resp := list(url.Values{"state": {"active"}})
+		assert.NotContains(t, ids(resp), "srch_f")
+
+		_, err = fsService.BackfillShortlinkDefaults(ctx)
+		require.NoError(t, err)
+
+		_, resp = list(url.Values{"state": {"active"}})
+		assert.Contains(t, ids(resp), "srch_f")
+		_, resp = list(url.Values{"sort": {"clicks"}})
+		assert.Contains(t, ids(resp), "srch_f")
+		_, resp = list(url.Values{"destination_host": {"legacy.example.net"}})
+		assert.Equal(t, []string{"srch_f"}, ids(resp))
+	})
+}
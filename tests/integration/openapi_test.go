@@ -0,0 +1,128 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/mfmahendr/url-shortener-backend/internal/controllers"
+	"github.com/mfmahendr/url-shortener-backend/internal/middleware"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/reputation_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/tracking_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/url_service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoutesMatchOpenAPISpec(t *testing.T) {
+	ctx := context.Background()
+	tcEnv = GetSharedTestContainerEnv(ctx, t)
+	require.NotNil(t, tcEnv, "tcEnv should be initialized")
+
+	doc, err := openapi3.NewLoader().LoadFromFile(specPath)
+	require.NoError(t, err)
+
+	controller := controllers.New(nil, nil, nil, nil, nil, nil, nil, nil, nil, middleware.NewRateLimiter(tcEnv.rdClient), nil)
+	controller.RegisterRoutes(*middleware.NewAuthMiddleware(tcEnv.FsApp), controllers.RouteConfig{RootShortURLs: true})
+
+	param := regexp.MustCompile(`:(\w+)`)
+	registered := make(map[string]bool)
+	for _, rt := range controller.Routes() {
+		// the static files under /docs include the spec itself
+		if strings.HasPrefix(rt.Path, "/docs/") {
+			continue
+		}
+		registered[rt.Method+" "+param.ReplaceAllString(rt.Path, "{$1}")] = true
+	}
+
+	documented := make(map[string]bool)
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			documented[method+" "+path] = true
+		}
+	}
+
+	for op := range registered {
+		assert.True(t, documented[op], "%s is registered but not documented", op)
+	}
+	for op := range documented {
+		assert.True(t, registered[op], "%s is documented but not registered", op)
+	}
+}
+
+func TestOpenAPIValidation(t *testing.T) {
+	ctx := context.Background()
+	tcEnv = GetSharedTestContainerEnv(ctx, t)
+	require.NotNil(t, tcEnv, "tcEnv should be initialized")
+
+	authMiddleware := middleware.NewAuthMiddleware(tcEnv.FsApp)
+	rateLimiter := middleware.NewRateLimiter(tcEnv.rdClient)
+	rateLimiter.SetLimit(100, time.Second)
+
+	noProviders := reputation_service.NewComposite(reputation_service.Config{}, nil)
+	urlSvc := url_service.New(fsService, fsService, noProviders, fsService, nil, nil, nil, nil, nil, url_service.Config{})
+	trackingSvc := tracking_service.New(fsService, tcEnv.rdClient)
+	controller := controllers.New(urlSvc, trackingSvc, fsService, nil, nil, nil, nil, nil, nil, rateLimiter, nil)
+	controller.RegisterRoutes(*authMiddleware, controllers.RouteConfig{})
+
+	handler := validated(t, controller.Router)
+
+	_, token, err := createTestUserAndToken(ctx, authMiddleware.AuthClient, "openapi@url-shortener.com", nil)
+	require.NoError(t, err)
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = "192.0.2.43:12345"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("Requests that do not match the spec are refused", func(t *testing.T) {
+		rec := do(http.MethodPost, "/api/v1/links", `{"custom_id": "openapi1"}`)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), `property "url" is missing`)
+	})
+
+	t.Run("Invalid query parameters are refused", func(t *testing.T) {
+		rec := do(http.MethodGet, "/api/v1/links?limit=many", "")
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), `parameter "limit"`)
+	})
+
+	t.Run("Responses match the spec", func(t *testing.T) {
+		rec := do(http.MethodPost, "/api/v1/links", `{"url": "https://example.com/openapi", "custom_id": "openapi1"}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		rec = do(http.MethodGet, "/api/v1/links", "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		rec = do(http.MethodGet, "/api/v1/links/openapi1/clicks/count", "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	})
+
+	t.Run("Exports are streamed", func(t *testing.T) {
+		for format, contentType := range map[string]string{"csv": "text/csv", "json": "application/json"} {
+			rec := do(http.MethodGet, "/api/v1/links/openapi1/clicks/export?format="+format, "")
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.Equal(t, contentType, rec.Header().Get("Content-Type"))
+			assert.NotEmpty(t, rec.Body.String())
+		}
+	})
+
+	t.Run("Routes missing from the spec are not checked", func(t *testing.T) {
+		rec := do(http.MethodGet, "/docs/apispec.yml", "")
+
+		assert.NotEqual(t, http.StatusBadRequest, rec.Code)
+	})
+}
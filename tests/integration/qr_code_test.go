@@ -0,0 +1,145 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/controllers"
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/middleware"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/domain_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/tracking_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/url_service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQRCode(t *testing.T) {
+	ctx := context.Background()
+	tcEnv = GetSharedTestContainerEnv(ctx, t)
+	require.NotNil(t, tcEnv, "tcEnv should be initialized")
+
+	authMiddleware := middleware.NewAuthMiddleware(tcEnv.FsApp)
+	rateLimiter := middleware.NewRateLimiter(tcEnv.rdClient)
+	rateLimiter.SetLimit(100, time.Second)
+	domainSvc := domain_service.New(fsService, fsService, fakeTXTResolver{}, domain_service.Config{})
+	urlSvc := url_service.New(fsService, fsService, nil, fsService, nil, nil, nil, nil, nil, url_service.Config{})
+	trackingSvc := tracking_service.New(fsService, tcEnv.rdClient)
+	controller := controllers.New(urlSvc, trackingSvc, fsService, nil, nil, domainSvc, nil, nil, nil, rateLimiter, nil)
+	handler := validated(t, controller.Router)
+	controller.RegisterRoutes(*authMiddleware, controllers.RouteConfig{PublicBaseURL: "https://sho.rt"})
+
+	ownerUID, ownerToken, err := createTestUserAndToken(ctx, authMiddleware.AuthClient, "qr-owner@example.com", nil)
+	require.NoError(t, err)
+	_, otherToken, err := createTestUserAndToken(ctx, authMiddleware.AuthClient, "qr-other@example.com", nil)
+	require.NoError(t, err)
+
+	shortID := "qrTest123"
+	require.NoError(t, fsService.SetShortlink(ctx, shortID, models.Shortlink{
+		ShortID:   shortID,
+		URL:       "https://example.com/qr",
+		CreatedBy: ownerUID,
+		CreatedAt: time.Now(),
+	}))
+
+	get := func(path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		req.RemoteAddr = "192.0.2.49:12345"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("The owner gets a PNG by default", func(t *testing.T) {
+		rec := get("/api/v1/links/"+shortID+"/qr?size=300", ownerToken)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, "image/png", rec.Header().Get("Content-Type"))
+
+		img, err := png.Decode(rec.Body)
+		require.NoError(t, err)
+		assert.Equal(t, 300, img.Bounds().Dx())
+	})
+
+	t.Run("SVG codes use the requested colors", func(t *testing.T) {
+		rec := get("/api/v1/links/"+shortID+"/qr?format=svg&fg=%23112233&bg=fff&ecc=h", ownerToken)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, "image/svg+xml", rec.Header().Get("Content-Type"))
+		assert.Contains(t, rec.Body.String(), `fill="#112233"`)
+	})
+
+	t.Run("Invalid options are rejected", func(t *testing.T) {
+		for _, query := range []string{"format=gif", "size=big", "size=10", "ecc=X", "fg=blue", "logo=true"} {
+			rec := get("/api/v1/links/"+shortID+"/qr?"+query, ownerToken)
+			assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+		}
+	})
+
+	t.Run("Codes are only drawn for verified domains", func(t *testing.T) {
+		for host, verified := range map[string]bool{"qr.verified.com": true, "qr.unverified.com": false} {
+			require.NoError(t, fsService.CreateDomain(ctx, models.Domain{Host: host, OwnerUID: ownerUID, CreatedAt: time.Now()},
+				func(*models.Domain) bool { return true }))
+			if verified {
+				require.NoError(t, fsService.SetDomainVerified(ctx, host, time.Now()))
+			}
+			require.NoError(t, fsService.SetShortlink(ctx, models.LinkKey(host, shortID), models.Shortlink{
+				ShortID:   shortID,
+				Domain:    host,
+				URL:       "https://example.com/qr",
+				CreatedBy: ownerUID,
+				CreatedAt: time.Now(),
+			}))
+		}
+
+		rec := get("/api/v1/links/"+shortID+"/qr?domain=qr.verified.com", ownerToken)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		rec = get("/api/v1/links/"+shortID+"/qr?domain=qr.unverified.com", ownerToken)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("Without PUBLIC_BASE_URL the request host is not used", func(t *testing.T) {
+		unconfigured := controllers.New(urlSvc, trackingSvc, fsService, nil, nil, domainSvc, nil, nil, nil, rateLimiter, nil)
+		unconfigured.RegisterRoutes(*authMiddleware, controllers.RouteConfig{})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/links/"+shortID+"/qr", nil)
+		req.Header.Set("Authorization", "Bearer "+ownerToken)
+		req.Header.Set("X-Forwarded-Proto", "https")
+		req.Host = "attacker.example"
+		req.RemoteAddr = "192.0.2.49:12345"
+		rec := httptest.NewRecorder()
+		validated(t, unconfigured.Router).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	})
+
+	t.Run("Other users cannot get the code", func(t *testing.T) {
+		rec := get("/api/v1/links/"+shortID+"/qr", otherToken)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("Scans are recorded as QR clicks", func(t *testing.T) {
+		require.Equal(t, http.StatusFound, get("/r/"+shortID+"?src=qr", "").Code)
+		require.Equal(t, http.StatusFound, get("/r/"+shortID, "").Code)
+
+		var resp dto.AnalyticsDTO
+		require.Eventually(t, func() bool {
+			rec := get("/api/v1/links/"+shortID+"/clicks", ownerToken)
+			return rec.Code == http.StatusOK && json.Unmarshal(rec.Body.Bytes(), &resp) == nil && len(resp.Clicks) == 2
+		}, 5*time.Second, 100*time.Millisecond)
+
+		rec := get("/api/v1/links/"+shortID+"/clicks?source=qr", ownerToken)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Len(t, resp.Clicks, 1)
+		assert.Equal(t, models.ClickSourceQR, resp.Clicks[0].Source)
+	})
+}
@@ -22,7 +22,8 @@ func TestRateLimit(t *testing.T) {
 	rateLimiter.SetLimit(3, 3*time.Second) // allow 3 requests per 3 seconds
 
 	// Dummy controller with limited endpoint
-	controller := controllers.New(nil, nil, nil, rateLimiter)
+	controller := controllers.New(nil, nil, nil, nil, nil, nil, nil, nil, nil, rateLimiter, nil)
+	handler := validated(t, controller.Router)
 	controller.Router.GET("/health", rateLimiter.Apply(func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
@@ -34,7 +35,7 @@ func TestRateLimit(t *testing.T) {
 			req.RemoteAddr = "127.0.0.1:1234" // same IP
 			rec := httptest.NewRecorder()
 
-			controller.Router.ServeHTTP(rec, req)
+			handler.ServeHTTP(rec, req)
 
 			if i <= 3 {
 				assert.Equal(t, http.StatusOK, rec.Code, "request #%d should succeed", i)
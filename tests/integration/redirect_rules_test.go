@@ -0,0 +1,108 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/controllers"
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/middleware"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/tracking_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/url_service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedirectRules(t *testing.T) {
+	ctx := context.Background()
+	tcEnv = GetSharedTestContainerEnv(ctx, t)
+	require.NotNil(t, tcEnv, "tcEnv should be initialized")
+
+	authMiddleware := middleware.NewAuthMiddleware(tcEnv.FsApp)
+	urlSvc := url_service.New(fsService, fsService, nil, fsService, nil, nil, nil, nil, nil, url_service.Config{})
+	trackingSvc := tracking_service.New(fsService, tcEnv.rdClient)
+	controller := controllers.New(urlSvc, trackingSvc, fsService, nil, nil, nil, nil, nil, nil, nil, nil)
+	handler := validated(t, controller.Router)
+	controller.Router.PATCH("/api/v1/links/:short_id", authMiddleware.RequireAuth(controller.UpdateShortlink))
+	controller.Router.GET("/api/v1/links/:short_id/clicks", authMiddleware.RequireAuth(controller.Analytics))
+	controller.Router.GET("/r/:short_id", authMiddleware.OptionalAuth(controller.Redirect))
+
+	ownerUID, ownerToken, err := createTestUserAndToken(ctx, authMiddleware.AuthClient, "rules-owner@example.com", nil)
+	require.NoError(t, err)
+
+	shortID := "rulesTest1"
+	require.NoError(t, fsService.SetShortlink(ctx, shortID, models.Shortlink{
+		ShortID:   shortID,
+		URL:       "https://example.com/app",
+		CreatedBy: ownerUID,
+		CreatedAt: time.Now(),
+		Rules: []models.RedirectRule{
+			{ID: "ios", URL: "https://apps.apple.com/app/id1", Devices: []string{models.DeviceIOS}},
+			{ID: "german", URL: "https://example.com/de", Languages: []string{"de"}},
+		},
+	}))
+
+	visit := func(userAgent, acceptLanguage string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/r/"+shortID, nil)
+		req.Header.Set("User-Agent", userAgent)
+		req.Header.Set("Accept-Language", acceptLanguage)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("Visitors are sent by the first matching rule", func(t *testing.T) {
+		for _, tc := range []struct{ ua, lang, want string }{
+			{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)", "de-DE", "https://apps.apple.com/app/id1"},
+			{"Mozilla/5.0 (Windows NT 10.0; Win64; x64)", "de-DE,en;q=0.8", "https://example.com/de"},
+			{"Mozilla/5.0 (Windows NT 10.0; Win64; x64)", "en-US", "https://example.com/app"},
+		} {
+			rec := visit(tc.ua, tc.lang)
+			require.Equal(t, http.StatusFound, rec.Code)
+			assert.Equal(t, tc.want, rec.Header().Get("Location"), tc.ua)
+		}
+	})
+
+	t.Run("Clicks record the rule", func(t *testing.T) {
+		var resp dto.AnalyticsDTO
+		require.Eventually(t, func() bool {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/links/"+shortID+"/clicks", nil)
+			req.Header.Set("Authorization", "Bearer "+ownerToken)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			return rec.Code == http.StatusOK && json.Unmarshal(rec.Body.Bytes(), &resp) == nil && len(resp.Clicks) == 3
+		}, 5*time.Second, 100*time.Millisecond)
+
+		var rules []string
+		for _, click := range resp.Clicks {
+			rules = append(rules, click.RuleID)
+		}
+		assert.ElementsMatch(t, []string{"ios", "german", ""}, rules)
+	})
+
+	t.Run("Invalid rules are refused on save", func(t *testing.T) {
+		for _, body := range []string{
+			`{"rules": [{"url": "https://example.com/any"}]}`,
+			`{"rules": [{"url": "https://example.com/x", "devices": ["tv"]}]}`,
+			`{"rules": [{"url": "https://example.com/x", "schedule": {"from": "09:00"}}]}`,
+		} {
+			req := httptest.NewRequest(http.MethodPatch, "/api/v1/links/"+shortID, strings.NewReader(body))
+			req.Header.Set("Authorization", "Bearer "+ownerToken)
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+		}
+
+		link, err := fsService.GetShortlink(ctx, shortID)
+		require.NoError(t, err)
+		assert.Len(t, link.Rules, 2)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -28,9 +29,10 @@ func TestRedirect(t *testing.T) {
 	rateLimiter.SetLimit(5, 5*time.Second)
 
 	// service and controllers
-	urlSvc := url_service.New(fsService, fsService, nil)
+	urlSvc := url_service.New(fsService, fsService, nil, fsService, nil, nil, nil, nil, nil, url_service.Config{})
 	trackingSvc := tracking_service.New(fsService, tcEnv.rdClient)
-	controller := controllers.New(urlSvc, trackingSvc, fsService, nil)
+	controller := controllers.New(urlSvc, trackingSvc, fsService, nil, nil, nil, nil, nil, nil, nil, nil)
+	handler := validated(t, controller.Router)
 	controller.Router.GET("/r/:short_id",
 		rateLimiter.Apply(
 			authMiddleware.OptionalAuth(controller.Redirect),
@@ -66,12 +68,22 @@ func TestRedirect(t *testing.T) {
 	})
 	assert.NoError(t, err)
 
+	previewShortID := "preview123"
+	err = fsService.SetShortlink(ctx, previewShortID, models.Shortlink{
+		ShortID:   previewShortID,
+		URL:       expectedURL,
+		Title:     "Search <engine>",
+		Preview:   true,
+		CreatedAt: time.Now(),
+	})
+	require.NoError(t, err)
+
 	t.Run("success redirect to public id", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/r/"+existingPublicShortID, nil)
 		req.RemoteAddr = "192.0.2.1:12345"
 
 		rec := httptest.NewRecorder()
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusFound, rec.Code)
 		assert.Equal(t, expectedURL, rec.Header().Get("Location"))
@@ -83,7 +95,7 @@ func TestRedirect(t *testing.T) {
 		req.RemoteAddr = "192.0.2.1:12345"
 
 		rec := httptest.NewRecorder()
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusFound, rec.Code)
 		assert.Equal(t, expectedURL, rec.Header().Get("Location"))
@@ -95,7 +107,7 @@ func TestRedirect(t *testing.T) {
 		req.RemoteAddr = "192.0.2.1:12345"
 
 		rec := httptest.NewRecorder()
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusForbidden, rec.Code)
 		assert.Contains(t, rec.Body.String(), shortlink_errors.ErrForbidden.Error())
@@ -104,7 +116,7 @@ func TestRedirect(t *testing.T) {
 	t.Run("not found shortlink", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/r/notexist", nil)
 		rec := httptest.NewRecorder()
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusNotFound, rec.Code)
 		assert.Contains(t, rec.Body.String(), shortlink_errors.ErrNotFound.Error())
@@ -113,7 +125,7 @@ func TestRedirect(t *testing.T) {
 	t.Run("invalid short ID format", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/r/@@@", nil)
 		rec := httptest.NewRecorder()
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusBadRequest, rec.Code)
 		assert.Contains(t, rec.Body.String(), shortlink_errors.ErrValidateRequest.Error())
@@ -125,9 +137,45 @@ func TestRedirect(t *testing.T) {
 		req.RemoteAddr = "192.0.2.1:12345"
 
 		rec := httptest.NewRecorder()
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, expectedURL, rec.Header().Get("Location"))
+	})
+
+	t.Run("preview link shows the interstitial page", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/r/"+previewShortID, nil)
+		req.RemoteAddr = "198.51.100.7:12345"
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.True(t, strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html"))
+		assert.Contains(t, rec.Body.String(), "google.com")
+		assert.Contains(t, rec.Body.String(), "Search &lt;engine&gt;")
+		assert.Contains(t, rec.Body.String(), "/r/"+previewShortID+"?continue=1")
+	})
+
+	t.Run("preview link redirects after continuing", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/r/"+previewShortID+"?continue=1", nil)
+		req.RemoteAddr = "198.51.100.7:12345"
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusFound, rec.Code)
 		assert.Equal(t, expectedURL, rec.Header().Get("Location"))
 	})
-}
\ No newline at end of file
+
+	t.Run("plus suffix previews any link", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/r/"+existingPublicShortID+"+", nil)
+		req.RemoteAddr = "198.51.100.7:12345"
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "google.com")
+	})
+}
@@ -0,0 +1,57 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/notification_service"
+	safebrowsing_service "github.com/mfmahendr/url-shortener-backend/internal/services/safebrowsing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSafeBrowsingRescan(t *testing.T) {
+	ctx := context.Background()
+	tcEnv = GetSharedTestContainerEnv(ctx, t)
+	require.NotNil(t, tcEnv, "tcEnv should be initialized")
+
+	for id, target := range map[string]string{
+		"turned-bad": "https://turned-malicious.example.com/download",
+		"still-fine": "https://still-fine.example.com",
+	} {
+		require.NoError(t, fsService.SetShortlink(ctx, id, models.Shortlink{
+			ShortID:   id,
+			URL:       target,
+			CreatedAt: time.Now(),
+			CreatedBy: "rescan-owner",
+		}))
+	}
+
+	checker := &safebrowsing_service.MockSafeBrowsingService{
+		UnsafeURLs:  map[string]bool{"https://turned-malicious.example.com/download": true},
+		ThreatTypes: map[string]string{"https://turned-malicious.example.com/download": "MALWARE"},
+	}
+	scanner := safebrowsing_service.NewRescanner(fsService, checker, notification_service.New(fsService), safebrowsing_service.RescanConfig{}, nil)
+
+	res, err := scanner.ScanAll(ctx)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, res.Flagged, 1)
+
+	link, err := fsService.GetShortlink(ctx, "turned-bad")
+	require.NoError(t, err)
+	assert.True(t, link.Disabled)
+	assert.Equal(t, models.DisabledReasonUnsafe, link.DisabledReason)
+	assert.Equal(t, "MALWARE", link.ThreatType)
+
+	link, err = fsService.GetShortlink(ctx, "still-fine")
+	require.NoError(t, err)
+	assert.False(t, link.Disabled)
+
+	notifications, _, err := fsService.ListNotifications(ctx, "rescan-owner", dto.PaginationQuery{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+	assert.Equal(t, "turned-bad", notifications[0].ShortID)
+}
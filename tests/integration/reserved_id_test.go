@@ -0,0 +1,103 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/controllers"
+	"github.com/mfmahendr/url-shortener-backend/internal/middleware"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/audit_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/reputation_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/reserved_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/url_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReservedIDs(t *testing.T) {
+	ctx := context.Background()
+	tcEnv = GetSharedTestContainerEnv(ctx, t)
+	require.NotNil(t, tcEnv, "tcEnv should be initialized")
+
+	authMiddleware := middleware.NewAuthMiddleware(tcEnv.FsApp)
+	auditSvc := audit_service.New(fsService)
+	reservedSvc, err := reserved_service.New(fsService, reserved_service.Config{})
+	require.NoError(t, err)
+	noProviders := reputation_service.NewComposite(reputation_service.Config{}, nil)
+	urlSvc := url_service.New(fsService, fsService, noProviders, fsService, auditSvc, nil, nil, reservedSvc, nil, url_service.Config{})
+	controller := controllers.New(urlSvc, nil, fsService, nil, reservedSvc, nil, nil, auditSvc, nil, nil, nil)
+	handler := validated(t, controller.Router)
+
+	controller.Router.GET("/admin/reserved-ids", authMiddleware.RequireAdminAuth(controller.FetchReservedIDs))
+	controller.Router.POST("/admin/reserved-ids", authMiddleware.RequireAdminAuth(controller.AddReservedID))
+	controller.Router.DELETE("/admin/reserved-ids", authMiddleware.RequireAdminAuth(controller.RemoveReservedID))
+	controller.Router.POST("/u/shorten", authMiddleware.RequireAuth(controller.Shorten))
+
+	claims := map[string]interface{}{
+		"admin": true,
+	}
+	_, adminToken, err := createTestUserAndToken(ctx, authMiddleware.AuthClient, "reserved-admin@url-shortener.com", &claims)
+	require.NoError(t, err)
+	_, userToken, err := createTestUserAndToken(ctx, authMiddleware.AuthClient, "reserved-user@url-shortener.com", nil)
+	require.NoError(t, err)
+
+	do := func(method, target, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("Admin reserves a word", func(t *testing.T) {
+		rec := do(http.MethodPost, "/admin/reserved-ids", adminToken, `{"word": "Megacorp", "match": "contains"}`)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"word":"megacorp"`)
+		assert.Contains(t, rec.Body.String(), `"match":"contains"`)
+	})
+
+	t.Run("Reserving the same word again conflicts", func(t *testing.T) {
+		rec := do(http.MethodPost, "/admin/reserved-ids", adminToken, `{"word": "megacorp"}`)
+
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("Reserved words are listed with the built-in ones", func(t *testing.T) {
+		rec := do(http.MethodGet, "/admin/reserved-ids", adminToken, "")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"word":"megacorp"`)
+		assert.Contains(t, rec.Body.String(), `"word":"dashboard"`)
+	})
+
+	t.Run("Custom IDs containing the word are refused", func(t *testing.T) {
+		rec := do(http.MethodPost, "/u/shorten", userToken, `{"url": "https://example.com/", "custom_id": "M3gaCorpSale"}`)
+
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Contains(t, rec.Body.String(), shortlink_errors.ErrBlacklistedID.Error())
+	})
+
+	t.Run("Non-admins cannot reserve words", func(t *testing.T) {
+		rec := do(http.MethodPost, "/admin/reserved-ids", userToken, `{"word": "mine"}`)
+
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("Admin removes the word", func(t *testing.T) {
+		rec := do(http.MethodDelete, "/admin/reserved-ids?word=megacorp", adminToken, "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		rec = do(http.MethodPost, "/u/shorten", userToken, `{"url": "https://example.com/", "custom_id": "M3gaCorpSale"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		rec = do(http.MethodDelete, "/admin/reserved-ids?word=megacorp", adminToken, "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
@@ -0,0 +1,87 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/controllers"
+	"github.com/mfmahendr/url-shortener-backend/internal/middleware"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/reputation_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/tracking_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/url_service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRootShortURLs(t *testing.T) {
+	ctx := context.Background()
+	tcEnv = GetSharedTestContainerEnv(ctx, t)
+	require.NotNil(t, tcEnv, "tcEnv should be initialized")
+
+	authMiddleware := middleware.NewAuthMiddleware(tcEnv.FsApp)
+	rateLimiter := middleware.NewRateLimiter(tcEnv.rdClient)
+	rateLimiter.SetLimit(100, time.Second)
+
+	noProviders := reputation_service.NewComposite(reputation_service.Config{}, nil)
+	urlSvc := url_service.New(fsService, fsService, noProviders, fsService, nil, nil, nil, nil, nil, url_service.Config{})
+	trackingSvc := tracking_service.New(fsService, tcEnv.rdClient)
+	controller := controllers.New(urlSvc, trackingSvc, fsService, nil, nil, nil, nil, nil, nil, rateLimiter, nil)
+	handler := validated(t, controller.Router)
+	controller.RegisterRoutes(*authMiddleware, controllers.RouteConfig{RootShortURLs: true})
+
+	_, token, err := createTestUserAndToken(ctx, authMiddleware.AuthClient, "root-path@url-shortener.com", nil)
+	require.NoError(t, err)
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = "192.0.2.41:12345"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("The API is served under /api/v1", func(t *testing.T) {
+		rec := do(http.MethodPost, controllers.APIPrefix+"/links", `{"url": "https://example.com/root", "custom_id": "rootlink"}`)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("Links resolve at the root and under /r/", func(t *testing.T) {
+		for _, path := range []string{"/rootlink", "/r/rootlink"} {
+			rec := do(http.MethodGet, path, "")
+
+			assert.Equal(t, http.StatusFound, rec.Code, path)
+			assert.Equal(t, "https://example.com/root", rec.Header().Get("Location"), path)
+		}
+	})
+
+	t.Run("Routes take precedence over short IDs", func(t *testing.T) {
+		rec := do(http.MethodGet, "/health", "")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"status":"ok"`)
+	})
+
+	t.Run("Reserved paths are never resolved as short IDs", func(t *testing.T) {
+		for _, path := range []string{"/admin", "/metrics", "/api", "/rootlink/extra"} {
+			rec := do(http.MethodGet, path, "")
+
+			assert.Equal(t, http.StatusNotFound, rec.Code, path)
+		}
+	})
+
+	t.Run("Reserved paths cannot be claimed as custom IDs", func(t *testing.T) {
+		for _, customID := range []string{"docs", "health", "metrics"} {
+			rec := do(http.MethodPost, "/u/shorten", `{"url": "https://example.com/", "custom_id": "`+customID+`"}`)
+
+			assert.Equal(t, http.StatusForbidden, rec.Code, customID)
+		}
+	})
+}
@@ -3,8 +3,10 @@ package integration
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 
 	firebase "firebase.google.com/go/v4"
@@ -16,9 +18,13 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 
+	"github.com/mfmahendr/url-shortener-backend/internal/middleware"
 	firestore_service "github.com/mfmahendr/url-shortener-backend/internal/services/firestore"
+	"github.com/stretchr/testify/require"
 )
 
+const specPath = "../../docs/apispec.yml"
+
 type TestContainerEnv struct {
 	rdClient    *redis.Client
 	FsApp       *firebase.App
@@ -75,6 +81,7 @@ func initializeTestContainerEnvironment(ctx context.Context) (*TestContainerEnv,
 	}
 
 	os.Setenv("FIREBASE_AUTH_EMULATOR_HOST", authHost)
+	os.Setenv("PAGINATION_CURSOR_SECRET", "integration-test-secret")
 	firebaseApp, err := firebase.NewApp(ctx, &firebase.Config{ProjectID: "dummy-project"}, option.WithGRPCConn(conn))
 	if err != nil {
 		return nil, fmt.Errorf("firebase.NewApp: %v", err)
@@ -118,6 +125,20 @@ func (tce *TestContainerEnv) Cleanup(ctx context.Context) (err error) {
 	return
 }
 
+// loadSpecValidator loads the strict OpenAPI validator shared by every test.
+var loadSpecValidator = sync.OnceValues(func() (*middleware.OpenAPIValidator, error) {
+	return middleware.NewOpenAPIValidator(specPath, true)
+})
+
+// validated checks the requests a test sends to router, and the responses it
+// gets back, against the OpenAPI spec, so the spec cannot drift from the
+// handlers unnoticed.
+func validated(t *testing.T, router http.Handler) http.Handler {
+	validator, err := loadSpecValidator()
+	require.NoError(t, err)
+	return validator.Handler(router)
+}
+
 // emulatorCreds implements grpc.PerRPCCredentials for Firestore Emulator
 type emulatorCreds struct{}
 
@@ -0,0 +1,112 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/controllers"
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/middleware"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/reputation_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/tracking_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/url_service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShortenBatch(t *testing.T) {
+	ctx := context.Background()
+	tcEnv = GetSharedTestContainerEnv(ctx, t)
+	require.NotNil(t, tcEnv, "tcEnv should be initialized")
+
+	authMiddleware := middleware.NewAuthMiddleware(tcEnv.FsApp)
+	noProviders := reputation_service.NewComposite(reputation_service.Config{}, nil)
+	urlSvc := url_service.New(fsService, fsService, noProviders, fsService, nil, nil, nil, nil, nil, url_service.Config{MaxBatchItems: 3})
+	trackingSvc := tracking_service.New(fsService, tcEnv.rdClient)
+	controller := controllers.New(urlSvc, trackingSvc, fsService, nil, nil, nil, nil, nil, nil, nil, nil)
+	handler := validated(t, controller.Router)
+
+	controller.Router.POST("/u/shorten/batch", authMiddleware.RequireAuth(controller.ShortenBatch))
+	controller.Router.GET("/r/:short_id", authMiddleware.OptionalAuth(controller.Redirect))
+
+	_, token, err := createTestUserAndToken(ctx, authMiddleware.AuthClient, "batch@url-shortener.com", nil)
+	require.NoError(t, err)
+
+	do := func(contentType, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/u/shorten/batch", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", contentType)
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	body := `[
+		{"url": "https://example.com/batch-a", "custom_id": "batch-a"},
+		{"url": "ftp://example.com/batch-b"},
+		{"url": "https://example.com/batch-c", "custom_id": "batch-a"}
+	]`
+
+	var first dto.BatchShortenResponse
+	t.Run("Each item gets its own result", func(t *testing.T) {
+		rec := do("application/json", "batch-key-1", body)
+
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&first))
+		assert.Equal(t, "batch-key-1", rec.Header().Get("Idempotency-Key"))
+		assert.Equal(t, 1, first.Created)
+		assert.Equal(t, 2, first.Failed)
+		require.Len(t, first.Results, 3)
+		assert.Equal(t, "batch-a", first.Results[0].ShortID)
+		assert.Equal(t, "unsupported_scheme", first.Results[1].Error)
+		assert.Equal(t, "id_exists", first.Results[2].Error)
+
+		redirect := httptest.NewRecorder()
+		handler.ServeHTTP(redirect, httptest.NewRequest(http.MethodGet, "/r/batch-a", nil))
+		assert.Equal(t, http.StatusFound, redirect.Code)
+		assert.Equal(t, "https://example.com/batch-a", redirect.Header().Get("Location"))
+	})
+
+	t.Run("Retries with the same key replay the response", func(t *testing.T) {
+		rec := do("application/json", "batch-key-1", body)
+
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var replay dto.BatchShortenResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&replay))
+		assert.True(t, replay.Replayed)
+		assert.Equal(t, first.Results, replay.Results)
+	})
+
+	t.Run("A key cannot be reused for other items", func(t *testing.T) {
+		rec := do("application/json", "batch-key-1", `[{"url": "https://example.com/batch-d"}]`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	})
+
+	t.Run("NDJSON is accepted and a key is generated", func(t *testing.T) {
+		rec := do("application/x-ndjson", "", "{\"url\": \"https://example.com/batch-e\"}\n{\"url\": \"https://example.com/batch-f\"}\n")
+
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var resp dto.BatchShortenResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.NotEmpty(t, resp.IdempotencyKey)
+		assert.Equal(t, 2, resp.Created)
+		for _, result := range resp.Results {
+			assert.NotEmpty(t, result.ShortID)
+		}
+	})
+
+	t.Run("Batches are limited in size", func(t *testing.T) {
+		rec := do("application/json", "", `[{"url": "https://example.com/1"}, {"url": "https://example.com/2"}, {"url": "https://example.com/3"}, {"url": "https://example.com/4"}]`)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	})
+}
@@ -7,14 +7,17 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 
 	"github.com/mfmahendr/url-shortener-backend/internal/controllers"
 	"github.com/mfmahendr/url-shortener-backend/internal/dto"
 	"github.com/mfmahendr/url-shortener-backend/internal/middleware"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/reputation_service"
 	safebrowsing_service "github.com/mfmahendr/url-shortener-backend/internal/services/safebrowsing"
 	"github.com/mfmahendr/url-shortener-backend/internal/services/tracking_service"
 	"github.com/mfmahendr/url-shortener-backend/internal/services/url_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/utils"
 	"github.com/mfmahendr/url-shortener-backend/internal/utils/shortlink_errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -31,14 +34,19 @@ func TestShorten(t *testing.T) {
 		},
 	}
 
-	urlSvc := url_service.New(fsService, fsService, mockSB)
+	reputation := reputation_service.NewComposite(reputation_service.Config{}, []reputation_service.Provider{
+		reputation_service.NewSafeBrowsingProvider(mockSB),
+	})
+
+	urlSvc := url_service.New(fsService, fsService, reputation, fsService, nil, nil, nil, nil, nil, url_service.Config{})
 	trackingSvc := tracking_service.New(fsService, tcEnv.rdClient)
 
 	// Middleware
 	authMiddleware := middleware.NewAuthMiddleware(tcEnv.FsApp)
 
 	// Controller
-	controller := controllers.New(urlSvc, trackingSvc, fsService, nil)
+	controller := controllers.New(urlSvc, trackingSvc, fsService, nil, nil, nil, nil, nil, nil, nil, nil)
+	handler := validated(t, controller.Router)
 	controller.Router.POST("/u/shorten",
 		authMiddleware.RequireAuth(controller.Shorten),
 	)
@@ -68,7 +76,7 @@ func TestShorten(t *testing.T) {
 		req.Header.Set("Content-Type", "application/json")
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusOK, rec.Code)
 
@@ -101,7 +109,7 @@ func TestShorten(t *testing.T) {
 		req.Header.Set("Content-Type", "application/json")
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusOK, rec.Code)
 
@@ -127,7 +135,7 @@ func TestShorten(t *testing.T) {
 		req := httptest.NewRequest(http.MethodPost, "/u/shorten", bytes.NewBuffer(bodyBytes))
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusUnauthorized, rec.Code)
 		assert.Contains(t, rec.Body.String(), "Missing or invalid Authorization header")
@@ -143,7 +151,7 @@ func TestShorten(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer invalid_token")
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusUnauthorized, rec.Code)
 		assert.Contains(t, rec.Body.String(), "Invalid token")
@@ -157,7 +165,7 @@ func TestShorten(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer "+token)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusBadRequest, rec.Code)
 		assert.Contains(t, rec.Body.String(), shortlink_errors.ErrValidateRequest.Error())
@@ -173,7 +181,7 @@ func TestShorten(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer "+token)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusBadRequest, rec.Code)
 		assert.Contains(t, rec.Body.String(), shortlink_errors.ErrValidateRequest.Error())
@@ -190,7 +198,7 @@ func TestShorten(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer "+token)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusForbidden, rec.Code)
 		assert.Contains(t, rec.Body.String(), shortlink_errors.ErrBlacklistedID.Error())
@@ -207,7 +215,7 @@ func TestShorten(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer "+token)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusConflict, rec.Code)
 		assert.Contains(t, rec.Body.String(), shortlink_errors.ErrIDExists.Error())
@@ -223,7 +231,7 @@ func TestShorten(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer "+token)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusForbidden, rec.Code)
 		assert.Contains(t, rec.Body.String(), shortlink_errors.ErrForbiddenInput.Error())
@@ -239,7 +247,7 @@ func TestShorten(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer "+token)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusForbidden, rec.Code)
 		assert.Contains(t, rec.Body.String(), shortlink_errors.ErrForbiddenInput.Error())
@@ -259,7 +267,7 @@ func TestShorten(t *testing.T) {
 		req.Header.Set("Content-Type", "application/json")
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusOK, rec.Code)
 
@@ -284,9 +292,91 @@ func TestShorten(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer "+token)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusForbidden, rec.Code)
 		assert.Contains(t, rec.Body.String(), shortlink_errors.ErrForbiddenInput.Error())
 	})
 }
+
+// fixedIDGenerator always proposes the same ID, so concurrent requests collide.
+type fixedIDGenerator string
+
+func (g fixedIDGenerator) Generate(ctx context.Context) (string, error) {
+	return string(g), nil
+}
+
+func TestShortenConcurrentCreate(t *testing.T) {
+	ctx := context.Background()
+	tcEnv = GetSharedTestContainerEnv(ctx, t)
+
+	const workers = 10
+	userCtx := context.WithValue(ctx, utils.UserKey, "race-user")
+	noProviders := reputation_service.NewComposite(reputation_service.Config{}, nil)
+
+	// shortens one URL per worker at the same time and returns the errors
+	race := func(t *testing.T, svc url_service.URLService, customID string) (map[int]string, []error) {
+		t.Helper()
+		var (
+			wg    sync.WaitGroup
+			start = make(chan struct{})
+			mu    sync.Mutex
+			won   = make(map[int]string)
+			errs  []error
+		)
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				<-start
+				shortID, err := svc.Shorten(userCtx, dto.ShortenRequest{
+					URL:      fmt.Sprintf("https://race.example.com/%d", i),
+					CustomID: customID,
+				})
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					errs = append(errs, err)
+					return
+				}
+				won[i] = shortID
+			}(i)
+		}
+		close(start)
+		wg.Wait()
+		return won, errs
+	}
+
+	t.Run("only one request gets a custom ID", func(t *testing.T) {
+		svc := url_service.New(fsService, fsService, noProviders, fsService, nil, nil, nil, nil, nil, url_service.Config{})
+
+		won, errs := race(t, svc, "raceCustom1")
+
+		require.Len(t, won, 1)
+		require.Len(t, errs, workers-1)
+		for _, err := range errs {
+			assert.ErrorIs(t, err, shortlink_errors.ErrIDExists)
+		}
+		for i := range won {
+			link, err := fsService.GetShortlink(ctx, "raceCustom1")
+			require.NoError(t, err)
+			assert.Equal(t, fmt.Sprintf("https://race.example.com/%d", i), link.URL)
+		}
+	})
+
+	t.Run("generated IDs are never overwritten", func(t *testing.T) {
+		svc := url_service.New(fsService, fsService, noProviders, fsService, nil, nil, fixedIDGenerator("raceGen1"), nil, nil, url_service.Config{})
+
+		won, errs := race(t, svc, "")
+
+		require.Len(t, won, 1)
+		for _, err := range errs {
+			assert.ErrorIs(t, err, shortlink_errors.ErrGenerateID)
+		}
+		for i := range won {
+			link, err := fsService.GetShortlink(ctx, "raceGen1")
+			require.NoError(t, err)
+			assert.Equal(t, fmt.Sprintf("https://race.example.com/%d", i), link.URL)
+		}
+	})
+}
@@ -24,13 +24,14 @@ func TestGetUserLinks(t *testing.T) {
 	tcEnv = GetSharedTestContainerEnv(ctx, t)
 	require.NotNil(t, tcEnv, "tcEnv should be initialized")
 
-	urlSvc := url_service.New(fsService, nil, nil)
+	urlSvc := url_service.New(fsService, nil, nil, fsService, nil, nil, nil, nil, nil, url_service.Config{})
 
 	// Middleware
 	authMiddleware := middleware.NewAuthMiddleware(tcEnv.FsApp)
 
 	// Controller
-	controller := controllers.New(urlSvc, nil, fsService, nil)
+	controller := controllers.New(urlSvc, nil, fsService, nil, nil, nil, nil, nil, nil, nil, nil)
+	handler := validated(t, controller.Router)
 	controller.Router.GET("/u/shortlinks", authMiddleware.RequireAuth(controller.GetShortlinks))
 
 	// Create test user and token
@@ -82,7 +83,7 @@ func TestGetUserLinks(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer "+token)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusOK, rec.Code)
 
@@ -101,7 +102,7 @@ func TestGetUserLinks(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer "+token)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusOK, rec.Code)
 
@@ -121,7 +122,7 @@ func TestGetUserLinks(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer "+token)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusOK, rec.Code)
 
@@ -140,7 +141,7 @@ func TestGetUserLinks(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer "+token)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusOK, rec.Code)
 
@@ -155,7 +156,7 @@ func TestGetUserLinks(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/u/shortlinks", nil)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusUnauthorized, rec.Code)
 	})
@@ -165,7 +166,7 @@ func TestGetUserLinks(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer invalid_token_here")
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusUnauthorized, rec.Code)
 	})
@@ -179,7 +180,7 @@ func TestGetUserLinks(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer "+newToken)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusOK, rec.Code)
 		var resp dto.UserLinksResponse
@@ -193,7 +194,7 @@ func TestGetUserLinks(t *testing.T) {
 		req.Header.Set("Authorization", "Bearer "+token)
 		rec := httptest.NewRecorder()
 
-		controller.Router.ServeHTTP(rec, req)
+		handler.ServeHTTP(rec, req)
 
 		assert.Equal(t, http.StatusBadRequest, rec.Code)
 	})
@@ -0,0 +1,123 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/controllers"
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/middleware"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/audit_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/tracking_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/url_service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTags(t *testing.T) {
+	ctx := context.Background()
+	tcEnv = GetSharedTestContainerEnv(ctx, t)
+	require.NotNil(t, tcEnv, "tcEnv should be initialized")
+
+	authMiddleware := middleware.NewAuthMiddleware(tcEnv.FsApp)
+	auditSvc := audit_service.New(fsService)
+	urlSvc := url_service.New(fsService, fsService, nil, fsService, auditSvc, nil, nil, nil, nil, url_service.Config{})
+	trackingSvc := tracking_service.New(fsService, tcEnv.rdClient)
+	controller := controllers.New(urlSvc, trackingSvc, fsService, nil, nil, nil, nil, auditSvc, nil, nil, nil)
+	handler := validated(t, controller.Router)
+	controller.Router.GET("/api/v1/tags", authMiddleware.RequireAuth(controller.ListTags))
+	controller.Router.GET("/api/v1/tags/:tag/analytics", authMiddleware.RequireAuth(controller.GetTagAnalytics))
+	controller.Router.PATCH("/api/v1/tags/:tag", authMiddleware.RequireAuth(controller.RenameTag))
+	controller.Router.DELETE("/api/v1/tags/:tag", authMiddleware.RequireAuth(controller.DeleteTag))
+	controller.Router.GET("/api/v1/links", authMiddleware.RequireAuth(controller.GetShortlinks))
+
+	userID, token, err := createTestUserAndToken(ctx, authMiddleware.AuthClient, "tags.user@example.com", nil)
+	require.NoError(t, err)
+	_, otherToken, err := createTestUserAndToken(ctx, authMiddleware.AuthClient, "tags.other@example.com", nil)
+	require.NoError(t, err)
+
+	for _, l := range []models.Shortlink{
+		{ShortID: "tag_a", URL: "https://example.com/a", Tags: []string{"launch", "email"}},
+		{ShortID: "tag_b", URL: "https://example.com/b", Tags: []string{"launch"}},
+		{ShortID: "tag_c", URL: "https://example.com/c", Tags: []string{"social"}},
+	} {
+		l.CreatedBy, l.CreatedAt = userID, time.Now()
+		require.NoError(t, fsService.SetShortlink(ctx, l.ShortID, l))
+	}
+	for _, id := range []string{"tag_a", "tag_b", "tag_b"} {
+		require.NoError(t, trackingSvc.TrackClick(ctx, id, "192.0.2.1", "test-agent", "", ""))
+	}
+
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("Tags are listed with their links and clicks", func(t *testing.T) {
+		rec := do(http.MethodGet, "/api/v1/tags", token, "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var resp dto.TagsResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Len(t, resp.Tags, 3)
+		assert.Equal(t, "launch", resp.Tags[0].Tag)
+		assert.Equal(t, 2, resp.Tags[0].Links)
+		assert.Equal(t, int64(3), resp.Tags[0].TotalClicks)
+
+		rec = do(http.MethodGet, "/api/v1/tags", otherToken, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"tags": []}`, rec.Body.String())
+	})
+
+	t.Run("Tag analytics show the links of a campaign together", func(t *testing.T) {
+		rec := do(http.MethodGet, "/api/v1/tags/LAUNCH/analytics", token, "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var resp dto.TagAnalyticsResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, int64(3), resp.TotalClicks)
+		require.Len(t, resp.LinkStats, 2)
+		assert.Equal(t, "tag_b", resp.LinkStats[0].ShortID)
+
+		rec = do(http.MethodGet, "/api/v1/tags/launch/analytics", otherToken, "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("Renaming a tag changes it on every link", func(t *testing.T) {
+		rec := do(http.MethodPatch, "/api/v1/tags/email", token, `{"name": "newsletter"}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		link, err := fsService.GetShortlink(ctx, "tag_a")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"launch", "newsletter"}, link.Tags)
+
+		rec = do(http.MethodGet, "/api/v1/links?tag=newsletter", token, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"short_id":"tag_a"`)
+
+		rec = do(http.MethodPatch, "/api/v1/tags/social", token, `{"name": "not valid"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("Deleting a tag keeps the links", func(t *testing.T) {
+		rec := do(http.MethodDelete, "/api/v1/tags/launch", token, "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		link, err := fsService.GetShortlink(ctx, "tag_b")
+		require.NoError(t, err)
+		assert.Empty(t, link.Tags)
+
+		rec = do(http.MethodDelete, "/api/v1/tags/launch", token, "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
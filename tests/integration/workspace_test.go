@@ -0,0 +1,172 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mfmahendr/url-shortener-backend/internal/controllers"
+	"github.com/mfmahendr/url-shortener-backend/internal/dto"
+	"github.com/mfmahendr/url-shortener-backend/internal/middleware"
+	"github.com/mfmahendr/url-shortener-backend/internal/models"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/audit_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/tracking_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/url_service"
+	"github.com/mfmahendr/url-shortener-backend/internal/services/workspace_service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkspaces(t *testing.T) {
+	ctx := context.Background()
+	tcEnv = GetSharedTestContainerEnv(ctx, t)
+	require.NotNil(t, tcEnv, "tcEnv should be initialized")
+
+	// services and controller
+	authMiddleware := middleware.NewAuthMiddleware(tcEnv.FsApp)
+	auditSvc := audit_service.New(fsService)
+	urlSvc := url_service.New(fsService, fsService, nil, fsService, auditSvc, nil, nil, nil, nil, url_service.Config{})
+	trackingSvc := tracking_service.New(fsService, tcEnv.rdClient)
+	workspaceSvc := workspace_service.New(fsService, auditSvc)
+	controller := controllers.New(urlSvc, trackingSvc, fsService, nil, nil, nil, workspaceSvc, auditSvc, nil, nil, nil)
+	handler := validated(t, controller.Router)
+
+	controller.Router.POST("/u/workspaces", authMiddleware.RequireAuth(controller.CreateWorkspace))
+	controller.Router.GET("/u/workspaces", authMiddleware.RequireAuth(controller.ListWorkspaces))
+	controller.Router.GET("/u/workspaces/:workspace_id/members", authMiddleware.RequireAuth(controller.ListWorkspaceMembers))
+	controller.Router.PATCH("/u/workspaces/:workspace_id/members/:uid", authMiddleware.RequireAuth(controller.UpdateWorkspaceMember))
+	controller.Router.POST("/u/workspaces/:workspace_id/invitations", authMiddleware.RequireAuth(controller.InviteWorkspaceMember))
+	controller.Router.POST("/u/invitations/:invitation_id/accept", authMiddleware.RequireAuth(controller.AcceptWorkspaceInvitation))
+	controller.Router.GET("/u/click-count/:short_id", authMiddleware.RequireAuth(controller.GetClickCount))
+	controller.Router.GET("/u/shortlinks", authMiddleware.RequireAuth(controller.GetShortlinks))
+
+	ownerUID, ownerToken, err := createTestUserAndToken(ctx, authMiddleware.AuthClient, "workspace.owner@example.com", nil)
+	require.NoError(t, err)
+	memberUID, memberToken, err := createVerifiedTestUserAndToken(ctx, authMiddleware.AuthClient, "workspace.member@example.com", nil)
+	require.NoError(t, err)
+	_, outsiderToken, err := createTestUserAndToken(ctx, authMiddleware.AuthClient, "workspace.outsider@example.com", nil)
+	require.NoError(t, err)
+
+	doRequest := func(method, path, token string, body any) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		if body != nil {
+			_ = json.NewEncoder(&buf).Encode(body)
+		}
+		req := httptest.NewRequest(method, path, &buf)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	var workspace dto.WorkspaceDTO
+	var invitation dto.InvitationDTO
+
+	t.Run("create workspace", func(t *testing.T) {
+		rec := doRequest(http.MethodPost, "/u/workspaces", ownerToken, map[string]string{"name": "Marketing"})
+		require.Equal(t, http.StatusCreated, rec.Code)
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &workspace))
+		assert.NotEmpty(t, workspace.ID)
+		assert.Equal(t, "owner", workspace.Role)
+	})
+
+	t.Run("invite and accept member", func(t *testing.T) {
+		rec := doRequest(http.MethodPost, "/u/workspaces/"+workspace.ID+"/invitations", ownerToken,
+			map[string]string{"email": "workspace.member@example.com", "role": "viewer"})
+		require.Equal(t, http.StatusCreated, rec.Code)
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &invitation))
+
+		rec = doRequest(http.MethodPost, "/u/invitations/"+invitation.ID+"/accept", outsiderToken, nil)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = doRequest(http.MethodPost, "/u/invitations/"+invitation.ID+"/accept", memberToken, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		member, err := fsService.GetMember(ctx, workspace.ID, memberUID)
+		require.NoError(t, err)
+		assert.Equal(t, models.RoleViewer, member.Role)
+	})
+
+	t.Run("an unverified email cannot accept", func(t *testing.T) {
+		rec := doRequest(http.MethodPost, "/u/workspaces/"+workspace.ID+"/invitations", ownerToken,
+			map[string]string{"email": "workspace.unverified@example.com", "role": "owner"})
+		require.Equal(t, http.StatusCreated, rec.Code)
+		var inv dto.InvitationDTO
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &inv))
+
+		// signed up with the invitee's address without proving it is theirs
+		uid, token, err := createTestUserAndToken(ctx, authMiddleware.AuthClient, "workspace.unverified@example.com", nil)
+		require.NoError(t, err)
+
+		rec = doRequest(http.MethodPost, "/u/invitations/"+inv.ID+"/accept", token, nil)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		_, err = fsService.GetMember(ctx, workspace.ID, uid)
+		assert.Error(t, err)
+	})
+
+	t.Run("invitation cannot be reused", func(t *testing.T) {
+		rec := doRequest(http.MethodPost, "/u/invitations/"+invitation.ID+"/accept", memberToken, nil)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("viewer cannot invite", func(t *testing.T) {
+		rec := doRequest(http.MethodPost, "/u/workspaces/"+workspace.ID+"/invitations", memberToken,
+			map[string]string{"email": "someone@example.com", "role": "viewer"})
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("members share link analytics", func(t *testing.T) {
+		shortID := "workspacelink1"
+		err := fsService.SetShortlink(ctx, shortID, models.Shortlink{
+			ShortID:     shortID,
+			URL:         "https://workspace.example.com",
+			CreatedBy:   ownerUID,
+			CreatedAt:   time.Now(),
+			IsPrivate:   true,
+			WorkspaceID: workspace.ID,
+		})
+		require.NoError(t, err)
+
+		rec := doRequest(http.MethodGet, "/u/click-count/"+shortID, memberToken, nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		rec = doRequest(http.MethodGet, "/u/click-count/"+shortID, outsiderToken, nil)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = doRequest(http.MethodGet, "/u/shortlinks?workspace_id="+workspace.ID, memberToken, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var resp dto.UserLinksResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Len(t, resp.Links, 1)
+		assert.Equal(t, shortID, resp.Links[0].ShortID)
+
+		rec = doRequest(http.MethodGet, "/u/shortlinks?workspace_id="+workspace.ID, outsiderToken, nil)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("owner cannot demote the last owner", func(t *testing.T) {
+		rec := doRequest(http.MethodPatch, "/u/workspaces/"+workspace.ID+"/members/"+ownerUID, ownerToken,
+			map[string]string{"role": "viewer"})
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("list workspaces and members", func(t *testing.T) {
+		rec := doRequest(http.MethodGet, "/u/workspaces", memberToken, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var workspaces []dto.WorkspaceDTO
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &workspaces))
+		require.Len(t, workspaces, 1)
+		assert.Equal(t, "viewer", workspaces[0].Role)
+
+		rec = doRequest(http.MethodGet, "/u/workspaces/"+workspace.ID+"/members", memberToken, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var members []dto.WorkspaceMemberDTO
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &members))
+		assert.Len(t, members, 2)
+	})
+}